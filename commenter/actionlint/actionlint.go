@@ -0,0 +1,48 @@
+// Package actionlint reads the errors of actionlint -format '{{json .}}' as findings on the workflow files
+package actionlint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+)
+
+// Tool names the findings of Parse
+const Tool = "actionlint"
+
+// Format is the actionlint -format template Parse reads the output of
+const Format = "{{json .}}"
+
+type lintError struct {
+	Message  string `json:"message"`
+	Filepath string `json:"filepath"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Kind     string `json:"kind"`
+	Snippet  string `json:"snippet"`
+}
+
+// Parse reads the errors actionlint writes with the Format template as SeverityError findings of
+// their kind, such as "expression" or "shellcheck", with the line of the workflow shown below
+func Parse(r io.Reader) ([]commenter.Finding, error) {
+	var errs []lintError
+	if err := json.NewDecoder(r).Decode(&errs); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("decode actionlint output: %w", err)
+	}
+	var findings []commenter.Finding
+	for _, e := range errs {
+		findings = append(findings, commenter.Finding{
+			Tool:      Tool,
+			RuleID:    e.Kind,
+			Severity:  commenter.SeverityError,
+			Path:      e.Filepath,
+			StartLine: e.Line,
+			EndLine:   e.Line,
+			Message:   e.Message,
+			Snippet:   e.Snippet,
+		})
+	}
+	return findings, nil
+}
@@ -0,0 +1,120 @@
+package commenter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// AutoApproveMode is what WriteFindings does on GitHub when a run has no findings left to report
+type AutoApproveMode int
+
+const (
+	// AutoApproveOff leaves the reviews alone, it is the default
+	AutoApproveOff AutoApproveMode = iota
+	// AutoApproveSubmit submits an APPROVE review
+	AutoApproveSubmit
+	// AutoApproveDismiss dismisses the commenter's earlier REQUEST_CHANGES reviews
+	AutoApproveDismiss
+)
+
+// WithAutoApprove lets the commenter take part in required reviews by approving, or dismissing its
+// own change requests, when every finding of a WriteFindings run was suppressed or there were none
+func WithAutoApprove(mode AutoApproveMode) Option {
+	return func(o *options) {
+		o.autoApprove = mode
+	}
+}
+
+// clean reports whether no finding of the run is left to report
+func clean(results []Result) bool {
+	for _, result := range results {
+		if result.Status != ResultSuppressed {
+			return false
+		}
+	}
+	return true
+}
+
+// approveClean applies WithAutoApprove after a clean run
+func (c *Commenter) approveClean(ctx context.Context) error {
+	if c.opts.autoApprove == AutoApproveOff {
+		return nil
+	}
+	gh := c.ghConnector
+	if gh == nil {
+		return fmt.Errorf("auto approve: %w", ErrNotSupported)
+	}
+	if c.opts.autoApprove == AutoApproveSubmit {
+		return gh.CreatePRReview(ctx, Approve, c.text(MessageApprove), nil)
+	}
+
+	reviews, err := gh.listReviews(ctx)
+	if err != nil {
+		return err
+	}
+	for _, review := range reviews {
+		if review.GetState() != "CHANGES_REQUESTED" || review.GetUser().GetLogin() != c.commenterName() {
+			continue
+		}
+		id := review.GetID()
+		err := gh.withRetry(ctx, "PullRequests.DismissReview", func() (*github.Response, error) {
+			_, resp, err := gh.prs.DismissReview(ctx, gh.owner, gh.repo, gh.prNumber, id, &github.PullRequestReviewDismissalRequest{Message: github.String(c.text(MessageDismissal))})
+			return resp, err
+		})
+		if err != nil {
+			return fmt.Errorf("dismiss review %d: %w", id, err)
+		}
+		c.logger().Info("dismissed review", "review_id", id)
+	}
+	return nil
+}
+
+// listReviews returns the reviews of the PR
+func (c *connector) listReviews(ctx context.Context) ([]*github.PullRequestReview, error) {
+	var reviews []*github.PullRequestReview
+	err := c.withRetry(ctx, "PullRequests.ListReviews", func() (*github.Response, error) {
+		var (
+			resp *github.Response
+			err  error
+		)
+		reviews, resp, err = c.prs.ListReviews(ctx, c.owner, c.repo, c.prNumber, &github.ListOptions{PerPage: 100})
+		return resp, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list reviews: %w", err)
+	}
+	return reviews, nil
+}
+
+// deletePendingReviews deletes the commenter's unsubmitted review so that a new one can be left pending
+func (c *connector) deletePendingReviews(ctx context.Context) error {
+	reviews, err := c.listReviews(ctx)
+	if err != nil {
+		return err
+	}
+	for _, review := range reviews {
+		if review.GetState() != Pending || review.GetUser().GetLogin() != c.botLogin() {
+			continue
+		}
+		id := review.GetID()
+		err := c.withRetry(ctx, "PullRequests.DeletePendingReview", func() (*github.Response, error) {
+			_, resp, err := c.prs.DeletePendingReview(ctx, c.owner, c.repo, c.prNumber, id)
+			return resp, err
+		})
+		if err != nil {
+			return fmt.Errorf("delete pending review %d: %w", id, err)
+		}
+		c.opts.logger.Info("deleted pending review", "review_id", id)
+	}
+	return nil
+}
+
+// botLogin is the author of the commenter's own reviews, WithBotLogin or CommenterName
+func (c *connector) botLogin() string {
+	if c.opts.botLogin != "" {
+		return c.opts.botLogin
+	}
+	return CommenterName
+}
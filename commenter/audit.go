@@ -0,0 +1,80 @@
+package commenter
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// mutationRegex matches the field a GraphQL mutation calls
+var mutationRegex = regexp.MustCompile(`^mutation[^{]*\{\s*(\w+)`)
+
+// mutatingPrefixes are the method name prefixes of the API calls which change the PR or repository
+var mutatingPrefixes = []string{"Create", "Edit", "Update", "Delete", "Dismiss", "Submit"}
+
+// AuditEntry records a single request the commenter made to change the PR or repository
+type AuditEntry struct {
+	Time time.Time
+	// Operation is the API call, such as "PullRequests.CreateComment" or "GraphQL.minimizeComment"
+	Operation string
+	// RequestID is GitHub's X-GitHub-Request-Id for the request, for looking it up in GitHub's logs
+	RequestID  string
+	StatusCode int
+	// Err is why the request failed, nil when it succeeded
+	Err error
+}
+
+// auditLog collects the AuditEntry of each mutating request, it is safe for concurrent use
+type auditLog struct {
+	mu      sync.Mutex
+	entries []AuditEntry
+}
+
+// record adds the request of operation to the log when it is a mutating one, l may be nil
+func (l *auditLog) record(operation string, resp *github.Response, err error) {
+	if l == nil || !mutating(operation) {
+		return
+	}
+	entry := AuditEntry{Time: time.Now().UTC(), Operation: operation, Err: err}
+	if resp != nil && resp.Response != nil {
+		entry.RequestID = resp.Header.Get("X-GitHub-Request-Id")
+		entry.StatusCode = resp.StatusCode
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+}
+
+// mutating reports whether the API call named operation changes anything on GitHub
+func mutating(operation string) bool {
+	if strings.HasPrefix(operation, "GraphQL.") {
+		return true
+	}
+	method := operation[strings.LastIndex(operation, ".")+1:]
+	for _, prefix := range mutatingPrefixes {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// graphqlOperation names a GraphQL call after the field of its mutation, queries are all "GraphQL"
+func graphqlOperation(query string) string {
+	if groups := mutationRegex.FindStringSubmatch(query); groups != nil {
+		return "GraphQL." + groups[1]
+	}
+	return "GraphQL"
+}
+
+// AuditLog returns every request the commenter made to create, change or delete something on GitHub,
+// in the order they were made, retries included. Requests of providers other than GitHub aren't logged
+func (c *Commenter) AuditLog() []AuditEntry {
+	l := c.opts.audit
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]AuditEntry(nil), l.entries...)
+}
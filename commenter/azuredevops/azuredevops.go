@@ -0,0 +1,398 @@
+// Package azuredevops provides a commenter.Provider writing Azure DevOps pull request threads
+package azuredevops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/internal/rest"
+)
+
+const apiVersion = "7.0"
+
+// ThreadStatus is the status of a pull request thread
+type ThreadStatus string
+
+const (
+	StatusActive   ThreadStatus = "active"
+	StatusPending  ThreadStatus = "pending"
+	StatusFixed    ThreadStatus = "fixed"
+	StatusWontFix  ThreadStatus = "wontFix"
+	StatusByDesign ThreadStatus = "byDesign"
+	StatusClosed   ThreadStatus = "closed"
+)
+
+// Provider implements commenter.Provider for a single Azure DevOps pull request, each comment is
+// the first comment of its own thread. A Comment's ID is the thread id and its NodeID the comment id.
+// Azure DevOps doesn't serve diffs, so as in its UI any line of a changed file can be commented on
+type Provider struct {
+	api        *rest.Client
+	project    string
+	repository string
+	id         int
+	headSHA    string
+	userID     string
+}
+
+var (
+	_ commenter.Provider       = (*Provider)(nil)
+	_ commenter.Identifier     = (*Provider)(nil)
+	_ commenter.ThreadResolver = (*Provider)(nil)
+)
+
+// Option configures the provider
+type Option func(*Provider)
+
+// WithBaseURL replaces https://dev.azure.com/{organization}, e.g. with the collection url of an Azure DevOps Server
+func WithBaseURL(baseURL string) Option {
+	return func(p *Provider) {
+		p.api.BaseURL = baseURL
+	}
+}
+
+// WithHTTPClient sets the client used for API calls
+func WithHTTPClient(client *http.Client) Option {
+	return func(p *Provider) {
+		p.api.HTTP = client
+	}
+}
+
+type identity struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+type connectionData struct {
+	AuthenticatedUser identity `json:"authenticatedUser"`
+}
+
+type pullRequest struct {
+	LastMergeSourceCommit struct {
+		CommitID string `json:"commitId"`
+	} `json:"lastMergeSourceCommit"`
+}
+
+type iteration struct {
+	ID              int `json:"id"`
+	SourceRefCommit struct {
+		CommitID string `json:"commitId"`
+	} `json:"sourceRefCommit"`
+}
+
+type changeEntry struct {
+	ChangeType string `json:"changeType"`
+	Item       struct {
+		Path string `json:"path"`
+	} `json:"item"`
+}
+
+type changes struct {
+	ChangeEntries []*changeEntry `json:"changeEntries"`
+	NextSkip      int            `json:"nextSkip"`
+	NextTop       int            `json:"nextTop"`
+}
+
+type item struct {
+	Content string `json:"content"`
+}
+
+type position struct {
+	Line   int `json:"line"`
+	Offset int `json:"offset"`
+}
+
+type threadContext struct {
+	FilePath       string    `json:"filePath"`
+	RightFileStart *position `json:"rightFileStart,omitempty"`
+	RightFileEnd   *position `json:"rightFileEnd,omitempty"`
+}
+
+// commentTypeSystem is the commentType of the comments Azure DevOps adds to a thread itself
+const commentTypeSystem = 3
+
+type comment struct {
+	ID              int64     `json:"id,omitempty"`
+	ParentCommentID int64     `json:"parentCommentId"`
+	Content         string    `json:"content"`
+	CommentType     int       `json:"commentType,omitempty"`
+	Author          *identity `json:"author,omitempty"`
+	IsDeleted       bool      `json:"isDeleted,omitempty"`
+}
+
+type thread struct {
+	ID            int64          `json:"id,omitempty"`
+	Status        ThreadStatus   `json:"status,omitempty"`
+	ThreadContext *threadContext `json:"threadContext,omitempty"`
+	Comments      []*comment     `json:"comments,omitempty"`
+	IsDeleted     bool           `json:"isDeleted,omitempty"`
+}
+
+type list struct {
+	Value interface{} `json:"value"`
+}
+
+// New creates a provider for pull request id of repository in project of organization, authenticating
+// with a personal access token. The pull request and the token's user are looked up straight away
+func New(ctx context.Context, token, organization, project, repository string, id int, opts ...Option) (*Provider, error) {
+	req := &http.Request{Header: http.Header{}}
+	req.SetBasicAuth("", token)
+	p := &Provider{
+		api: &rest.Client{
+			Forge:   "Azure DevOps",
+			BaseURL: "https://dev.azure.com/" + url.PathEscape(organization),
+			Header:  http.Header{"Authorization": req.Header["Authorization"]},
+		},
+		project:    project,
+		repository: repository,
+		id:         id,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	var pr pullRequest
+	if _, err := p.api.Do(ctx, http.MethodGet, p.prPath(""), version(), nil, &pr); err != nil {
+		var restErr *rest.Error
+		if errors.As(err, &restErr) && restErr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("PR number [%d] not found for %s/%s: %w", id, project, repository, commenter.ErrPRNotFound)
+		}
+		return nil, err
+	}
+	p.headSHA = pr.LastMergeSourceCommit.CommitID
+
+	var conn connectionData
+	if _, err := p.api.Do(ctx, http.MethodGet, "/_apis/connectionData", nil, nil, &conn); err != nil {
+		return nil, fmt.Errorf("resolve the token's user: %w", err)
+	}
+	p.userID = conn.AuthenticatedUser.ID
+	return p, nil
+}
+
+// CommenterName implements commenter.Identifier with the identity id of the token's user
+func (p *Provider) CommenterName() string {
+	return p.userID
+}
+
+// ListChangedFiles implements commenter.Provider with the changes of the latest iteration, each file
+// reported as a single hunk spanning all of its lines
+func (p *Provider) ListChangedFiles(ctx context.Context) ([]*commenter.ChangedFile, error) {
+	var iterations []*iteration
+	if _, err := p.api.Do(ctx, http.MethodGet, p.prPath("/iterations"), version(), nil, &list{Value: &iterations}); err != nil {
+		return nil, err
+	}
+	if len(iterations) == 0 {
+		return nil, nil
+	}
+	latest := iterations[len(iterations)-1]
+	sha := latest.SourceRefCommit.CommitID
+	if sha == "" {
+		sha = p.headSHA
+	}
+
+	var entries []*changeEntry
+	query := version()
+	for {
+		var page changes
+		if _, err := p.api.Do(ctx, http.MethodGet, p.prPath("/iterations/%d/changes", latest.ID), query, nil, &page); err != nil {
+			return nil, err
+		}
+		entries = append(entries, page.ChangeEntries...)
+		if page.NextTop == 0 {
+			break
+		}
+		query.Set("$skip", strconv.Itoa(page.NextSkip))
+		query.Set("$top", strconv.Itoa(page.NextTop))
+	}
+
+	files := make([]*commenter.ChangedFile, 0, len(entries))
+	for _, entry := range entries {
+		file := &commenter.ChangedFile{
+			Filename:  strings.TrimPrefix(entry.Item.Path, "/"),
+			Status:    changeStatus(entry.ChangeType),
+			CommitSHA: sha,
+		}
+		if file.Status != "deleted" && file.Status != "renamed" {
+			lines, err := p.countLines(ctx, entry.Item.Path, sha)
+			if err != nil {
+				return nil, err
+			}
+			file.Patch = fmt.Sprintf("@@ -1,%d +1,%d @@", lines, lines)
+			file.Changes = lines
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// ListComments implements commenter.Provider with the comments of every file thread, the later ones
+// are InReplyTo the thread. As a Comment's ID is its thread id, a reply shares it with the first comment
+func (p *Provider) ListComments(ctx context.Context) ([]*commenter.Comment, error) {
+	var threads []*thread
+	if _, err := p.api.Do(ctx, http.MethodGet, p.prPath("/threads"), version(), nil, &list{Value: &threads}); err != nil {
+		return nil, err
+	}
+
+	var comments []*commenter.Comment
+	for _, t := range threads {
+		if t.IsDeleted || t.ThreadContext == nil || len(t.Comments) == 0 || t.Comments[0].IsDeleted {
+			continue
+		}
+		first := p.toComment(t)
+		comments = append(comments, first)
+		for _, c := range t.Comments[1:] {
+			// system comments record status changes and votes
+			if c.IsDeleted || c.CommentType == commentTypeSystem {
+				continue
+			}
+			reply := *first
+			reply.NodeID = strconv.FormatInt(c.ID, 10)
+			reply.Body = c.Content
+			reply.Author = ""
+			if c.Author != nil {
+				reply.Author = c.Author.ID
+			}
+			reply.InReplyTo = t.ID
+			comments = append(comments, &reply)
+		}
+	}
+	return comments, nil
+}
+
+// CreateInlineComment implements commenter.Provider with a new active thread on the lines
+func (p *Provider) CreateInlineComment(ctx context.Context, c commenter.InlineComment) (*commenter.Comment, error) {
+	req := &thread{
+		Status:   StatusActive,
+		Comments: []*comment{{Content: c.Body, CommentType: 1}},
+		ThreadContext: &threadContext{
+			FilePath:       "/" + c.Path,
+			RightFileStart: &position{Line: c.StartLine, Offset: 1},
+			RightFileEnd:   &position{Line: c.EndLine, Offset: 1},
+		},
+	}
+	var created thread
+	if _, err := p.api.Do(ctx, http.MethodPost, p.prPath("/threads"), version(), req, &created); err != nil {
+		return nil, fmt.Errorf("create comment on %s line %d: %w", c.Path, c.EndLine, err)
+	}
+	if created.ThreadContext == nil {
+		created.ThreadContext = req.ThreadContext
+	}
+	return p.toComment(&created), nil
+}
+
+// CreateSummaryComment implements commenter.Provider with a new thread on the pull request itself
+func (p *Provider) CreateSummaryComment(ctx context.Context, body string) (*commenter.Comment, error) {
+	req := &thread{
+		Status:   StatusActive,
+		Comments: []*comment{{Content: body, CommentType: 1}},
+	}
+	var created thread
+	if _, err := p.api.Do(ctx, http.MethodPost, p.prPath("/threads"), version(), req, &created); err != nil {
+		return nil, fmt.Errorf("create summary comment: %w", err)
+	}
+	return p.toComment(&created), nil
+}
+
+// UpdateComment implements commenter.Provider
+func (p *Provider) UpdateComment(ctx context.Context, c *commenter.Comment, body string) error {
+	path := p.prPath("/threads/%d/comments/%s", c.ID, c.NodeID)
+	if _, err := p.api.Do(ctx, http.MethodPatch, path, version(), &comment{Content: body}, nil); err != nil {
+		return fmt.Errorf("update existing comment %d: %w", c.ID, err)
+	}
+	return nil
+}
+
+// DeleteComment implements commenter.Provider, the thread is marked deleted with its only comment
+func (p *Provider) DeleteComment(ctx context.Context, c *commenter.Comment) error {
+	path := p.prPath("/threads/%d/comments/%s", c.ID, c.NodeID)
+	if _, err := p.api.Do(ctx, http.MethodDelete, path, version(), nil, nil); err != nil {
+		return fmt.Errorf("delete existing comment %d: %w", c.ID, err)
+	}
+	return nil
+}
+
+// SetStatus changes the status of the thread holding comment, e.g. to StatusFixed once resolved
+func (p *Provider) SetStatus(ctx context.Context, c *commenter.Comment, status ThreadStatus) error {
+	if _, err := p.api.Do(ctx, http.MethodPatch, p.prPath("/threads/%d", c.ID), version(), &thread{Status: status}, nil); err != nil {
+		return fmt.Errorf("set status of thread %d: %w", c.ID, err)
+	}
+	return nil
+}
+
+// ResolveThread implements commenter.ThreadResolver by setting the thread of comment to StatusFixed
+func (p *Provider) ResolveThread(ctx context.Context, c *commenter.Comment) error {
+	return p.SetStatus(ctx, c, StatusFixed)
+}
+
+func (p *Provider) toComment(t *thread) *commenter.Comment {
+	result := &commenter.Comment{
+		ID:  t.ID,
+		URL: fmt.Sprintf("%s/%s/_git/%s/pullrequest/%d?discussionId=%d", p.api.BaseURL, url.PathEscape(p.project), url.PathEscape(p.repository), p.id, t.ID),
+	}
+	if len(t.Comments) > 0 {
+		result.NodeID = strconv.FormatInt(t.Comments[0].ID, 10)
+		result.Body = t.Comments[0].Content
+		if t.Comments[0].Author != nil {
+			result.Author = t.Comments[0].Author.ID
+		}
+	}
+	if t.ThreadContext != nil {
+		result.Path = strings.TrimPrefix(t.ThreadContext.FilePath, "/")
+		// threads on the left side of the diff have no right file position
+		result.Outdated = t.ThreadContext.RightFileStart == nil
+		if t.ThreadContext.RightFileStart != nil {
+			result.StartLine = t.ThreadContext.RightFileStart.Line
+		}
+		if t.ThreadContext.RightFileEnd != nil {
+			result.Line = t.ThreadContext.RightFileEnd.Line
+		}
+	}
+	return result
+}
+
+func (p *Provider) countLines(ctx context.Context, path, sha string) (int, error) {
+	query := version()
+	query.Set("path", path)
+	query.Set("includeContent", "true")
+	query.Set("versionDescriptor.version", sha)
+	query.Set("versionDescriptor.versionType", "commit")
+	var file item
+	if _, err := p.api.Do(ctx, http.MethodGet, p.repoPath("/items"), query, nil, &file); err != nil {
+		return 0, fmt.Errorf("read %s at %s: %w", path, sha, err)
+	}
+	lines := strings.Count(file.Content, "\n")
+	if file.Content != "" && !strings.HasSuffix(file.Content, "\n") {
+		lines++
+	}
+	return lines, nil
+}
+
+func (p *Provider) repoPath(suffix string) string {
+	return fmt.Sprintf("/%s/_apis/git/repositories/%s%s", url.PathEscape(p.project), url.PathEscape(p.repository), suffix)
+}
+
+func (p *Provider) prPath(format string, args ...interface{}) string {
+	return p.repoPath(fmt.Sprintf("/pullRequests/%d", p.id) + fmt.Sprintf(format, args...))
+}
+
+func changeStatus(changeType string) string {
+	switch {
+	case strings.Contains(changeType, "delete"):
+		return "deleted"
+	case changeType == "rename":
+		return "renamed"
+	case strings.Contains(changeType, "add"):
+		return "added"
+	default:
+		return "modified"
+	}
+}
+
+func version() url.Values {
+	return url.Values{"api-version": {apiVersion}}
+}
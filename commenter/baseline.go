@@ -0,0 +1,153 @@
+package commenter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const baselineVersion = 1
+
+// Baseline is a set of known findings which aren't reported again, so adopting a scanner on an
+// existing codebase only comments on newly introduced issues. Findings are matched on their rule,
+// path and message, not their line, so they still match after unrelated lines move them
+type Baseline struct {
+	fingerprints map[string]bool
+}
+
+// BaselineEntry is a single known finding in a baseline file
+type BaselineEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	RuleID      string `json:"rule_id,omitempty"`
+	Path        string `json:"path"`
+	Message     string `json:"message"`
+}
+
+type baselineFile struct {
+	Version  int             `json:"version"`
+	Findings []BaselineEntry `json:"findings"`
+}
+
+// WithBaseline makes WriteFindings suppress the findings contained in baseline
+func WithBaseline(baseline *Baseline) Option {
+	return func(o *options) {
+		o.baseline = baseline
+	}
+}
+
+// NewBaseline creates a baseline of findings
+func NewBaseline(findings []Finding) *Baseline {
+	b := &Baseline{fingerprints: map[string]bool{}}
+	for _, finding := range findings {
+		b.fingerprints[Fingerprint(finding)] = true
+	}
+	return b
+}
+
+// Contains reports whether the finding is a known one
+func (b *Baseline) Contains(finding Finding) bool {
+	return b != nil && b.fingerprints[Fingerprint(finding)]
+}
+
+// Len is the number of distinct findings in the baseline
+func (b *Baseline) Len() int {
+	if b == nil {
+		return 0
+	}
+	return len(b.fingerprints)
+}
+
+// Fingerprint identifies a finding independently of the lines it is on
+func Fingerprint(finding Finding) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{finding.RuleID, filepath.ToSlash(finding.Path), strings.TrimSpace(finding.Message)}, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// WriteBaseline exports findings to w as baseline JSON, sorted so the file diffs cleanly
+func WriteBaseline(w io.Writer, findings []Finding) error {
+	seen := map[string]bool{}
+	file := baselineFile{Version: baselineVersion, Findings: []BaselineEntry{}}
+	for _, finding := range findings {
+		fingerprint := Fingerprint(finding)
+		if seen[fingerprint] {
+			continue
+		}
+		seen[fingerprint] = true
+		file.Findings = append(file.Findings, BaselineEntry{
+			Fingerprint: fingerprint,
+			RuleID:      finding.RuleID,
+			Path:        filepath.ToSlash(finding.Path),
+			Message:     strings.TrimSpace(finding.Message),
+		})
+	}
+	sort.Slice(file.Findings, func(i, j int) bool {
+		a, b := file.Findings[i], file.Findings[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.RuleID != b.RuleID {
+			return a.RuleID < b.RuleID
+		}
+		return a.Fingerprint < b.Fingerprint
+	})
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(file)
+}
+
+// ReadBaseline reads baseline JSON written by WriteBaseline
+func ReadBaseline(r io.Reader) (*Baseline, error) {
+	var file baselineFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("decode baseline: %w", err)
+	}
+	if file.Version != baselineVersion {
+		return nil, fmt.Errorf("unsupported baseline version %d", file.Version)
+	}
+	b := &Baseline{fingerprints: map[string]bool{}}
+	for _, entry := range file.Findings {
+		fingerprint := entry.Fingerprint
+		if fingerprint == "" {
+			fingerprint = Fingerprint(Finding{RuleID: entry.RuleID, Path: entry.Path, Message: entry.Message})
+		}
+		b.fingerprints[fingerprint] = true
+	}
+	return b, nil
+}
+
+// LoadBaselineFile reads the baseline at path, a missing file is an empty baseline
+func LoadBaselineFile(path string) (*Baseline, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return NewBaseline(nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadBaseline(f)
+}
+
+// SaveBaselineFile writes findings as the baseline at path, replacing it atomically
+func SaveBaselineFile(path string, findings []Finding) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".baseline-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := WriteBaseline(tmp, findings); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,270 @@
+package commenter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v38/github"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+)
+
+const defaultConcurrency = 4
+
+// WithConcurrency sets how many comments WriteComments posts at the same time
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		o.concurrency = n
+	}
+}
+
+// WithPostInterval paces WriteComments so that a new comment is started at most once per interval
+func WithPostInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.postInterval = interval
+	}
+}
+
+// WriteComments posts each relevant comment as an individual review comment instead of batching
+// them into one review, using a bounded number of concurrent requests. A failing comment doesn't stop
+// the others; the returned results line up with comments and a BatchError lists any failures. Comments
+// are started sorted by path and line, so with WithConcurrency(1) re-runs post them in the same order.
+// Comments rejected with a 403 go to the WithPermissionFallback sink and an InsufficientPermissionsError is returned.
+// When GitHub rejects a comment because the PR was closed, merged or pushed to meanwhile the rest are skipped
+// and a PRStateChangedError is returned
+func (c *Commenter) WriteComments(comments []PRReviewComment) ([]Result, error) {
+	return c.WriteCommentsContext(context.Background(), comments)
+}
+
+// WriteCommentsContext is WriteComments using ctx for the API calls
+func (c *Commenter) WriteCommentsContext(ctx context.Context, comments []PRReviewComment) (results []Result, err error) {
+
+	ctx, span := c.opts.tracer.Start(ctx, "commenter.WriteComments", trace.WithAttributes(attribute.Int("commenter.comments", len(comments))))
+	defer func() {
+		if err != nil {
+			recordSpanError(span, err)
+		}
+		span.End()
+	}()
+
+	if err := c.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+	comments = c.normalizeComments(comments)
+
+	concurrency := c.opts.concurrency
+	if concurrency < 1 {
+		concurrency = defaultConcurrency
+	}
+	var pacer *rate.Limiter
+	if interval := c.opts.postInterval; interval > 0 {
+		pacer = rate.NewLimiter(rate.Every(interval), 1)
+	}
+
+	batchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	watch := &stateWatch{gh: c.ghConnector, cancel: cancel}
+
+	progress := newProgressReporter(c.opts.progress, len(comments))
+	results = make([]Result, len(comments))
+	slots := make(chan struct{}, concurrency)
+	var (
+		wg       sync.WaitGroup
+		mentions []int
+	)
+	capped := newFileCap(c.opts.maxPerFile)
+	idempotency := c.newIdempotency()
+	keys := commentKeys{}
+	for _, i := range postingOrder(comments) {
+		comment := comments[i]
+		key := keys.key(comment)
+		if err := watch.changed(); err != nil {
+			results[i] = Result{Comment: comment, Status: ResultSkipped, Err: err}
+			progress.report(results[i])
+			continue
+		}
+		var marker string
+		if idempotency != nil {
+			var earlier *Comment
+			if marker, earlier = idempotency.marker(key); earlier != nil {
+				c.logger().Info("comment was written by an earlier attempt", "file", comment.FileName, "line", comment.EndLine, "comment_id", earlier.ID)
+				results[i] = Result{Comment: comment, Status: ResultUnchanged, CommentID: earlier.ID, URL: earlier.URL}
+				progress.report(results[i])
+				continue
+			}
+		}
+		if state := c.opts.resumeState; state != nil {
+			if entry, ok := state.postedAs(key); ok {
+				results[i] = Result{Comment: comment, Status: ResultUnchanged, CommentID: entry.CommentID, URL: entry.URL}
+				progress.report(results[i])
+				continue
+			}
+		}
+		if !c.pathAllowed(comment.FileName) {
+			c.logger().Info("skipping comment on a filtered path", "file", comment.FileName)
+			c.metrics().Add(MetricCommentsSkipped, 1)
+			results[i] = Result{
+				Comment: comment,
+				Status:  ResultSuppressed,
+				Err:     fmt.Errorf("%s is excluded by the path filters: %w", comment.FileName, ErrSuppressed),
+			}
+			progress.report(results[i])
+			continue
+		}
+		anchored, info, mention := c.placement(comment)
+		if mention {
+			results[i] = Result{Comment: comment}
+			mentions = append(mentions, i)
+			continue
+		}
+		if info == nil {
+			c.logger().Info("skipping comment outside the diff", "file", comment.FileName, "start_line", comment.StartLine, "end_line", comment.EndLine)
+			c.metrics().Add(MetricCommentsSkipped, 1)
+			results[i] = Result{
+				Comment: comment,
+				Status:  ResultSkipped,
+				Err:     newCommentNotValidError(comment.FileName, comment.StartLine),
+			}
+			progress.report(results[i])
+			continue
+		}
+		if capped.full(i, comment.FileName, info) {
+			results[i] = Result{Comment: comment}
+			continue
+		}
+		if pacer != nil {
+			if err := pacer.Wait(ctx); err != nil {
+				results[i] = Result{Comment: comment, Status: ResultFailed, Err: err}
+				progress.report(results[i])
+				continue
+			}
+		}
+
+		written := c.withSnippet(anchored, info)
+		if marker != "" {
+			written.Body += "\n\n" + marker
+		}
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i] = c.writeComment(batchCtx, written, info.sha)
+			if state := c.opts.resumeState; state != nil && results[i].Status == ResultCreated {
+				if err := state.recordPosted(ResumeEntry{Fingerprint: key, CommentID: results[i].CommentID, URL: results[i].URL}); err != nil {
+					c.logger().Info("could not save the resume state", "error", err)
+				}
+			}
+			// the result reports the comment as given, even when it was anchored on another line
+			results[i].Comment = comment
+			watch.observe(ctx, results[i])
+			if err := watch.changed(); err != nil && results[i].Status == ResultFailed && errors.Is(results[i].Err, context.Canceled) {
+				results[i].Status, results[i].Err = ResultSkipped, err
+			}
+			progress.report(results[i])
+		}(i)
+	}
+	wg.Wait()
+	if err := watch.changed(); err != nil {
+		// comments held back for the summary or the per file cap are never written
+		for i := range results {
+			if results[i].Status == "" {
+				results[i].Status, results[i].Err = ResultSkipped, err
+				progress.report(results[i])
+			}
+		}
+		return results, err
+	}
+	c.writeMentions(ctx, results, mentions)
+	c.writeOverflow(ctx, capped, results)
+	for _, i := range mentions {
+		progress.report(results[i])
+	}
+	for _, file := range capped.files {
+		for _, i := range capped.overflow[file] {
+			progress.report(results[i])
+		}
+	}
+
+	var forbidden []InlineComment
+	var cause error
+	for _, result := range results {
+		if result.Status == ResultFailed && permissionDenied(result.Err) {
+			forbidden = append(forbidden, InlineComment{
+				Path:      result.Comment.FileName,
+				StartLine: result.Comment.StartLine,
+				EndLine:   result.Comment.EndLine,
+				Body:      result.Comment.Body,
+			})
+			cause = result.Err
+		}
+	}
+	if len(forbidden) > 0 {
+		return results, c.fallback(ctx, "", c.text(MessageRejectedComments), forbidden, cause)
+	}
+	return results, newBatchError(results)
+}
+
+// postingOrder returns the indexes of comments sorted by path, then lines, then body
+func postingOrder(comments []PRReviewComment) []int {
+	order := make([]int, len(comments))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		x, y := comments[order[a]], comments[order[b]]
+		if x.FileName != y.FileName {
+			return x.FileName < y.FileName
+		}
+		if x.StartLine != y.StartLine {
+			return x.StartLine < y.StartLine
+		}
+		if x.EndLine != y.EndLine {
+			return x.EndLine < y.EndLine
+		}
+		return x.Body < y.Body
+	})
+	return order
+}
+
+func (c *Commenter) writeComment(ctx context.Context, comment PRReviewComment, sha string) Result {
+	created, err := c.provider.CreateInlineComment(ctx, InlineComment{
+		Path:      comment.FileName,
+		StartLine: comment.StartLine,
+		EndLine:   comment.EndLine,
+		Body:      comment.Body,
+		CommitSHA: sha,
+	})
+	if err != nil {
+		return Result{Comment: comment, Status: ResultFailed, Err: err}
+	}
+	c.rememberComment(created)
+	return Result{
+		Comment:   comment,
+		Status:    ResultCreated,
+		CommentID: created.ID,
+		URL:       created.URL,
+	}
+}
+
+func buildReviewComment(comment PRReviewComment, sha string) *github.PullRequestComment {
+	side := "RIGHT"
+	prComment := &github.PullRequestComment{
+		Body:     &comment.Body,
+		Path:     &comment.FileName,
+		CommitID: &sha,
+		Line:     &comment.EndLine,
+		Side:     &side,
+	}
+	if comment.StartLine < comment.EndLine {
+		startSide := "RIGHT"
+		prComment.StartLine = &comment.StartLine
+		prComment.StartSide = &startSide
+	}
+	return prComment
+}
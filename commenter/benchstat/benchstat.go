@@ -0,0 +1,184 @@
+// Package benchstat reports the benchmarks a PR slows down, from the old/new comparison of benchstat
+package benchstat
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+)
+
+// StickyKey is the WriteStickyComment key of the comment Write keeps up to date
+const StickyKey = "benchstat"
+
+var (
+	headerRegex = regexp.MustCompile(`^name\s+old (\S+)\s+new (\S+)\s+delta\s*$`)
+	rowRegex    = regexp.MustCompile(`^(\S+)\s+(.+?)\s+([+-]\d+(?:\.\d+)?%|~)(?:\s+\(p=(\d+(?:\.\d+)?) n=([^)]*)\))?\s*$`)
+	valueRegex  = regexp.MustCompile(`\S+(?: ± \S+)?`)
+)
+
+// Comparison is a row of benchstat's old/new table, the change of one benchmark in one unit
+type Comparison struct {
+	Name string
+	// Unit is the measure of the table the row is in, such as "time/op", "alloc/op" or "speed"
+	Unit string
+	// Old and New are the values as benchstat prints them, such as "10.0ns ± 1%"
+	Old string
+	New string
+	// Delta is the change in percent, 0 when it isn't Significant
+	Delta float64
+	// Significant is false when benchstat printed "~" as the change isn't statistically significant
+	Significant bool
+	// P is the p-value of the change, 0 when benchstat didn't print one
+	P float64
+	// Samples is benchstat's sample count, such as "10+10"
+	Samples string
+}
+
+// Parse reads the tables benchstat prints comparing an old and a new set of results, rows of units
+// benchstat didn't compare and the geomean rows are left out
+func Parse(r io.Reader) ([]Comparison, error) {
+	var comparisons []Comparison
+	var unit string
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if groups := headerRegex.FindStringSubmatch(line); groups != nil {
+			unit = groups[1]
+			continue
+		}
+		groups := rowRegex.FindStringSubmatch(line)
+		if groups == nil || strings.HasPrefix(groups[1], "[") {
+			continue
+		}
+		if unit == "" {
+			return nil, fmt.Errorf("benchstat line %d: row before a table header", n)
+		}
+		values := valueRegex.FindAllString(groups[2], -1)
+		if len(values) != 2 {
+			return nil, fmt.Errorf("benchstat line %d: malformed values %q", n, groups[2])
+		}
+		comparison := Comparison{Name: groups[1], Unit: unit, Old: values[0], New: values[1], Samples: groups[5]}
+		if groups[3] != "~" {
+			delta, err := strconv.ParseFloat(strings.TrimSuffix(groups[3], "%"), 64)
+			if err != nil {
+				return nil, fmt.Errorf("benchstat line %d: malformed delta %q", n, groups[3])
+			}
+			comparison.Delta, comparison.Significant = delta, true
+		}
+		if groups[4] != "" {
+			p, err := strconv.ParseFloat(groups[4], 64)
+			if err != nil {
+				return nil, fmt.Errorf("benchstat line %d: malformed p-value %q", n, groups[4])
+			}
+			comparison.P = p
+		}
+		comparisons = append(comparisons, comparison)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read benchstat output: %w", err)
+	}
+	return comparisons, nil
+}
+
+// Regressed reports whether the change is significant and worse by more than threshold percent.
+// Lower is better for every unit but throughputs such as "speed", where a drop is the regression
+func (c Comparison) Regressed(threshold float64) bool {
+	if !c.Significant {
+		return false
+	}
+	if higherIsBetter(c.Unit) {
+		return -c.Delta > threshold
+	}
+	return c.Delta > threshold
+}
+
+func higherIsBetter(unit string) bool {
+	return unit == "speed" || strings.HasSuffix(unit, "/s")
+}
+
+// Summary renders the comparisons as a comment with a table of the regressions by more than threshold
+// percent, followed by the details of every benchmark in a collapsible section
+func Summary(comparisons []Comparison, threshold float64) string {
+	var b strings.Builder
+	b.WriteString("### Benchmarks\n\n")
+	if len(comparisons) == 0 {
+		b.WriteString("No benchmarks were compared.\n")
+		return b.String()
+	}
+
+	var regressions []Comparison
+	byName := map[string][]Comparison{}
+	var names []string
+	for _, comparison := range comparisons {
+		if comparison.Regressed(threshold) {
+			regressions = append(regressions, comparison)
+		}
+		if _, ok := byName[comparison.Name]; !ok {
+			names = append(names, comparison.Name)
+		}
+		byName[comparison.Name] = append(byName[comparison.Name], comparison)
+	}
+	sort.Strings(names)
+
+	switch len(regressions) {
+	case 0:
+		fmt.Fprintf(&b, "No benchmark regressed by more than %s%%.\n", formatPercent(threshold))
+	default:
+		noun := "regressions"
+		if len(regressions) == 1 {
+			noun = "regression"
+		}
+		fmt.Fprintf(&b, "**%d %s by more than %s%%**\n\n", len(regressions), noun, formatPercent(threshold))
+		b.WriteString("| Benchmark | Unit | Old | New | Delta |\n|---|---|---|---|---|\n")
+		for _, r := range regressions {
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s |\n", r.Name, r.Unit, r.Old, r.New, formatDelta(r))
+		}
+	}
+
+	for _, name := range names {
+		marker := "🟢"
+		for _, comparison := range byName[name] {
+			if comparison.Regressed(threshold) {
+				marker = "🔴"
+				break
+			}
+		}
+		fmt.Fprintf(&b, "\n<details><summary>%s <code>%s</code></summary>\n\n", marker, name)
+		b.WriteString("| Unit | Old | New | Delta | p |\n|---|---|---|---|---|\n")
+		for _, comparison := range byName[name] {
+			p := ""
+			if comparison.P > 0 || comparison.Samples != "" {
+				p = fmt.Sprintf("%g (n=%s)", comparison.P, comparison.Samples)
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", comparison.Unit, comparison.Old, comparison.New, formatDelta(comparison), p)
+		}
+		b.WriteString("\n</details>\n")
+	}
+	return b.String()
+}
+
+func formatDelta(c Comparison) string {
+	if !c.Significant {
+		return "~"
+	}
+	return fmt.Sprintf("%+.2f%%", c.Delta)
+}
+
+func formatPercent(percent float64) string {
+	return strconv.FormatFloat(percent, 'f', -1, 64)
+}
+
+// Write keeps the Summary of the comparisons in a single comment on the PR, edited on every run
+func Write(ctx context.Context, c *commenter.Commenter, comparisons []Comparison, threshold float64) error {
+	return c.WriteStickyCommentContext(ctx, StickyKey, Summary(comparisons, threshold))
+}
@@ -0,0 +1,304 @@
+// Package bitbucket provides a commenter.Provider writing Bitbucket Cloud pull request comments
+package bitbucket
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/internal/rest"
+)
+
+// DefaultBaseURL is the Bitbucket Cloud API
+const DefaultBaseURL = "https://api.bitbucket.org/2.0"
+
+// Provider implements commenter.Provider for a single Bitbucket Cloud pull request
+type Provider struct {
+	api       *rest.Client
+	workspace string
+	repo      string
+	id        int
+	headSHA   string
+	userUUID  string
+}
+
+var (
+	_ commenter.Provider   = (*Provider)(nil)
+	_ commenter.Identifier = (*Provider)(nil)
+)
+
+// Option configures the provider
+type Option func(*Provider)
+
+// WithBaseURL points the provider at another API root, mostly for tests
+func WithBaseURL(baseURL string) Option {
+	return func(p *Provider) {
+		p.api.BaseURL = baseURL
+	}
+}
+
+// WithHTTPClient sets the client used for API calls
+func WithHTTPClient(client *http.Client) Option {
+	return func(p *Provider) {
+		p.api.HTTP = client
+	}
+}
+
+// WithAppPassword authenticates with a username and app password instead of an access token
+func WithAppPassword(username, password string) Option {
+	return func(p *Provider) {
+		req := &http.Request{Header: http.Header{}}
+		req.SetBasicAuth(username, password)
+		p.api.Header.Set("Authorization", req.Header.Get("Authorization"))
+	}
+}
+
+type pullRequest struct {
+	ID     int `json:"id"`
+	Source struct {
+		Commit struct {
+			Hash string `json:"hash"`
+		} `json:"commit"`
+	} `json:"source"`
+}
+
+type user struct {
+	UUID        string `json:"uuid"`
+	DisplayName string `json:"display_name"`
+}
+
+type content struct {
+	Raw string `json:"raw"`
+}
+
+type inline struct {
+	Path     string `json:"path"`
+	To       int    `json:"to,omitempty"`
+	StartTo  int    `json:"start_to,omitempty"`
+	Outdated bool   `json:"outdated,omitempty"`
+}
+
+type comment struct {
+	ID      int64   `json:"id"`
+	Content content `json:"content"`
+	User    user    `json:"user"`
+	Inline  *inline `json:"inline,omitempty"`
+	Parent  *struct {
+		ID int64 `json:"id"`
+	} `json:"parent,omitempty"`
+	Deleted bool `json:"deleted"`
+	Links   struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+}
+
+type commentPage struct {
+	Values []*comment `json:"values"`
+	Next   string     `json:"next"`
+}
+
+type commentRequest struct {
+	Content content `json:"content"`
+	Inline  *inline `json:"inline,omitempty"`
+}
+
+// New creates a provider for pull request id of workspace/repo authenticating with an access token,
+// use WithAppPassword for app passwords. The pull request and the token's user are looked up straight away
+func New(ctx context.Context, token, workspace, repo string, id int, opts ...Option) (*Provider, error) {
+	p := &Provider{
+		api: &rest.Client{
+			Forge:   "Bitbucket",
+			BaseURL: DefaultBaseURL,
+			Header:  http.Header{"Authorization": []string{"Bearer " + token}},
+		},
+		workspace: workspace,
+		repo:      repo,
+		id:        id,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	var pr pullRequest
+	if _, err := p.api.Do(ctx, http.MethodGet, p.prPath(""), nil, nil, &pr); err != nil {
+		var restErr *rest.Error
+		if errors.As(err, &restErr) && restErr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("PR number [%d] not found for %s/%s: %w", id, workspace, repo, commenter.ErrPRNotFound)
+		}
+		return nil, err
+	}
+	p.headSHA = pr.Source.Commit.Hash
+
+	var u user
+	if _, err := p.api.Do(ctx, http.MethodGet, "/user", nil, nil, &u); err != nil {
+		return nil, fmt.Errorf("resolve the token's user: %w", err)
+	}
+	p.userUUID = u.UUID
+	return p, nil
+}
+
+// CommenterName implements commenter.Identifier with the uuid of the token's user
+func (p *Provider) CommenterName() string {
+	return p.userUUID
+}
+
+// ListChangedFiles implements commenter.Provider from the pull request diff
+func (p *Provider) ListChangedFiles(ctx context.Context) ([]*commenter.ChangedFile, error) {
+	diff, err := p.api.Raw(ctx, p.prPath("/diff"), nil)
+	if err != nil {
+		return nil, err
+	}
+	return commenter.ParseUnifiedDiff(bytes.NewReader(diff), p.headSHA)
+}
+
+// ListComments implements commenter.Provider with the inline comments which haven't been deleted and
+// the replies to them, a reply is InReplyTo the comment starting its thread like on GitHub
+func (p *Provider) ListComments(ctx context.Context) ([]*commenter.Comment, error) {
+	var all []*comment
+	byID := map[int64]*comment{}
+	path, query := p.prPath("/comments"), url.Values{"pagelen": {"100"}}
+	for path != "" {
+		var page commentPage
+		if _, err := p.api.Do(ctx, http.MethodGet, path, query, nil, &page); err != nil {
+			return nil, err
+		}
+		for _, c := range page.Values {
+			all = append(all, c)
+			byID[c.ID] = c
+		}
+		var err error
+		if path, query, err = p.nextPage(page.Next); err != nil {
+			return nil, err
+		}
+	}
+
+	var comments []*commenter.Comment
+	for _, c := range all {
+		if c.Deleted {
+			continue
+		}
+		root := threadRoot(c, byID)
+		if root.Inline == nil {
+			continue
+		}
+		result := p.toComment(c)
+		if root != c {
+			result.InReplyTo = root.ID
+			if c.Inline == nil {
+				anchor := p.toComment(root)
+				result.Path, result.Line, result.StartLine, result.Outdated = anchor.Path, anchor.Line, anchor.StartLine, anchor.Outdated
+			}
+		}
+		comments = append(comments, result)
+	}
+	return comments, nil
+}
+
+// threadRoot follows the parents of c to the comment starting its thread, Bitbucket nests replies
+// to replies while GitHub threads are flat
+func threadRoot(c *comment, byID map[int64]*comment) *comment {
+	seen := map[int64]bool{c.ID: true}
+	for c.Parent != nil {
+		parent, ok := byID[c.Parent.ID]
+		if !ok || seen[parent.ID] {
+			break
+		}
+		seen[parent.ID] = true
+		c = parent
+	}
+	return c
+}
+
+// CreateInlineComment implements commenter.Provider
+func (p *Provider) CreateInlineComment(ctx context.Context, c commenter.InlineComment) (*commenter.Comment, error) {
+	req := commentRequest{
+		Content: content{Raw: c.Body},
+		Inline:  &inline{Path: c.Path, To: c.EndLine},
+	}
+	if c.StartLine < c.EndLine {
+		req.Inline.StartTo = c.StartLine
+	}
+	var created comment
+	if _, err := p.api.Do(ctx, http.MethodPost, p.prPath("/comments"), nil, req, &created); err != nil {
+		return nil, fmt.Errorf("create comment on %s line %d: %w", c.Path, c.EndLine, err)
+	}
+	result := p.toComment(&created)
+	result.StartLine = c.StartLine
+	return result, nil
+}
+
+// CreateSummaryComment implements commenter.Provider with a comment on the pull request itself
+func (p *Provider) CreateSummaryComment(ctx context.Context, body string) (*commenter.Comment, error) {
+	var created comment
+	if _, err := p.api.Do(ctx, http.MethodPost, p.prPath("/comments"), nil, commentRequest{Content: content{Raw: body}}, &created); err != nil {
+		return nil, fmt.Errorf("create summary comment: %w", err)
+	}
+	return p.toComment(&created), nil
+}
+
+// UpdateComment implements commenter.Provider
+func (p *Provider) UpdateComment(ctx context.Context, c *commenter.Comment, body string) error {
+	path := p.prPath("/comments/" + strconv.FormatInt(c.ID, 10))
+	if _, err := p.api.Do(ctx, http.MethodPut, path, nil, commentRequest{Content: content{Raw: body}}, nil); err != nil {
+		return fmt.Errorf("update existing comment %d: %w", c.ID, err)
+	}
+	return nil
+}
+
+// DeleteComment implements commenter.Provider
+func (p *Provider) DeleteComment(ctx context.Context, c *commenter.Comment) error {
+	path := p.prPath("/comments/" + strconv.FormatInt(c.ID, 10))
+	if _, err := p.api.Do(ctx, http.MethodDelete, path, nil, nil, nil); err != nil {
+		return fmt.Errorf("delete existing comment %d: %w", c.ID, err)
+	}
+	return nil
+}
+
+func (p *Provider) toComment(c *comment) *commenter.Comment {
+	result := &commenter.Comment{
+		ID:     c.ID,
+		Body:   c.Content.Raw,
+		Author: c.User.UUID,
+		URL:    c.Links.HTML.Href,
+	}
+	if c.Inline != nil {
+		result.Path = c.Inline.Path
+		result.Line = c.Inline.To
+		result.StartLine = c.Inline.StartTo
+		if result.StartLine == 0 {
+			result.StartLine = c.Inline.To
+		}
+		// comments on removed lines have no new side line
+		result.Outdated = c.Inline.Outdated || c.Inline.To == 0
+	}
+	return result
+}
+
+func (p *Provider) prPath(suffix string) string {
+	return fmt.Sprintf("/repositories/%s/%s/pullrequests/%d%s", url.PathEscape(p.workspace), url.PathEscape(p.repo), p.id, suffix)
+}
+
+// nextPage splits the absolute next link of a page into a path below the base url and its query, an
+// empty path means there is no next page
+func (p *Provider) nextPage(next string) (string, url.Values, error) {
+	if next == "" {
+		return "", nil, nil
+	}
+	u, err := url.Parse(next)
+	if err != nil {
+		return "", nil, fmt.Errorf("parse next page link %s: %w", next, err)
+	}
+	base, err := url.Parse(p.api.BaseURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("parse base url %s: %w", p.api.BaseURL, err)
+	}
+	return strings.TrimPrefix(u.Path, strings.TrimSuffix(base.Path, "/")), u.Query(), nil
+}
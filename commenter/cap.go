@@ -0,0 +1,103 @@
+package commenter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// WithMaxCommentsPerFile caps the inline comments written on one file, the rest are listed together
+// in a single comment at the top of the file's diff so one badly linted file doesn't dominate the review
+func WithMaxCommentsPerFile(n int) Option {
+	return func(o *options) {
+		o.maxPerFile = n
+	}
+}
+
+// fileCap counts the inline comments of each file and keeps the ones over WithMaxCommentsPerFile
+type fileCap struct {
+	max      int
+	counts   map[string]int
+	overflow map[string][]int
+	infos    map[string]*CommitFileInfo
+	files    []string
+}
+
+func newFileCap(max int) *fileCap {
+	return &fileCap{max: max, counts: map[string]int{}, overflow: map[string][]int{}, infos: map[string]*CommitFileInfo{}}
+}
+
+// full records comment i on file and reports whether it is over the cap, over cap comments are kept
+func (f *fileCap) full(i int, file string, info *CommitFileInfo) bool {
+	if f.max < 1 {
+		return false
+	}
+	if f.counts[file] < f.max {
+		f.counts[file]++
+		return false
+	}
+	if _, ok := f.overflow[file]; !ok {
+		f.files = append(f.files, file)
+		f.infos[file] = info
+	}
+	f.overflow[file] = append(f.overflow[file], i)
+	return true
+}
+
+// overflowComment lists the comments over the cap of a file in one comment on the first line of its hunk
+func (c *Commenter) overflowComment(file string, info *CommitFileInfo, comments []PRReviewComment) PRReviewComment {
+	var b strings.Builder
+	b.WriteString(c.text(MessageMoreInFile, len(comments)) + "\n")
+	for _, comment := range comments {
+		location := commentLocation(InlineComment{Path: comment.FileName, StartLine: comment.StartLine, EndLine: comment.EndLine})
+		fmt.Fprintf(&b, "\n- `%s`: %s", location, strings.ReplaceAll(comment.Body, "\n", " "))
+	}
+	return PRReviewComment{
+		FileName:  file,
+		StartLine: info.hunkStartLine,
+		EndLine:   info.hunkStartLine,
+		Body:      b.String(),
+	}
+}
+
+// writeOverflow writes the overflow comment of every capped file and marks its comments summarized
+func (c *Commenter) writeOverflow(ctx context.Context, capped *fileCap, results []Result) {
+	for _, file := range capped.files {
+		indexes := capped.overflow[file]
+		comments := make([]PRReviewComment, 0, len(indexes))
+		for _, i := range indexes {
+			comments = append(comments, results[i].Comment)
+		}
+		info := capped.infos[file]
+		written := c.writeComment(ctx, c.overflowComment(file, info, comments), info.sha)
+		for _, i := range indexes {
+			if written.Status != ResultCreated {
+				results[i].Status, results[i].Err = ResultFailed, written.Err
+				continue
+			}
+			results[i].Status, results[i].CommentID, results[i].URL = ResultSummarized, written.CommentID, written.URL
+		}
+	}
+}
+
+// overflowDrafts turns the comments over the cap of each file into one draft per file
+func (c *Commenter) overflowDrafts(capped *fileCap, comments []PRReviewComment) []*github.DraftReviewComment {
+	var drafts []*github.DraftReviewComment
+	for _, file := range capped.files {
+		var overflowed []PRReviewComment
+		for _, i := range capped.overflow[file] {
+			overflowed = append(overflowed, comments[i])
+		}
+		comment := c.overflowComment(file, capped.infos[file], overflowed)
+		side := "RIGHT"
+		drafts = append(drafts, &github.DraftReviewComment{
+			Body: &comment.Body,
+			Path: &comment.FileName,
+			Line: &comment.EndLine,
+			Side: &side,
+		})
+	}
+	return drafts
+}
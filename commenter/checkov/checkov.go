@@ -0,0 +1,97 @@
+// Package checkov reads the failed checks of checkov -o json as findings
+package checkov
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+)
+
+// Tool names the findings of Parse
+const Tool = "checkov"
+
+type check struct {
+	CheckID       string  `json:"check_id"`
+	CheckName     string  `json:"check_name"`
+	FilePath      string  `json:"file_path"`
+	RepoFilePath  string  `json:"repo_file_path"`
+	FileLineRange []int   `json:"file_line_range"`
+	Resource      string  `json:"resource"`
+	Severity      *string `json:"severity"`
+	Guideline     string  `json:"guideline"`
+}
+
+type report struct {
+	CheckType string `json:"check_type"`
+	Results   struct {
+		FailedChecks []check `json:"failed_checks"`
+	} `json:"results"`
+}
+
+// Parse reads the report checkov -o json writes, or the array of them it writes when several
+// frameworks ran, as findings of the failed checks linked to their guideline. The files are
+// relative to the repository when checkov ran from its root, checks without a severity, which
+// needs a Prisma Cloud API key, are SeverityUnknown
+func Parse(r io.Reader) ([]commenter.Finding, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read checkov output: %w", err)
+	}
+	var reports []report
+	switch data = bytes.TrimSpace(data); {
+	case len(data) == 0:
+	case data[0] == '[':
+		err = json.Unmarshal(data, &reports)
+	default:
+		reports = make([]report, 1)
+		err = json.Unmarshal(data, &reports[0])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode checkov output: %w", err)
+	}
+
+	var findings []commenter.Finding
+	for _, rep := range reports {
+		for _, c := range rep.Results.FailedChecks {
+			finding := commenter.Finding{
+				Tool:    Tool,
+				RuleID:  c.CheckID,
+				Path:    c.path(),
+				Message: c.message(),
+			}
+			if c.Severity != nil {
+				finding.Severity, _ = commenter.ParseSeverity(*c.Severity)
+			}
+			if len(c.FileLineRange) == 2 {
+				finding.StartLine, finding.EndLine = c.FileLineRange[0], c.FileLineRange[1]
+			}
+			findings = append(findings, finding)
+		}
+	}
+	return findings, nil
+}
+
+// path is the file of the check relative to the repository, checkov starts both paths with a
+// slash when they are relative to the directory it scanned
+func (c check) path() string {
+	if c.RepoFilePath != "" {
+		return strings.TrimPrefix(c.RepoFilePath, "/")
+	}
+	return strings.TrimPrefix(c.FilePath, "/")
+}
+
+func (c check) message() string {
+	message := c.CheckName
+	if c.Resource != "" {
+		message += fmt.Sprintf(" (`%s`)", c.Resource)
+	}
+	if c.Guideline != "" {
+		message += fmt.Sprintf(" ([guideline](%s))", c.Guideline)
+	}
+	return message
+}
@@ -0,0 +1,180 @@
+package commenter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+)
+
+const (
+	// maxCheckRunAnnotations is how many annotations GitHub accepts in one check run request
+	maxCheckRunAnnotations = 50
+	// maxCheckRunText is GitHub's limit on the summary and on the text of a check run output
+	maxCheckRunText = 65535
+)
+
+// CheckRunReport is a check run written by WriteCheckRun, a persistent per commit report page which
+// doesn't depend on PR comments
+type CheckRunReport struct {
+	Name  string
+	Title string
+	// Summary defaults to the number of findings
+	Summary string
+	// Text is the markdown report body, images and links included. It defaults to RenderReport
+	Text string
+	// Conclusion is one of the check run conclusions, it defaults to the Policy result when Policy is
+	// set and otherwise to "neutral" when there are findings and "success" when there are none
+	Conclusion string
+	Policy     *Policy
+	// Findings become the annotations of the check run
+	Findings []Finding
+}
+
+// WriteCheckRun creates a completed check run on the head commit and returns its ID
+func (c *Commenter) WriteCheckRun(report CheckRunReport) (int64, error) {
+	return c.WriteCheckRunContext(context.Background(), report)
+}
+
+// WriteCheckRunContext is WriteCheckRun using ctx for the API calls
+func (c *Commenter) WriteCheckRunContext(ctx context.Context, report CheckRunReport) (int64, error) {
+	gh := c.repoConnector()
+	if gh == nil {
+		return 0, fmt.Errorf("write check run: %w", ErrNotSupported)
+	}
+	if err := c.ensureLoaded(ctx); err != nil {
+		return 0, err
+	}
+
+	title := report.Title
+	if title == "" {
+		title = report.Name
+	}
+	summary := report.Summary
+	if summary == "" {
+		summary = c.text(MessageFindingCount, len(report.Findings))
+	}
+	text := report.Text
+	if text == "" && len(report.Findings) > 0 {
+		text = c.RenderReport(report.Findings)
+	}
+	conclusion := report.Conclusion
+	switch {
+	case conclusion != "":
+	case report.Policy != nil && report.Policy.Evaluate(report.Findings, nil).Passed:
+		conclusion = "success"
+	case report.Policy != nil:
+		conclusion = "failure"
+	case len(report.Findings) > 0:
+		conclusion = "neutral"
+	default:
+		conclusion = "success"
+	}
+
+	annotations := make([]*github.CheckRunAnnotation, 0, len(report.Findings))
+	for _, finding := range report.Findings {
+		comment := finding.Comment()
+		annotation := &github.CheckRunAnnotation{
+			Path:            github.String(comment.FileName),
+			StartLine:       github.Int(comment.StartLine),
+			EndLine:         github.Int(comment.EndLine),
+			AnnotationLevel: github.String(severityAnnotationLevel(finding.Severity)),
+			Message:         github.String(finding.Message),
+		}
+		if finding.RuleID != "" {
+			annotation.Title = github.String(finding.RuleID)
+		}
+		annotations = append(annotations, annotation)
+	}
+
+	output := &github.CheckRunOutput{
+		Title:   github.String(title),
+		Summary: github.String(truncate(summary, maxCheckRunText)),
+	}
+	if text != "" {
+		output.Text = github.String(truncate(text, maxCheckRunText))
+	}
+	// not retried as a retry after a failed annotation update would create a second run
+	run, err := createCheckRun(ctx, c.opts.audit, gh.client, gh.owner, gh.repo, github.CreateCheckRunOptions{
+		Name:       report.Name,
+		HeadSHA:    c.headSHA(),
+		Status:     github.String("completed"),
+		Conclusion: github.String(conclusion),
+		Output:     output,
+	}, annotations)
+	if err != nil {
+		return 0, fmt.Errorf("write check run %s: %w", report.Name, err)
+	}
+	return run.GetID(), nil
+}
+
+// RenderReport renders findings as a markdown table for a report body, with English column headings
+func RenderReport(findings []Finding) string {
+	return renderReport(LocaleEnglish[MessageReportHeader], findings)
+}
+
+// RenderReport is RenderReport with the column headings from the WithLocale catalog
+func (c *Commenter) RenderReport(findings []Finding) string {
+	return renderReport(c.text(MessageReportHeader), findings)
+}
+
+func renderReport(header string, findings []Finding) string {
+	var b strings.Builder
+	b.WriteString(header + "\n| --- | --- | --- | --- |\n")
+	for _, finding := range findings {
+		comment := finding.Comment()
+		location := commentLocation(InlineComment{Path: comment.FileName, StartLine: comment.StartLine, EndLine: comment.EndLine})
+		fmt.Fprintf(&b, "| %s | `%s` | %s | %s |\n", finding.Severity, location, escapeTableCell(finding.RuleID), escapeTableCell(finding.Message))
+	}
+	return b.String()
+}
+
+// createCheckRun creates the check run with the first annotations and appends the rest by updating
+// it, GitHub only accepts maxCheckRunAnnotations per request. The requests are recorded in audit unless it is nil
+func createCheckRun(ctx context.Context, audit *auditLog, client *github.Client, owner, repo string, opts github.CreateCheckRunOptions, annotations []*github.CheckRunAnnotation) (*github.CheckRun, error) {
+	first := annotations
+	if len(first) > maxCheckRunAnnotations {
+		first = first[:maxCheckRunAnnotations]
+	}
+	output := *opts.Output
+	output.Annotations = first
+	opts.Output = &output
+	run, resp, err := client.Checks.CreateCheckRun(ctx, owner, repo, opts)
+	audit.record("Checks.CreateCheckRun", resp, err)
+	if err != nil {
+		return nil, wrapAPIError(err)
+	}
+	for rest := annotations[len(first):]; len(rest) > 0; {
+		batch := rest
+		if len(batch) > maxCheckRunAnnotations {
+			batch = batch[:maxCheckRunAnnotations]
+		}
+		rest = rest[len(batch):]
+		update := output
+		update.Annotations = batch
+		_, resp, err := client.Checks.UpdateCheckRun(ctx, owner, repo, run.GetID(), github.UpdateCheckRunOptions{
+			Name:   opts.Name,
+			Output: &update,
+		})
+		audit.record("Checks.UpdateCheckRun", resp, err)
+		if err != nil {
+			return nil, wrapAPIError(err)
+		}
+	}
+	return run, nil
+}
+
+func severityAnnotationLevel(severity Severity) string {
+	switch {
+	case severity >= SeverityError:
+		return "failure"
+	case severity == SeverityWarning, severity == SeverityUnknown:
+		return "warning"
+	}
+	return "notice"
+}
+
+func escapeTableCell(s string) string {
+	return strings.NewReplacer("|", "\\|", "\r", "", "\n", "<br>").Replace(s)
+}
@@ -0,0 +1,91 @@
+// Package clangtidy reads the diagnostics clang-tidy prints as findings
+package clangtidy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+)
+
+// Tool names the findings of Parse
+const Tool = "clang-tidy"
+
+// lineRegex matches clang-tidy's "file:line:column: severity: message [check]" lines
+var lineRegex = regexp.MustCompile(`^(.+?):(\d+):(\d+): (warning|error|note): (.*?)(?: \[([\w.,-]+)\])?$`)
+
+// Parse reads the diagnostics of clang-tidy's output as findings of the check linked to its
+// documentation. The notes below a diagnostic are added to its message, and a diagnostic reported
+// again for each file including the same header is only returned once. The files are as clang-tidy
+// reports them, usually absolute, see commenter.WithRepoRoot
+func Parse(r io.Reader) ([]commenter.Finding, error) {
+	var findings []commenter.Finding
+	seen := map[string]bool{}
+	// skipping is set while the notes of a diagnostic left out as a repeat are read
+	skipping := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		groups := lineRegex.FindStringSubmatch(strings.TrimRight(scanner.Text(), "\r"))
+		if groups == nil {
+			continue
+		}
+		file, severity, message := groups[1], groups[4], groups[5]
+		line, _ := strconv.Atoi(groups[2])
+		if severity == "note" {
+			if last := len(findings) - 1; last >= 0 && !skipping {
+				findings[last].Message += fmt.Sprintf("\n\nnote: %s (`%s:%d`)", message, file, line)
+			}
+			continue
+		}
+
+		// the first check is the one of the diagnostic, clang-tidy adds -warnings-as-errors after it
+		check := strings.Split(groups[6], ",")[0]
+		key := strings.Join([]string{file, groups[2], groups[3], check, message}, "\x00")
+		if skipping = seen[key]; skipping {
+			continue
+		}
+		seen[key] = true
+		if url := DocsURL(check); url != "" {
+			message += fmt.Sprintf(" ([%s](%s))", check, url)
+		}
+		finding := commenter.Finding{
+			Tool:      Tool,
+			RuleID:    check,
+			Severity:  commenter.SeverityWarning,
+			Path:      file,
+			StartLine: line,
+			EndLine:   line,
+			Message:   message,
+		}
+		if severity == "error" {
+			finding.Severity = commenter.SeverityError
+		}
+		findings = append(findings, finding)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read clang-tidy output: %w", err)
+	}
+	return findings, nil
+}
+
+// DocsURL returns the documentation of the check, such as bugprone-use-after-move, "" for the
+// compiler's clang-diagnostic checks
+func DocsURL(check string) string {
+	if check == "" || strings.HasPrefix(check, "clang-diagnostic-") {
+		return ""
+	}
+	group, name := check, ""
+	if strings.HasPrefix(check, "clang-analyzer-") {
+		group, name = "clang-analyzer", strings.TrimPrefix(check, "clang-analyzer-")
+	} else if dash := strings.Index(check, "-"); dash > 0 {
+		group, name = check[:dash], check[dash+1:]
+	}
+	if name == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://clang.llvm.org/extra/clang-tidy/checks/%s/%s.html", group, name)
+}
@@ -5,15 +5,38 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/google/go-github/v38/github"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-// Commenter is the main commenter struct
+// Commenter is the main commenter struct, it is safe for concurrent use by multiple goroutines
 type Commenter struct {
-	ghConnector      *connector
-	existingComments []*existingComment
+	provider Provider
+	// ghConnector is nil when the provider isn't GitHub
+	ghConnector *connector
+	opts        *options
+
+	// loadMu serializes loading the PR info so concurrent first calls only load it once
+	loadMu sync.Mutex
+	// mu guards existingComments, files and loaded, API calls are made against snapshots taken under it
+	mu               sync.RWMutex
+	existingComments []*Comment
 	files            []*CommitFileInfo
+	// fileHunks indexes files by name, so placing a comment doesn't scan every hunk of the PR
+	fileHunks map[string][]*CommitFileInfo
+	// patchless are the changed files GitHub shows no patch for, such as binary files
+	patchless map[string]bool
+	// foldedFiles are the changed files by their lower case name for WithCaseInsensitivePaths
+	foldedFiles map[string]string
+	loaded      bool
+	// mentions are drafted comments outside the diff for the body of the next review
+	mentions []PRReviewComment
+	// contents caches the lines of files read for WithContentsFetch, nil when the read failed
+	contents map[string][]string
 }
 
 type CommitFileInfo struct {
@@ -21,6 +44,11 @@ type CommitFileInfo struct {
 	hunkStartLine int
 	hunkEndLine   int
 	sha           string
+	// lines are the new side lines shown by the patch, added those of them which were added
+	lines map[int]string
+	added map[int]bool
+	// positions are the diff positions of the new side lines of the whole patch, shared by its hunks
+	positions map[int]int
 }
 
 type PRReviewComment struct {
@@ -31,105 +59,504 @@ type PRReviewComment struct {
 }
 
 var (
-	patchRegex     = regexp.MustCompile(`^@@.*\+(\d+),(\d+).+?@@`)
+	patchHunkRegex = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
 	commitRefRegex = regexp.MustCompile(".+ref=(.+)")
 )
 
 const (
-	Approve            = "APPROVE"
-	RequestChanges     = "REQUEST_CHANGES"
+	Approve        = "APPROVE"
+	RequestChanges = "REQUEST_CHANGES"
+	// Pending leaves the review unsubmitted for a person to edit and submit from the GitHub UI
+	Pending            = "PENDING"
 	ApproveBody        = "Approve:tada:"
 	RequestChangesBody = "Request changes:rotating_light:"
 )
 
+// ErrNotSupported returned when the provider has no equivalent of a GitHub specific operation
+var ErrNotSupported = errors.New("operation not supported by this provider")
+
 // NewCommenter creates a Commenter for updating PR with comments
-func NewCommenter(token, owner, repo string, prNumber int) (*Commenter, error) {
+func NewCommenter(token, owner, repo string, prNumber int, opts ...Option) (*Commenter, error) {
+	return NewCommenterContext(context.Background(), token, owner, repo, prNumber, opts...)
+}
+
+// NewCommenterContext is NewCommenter using ctx for checking the PR exists. The PR's files and existing
+// comments are loaded on first use, call Refresh to reload them
+func NewCommenterContext(ctx context.Context, token, owner, repo string, prNumber int, opts ...Option) (*Commenter, error) {
 
-	if len(token) == 0 {
+	o := newOptions(opts)
+	if len(token) == 0 && o.tokenSource == nil && o.client == nil && o.pullRequests == nil {
 		return nil, errors.New("the GITHUB_TOKEN has not been set")
 	}
 
-	ghConnector, err := createConnector(token, owner, repo, prNumber)
+	ghConnector, err := createConnector(ctx, token, owner, repo, prNumber, o)
 	if err != nil {
 		return nil, err
 	}
 
-	commitFileInfos, existingComments, err := ghConnector.getPRInfo()
+	return &Commenter{
+		provider:    ghConnector,
+		ghConnector: ghConnector,
+		opts:        o,
+	}, nil
+}
+
+// Refresh reloads the PR's files and existing comments, e.g. after a push to the PR mid-run
+func (c *Commenter) Refresh(ctx context.Context) error {
+	c.loadMu.Lock()
+	defer c.loadMu.Unlock()
+	return c.loadPRInfo(ctx, false)
+}
+
+// ensureLoaded loads the PR info unless it already has been, a failed load is retried on the next call
+func (c *Commenter) ensureLoaded(ctx context.Context) error {
+	c.loadMu.Lock()
+	defer c.loadMu.Unlock()
+	c.mu.RLock()
+	loaded := c.loaded
+	c.mu.RUnlock()
+	if loaded {
+		return nil
+	}
+	return c.loadPRInfo(ctx, true)
+}
+
+// loadPRInfo reads the changed files and the commenter's existing comments from the provider, or
+// from the snapshot cache when cached is set
+func (c *Commenter) loadPRInfo(ctx context.Context, cached bool) error {
+
+	ctx, span := c.opts.tracer.Start(ctx, "commenter.LoadPRInfo")
+	defer span.End()
+
+	var (
+		changedFiles     []*ChangedFile
+		existingComments []*Comment
+		snapshot         *Snapshot
+	)
+	if cached {
+		snapshot, cached = c.cachedSnapshot()
+	}
+	if cached {
+		c.logger().Debug("using cached PR snapshot", "head_sha", snapshot.HeadSHA, "fetched_at", snapshot.FetchedAt)
+		changedFiles, existingComments = snapshot.Files, snapshot.Comments
+	} else {
+		var err error
+		if changedFiles, existingComments, err = c.fetchPRInfo(ctx); err != nil {
+			recordSpanError(span, err)
+			return err
+		}
+		c.storeSnapshot(changedFiles, existingComments)
+	}
+	span.SetAttributes(attribute.Bool("commenter.cached", cached))
+
+	commitFileInfos, err := getCommitFileInfos(changedFiles)
 	if err != nil {
-		return nil, err
+		recordSpanError(span, err)
+		return err
 	}
 
-	return &Commenter{
-		ghConnector:      ghConnector,
-		existingComments: existingComments,
-		files:            commitFileInfos,
-	}, nil
+	span.SetAttributes(
+		attribute.Int("commenter.files", len(commitFileInfos)),
+		attribute.Int("commenter.existing_comments", len(existingComments)),
+	)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.files = commitFileInfos
+	c.fileHunks = make(map[string][]*CommitFileInfo, len(commitFileInfos))
+	for _, info := range commitFileInfos {
+		c.fileHunks[info.fileName] = append(c.fileHunks[info.fileName], info)
+	}
+	c.patchless = map[string]bool{}
+	for _, file := range changedFiles {
+		if file.Status == "deleted" || file.Status == "renamed" || c.fileHunks[file.Filename] != nil {
+			continue
+		}
+		c.patchless[file.Filename] = true
+		if file.Changes > 0 {
+			c.logger().Info("skipping file without a patch", "file", file.Filename, "changes", file.Changes)
+		}
+	}
+	c.foldedFiles = nil
+	if c.opts.caseInsensitivePaths {
+		c.foldedFiles = make(map[string]string, len(changedFiles))
+		for _, file := range changedFiles {
+			c.foldedFiles[strings.ToLower(file.Filename)] = file.Filename
+		}
+	}
+	c.existingComments = existingComments
+	c.contents = nil
+	c.loaded = true
+	return nil
+}
+
+// fetchPRInfo lists the changed files and comments, at the same time for a GitHub PR. Other providers,
+// and GitHub with WithLegacyPositions, are listed one after the other as they read their comments'
+// positions from the files' patches
+func (c *Commenter) fetchPRInfo(ctx context.Context) ([]*ChangedFile, []*Comment, error) {
+	var (
+		changedFiles []*ChangedFile
+		comments     []*Comment
+		filesErr     error
+		commentsErr  error
+	)
+	if _, ok := c.provider.(*connector); ok && !c.opts.legacyPositions {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			changedFiles, filesErr = c.provider.ListChangedFiles(ctx)
+		}()
+		comments, commentsErr = c.provider.ListComments(ctx)
+		wg.Wait()
+	} else {
+		if changedFiles, filesErr = c.provider.ListChangedFiles(ctx); filesErr == nil {
+			comments, commentsErr = c.provider.ListComments(ctx)
+		}
+	}
+	if filesErr != nil {
+		return nil, nil, filesErr
+	}
+	if commentsErr != nil {
+		return nil, nil, commentsErr
+	}
+	author := c.commenterName()
+	var existingComments []*Comment
+	for _, comment := range comments {
+		if comment.Author == author {
+			existingComments = append(existingComments, comment)
+		}
+	}
+	return changedFiles, existingComments, nil
+}
+
+func getCommitFileInfos(files []*ChangedFile) ([]*CommitFileInfo, error) {
+
+	var (
+		errs            []string
+		commitFileInfos []*CommitFileInfo
+	)
+
+	for _, file := range files {
+		if file.Status == "deleted" || file.Status == "renamed" {
+			continue
+		}
+		infos, err := getCommitInfos(file)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		commitFileInfos = append(commitFileInfos, infos...)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("there were errors processing the PR files.\n%s", strings.Join(errs, "\n"))
+	}
+	return commitFileInfos, nil
+}
+
+// getCommitInfos returns the info of each hunk of the file's patch. Files GitHub shows no patch for,
+// such as binary, very large or empty files, have no hunks
+func getCommitInfos(file *ChangedFile) ([]*CommitFileInfo, error) {
+	var infos []*CommitFileInfo
+	positions := patchPositions(file.Patch)
+	for _, hunk := range splitHunks(file.Patch) {
+		groups := hunkHeaderRegex.FindStringSubmatch(hunk)
+		if groups == nil {
+			continue
+		}
+		// a hunk header leaves out the count of a single line
+		hunkStart, _ := strconv.Atoi(groups[3])
+		hunkLength := 1
+		if groups[4] != "" {
+			hunkLength, _ = strconv.Atoi(groups[4])
+		}
+		lines, added := patchLines(hunk)
+		infos = append(infos, &CommitFileInfo{
+			fileName:      file.Filename,
+			hunkStartLine: hunkStart,
+			hunkEndLine:   hunkStart + (hunkLength - 1),
+			sha:           file.CommitSHA,
+			lines:         lines,
+			added:         added,
+			positions:     positions,
+		})
+	}
+	if len(infos) > 0 && file.CommitSHA == "" {
+		return nil, errors.New("the sha details could not be resolved")
+	}
+	return infos, nil
+}
+
+// splitHunks splits patch into its hunks, each starting with its header
+func splitHunks(patch string) []string {
+	var (
+		hunks []string
+		hunk  []string
+	)
+	for _, line := range strings.Split(patch, "\n") {
+		if strings.HasPrefix(line, "@@") {
+			if hunk != nil {
+				hunks = append(hunks, strings.Join(hunk, "\n"))
+			}
+			hunk = []string{line}
+			continue
+		}
+		if hunk != nil {
+			hunk = append(hunk, line)
+		}
+	}
+	if hunk != nil {
+		hunks = append(hunks, strings.Join(hunk, "\n"))
+	}
+	return hunks
+}
+
+// PositionFor returns the position of the new side line of file in its patch, which the legacy review
+// comment and commit comment APIs take instead of a line: the number of lines below the first hunk
+// header, later hunk headers counting as lines. ok is false when the patch doesn't show the line, or the
+// PR info, loaded first if it hasn't been, can't be
+func (c *Commenter) PositionFor(file string, line int) (position int, ok bool) {
+	if err := c.ensureLoaded(context.Background()); err != nil {
+		return 0, false
+	}
+	for _, hunk := range c.hunksOf(file) {
+		if position, ok := hunk.positions[line]; ok {
+			return position, true
+		}
+	}
+	return 0, false
 }
 
+// CreateDraftPRReviewComments drafts the comments which are in the diff, sorted by path and line so
+// re-runs produce identical reviews. When the PR info can't be loaded nothing is drafted, the error is
+// logged and returned again by WritePRReview
 func (c *Commenter) CreateDraftPRReviewComments(comments []PRReviewComment) []*github.DraftReviewComment {
 	var draftReviewComments []*github.DraftReviewComment
-	for i := range comments {
+	if err := c.ensureLoaded(context.Background()); err != nil {
+		c.logger().Info("could not load the PR info", "error", err)
+		return nil
+	}
+	comments = c.normalizeComments(comments)
+	capped := newFileCap(c.opts.maxPerFile)
+	for _, i := range postingOrder(comments) {
 		comment := comments[i]
-		if c.checkCommentRelevant(comment.FileName, comment.StartLine, comment.EndLine) {
-			reviewCommentSide := "RIGHT"
-			draftReviewComment := &github.DraftReviewComment{
-				Body: &comment.Body,
-				Path: &comment.FileName,
-				Line: &comment.EndLine,
-				Side: &reviewCommentSide,
-			}
-			if comment.StartLine < comment.EndLine {
-				reviewCommentStartSide := "RIGHT"
-				draftReviewComment.StartLine = &comment.StartLine
-				draftReviewComment.StartSide = &reviewCommentStartSide
+		if !c.pathAllowed(comment.FileName) {
+			c.logger().Info("skipping comment on a filtered path", "file", comment.FileName)
+			c.metrics().Add(MetricCommentsSkipped, 1)
+			continue
+		}
+		comment, info, mention := c.placement(comment)
+		if info == nil {
+			if mention {
+				c.mention(comment)
+				continue
 			}
-			draftReviewComments = append(draftReviewComments, draftReviewComment)
+			c.logger().Info("skipping comment outside the diff", "file", comment.FileName, "start_line", comment.StartLine, "end_line", comment.EndLine)
+			c.metrics().Add(MetricCommentsSkipped, 1)
+			continue
+		}
+		if capped.full(i, comment.FileName, info) {
+			continue
+		}
+		comment = c.withSnippet(comment, info)
+		reviewCommentSide := "RIGHT"
+		draftReviewComment := &github.DraftReviewComment{
+			Body: &comment.Body,
+			Path: &comment.FileName,
+			Line: &comment.EndLine,
+			Side: &reviewCommentSide,
+		}
+		if comment.StartLine < comment.EndLine {
+			reviewCommentStartSide := "RIGHT"
+			draftReviewComment.StartLine = &comment.StartLine
+			draftReviewComment.StartSide = &reviewCommentStartSide
 		}
+		draftReviewComments = append(draftReviewComments, draftReviewComment)
+	}
+	return append(draftReviewComments, c.overflowDrafts(capped, comments)...)
+}
+
+// GitHubClient returns the underlying go-github client for endpoints the commenter doesn't cover,
+// nil when the commenter uses another provider
+func (c *Commenter) GitHubClient() *github.Client {
+	if p, ok := c.provider.(interface{ githubClient() *github.Client }); ok {
+		return p.githubClient()
 	}
-	return draftReviewComments
+	return nil
 }
 
-func (c *Commenter) checkCommentRelevant(filename string, startLine int, endLine int) bool {
-	for _, file := range c.files {
-		if file.fileName == filename && startLine >= file.hunkStartLine && startLine <= file.hunkEndLine && endLine >= file.hunkStartLine && endLine <= file.hunkEndLine {
-			return true
+func (c *Commenter) logger() Logger {
+	return c.opts.logger
+}
+
+func (c *Commenter) metrics() Metrics {
+	return c.opts.metrics
+}
+
+// fileInfoFor returns the info of the file hunk containing both lines, nil when there is none
+func (c *Commenter) fileInfoFor(filename string, startLine int, endLine int) *CommitFileInfo {
+	for _, file := range c.hunksOf(filename) {
+		if startLine >= file.hunkStartLine && startLine <= file.hunkEndLine && endLine >= file.hunkStartLine && endLine <= file.hunkEndLine {
+			return file
 		}
 	}
-	return false
+	return nil
 }
 
 func (c *Commenter) WritePRReview(comments []*github.DraftReviewComment, event string) error {
+	return c.WritePRReviewContext(context.Background(), comments, event)
+}
+
+// WritePRReviewContext is WritePRReview using ctx for the API calls. Providers without reviews
+// get the comments written inline and the review body as a summary comment. A Pending review replaces
+// the commenter's earlier pending one and is only supported on GitHub
+func (c *Commenter) WritePRReviewContext(ctx context.Context, comments []*github.DraftReviewComment, event string) error {
+
+	if err := c.ensureLoaded(ctx); err != nil {
+		return err
+	}
 
-	ctx := context.Background()
-	errs := c.removeAlreadyExistComments(ctx)
-	for _, err := range errs {
-		fmt.Printf("%s\n", err)
+	// a pending review isn't published yet, so the comments it replaces are kept until it is submitted
+	if event != Pending {
+		// a comment which couldn't be deleted is left next to the new review rather than failing it
+		for _, err := range c.removeAlreadyExistComments(ctx) {
+			c.logger().Info("delete existing comment", "error", err)
+		}
 	}
-	body, err := selectBodyBy(event)
+	body, err := c.reviewBody(event)
 	if err != nil {
 		return err
 	}
+	if mentions := c.takeMentions(); len(mentions) > 0 {
+		body += "\n\n" + c.outsideDiffSummary(mentions)
+	}
+	if c.ghConnector == nil {
+		if event == Pending {
+			return fmt.Errorf("pending review: %w", ErrNotSupported)
+		}
+		return c.writeReviewWithoutReviews(ctx, comments, event, body)
+	}
 	err = c.ghConnector.CreatePRReview(ctx, event, body, comments)
+	if err == nil && len(comments) > 0 {
+		// GitHub doesn't return the review's comments, they are read back on the next call
+		c.invalidate()
+	}
+	if permissionDenied(err) {
+		return c.fallback(ctx, event, body, draftsToInline(comments), err)
+	}
+	var validationErr ValidationError
+	if errors.As(err, &validationErr) {
+		if stateErr := c.ghConnector.stateChanged(ctx); stateErr != nil {
+			return stateErr
+		}
+	}
+	return err
+}
+
+func (c *Commenter) writeReviewWithoutReviews(ctx context.Context, drafts []*github.DraftReviewComment, event, body string) error {
+	inline := draftsToInline(drafts)
+	comments := make([]PRReviewComment, 0, len(inline))
+	for _, comment := range inline {
+		comments = append(comments, PRReviewComment{
+			FileName:  comment.Path,
+			StartLine: comment.StartLine,
+			EndLine:   comment.EndLine,
+			Body:      comment.Body,
+		})
+	}
+	if _, err := c.WriteCommentsContext(ctx, comments); err != nil {
+		return err
+	}
+	_, err := c.writeSummary(ctx, body)
+	if permissionDenied(err) {
+		return c.fallback(ctx, event, body, nil, err)
+	}
 	return err
 }
 
 func (c *Commenter) removeAlreadyExistComments(ctx context.Context) []error {
 	var errs []error
-	for _, comment := range c.existingComments {
-		err := c.ghConnector.DeletePRReviewComment(ctx, comment.commentId)
+	deleted := map[int64]bool{}
+	for _, comment := range c.snapshotExistingComments() {
+		err := c.deleteComment(ctx, comment)
 		if err != nil {
 			errs = append(errs, err)
+			continue
 		}
+		deleted[comment.ID] = true
 	}
+	c.forgetComments(deleted)
 	return errs
 }
 
-func selectBodyBy(event string) (string, error) {
+func (c *Commenter) snapshotFiles() []*CommitFileInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]*CommitFileInfo(nil), c.files...)
+}
+
+// hunksOf returns the hunks of the file, the slice is shared and must not be modified
+func (c *Commenter) hunksOf(filename string) []*CommitFileInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.fileHunks[filename]
+}
+
+func (c *Commenter) snapshotExistingComments() []*Comment {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]*Comment(nil), c.existingComments...)
+}
+
+// forgetComments drops comments which no longer exist on the provider from the existing comments
+func (c *Commenter) forgetComments(ids map[int64]bool) {
+	if len(ids) == 0 {
+		return
+	}
+	c.mu.Lock()
+	var remaining []*Comment
+	for _, comment := range c.existingComments {
+		if !ids[comment.ID] {
+			remaining = append(remaining, comment)
+		}
+	}
+	c.existingComments = remaining
+	c.mu.Unlock()
+	c.updateSnapshot(func(comments []*Comment) []*Comment {
+		var kept []*Comment
+		for _, comment := range comments {
+			if !ids[comment.ID] {
+				kept = append(kept, comment)
+			}
+		}
+		return kept
+	})
+}
+
+// rememberComment adds a comment the commenter just wrote to its existing comments, so later calls
+// on the commenter match it as they would after loading the PR again
+func (c *Commenter) rememberComment(comment *Comment) {
+	if comment.Author == "" {
+		comment.Author = c.commenterName()
+	}
+	if comment.Author != c.commenterName() {
+		return
+	}
+	c.mu.Lock()
+	c.existingComments = append(c.existingComments, comment)
+	c.mu.Unlock()
+	c.updateSnapshot(func(comments []*Comment) []*Comment {
+		return append(comments[:len(comments):len(comments)], comment)
+	})
+}
+
+func (c *Commenter) reviewBody(event string) (string, error) {
 	switch event {
 	case Approve:
-		return ApproveBody, nil
+		return c.text(MessageApprove), nil
 	case RequestChanges:
-		return RequestChangesBody, nil
+		return c.text(MessageRequestChanges), nil
+	case Pending:
+		return "", nil
 	default:
 		return "", fmt.Errorf("this event type is not supported")
 	}
@@ -0,0 +1,933 @@
+// Package commentertest provides a fake GitHub API for testing tools built on the commenter
+package commentertest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+)
+
+// HeadSHA is the commit every fixture file is reported at
+const HeadSHA = "3f786850e387550fdab836ed7e6dc881de23001b"
+
+var hunkStartRegex = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)`)
+
+// Server is a fake GitHub API serving a single pull request from fixtures and recording
+// every comment, review and deletion made against it
+type Server struct {
+	*httptest.Server
+
+	Owner  string
+	Repo   string
+	Number int
+	// Branch is the head branch of the pull request
+	Branch string
+	// Author is the login which opened the pull request
+	Author string
+	// Bot is the login the comments and reviews written through the server are authored by
+	Bot string
+	// ReadOnly rejects every write with a 403, like the token of a pull request from a fork
+	ReadOnly bool
+	// SSOURL rejects every request with the 403 of an organization enforcing SAML SSO when set
+	SSOURL string
+	// Scopes is sent as X-OAuth-Scopes when set, as for a classic personal access token
+	Scopes string
+	// PageSize splits the pull request's files and comments into pages of at most that many, linked
+	// with a Link header as GitHub does. They aren't paged when it is 0
+	PageSize int
+	// ListFilesLimit lists at most that many of the pull request's files when set, as GitHub stops
+	// listing them at 3000 and only serves the rest in the pull request's diff
+	ListFilesLimit int
+	// DiffTooLarge refuses to serve the pull request's diff, as GitHub does for very large diffs
+	DiffTooLarge bool
+
+	mu             sync.Mutex
+	files          []*github.CommitFile
+	comments       []*github.PullRequestComment
+	reviews        []*github.PullRequestReviewRequest
+	issueComments  []*github.IssueComment
+	issues         []int
+	contents       map[string]string
+	gists          []*github.Gist
+	statuses       []*github.RepoStatus
+	checkRuns      []*github.CheckRun
+	dismissed      []int64
+	commitComments []*github.RepositoryComment
+	deleted        []int64
+	reactions      map[int64][]*github.Reaction
+	merged         bool
+	head           string
+	commits        map[string][]*github.CommitFile
+	deletedReviews map[int64]bool
+	graphqlBodies  []string
+	requests       int
+	pageRequests   int
+	nextID         int64
+}
+
+// NewServer starts a fake GitHub serving pull request number of owner/repo, Close it when done
+func NewServer(owner, repo string, number int) *Server {
+	s := &Server{
+		Owner:  owner,
+		Repo:   repo,
+		Number: number,
+		Branch: "feature",
+		Author: "octocat",
+		Bot:    commenter.CommenterName,
+		nextID: 1000,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Options returns the commenter options which point it at the fake server
+func (s *Server) Options() []commenter.Option {
+	return []commenter.Option{commenter.WithBaseURL(s.URL + "/")}
+}
+
+// NewCommenter creates a commenter for the fake pull request, opts are applied after the server options
+func (s *Server) NewCommenter(opts ...commenter.Option) (*commenter.Commenter, error) {
+	return commenter.NewCommenter("fake-token", s.Owner, s.Repo, s.Number, append(s.Options(), opts...)...)
+}
+
+// AddContent serves content as the file at path on the head commit
+func (s *Server) AddContent(path, content string) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.contents == nil {
+		s.contents = map[string]string{}
+	}
+	s.contents[path] = content
+	return s
+}
+
+// AddIssue adds an issue which isn't a pull request, it can only take conversation comments
+func (s *Server) AddIssue(number int) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.issues = append(s.issues, number)
+	return s
+}
+
+// AddFile adds a changed file with the given unified diff patch to the pull request, replacing the
+// patch of a file added before as a force push would
+func (s *Server) AddFile(filename, patch string) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, file := range s.files {
+		if file.GetFilename() == filename {
+			s.files = append(s.files[:i], s.files[i+1:]...)
+			break
+		}
+	}
+	changes := 0
+	if patch != "" {
+		changes = strings.Count(patch, "\n") + 1
+	}
+	s.files = append(s.files, &github.CommitFile{
+		SHA:         github.String(HeadSHA),
+		Filename:    github.String(filename),
+		Status:      github.String("modified"),
+		Patch:       github.String(patch),
+		Changes:     github.Int(changes),
+		ContentsURL: github.String(fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s", s.URL, s.Owner, s.Repo, filename, HeadSHA)),
+	})
+	return s
+}
+
+// AddBinaryFile adds a changed binary file, which GitHub lists without a patch
+func (s *Server) AddBinaryFile(filename string) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files = append(s.files, &github.CommitFile{
+		SHA:         github.String(HeadSHA),
+		Filename:    github.String(filename),
+		Status:      github.String("added"),
+		Changes:     github.Int(0),
+		ContentsURL: github.String(fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s", s.URL, s.Owner, s.Repo, filename, HeadSHA)),
+	})
+	return s
+}
+
+// AddCommit makes sha an earlier commit of the pull request which changed filename with patch, call it
+// again with the same sha for every file the commit changed
+func (s *Server) AddCommit(sha, filename, patch string) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.commits == nil {
+		s.commits = map[string][]*github.CommitFile{}
+	}
+	s.commits[sha] = append(s.commits[sha], &github.CommitFile{
+		SHA:         github.String(sha),
+		Filename:    github.String(filename),
+		Status:      github.String("modified"),
+		Patch:       github.String(patch),
+		Changes:     github.Int(strings.Count(patch, "\n") + 1),
+		ContentsURL: github.String(fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s", s.URL, s.Owner, s.Repo, filename, sha)),
+	})
+	return s
+}
+
+// AddComment adds an existing review comment by author, returning its id
+func (s *Server) AddComment(author, path string, line int, body string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	comment := &github.PullRequestComment{
+		Path:     github.String(path),
+		Line:     github.Int(line),
+		Position: github.Int(line),
+		Body:     github.String(body),
+		CommitID: github.String(HeadSHA),
+		User:     &github.User{Login: github.String(author)},
+	}
+	s.storeComment(comment)
+	return comment.GetID()
+}
+
+// AddIssueComment adds a comment by author to the pull request conversation, returning its id
+func (s *Server) AddIssueComment(author, body string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	comment := &github.IssueComment{
+		ID:       github.Int64(s.nextID),
+		Body:     github.String(body),
+		User:     &github.User{Login: github.String(author)},
+		IssueURL: github.String(fmt.Sprintf("%s/repos/%s/%s/issues/%d", s.URL, s.Owner, s.Repo, s.Number)),
+	}
+	s.issueComments = append(s.issueComments, comment)
+	return comment.GetID()
+}
+
+// AddReply adds a reply by author to the review comment inReplyTo, returning its id
+func (s *Server) AddReply(author string, inReplyTo int64, body string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	comment := &github.PullRequestComment{
+		InReplyTo: github.Int64(inReplyTo),
+		Body:      github.String(body),
+		CommitID:  github.String(HeadSHA),
+		User:      &github.User{Login: github.String(author)},
+	}
+	for _, parent := range s.comments {
+		if parent.GetID() == inReplyTo {
+			comment.Path, comment.Line, comment.Position = parent.Path, parent.Line, parent.Position
+		}
+	}
+	s.storeComment(comment)
+	return comment.GetID()
+}
+
+// AddReaction adds a reaction by user to the review comment id, content is one of GitHub's reactions such as +1
+func (s *Server) AddReaction(id int64, user, content string) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.reactions == nil {
+		s.reactions = map[int64][]*github.Reaction{}
+	}
+	s.nextID++
+	s.reactions[id] = append(s.reactions[id], &github.Reaction{
+		ID:      github.Int64(s.nextID),
+		User:    &github.User{Login: github.String(user)},
+		Content: github.String(content),
+	})
+	return s
+}
+
+// Outdate makes the review comment id look made on commitID before a force push moved its line out of the diff
+func (s *Server) Outdate(id int64, commitID string) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, comment := range s.comments {
+		if comment.GetID() == id {
+			comment.CommitID = github.String(commitID)
+			comment.OriginalLine, comment.Line, comment.Position = comment.Line, nil, nil
+		}
+	}
+	return s
+}
+
+// Merge merges the pull request, later comments and reviews are rejected like GitHub does
+func (s *Server) Merge() *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.merged = true
+	return s
+}
+
+// Push moves the head of the pull request to sha, later comments made against HeadSHA are rejected
+func (s *Server) Push(sha string) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.head = sha
+	return s
+}
+
+// Comments returns the review comments currently on the pull request
+func (s *Server) Comments() []*github.PullRequestComment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*github.PullRequestComment(nil), s.comments...)
+}
+
+// IssueComments returns the comments made on the pull request or issue conversations rather than on files
+func (s *Server) IssueComments() []*github.IssueComment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*github.IssueComment(nil), s.issueComments...)
+}
+
+// Statuses returns the commit statuses set on the head commit
+func (s *Server) Statuses() []*github.RepoStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*github.RepoStatus(nil), s.statuses...)
+}
+
+// CheckRuns returns the check runs created, with every annotation they were updated with
+func (s *Server) CheckRuns() []*github.CheckRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*github.CheckRun(nil), s.checkRuns...)
+}
+
+// DismissedReviewIDs returns the IDs of the reviews dismissed so far, reviews are numbered from 1
+func (s *Server) DismissedReviewIDs() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int64(nil), s.dismissed...)
+}
+
+// Gists returns the gists created
+func (s *Server) Gists() []*github.Gist {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*github.Gist(nil), s.gists...)
+}
+
+// CommitComments returns the comments made on commits rather than the pull request
+func (s *Server) CommitComments() []*github.RepositoryComment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*github.RepositoryComment(nil), s.commitComments...)
+}
+
+// Reviews returns the reviews submitted so far
+func (s *Server) Reviews() []*github.PullRequestReviewRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var reviews []*github.PullRequestReviewRequest
+	for i, review := range s.reviews {
+		if !s.deletedReviews[int64(i+1)] {
+			reviews = append(reviews, review)
+		}
+	}
+	return reviews
+}
+
+// DeletedCommentIDs returns the ids of the review comments deleted so far
+func (s *Server) DeletedCommentIDs() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int64(nil), s.deleted...)
+}
+
+// GraphQLRequests returns the raw bodies of the GraphQL requests received so far
+func (s *Server) GraphQLRequests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.graphqlBodies...)
+}
+
+func (s *Server) storeComment(comment *github.PullRequestComment) {
+	s.nextID++
+	id := s.nextID
+	now := time.Now()
+	comment.ID = &id
+	comment.NodeID = github.String(fmt.Sprintf("PRRC_%d", id))
+	comment.HTMLURL = github.String(fmt.Sprintf("https://github.com/%s/%s/pull/%d#discussion_r%d", s.Owner, s.Repo, s.Number, id))
+	comment.CreatedAt = &now
+	if comment.DiffHunk == nil && comment.GetLine() > 0 {
+		comment.DiffHunk = s.diffHunk(comment.GetPath(), comment.GetLine())
+	}
+	// GitHub answers with the position of comments made by line, only dropping it once they are outdated
+	if comment.Position == nil && comment.Line != nil {
+		comment.Position = comment.Line
+	}
+	s.comments = append(s.comments, comment)
+}
+
+// diffHunk returns the patch of path from its hunk header to line, as GitHub reports with a comment
+func (s *Server) diffHunk(path string, line int) *string {
+	for _, file := range s.files {
+		if file.GetFilename() != path {
+			continue
+		}
+		var hunk []string
+		newLine := 0
+		for _, l := range strings.Split(file.GetPatch(), "\n") {
+			if strings.HasPrefix(l, "@@") {
+				hunk = []string{l}
+				if groups := hunkStartRegex.FindStringSubmatch(l); groups != nil {
+					newLine, _ = strconv.Atoi(groups[1])
+				}
+				continue
+			}
+			hunk = append(hunk, l)
+			if strings.HasPrefix(l, "-") {
+				continue
+			}
+			if newLine == line {
+				return github.String(strings.Join(hunk, "\n"))
+			}
+			newLine++
+		}
+	}
+	return nil
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	w.Header().Set("X-GitHub-Request-Id", fmt.Sprintf("FAKE:%d", s.requests))
+
+	if s.SSOURL != "" {
+		w.Header().Set("X-GitHub-SSO", "required; url="+s.SSOURL)
+		writeJSON(w, http.StatusForbidden, map[string]string{"message": "Resource protected by organization SAML enforcement. You must grant your Personal Access token access to this organization."})
+		return
+	}
+	if s.ReadOnly && r.Method != http.MethodGet {
+		writeJSON(w, http.StatusForbidden, map[string]string{"message": "Resource not accessible by integration"})
+		return
+	}
+
+	if r.URL.Path == "/graphql" || r.URL.Path == "/api/graphql" {
+		var body json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.graphqlBodies = append(s.graphqlBodies, string(body))
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{}})
+		return
+	}
+
+	if r.URL.Path == "/gists" && r.Method == http.MethodPost {
+		gist := new(github.Gist)
+		if err := json.NewDecoder(r.Body).Decode(gist); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.nextID++
+		gist.ID = github.String(strconv.FormatInt(s.nextID, 10))
+		gist.HTMLURL = github.String("https://gist.github.com/" + gist.GetID())
+		s.gists = append(s.gists, gist)
+		writeJSON(w, http.StatusCreated, gist)
+		return
+	}
+	if s.Scopes != "" {
+		w.Header().Set("X-OAuth-Scopes", s.Scopes)
+	}
+	if r.URL.Path == fmt.Sprintf("/repos/%s/%s", s.Owner, s.Repo) && r.Method == http.MethodGet {
+		writeJSON(w, http.StatusOK, &github.Repository{
+			Name:        github.String(s.Repo),
+			Private:     github.Bool(true),
+			Permissions: map[string]bool{"pull": true, "push": !s.ReadOnly},
+		})
+		return
+	}
+	if r.URL.Path == fmt.Sprintf("/repos/%s/%s/statuses/%s", s.Owner, s.Repo, HeadSHA) && r.Method == http.MethodPost {
+		status := new(github.RepoStatus)
+		if err := json.NewDecoder(r.Body).Decode(status); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.nextID++
+		status.ID = github.Int64(s.nextID)
+		s.statuses = append(s.statuses, status)
+		writeJSON(w, http.StatusCreated, status)
+		return
+	}
+	if checkRuns := fmt.Sprintf("/repos/%s/%s/check-runs", s.Owner, s.Repo); strings.HasPrefix(r.URL.Path, checkRuns) {
+		s.handleCheckRun(w, r, strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, checkRuns), "/"))
+		return
+	}
+	if contents := fmt.Sprintf("/repos/%s/%s/contents/", s.Owner, s.Repo); strings.HasPrefix(r.URL.Path, contents) && r.Method == http.MethodGet {
+		path := strings.TrimPrefix(r.URL.Path, contents)
+		content, ok := s.contents[path]
+		if !ok || r.URL.Query().Get("ref") != HeadSHA {
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, &github.RepositoryContent{
+			Type:     github.String("file"),
+			Path:     github.String(path),
+			Encoding: github.String("base64"),
+			Content:  github.String(base64.StdEncoding.EncodeToString([]byte(content))),
+		})
+		return
+	}
+	if issues := fmt.Sprintf("/repos/%s/%s/issues/", s.Owner, s.Repo); strings.HasPrefix(r.URL.Path, issues) {
+		s.handleIssue(w, r, strings.Split(strings.TrimPrefix(r.URL.Path, issues), "/"))
+		return
+	}
+
+	if commits := fmt.Sprintf("/repos/%s/%s/commits/", s.Owner, s.Repo); strings.HasPrefix(r.URL.Path, commits) {
+		s.handleCommit(w, r, strings.Split(strings.TrimPrefix(r.URL.Path, commits), "/"))
+		return
+	}
+	if r.URL.Path == fmt.Sprintf("/repos/%s/%s/pulls", s.Owner, s.Repo) && r.Method == http.MethodGet {
+		var prs []*github.PullRequest
+		if r.URL.Query().Get("head") == s.Owner+":"+s.Branch {
+			prs = append(prs, s.pullRequest())
+		}
+		writeJSON(w, http.StatusOK, prs)
+		return
+	}
+	if prefix := fmt.Sprintf("/repos/%s/%s/comments/", s.Owner, s.Repo); strings.HasPrefix(r.URL.Path, prefix) && r.Method == http.MethodDelete {
+		id, _ := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, prefix), 10, 64)
+		for i, comment := range s.commitComments {
+			if comment.GetID() == id {
+				s.commitComments = append(s.commitComments[:i], s.commitComments[i+1:]...)
+				s.deleted = append(s.deleted, id)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+
+	prefix := fmt.Sprintf("/repos/%s/%s/pulls/", s.Owner, s.Repo)
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		http.NotFound(w, r)
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, prefix), "/")
+
+	if len(parts) == 3 && parts[0] == "comments" && parts[2] == "reactions" && r.Method == http.MethodGet {
+		id, _ := strconv.ParseInt(parts[1], 10, 64)
+		reactions := s.reactions[id]
+		if reactions == nil {
+			reactions = []*github.Reaction{}
+		}
+		writeJSON(w, http.StatusOK, reactions)
+		return
+	}
+	if len(parts) == 2 && parts[0] == "comments" {
+		id, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodDelete:
+			s.deleteComment(w, id)
+		case http.MethodPatch:
+			s.editComment(w, r, id)
+		default:
+			http.NotFound(w, r)
+		}
+		return
+	}
+
+	if parts[0] != strconv.Itoa(s.Number) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet && r.Header.Get("Accept") == "application/vnd.github.v3.diff":
+		s.writeDiff(w)
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.pullRequest())
+	case len(parts) == 2 && parts[1] == "files" && r.Method == http.MethodGet:
+		files := s.files
+		if s.ListFilesLimit > 0 && len(files) > s.ListFilesLimit {
+			files = files[:s.ListFilesLimit]
+		}
+		start, end := s.page(w, r, len(files))
+		writeJSON(w, http.StatusOK, files[start:end])
+	case len(parts) == 2 && parts[1] == "comments" && r.Method == http.MethodGet:
+		start, end := s.page(w, r, len(s.comments))
+		writeJSON(w, http.StatusOK, s.comments[start:end])
+	case len(parts) == 2 && parts[1] == "comments" && r.Method == http.MethodPost:
+		comment := new(github.PullRequestComment)
+		if err := json.NewDecoder(r.Body).Decode(comment); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if s.rejectWrite(w, comment.GetCommitID()) {
+			return
+		}
+		comment.User = &github.User{Login: github.String(s.Bot)}
+		s.storeComment(comment)
+		writeJSON(w, http.StatusCreated, comment)
+	case len(parts) == 2 && parts[1] == "reviews" && r.Method == http.MethodGet:
+		reviews := make([]*github.PullRequestReview, 0, len(s.reviews))
+		for i, review := range s.reviews {
+			id := int64(i + 1)
+			if s.deletedReviews[id] {
+				continue
+			}
+			state := map[string]string{"APPROVE": "APPROVED", "REQUEST_CHANGES": "CHANGES_REQUESTED", "": "PENDING"}[review.GetEvent()]
+			if state == "" {
+				state = "COMMENTED"
+			}
+			for _, dismissed := range s.dismissed {
+				if dismissed == id {
+					state = "DISMISSED"
+				}
+			}
+			reviews = append(reviews, &github.PullRequestReview{
+				ID:    github.Int64(id),
+				State: github.String(state),
+				Body:  review.Body,
+				User:  &github.User{Login: github.String(s.Bot)},
+			})
+		}
+		writeJSON(w, http.StatusOK, reviews)
+	case len(parts) == 3 && parts[1] == "reviews" && r.Method == http.MethodDelete:
+		id, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil || id < 1 || id > int64(len(s.reviews)) || s.deletedReviews[id] {
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		if s.reviews[id-1].GetEvent() != "" {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "Can not delete a non-pending pull request review"})
+			return
+		}
+		if s.deletedReviews == nil {
+			s.deletedReviews = map[int64]bool{}
+		}
+		s.deletedReviews[id] = true
+		writeJSON(w, http.StatusOK, &github.PullRequestReview{ID: github.Int64(id), State: github.String("PENDING")})
+	case len(parts) == 4 && parts[1] == "reviews" && parts[3] == "dismissals" && r.Method == http.MethodPut:
+		id, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil || id < 1 || id > int64(len(s.reviews)) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		s.dismissed = append(s.dismissed, id)
+		writeJSON(w, http.StatusOK, &github.PullRequestReview{ID: github.Int64(id), State: github.String("DISMISSED")})
+	case len(parts) == 2 && parts[1] == "reviews" && r.Method == http.MethodPost:
+		review := new(github.PullRequestReviewRequest)
+		if err := json.NewDecoder(r.Body).Decode(review); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if s.rejectWrite(w, review.GetCommitID()) {
+			return
+		}
+		pending := review.GetEvent() == ""
+		for i, earlier := range s.reviews {
+			if pending && earlier.GetEvent() == "" && !s.deletedReviews[int64(i+1)] {
+				writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "User can only have one pending review per pull request"})
+				return
+			}
+		}
+		s.reviews = append(s.reviews, review)
+		state := review.Event
+		if pending {
+			state = github.String("PENDING")
+		}
+		// the comments of a pending review are only published once it is submitted
+		for _, draft := range review.Comments {
+			if pending {
+				break
+			}
+			s.storeComment(&github.PullRequestComment{
+				Path:      draft.Path,
+				Line:      draft.Line,
+				Position:  draft.Line,
+				StartLine: draft.StartLine,
+				Body:      draft.Body,
+				CommitID:  github.String(HeadSHA),
+				User:      &github.User{Login: github.String(s.Bot)},
+			})
+		}
+		writeJSON(w, http.StatusOK, &github.PullRequestReview{
+			ID:    github.Int64(int64(len(s.reviews))),
+			State: state,
+			Body:  review.Body,
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) pullRequest() *github.PullRequest {
+	state, head := "open", HeadSHA
+	if s.merged {
+		state = "closed"
+	}
+	if s.head != "" {
+		head = s.head
+	}
+	return &github.PullRequest{
+		Number:       github.Int(s.Number),
+		State:        github.String(state),
+		Merged:       github.Bool(s.merged),
+		Head:         &github.PullRequestBranch{SHA: github.String(head), Ref: github.String(s.Branch)},
+		User:         &github.User{Login: github.String(s.Author)},
+		ChangedFiles: github.Int(len(s.files)),
+	}
+}
+
+// writeDiff answers a request for the pull request's diff with the patches of all its files
+func (s *Server) writeDiff(w http.ResponseWriter) {
+	if s.DiffTooLarge {
+		writeJSON(w, http.StatusNotAcceptable, map[string]string{"message": "Sorry, the diff exceeded the maximum number of files (300)."})
+		return
+	}
+	var b strings.Builder
+	for _, file := range s.files {
+		name := file.GetFilename()
+		fmt.Fprintf(&b, "diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n", name, name, name, name)
+		if file.GetPatch() != "" {
+			b.WriteString(file.GetPatch() + "\n")
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, b.String())
+}
+
+// rejectWrite answers a comment or review GitHub would refuse after the pull request was merged or
+// pushed to, reporting whether it did
+func (s *Server) rejectWrite(w http.ResponseWriter, commitID string) bool {
+	switch {
+	case s.merged:
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{"message": "Validation Failed", "errors": []map[string]string{
+			{"resource": "PullRequestReviewComment", "code": "custom", "message": "pull request is closed"},
+		}})
+		return true
+	case s.head != "" && commitID != "" && commitID != s.head:
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{"message": "Validation Failed", "errors": []map[string]string{
+			{"resource": "PullRequestReviewComment", "code": "custom", "field": "pull_request_review_thread.sha", "message": "commit_id is not part of the pull request"},
+		}})
+		return true
+	}
+	return false
+}
+
+// handleCommit serves the commits added with AddCommit and every other commit with the pull request's
+// files, only HeadSHA and the added commits belong to the pull request
+func (s *Server) handleCommit(w http.ResponseWriter, r *http.Request, parts []string) {
+	sha := parts[0]
+	files, added := s.commits[sha]
+	if !added {
+		files = s.files
+	}
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, &github.RepositoryCommit{SHA: github.String(sha), Files: files})
+	case len(parts) == 2 && parts[1] == "pulls" && r.Method == http.MethodGet:
+		prs := []*github.PullRequest{}
+		if sha == HeadSHA || added {
+			prs = append(prs, s.pullRequest())
+		}
+		writeJSON(w, http.StatusOK, prs)
+	case len(parts) == 2 && parts[1] == "comments" && r.Method == http.MethodGet:
+		var comments []*github.RepositoryComment
+		for _, comment := range s.commitComments {
+			if comment.GetCommitID() == sha {
+				comments = append(comments, comment)
+			}
+		}
+		writeJSON(w, http.StatusOK, comments)
+	case len(parts) == 2 && parts[1] == "comments" && r.Method == http.MethodPost:
+		comment := new(github.RepositoryComment)
+		if err := json.NewDecoder(r.Body).Decode(comment); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.nextID++
+		comment.ID = github.Int64(s.nextID)
+		comment.CommitID = github.String(sha)
+		comment.User = &github.User{Login: github.String(s.Bot)}
+		s.commitComments = append(s.commitComments, comment)
+		writeJSON(w, http.StatusCreated, comment)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) handleCheckRun(w http.ResponseWriter, r *http.Request, id string) {
+	request := new(github.CheckRun)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if id == "" && r.Method == http.MethodPost {
+		s.nextID++
+		request.ID = github.Int64(s.nextID)
+		s.checkRuns = append(s.checkRuns, request)
+		writeJSON(w, http.StatusCreated, request)
+		return
+	}
+	for _, run := range s.checkRuns {
+		if strconv.FormatInt(run.GetID(), 10) == id && r.Method == http.MethodPatch {
+			if request.Output != nil {
+				run.Output.Annotations = append(run.Output.Annotations, request.Output.Annotations...)
+			}
+			writeJSON(w, http.StatusOK, run)
+			return
+		}
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+}
+
+func (s *Server) handleIssue(w http.ResponseWriter, r *http.Request, parts []string) {
+	if len(parts) == 2 && parts[0] == "comments" {
+		id, _ := strconv.ParseInt(parts[1], 10, 64)
+		for i, comment := range s.issueComments {
+			if comment.GetID() != id {
+				continue
+			}
+			switch r.Method {
+			case http.MethodPatch:
+				edit := new(github.IssueComment)
+				if err := json.NewDecoder(r.Body).Decode(edit); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				comment.Body = edit.Body
+				writeJSON(w, http.StatusOK, comment)
+			case http.MethodDelete:
+				s.issueComments = append(s.issueComments[:i], s.issueComments[i+1:]...)
+				s.deleted = append(s.deleted, id)
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				http.NotFound(w, r)
+			}
+			return
+		}
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+
+	number, _ := strconv.Atoi(parts[0])
+	known := number == s.Number
+	for _, issue := range s.issues {
+		known = known || issue == number
+	}
+	if !known {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, &github.Issue{Number: github.Int(number), State: github.String("open")})
+	case len(parts) == 2 && parts[1] == "comments" && r.Method == http.MethodGet:
+		issueURL := fmt.Sprintf("%s/repos/%s/%s/issues/%d", s.URL, s.Owner, s.Repo, number)
+		var comments []*github.IssueComment
+		for _, comment := range s.issueComments {
+			if comment.GetIssueURL() == issueURL {
+				comments = append(comments, comment)
+			}
+		}
+		start, end := s.page(w, r, len(comments))
+		writeJSON(w, http.StatusOK, comments[start:end])
+	case len(parts) == 2 && parts[1] == "comments" && r.Method == http.MethodPost:
+		comment := new(github.IssueComment)
+		if err := json.NewDecoder(r.Body).Decode(comment); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.nextID++
+		comment.ID = github.Int64(s.nextID)
+		comment.User = &github.User{Login: github.String(s.Bot)}
+		comment.IssueURL = github.String(fmt.Sprintf("%s/repos/%s/%s/issues/%d", s.URL, s.Owner, s.Repo, number))
+		s.issueComments = append(s.issueComments, comment)
+		writeJSON(w, http.StatusCreated, comment)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) editComment(w http.ResponseWriter, r *http.Request, id int64) {
+	edit := new(github.PullRequestComment)
+	if err := json.NewDecoder(r.Body).Decode(edit); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, comment := range s.comments {
+		if comment.GetID() == id {
+			comment.Body = edit.Body
+			writeJSON(w, http.StatusOK, comment)
+			return
+		}
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+}
+
+func (s *Server) deleteComment(w http.ResponseWriter, id int64) {
+	for i, comment := range s.comments {
+		if comment.GetID() == id {
+			s.comments = append(s.comments[:i], s.comments[i+1:]...)
+			s.deleted = append(s.deleted, id)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+}
+
+// page returns the bounds of the requested page of a listing of n items and links the next and last
+// pages from the response, the whole listing when PageSize isn't set
+func (s *Server) page(w http.ResponseWriter, r *http.Request, n int) (int, int) {
+	if s.PageSize < 1 {
+		return 0, n
+	}
+	s.pageRequests++
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	last := (n + s.PageSize - 1) / s.PageSize
+	if last < 1 {
+		last = 1
+	}
+	link := func(page int, rel string) string {
+		u := *r.URL
+		query := u.Query()
+		query.Set("page", strconv.Itoa(page))
+		u.RawQuery = query.Encode()
+		return fmt.Sprintf(`<%s%s>; rel="%s"`, s.URL, u.String(), rel)
+	}
+	if page < last {
+		w.Header().Set("Link", link(page+1, "next")+", "+link(last, "last"))
+	}
+	start, end := (page-1)*s.PageSize, page*s.PageSize
+	if start > n {
+		start = n
+	}
+	if end > n {
+		end = n
+	}
+	return start, end
+}
+
+// PageRequests returns how many paged listing requests were served
+func (s *Server) PageRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pageRequests
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
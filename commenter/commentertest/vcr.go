@@ -0,0 +1,144 @@
+package commentertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// Mode selects whether a Recorder talks to GitHub or replays a cassette
+type Mode int
+
+const (
+	// ModeReplay serves responses from the cassette and fails requests it has no recording for
+	ModeReplay Mode = iota
+	// ModeRecord forwards requests to GitHub and appends the interactions to the cassette
+	ModeRecord
+)
+
+// Interaction is a recorded request and the response GitHub gave to it
+type Interaction struct {
+	Method         string      `json:"method"`
+	URL            string      `json:"url"`
+	RequestBody    string      `json:"request_body,omitempty"`
+	StatusCode     int         `json:"status_code"`
+	ResponseHeader http.Header `json:"response_header"`
+	ResponseBody   string      `json:"response_body"`
+}
+
+// Recorder is an http.RoundTripper recording real GitHub interactions to a cassette file and
+// replaying them in order, so pagination, rate limiting and dedup can be tested without network access.
+// Pass it to the commenter with commenter.WithTransport
+type Recorder struct {
+	mode     Mode
+	path     string
+	base     http.RoundTripper
+	mu       sync.Mutex
+	recorded []Interaction
+	used     []bool
+}
+
+// NewRecorder loads the cassette at path when replaying, in ModeRecord requests go through http.DefaultTransport
+func NewRecorder(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{mode: mode, path: path, base: http.DefaultTransport}
+	if mode == ModeRecord {
+		return r, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read cassette %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &r.recorded); err != nil {
+		return nil, fmt.Errorf("parse cassette %s: %w", path, err)
+	}
+	r.used = make([]bool, len(r.recorded))
+	return r, nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if reqBody, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	if r.mode == ModeRecord {
+		return r.record(req, reqBody)
+	}
+	return r.replay(req)
+}
+
+func (r *Recorder) record(req *http.Request, reqBody []byte) (*http.Response, error) {
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recorded = append(r.recorded, Interaction{
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		RequestBody:    string(reqBody),
+		StatusCode:     resp.StatusCode,
+		ResponseHeader: resp.Header.Clone(),
+		ResponseBody:   string(body),
+	})
+	return resp, nil
+}
+
+// replay serves the first unused interaction matching method and url, so repeated calls replay in recorded order
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.recorded {
+		if r.used[i] || interaction.Method != req.Method || interaction.URL != req.URL.String() {
+			continue
+		}
+		r.used[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.StatusCode, http.StatusText(interaction.StatusCode)),
+			StatusCode:    interaction.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.ResponseHeader.Clone(),
+			Body:          ioutil.NopCloser(bytes.NewReader([]byte(interaction.ResponseBody))),
+			ContentLength: int64(len(interaction.ResponseBody)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("no recorded interaction left for %s %s", req.Method, req.URL)
+}
+
+// Save writes the recorded interactions to the cassette, sensitive headers are dropped
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, interaction := range r.recorded {
+		interaction.ResponseHeader.Del("Set-Cookie")
+	}
+	data, err := json.MarshalIndent(r.recorded, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, data, os.FileMode(0o644))
+}
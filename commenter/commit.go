@@ -0,0 +1,305 @@
+package commenter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// NewCommitCommenter creates a Commenter which writes commit comments on sha instead of PR review
+// comments, for scanner feedback on direct pushes. Reviews are written as the comments plus a comment
+// on the commit carrying the review body
+func NewCommitCommenter(token, owner, repo, sha string, opts ...Option) (*Commenter, error) {
+	return NewCommitCommenterContext(context.Background(), token, owner, repo, sha, opts...)
+}
+
+// NewCommitCommenterContext is NewCommitCommenter using ctx for the API calls
+func NewCommitCommenterContext(ctx context.Context, token, owner, repo, sha string, opts ...Option) (*Commenter, error) {
+	o := newOptions(opts)
+	if len(token) == 0 && o.tokenSource == nil && o.client == nil {
+		return nil, errors.New("the GITHUB_TOKEN has not been set")
+	}
+	client := o.client
+	if client == nil {
+		var err error
+		if client, err = newGithubClient(token, o); err != nil {
+			return nil, err
+		}
+	}
+	return &Commenter{
+		provider: &commitProvider{
+			gh:  &connector{client: client, owner: owner, repo: repo, opts: o, rate: &rateTracker{}, headSHA: sha},
+			sha: sha,
+		},
+		opts: o,
+	}, nil
+}
+
+// WithCommitCommentFallback makes NewCommenterForCommit return a commit commenter instead of a
+// NoOpenPRError when the commit isn't part of an open PR
+func WithCommitCommentFallback() Option {
+	return func(o *options) {
+		o.commitFallback = true
+	}
+}
+
+// commitProvider implements Provider with the comments of a single commit. Commit comments are placed by
+// their position in the file's patch, so the patches are kept to convert between positions and lines
+type commitProvider struct {
+	gh      *connector
+	sha     string
+	patches map[string]string
+}
+
+var _ Provider = (*commitProvider)(nil)
+
+func (p *commitProvider) githubClient() *github.Client {
+	return p.gh.client
+}
+
+// ListChangedFiles implements Provider
+func (p *commitProvider) ListChangedFiles(ctx context.Context) ([]*ChangedFile, error) {
+	files, err := p.gh.listCommitFiles(ctx, p.sha)
+	if err != nil {
+		return nil, err
+	}
+	p.patches = map[string]string{}
+	for _, file := range files {
+		p.patches[file.Filename] = file.Patch
+	}
+	return files, nil
+}
+
+// WithReviewCommit makes a PR commenter review sha, a commit of the PR other than its head, for
+// workflows reviewing every pushed commit. Comments are placed on the commit's own diff and made
+// against it; NewCommenter returns a NoOpenPRError when the commit isn't part of the PR
+func WithReviewCommit(sha string) Option {
+	return func(o *options) {
+		o.reviewCommit = sha
+	}
+}
+
+// checkReviewCommit makes sure the WithReviewCommit commit belongs to the PR
+func (c *connector) checkReviewCommit(ctx context.Context) error {
+	sha := c.opts.reviewCommit
+	var prs []*github.PullRequest
+	err := c.withRetry(ctx, "PullRequests.ListPullRequestsWithCommit", func() (*github.Response, error) {
+		var (
+			resp *github.Response
+			err  error
+		)
+		prs, resp, err = c.prs.ListPullRequestsWithCommit(ctx, c.owner, c.repo, sha, nil)
+		return resp, err
+	})
+	if err != nil {
+		return err
+	}
+	for _, pr := range prs {
+		if pr.GetNumber() == c.prNumber {
+			return nil
+		}
+	}
+	return newNoOpenPRError(c.owner, c.repo, sha)
+}
+
+// listCommitFiles returns the files changed by the commit sha, with the patches against its parent
+func (c *connector) listCommitFiles(ctx context.Context, sha string) ([]*ChangedFile, error) {
+	var commit *github.RepositoryCommit
+	err := c.withRetry(ctx, "Repositories.GetCommit", func() (*github.Response, error) {
+		var (
+			resp *github.Response
+			err  error
+		)
+		commit, resp, err = c.client.Repositories.GetCommit(ctx, c.owner, c.repo, sha, nil)
+		return resp, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]*ChangedFile, 0, len(commit.Files))
+	for _, file := range commit.Files {
+		files = append(files, &ChangedFile{
+			Filename:  file.GetFilename(),
+			Status:    file.GetStatus(),
+			Patch:     file.GetPatch(),
+			Changes:   file.GetChanges(),
+			CommitSHA: sha,
+		})
+	}
+	return files, nil
+}
+
+// ListComments implements Provider
+func (p *commitProvider) ListComments(ctx context.Context) ([]*Comment, error) {
+	var comments []*github.RepositoryComment
+	err := p.gh.withRetry(ctx, "Repositories.ListCommitComments", func() (*github.Response, error) {
+		var (
+			resp *github.Response
+			err  error
+		)
+		comments, resp, err = p.gh.client.Repositories.ListCommitComments(ctx, p.gh.owner, p.gh.repo, p.sha, nil)
+		return resp, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Comment, 0, len(comments))
+	for _, comment := range comments {
+		// as with PR reviews, comments on the commit as a whole are left alone
+		if comment.GetPath() == "" {
+			continue
+		}
+		line, ok := linePosition(p.patches[comment.GetPath()], comment.GetPosition())
+		result = append(result, &Comment{
+			ID:        comment.GetID(),
+			NodeID:    comment.GetNodeID(),
+			Path:      comment.GetPath(),
+			StartLine: line,
+			Line:      line,
+			Outdated:  !ok,
+			Body:      comment.GetBody(),
+			Author:    comment.GetUser().GetLogin(),
+			URL:       comment.GetHTMLURL(),
+		})
+	}
+	return result, nil
+}
+
+// CreateInlineComment implements Provider, commit comments are single line so it is placed at the end line
+func (p *commitProvider) CreateInlineComment(ctx context.Context, comment InlineComment) (*Comment, error) {
+	position, ok := diffPosition(p.patches[comment.Path], comment.EndLine)
+	if !ok {
+		return nil, newCommentNotValidError(comment.Path, comment.EndLine)
+	}
+	created, err := p.createComment(ctx, &github.RepositoryComment{
+		Body:     &comment.Body,
+		Path:     &comment.Path,
+		Position: &position,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create comment on %s line %d: %w", comment.Path, comment.EndLine, err)
+	}
+	return &Comment{
+		ID:        created.GetID(),
+		NodeID:    created.GetNodeID(),
+		Path:      comment.Path,
+		StartLine: comment.StartLine,
+		Line:      comment.EndLine,
+		Body:      comment.Body,
+		Author:    created.GetUser().GetLogin(),
+		URL:       created.GetHTMLURL(),
+	}, nil
+}
+
+// CreateSummaryComment implements Provider with a comment on the commit as a whole
+func (p *commitProvider) CreateSummaryComment(ctx context.Context, body string) (*Comment, error) {
+	created, err := p.createComment(ctx, &github.RepositoryComment{Body: &body})
+	if err != nil {
+		return nil, fmt.Errorf("create summary comment: %w", err)
+	}
+	return &Comment{
+		ID:     created.GetID(),
+		NodeID: created.GetNodeID(),
+		Body:   body,
+		Author: created.GetUser().GetLogin(),
+		URL:    created.GetHTMLURL(),
+	}, nil
+}
+
+// UpdateComment implements Provider
+func (p *commitProvider) UpdateComment(ctx context.Context, comment *Comment, body string) error {
+	err := p.gh.withRetry(ctx, "Repositories.UpdateComment", func() (*github.Response, error) {
+		_, resp, err := p.gh.client.Repositories.UpdateComment(ctx, p.gh.owner, p.gh.repo, comment.ID, &github.RepositoryComment{Body: &body})
+		return resp, err
+	})
+	if err != nil {
+		return fmt.Errorf("update existing comment %d: %w", comment.ID, err)
+	}
+	p.gh.opts.metrics.Add(MetricCommentsUpdated, 1)
+	return nil
+}
+
+// DeleteComment implements Provider
+func (p *commitProvider) DeleteComment(ctx context.Context, comment *Comment) error {
+	err := p.gh.withRetry(ctx, "Repositories.DeleteComment", func() (*github.Response, error) {
+		return p.gh.client.Repositories.DeleteComment(ctx, p.gh.owner, p.gh.repo, comment.ID)
+	})
+	if err != nil {
+		return fmt.Errorf("delete existing comment %d: %w", comment.ID, err)
+	}
+	p.gh.opts.logger.Info("deleted existing comment", "comment_id", comment.ID)
+	p.gh.opts.metrics.Add(MetricCommentsDeleted, 1)
+	return nil
+}
+
+func (p *commitProvider) createComment(ctx context.Context, comment *github.RepositoryComment) (*github.RepositoryComment, error) {
+	var created *github.RepositoryComment
+	err := p.gh.withRetry(ctx, "Repositories.CreateComment", func() (*github.Response, error) {
+		var (
+			resp *github.Response
+			err  error
+		)
+		created, resp, err = p.gh.client.Repositories.CreateComment(ctx, p.gh.owner, p.gh.repo, p.sha, comment)
+		return resp, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.gh.opts.metrics.Add(MetricCommentsCreated, 1)
+	return created, nil
+}
+
+// diffPosition returns the position of the new side line in patch, counted in lines below the first
+// hunk header with later hunk headers counting as lines
+func diffPosition(patch string, line int) (int, bool) {
+	position, ok := patchPositions(patch)[line]
+	return position, ok
+}
+
+// patchPositions maps every new side line shown by patch to its diffPosition
+func patchPositions(patch string) map[int]int {
+	positions := map[int]int{}
+	var newLine int
+	for i, text := range strings.Split(patch, "\n") {
+		if groups := patchHunkRegex.FindStringSubmatch(text); groups != nil {
+			newLine, _ = strconv.Atoi(groups[1])
+			continue
+		}
+		if strings.HasPrefix(text, "-") || strings.HasPrefix(text, "\\") {
+			continue
+		}
+		if newLine > 0 {
+			positions[newLine] = i
+			newLine++
+		}
+	}
+	return positions
+}
+
+// linePosition is the inverse of diffPosition, returning the new side line at position of patch
+func linePosition(patch string, position int) (int, bool) {
+	var newLine int
+	for i, text := range strings.Split(patch, "\n") {
+		if groups := patchHunkRegex.FindStringSubmatch(text); groups != nil {
+			newLine, _ = strconv.Atoi(groups[1])
+			continue
+		}
+		if strings.HasPrefix(text, "-") || strings.HasPrefix(text, "\\") {
+			if i == position {
+				return 0, false
+			}
+			continue
+		}
+		if i == position {
+			return newLine, true
+		}
+		newLine++
+	}
+	return 0, false
+}
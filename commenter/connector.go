@@ -4,10 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strconv"
+	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 
 	"github.com/google/go-github/v38/github"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/oauth2"
 )
 
@@ -16,166 +20,434 @@ const (
 )
 
 type connector struct {
-	prs      *github.PullRequestsService
-	comments *github.IssuesService
+	client   *github.Client
+	prs      PullRequestsAPI
+	comments issueCommentsAPI
 	owner    string
 	repo     string
 	prNumber int
+	opts     *options
+	rate     *rateTracker
+	headSHA  string
+	// author is the login which opened the PR
+	author string
+	// changedFiles is the number of files the PR changes, 0 when unknown
+	changedFiles int
+	// prefetched is only set by WithGraphQLFetch
+	prefetched *threadPage
+	// patchMu guards patches, the patches of the changed files by name only kept for
+	// WithLegacyPositions, and filesTruncated
+	patchMu sync.Mutex
+	patches map[string]string
+	// filesTruncated is set when neither the file listing nor the diff covers every changed file
+	filesTruncated bool
 }
 
-type existingComment struct {
-	filename  *string
-	comment   *string
-	commentId *int64
-}
+var _ Provider = (*connector)(nil)
 
 // create github connector and check if supplied pr number exists
-func createConnector(token, owner, repo string, prNumber int) (*connector, error) {
+func createConnector(ctx context.Context, token, owner, repo string, prNumber int, opts *options) (*connector, error) {
 
-	client := newGithubClient(token)
-	if _, _, err := client.PullRequests.Get(context.Background(), owner, repo, prNumber); err != nil {
-		return nil, newPRDoesNotExistError(owner, repo, prNumber)
+	client := opts.client
+	if client == nil {
+		var err error
+		if client, err = newGithubClient(token, opts); err != nil {
+			return nil, err
+		}
 	}
-
-	return &connector{
+	c := &connector{
+		client:   client,
 		prs:      client.PullRequests,
 		comments: client.Issues,
 		owner:    owner,
 		repo:     repo,
 		prNumber: prNumber,
-	}, nil
-}
-
-func newGithubClient(token string) *github.Client {
-
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
-	tc := oauth2.NewClient(ctx, ts)
-
-	return github.NewClient(tc)
-}
-
-func (c *connector) getPRInfo() ([]*CommitFileInfo, []*existingComment, error) {
-
-	commitFileInfos, err := c.getCommitFileInfos()
-	if err != nil {
-		return nil, nil, err
+		opts:     opts,
+		rate:     opts.rate,
+	}
+	if c.rate == nil {
+		c.rate = &rateTracker{}
+	}
+	if opts.pullRequests != nil {
+		c.prs = opts.pullRequests
+	}
+	if opts.issueComments != nil {
+		c.comments = opts.issueComments
 	}
 
-	existingComments, err := c.getExistingComments()
+	var err error
+	if opts.graphqlFetch {
+		err = c.prefetch(ctx)
+	} else {
+		err = c.withRetry(ctx, "PullRequests.Get", func() (*github.Response, error) {
+			pr, resp, err := c.prs.Get(ctx, owner, repo, prNumber)
+			c.headSHA, c.author, c.changedFiles = pr.GetHead().GetSHA(), pr.GetUser().GetLogin(), pr.GetChangedFiles()
+			return resp, err
+		})
+	}
 	if err != nil {
-		return nil, nil, err
+		var apiErr APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, newPRDoesNotExistError(owner, repo, prNumber, err)
+		}
+		return nil, err
 	}
-	return commitFileInfos, existingComments, nil
+	if opts.reviewCommit != "" {
+		if err := c.checkReviewCommit(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
 }
 
-func (c *connector) getCommitFileInfos() ([]*CommitFileInfo, error) {
+func newGithubClient(token string, opts *options) (*github.Client, error) {
 
-	prFiles, err := c.getFilesForPr()
-	if err != nil {
-		return nil, err
+	base := opts.transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if opts.debugWriter != nil {
+		base = &debugTransport{w: opts.debugWriter, base: base}
+	}
+	base = &instrumentedTransport{logger: opts.logger, metrics: opts.metrics, base: base}
+	// the ETag cache sits below the token so that its entries are kept per token
+	if opts.etagCache != nil {
+		base = &etagTransport{cache: opts.etagCache, base: base}
 	}
 
-	var (
-		errs            []string
-		commitFileInfos []*CommitFileInfo
-	)
+	ts := opts.tokenSource
+	if ts == nil {
+		ts = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	}
+	tc := &http.Client{
+		Transport: &oauth2.Transport{Source: oauth2.ReuseTokenSource(nil, ts), Base: base},
+		Timeout:   opts.timeout,
+	}
+	if opts.apiVersion != "" {
+		tc.Transport = &headerTransport{
+			headers: http.Header{"X-Github-Api-Version": []string{opts.apiVersion}},
+			base:    tc.Transport,
+		}
+	}
 
-	for _, file := range prFiles {
-		info, err := getCommitInfo(file)
+	client := github.NewClient(tc)
+	if opts.baseURL != "" {
+		baseURL := opts.baseURL
+		if !strings.HasSuffix(baseURL, "/") {
+			baseURL += "/"
+		}
+		u, err := url.Parse(baseURL)
 		if err != nil {
-			errs = append(errs, err.Error())
-			continue
+			return nil, fmt.Errorf("parse base url %s: %w", opts.baseURL, err)
 		}
-		commitFileInfos = append(commitFileInfos, info)
+		client.BaseURL = u
 	}
-	if len(errs) > 0 {
-		return nil, fmt.Errorf("there were errors processing the PR files.\n%s", strings.Join(errs, "\n"))
+	if opts.userAgent != "" {
+		client.UserAgent = opts.userAgent
 	}
-	return commitFileInfos, nil
+	return client, nil
 }
 
-func getCommitInfo(file *github.CommitFile) (*CommitFileInfo, error) {
+func (c *connector) CreatePRReview(ctx context.Context, event string, body string, comments []*github.DraftReviewComment) error {
 
-	groups := patchRegex.FindAllStringSubmatch(file.GetPatch(), -1)
-	var hunkStart, hunkEnd int
-	if len(groups) < 1 {
-		if file.GetChanges() >= 1 {
-			hunkStart, hunkEnd = 1, 1
-		} else {
-			return nil, errors.New("the patch details could not be resolved")
-		}
-	} else {
-		hunkStart, _ = strconv.Atoi(groups[0][1])
-		hunkEnd, _ = strconv.Atoi(groups[0][2])
-	}
+	ctx, span := c.opts.tracer.Start(ctx, "commenter.CreatePRReview", trace.WithAttributes(
+		append(c.spanAttributes(), attribute.String("commenter.event", event), attribute.Int("commenter.comments", len(comments)))...))
+	defer span.End()
 
-	shaGroups := commitRefRegex.FindAllStringSubmatch(file.GetContentsURL(), -1)
-	if len(shaGroups) < 1 {
-		return nil, errors.New("the sha details could not be resolved")
+	if c.opts.legacyPositions {
+		for _, draft := range comments {
+			if err := c.draftToPosition(draft); err != nil {
+				recordSpanError(span, err)
+				return err
+			}
+		}
 	}
-	sha := shaGroups[0][1]
-
-	return &CommitFileInfo{
-		fileName:      *file.Filename,
-		hunkStartLine: hunkStart,
-		hunkEndLine:   hunkStart + (hunkEnd - 1),
-		sha:           sha,
-	}, nil
-}
-
-func (c *connector) CreatePRReview(ctx context.Context, event string, body string, comments []*github.DraftReviewComment) error {
 	review := &github.PullRequestReviewRequest{
 		Body:     &body,
 		Event:    &event,
 		Comments: comments,
 	}
-	if _, _, err := c.prs.CreateReview(ctx, c.owner, c.repo, c.prNumber, review); err != nil {
+	if c.opts.reviewCommit != "" {
+		review.CommitID = &c.opts.reviewCommit
+	}
+	if event == Pending {
+		// a review is left pending by omitting its event, and GitHub allows one pending review per user
+		review.Event = nil
+		if err := c.deletePendingReviews(ctx); err != nil {
+			recordSpanError(span, err)
+			return err
+		}
+	}
+	err := c.withRetry(ctx, "PullRequests.CreateReview", func() (*github.Response, error) {
+		_, resp, err := c.prs.CreateReview(ctx, c.owner, c.repo, c.prNumber, review)
+		return resp, err
+	})
+	if err != nil {
+		recordSpanError(span, err)
 		return err
 	}
+	c.opts.metrics.Add(MetricCommentsCreated, len(comments))
 	return nil
 }
 
+func (c *connector) CreatePRReviewComment(ctx context.Context, comment *github.PullRequestComment) (*github.PullRequestComment, error) {
+
+	ctx, span := c.opts.tracer.Start(ctx, "commenter.CreatePRReviewComment", trace.WithAttributes(
+		append(c.spanAttributes(), attribute.String("commenter.file", comment.GetPath()), attribute.Int("commenter.line", comment.GetLine()))...))
+	defer span.End()
+
+	if c.opts.legacyPositions && comment.InReplyTo == nil {
+		if err := c.toPosition(comment); err != nil {
+			recordSpanError(span, err)
+			return nil, err
+		}
+	}
+	var created *github.PullRequestComment
+	err := c.withRetry(ctx, "PullRequests.CreateComment", func() (*github.Response, error) {
+		var (
+			resp *github.Response
+			err  error
+		)
+		created, resp, err = c.prs.CreateComment(ctx, c.owner, c.repo, c.prNumber, comment)
+		return resp, err
+	})
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, fmt.Errorf("create comment on %s line %d: %w", comment.GetPath(), comment.GetLine(), err)
+	}
+	c.opts.metrics.Add(MetricCommentsCreated, 1)
+	return created, nil
+}
+
 func (c *connector) DeletePRReviewComment(ctx context.Context, commentID *int64) error {
-	if _, err := c.prs.DeleteComment(ctx, c.owner, c.repo, *commentID); err != nil {
+	err := c.withRetry(ctx, "PullRequests.DeleteComment", func() (*github.Response, error) {
+		return c.prs.DeleteComment(ctx, c.owner, c.repo, *commentID)
+	})
+	if err != nil {
 		return fmt.Errorf("delete existing comment %d: %w", *commentID, err)
 	}
+	c.opts.logger.Info("deleted existing comment", "comment_id", *commentID)
+	c.opts.metrics.Add(MetricCommentsDeleted, 1)
 	return nil
 }
 
-func (c *connector) getFilesForPr() ([]*github.CommitFile, error) {
+func (c *connector) githubClient() *github.Client {
+	return c.client
+}
 
-	files, _, err := c.prs.ListFiles(context.Background(), c.owner, c.repo, c.prNumber, nil)
+func (c *connector) snapshotKey() (string, string) {
+	sha := c.headSHA
+	if c.opts.reviewCommit != "" {
+		sha = c.opts.reviewCommit
+	}
+	if sha == "" {
+		return "", ""
+	}
+	return fmt.Sprintf("%s/%s#%d@%s", c.owner, c.repo, c.prNumber, sha), sha
+}
+
+// ListChangedFiles implements Provider
+func (c *connector) ListChangedFiles(ctx context.Context) ([]*ChangedFile, error) {
+	files, err := c.listChangedFiles(ctx)
+	if err == nil && c.opts.legacyPositions {
+		c.keepPatches(files)
+	}
+	return files, err
+}
+
+func (c *connector) listChangedFiles(ctx context.Context) ([]*ChangedFile, error) {
+	if c.opts.reviewCommit != "" {
+		return c.listCommitFiles(ctx, c.opts.reviewCommit)
+	}
+	if c.opts.graphqlFetch {
+		return c.listChangedFilesFromDiff(ctx)
+	}
+
+	pages := map[int][]*github.CommitFile{}
+	var mu sync.Mutex
+	last, err := c.fetchPages(ctx, "PullRequests.ListFiles", func(page int) (*github.Response, error) {
+		files, resp, err := c.prs.ListFiles(ctx, c.owner, c.repo, c.prNumber, &github.ListOptions{Page: page, PerPage: pageSize})
+		mu.Lock()
+		pages[page] = files
+		mu.Unlock()
+		return resp, err
+	})
 	if err != nil {
 		return nil, err
 	}
+	var files []*github.CommitFile
+	for page := 1; page <= last; page++ {
+		files = append(files, pages[page]...)
+	}
+	c.setFilesTruncated(false)
+	if len(files) >= maxListedFiles || c.changedFiles > len(files) {
+		c.opts.logger.Info("the PR changes more files than GitHub lists, reading its diff instead", "listed", len(files), "changed_files", c.changedFiles)
+		changedFiles, err := c.listChangedFilesFromDiff(ctx)
+		if err == nil {
+			return changedFiles, nil
+		}
+		c.opts.logger.Info("could not read the diff of the PR, comments on files past the listing are mentioned in the summary", "error", err)
+		c.setFilesTruncated(true)
+	}
 
-	var commitFiles []*github.CommitFile
+	changedFiles := make([]*ChangedFile, 0, len(files))
 	for _, file := range files {
-		if *file.Status != "deleted" && *file.Status != "renamed" {
-			commitFiles = append(commitFiles, file)
+		changedFile := &ChangedFile{
+			Filename: file.GetFilename(),
+			Status:   file.GetStatus(),
+			Patch:    file.GetPatch(),
+			Changes:  file.GetChanges(),
+		}
+		if shaGroups := commitRefRegex.FindAllStringSubmatch(file.GetContentsURL(), -1); len(shaGroups) > 0 {
+			changedFile.CommitSHA = shaGroups[0][1]
 		}
+		changedFiles = append(changedFiles, changedFile)
 	}
-	return commitFiles, nil
+	return changedFiles, nil
 }
 
-func (c *connector) getExistingComments() ([]*existingComment, error) {
+// ListComments implements Provider
+func (c *connector) ListComments(ctx context.Context) ([]*Comment, error) {
+	if c.opts.graphqlFetch {
+		return c.listCommentsGraphQL(ctx)
+	}
 
-	ctx := context.Background()
-	comments, _, err := c.prs.ListComments(ctx, c.owner, c.repo, c.prNumber, &github.PullRequestListCommentsOptions{})
+	pages := map[int][]*github.PullRequestComment{}
+	var mu sync.Mutex
+	last, err := c.fetchPages(ctx, "PullRequests.ListComments", func(page int) (*github.Response, error) {
+		comments, resp, err := c.prs.ListComments(ctx, c.owner, c.repo, c.prNumber, &github.PullRequestListCommentsOptions{
+			ListOptions: github.ListOptions{Page: page, PerPage: pageSize},
+		})
+		mu.Lock()
+		pages[page] = comments
+		mu.Unlock()
+		return resp, err
+	})
 	if err != nil {
 		return nil, err
 	}
+	var comments []*github.PullRequestComment
+	for page := 1; page <= last; page++ {
+		comments = append(comments, pages[page]...)
+	}
 
-	var existingComments []*existingComment
+	existingComments := make([]*Comment, 0, len(comments))
 	for _, comment := range comments {
-		if CommenterName == *comment.User.Login {
-			existingComments = append(existingComments, &existingComment{
-				filename:  comment.Path,
-				comment:   comment.Body,
-				commentId: comment.ID,
-			})
-		}
+		line, onDiff := c.lineOf(comment)
+		existingComments = append(existingComments, &Comment{
+			ID:        comment.GetID(),
+			NodeID:    comment.GetNodeID(),
+			Path:      comment.GetPath(),
+			StartLine: comment.GetStartLine(),
+			Line:      line,
+			// GitHub drops the position of comments on lines no longer in the diff
+			Outdated:  !onDiff,
+			Body:      comment.GetBody(),
+			Author:    comment.GetUser().GetLogin(),
+			URL:       comment.GetHTMLURL(),
+			InReplyTo: comment.GetInReplyTo(),
+			CommitSHA: comment.GetCommitID(),
+			DiffHunk:  comment.GetDiffHunk(),
+		})
 	}
 	return existingComments, nil
 }
+
+// CreateInlineComment implements Provider
+func (c *connector) CreateInlineComment(ctx context.Context, comment InlineComment) (*Comment, error) {
+	created, err := c.CreatePRReviewComment(ctx, buildReviewComment(PRReviewComment{
+		FileName:  comment.Path,
+		StartLine: comment.StartLine,
+		EndLine:   comment.EndLine,
+		Body:      comment.Body,
+	}, comment.CommitSHA))
+	if err != nil {
+		return nil, err
+	}
+	return &Comment{
+		ID:        created.GetID(),
+		NodeID:    created.GetNodeID(),
+		Path:      comment.Path,
+		StartLine: comment.StartLine,
+		Line:      comment.EndLine,
+		Body:      comment.Body,
+		Author:    created.GetUser().GetLogin(),
+		URL:       created.GetHTMLURL(),
+		CommitSHA: comment.CommitSHA,
+	}, nil
+}
+
+// CreateSummaryComment implements Provider with an issue comment on the PR
+func (c *connector) CreateSummaryComment(ctx context.Context, body string) (*Comment, error) {
+	var created *github.IssueComment
+	err := c.withRetry(ctx, "Issues.CreateComment", func() (*github.Response, error) {
+		var (
+			resp *github.Response
+			err  error
+		)
+		created, resp, err = c.comments.CreateComment(ctx, c.owner, c.repo, c.prNumber, &github.IssueComment{Body: &body})
+		return resp, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create summary comment: %w", err)
+	}
+	c.opts.metrics.Add(MetricCommentsCreated, 1)
+	return &Comment{
+		ID:     created.GetID(),
+		NodeID: created.GetNodeID(),
+		Body:   body,
+		Author: created.GetUser().GetLogin(),
+		URL:    created.GetHTMLURL(),
+	}, nil
+}
+
+// UpdateComment implements Provider, comments without a path are issue comments
+func (c *connector) UpdateComment(ctx context.Context, comment *Comment, body string) error {
+	var err error
+	if comment.Path == "" {
+		err = c.withRetry(ctx, "Issues.EditComment", func() (*github.Response, error) {
+			_, resp, err := c.comments.EditComment(ctx, c.owner, c.repo, comment.ID, &github.IssueComment{Body: &body})
+			return resp, err
+		})
+	} else {
+		err = c.withRetry(ctx, "PullRequests.EditComment", func() (*github.Response, error) {
+			_, resp, err := c.prs.EditComment(ctx, c.owner, c.repo, comment.ID, &github.PullRequestComment{Body: &body})
+			return resp, err
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("update existing comment %d: %w", comment.ID, err)
+	}
+	c.opts.metrics.Add(MetricCommentsUpdated, 1)
+	return nil
+}
+
+// DeleteComment implements Provider, comments without a path are issue comments
+func (c *connector) DeleteComment(ctx context.Context, comment *Comment) error {
+	if comment.Path != "" {
+		return c.DeletePRReviewComment(ctx, &comment.ID)
+	}
+	err := c.withRetry(ctx, "Issues.DeleteComment", func() (*github.Response, error) {
+		return c.comments.DeleteComment(ctx, c.owner, c.repo, comment.ID)
+	})
+	if err != nil {
+		return fmt.Errorf("delete existing comment %d: %w", comment.ID, err)
+	}
+	c.opts.logger.Info("deleted existing comment", "comment_id", comment.ID)
+	c.opts.metrics.Add(MetricCommentsDeleted, 1)
+	return nil
+}
+
+// setFilesTruncated records whether the changed files listed are only some of the PR's
+func (c *connector) setFilesTruncated(truncated bool) {
+	c.patchMu.Lock()
+	defer c.patchMu.Unlock()
+	c.filesTruncated = truncated
+}
+
+// truncatedFiles reports whether the changed files last listed are only some of the PR's
+func (c *connector) truncatedFiles() bool {
+	c.patchMu.Lock()
+	defer c.patchMu.Unlock()
+	return c.filesTruncated
+}
@@ -0,0 +1,62 @@
+package commenter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+)
+
+const redacted = "[REDACTED]"
+
+// WithHTTPDebug dumps every HTTP request and response exchanged with GitHub to w, with the
+// Authorization header redacted. Useful for troubleshooting requests GitHub rejects as invalid
+func WithHTTPDebug(w io.Writer) Option {
+	return func(o *options) {
+		o.debugWriter = w
+	}
+}
+
+type debugTransport struct {
+	mu   sync.Mutex
+	w    io.Writer
+	base http.RoundTripper
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	sanitized := req.Clone(req.Context())
+	for _, h := range []string{"Authorization", "Cookie"} {
+		if sanitized.Header.Get(h) != "" {
+			sanitized.Header.Set(h, redacted)
+		}
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		sanitized.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	reqDump, err := httputil.DumpRequestOut(sanitized, true)
+	if err != nil {
+		reqDump = []byte(fmt.Sprintf("failed to dump request: %s", err))
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	var respDump []byte
+	if err != nil {
+		respDump = []byte(fmt.Sprintf("request failed: %s", err))
+	} else if respDump, err = httputil.DumpResponse(resp, true); err != nil {
+		respDump = []byte(fmt.Sprintf("failed to dump response: %s", err))
+	}
+
+	t.mu.Lock()
+	fmt.Fprintf(t.w, "---> request\n%s\n<--- response\n%s\n\n", reqDump, respDump)
+	t.mu.Unlock()
+	return resp, err
+}
@@ -0,0 +1,94 @@
+package commenter
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DedupKeyFunc normalizes a finding into the key findings are deduplicated on, findings with the
+// same key are collapsed into one comment
+type DedupKeyFunc func(Finding) string
+
+// WithDeduplication collapses findings which several tools report for the same problem, such as
+// tfsec and checkov, into one comment listing every reporting tool. A nil key uses DefaultDedupKey
+func WithDeduplication(key DedupKeyFunc) Option {
+	return func(o *options) {
+		if key == nil {
+			key = DefaultDedupKey
+		}
+		o.dedupKey = key
+	}
+}
+
+// DefaultDedupKey matches findings on their path, lines and case and whitespace insensitive message
+func DefaultDedupKey(f Finding) string {
+	comment := f.Comment()
+	message := strings.Join(strings.Fields(strings.ToLower(f.Message)), " ")
+	return strings.Join([]string{filepath.ToSlash(f.Path), strconv.Itoa(comment.StartLine), strconv.Itoa(comment.EndLine), message}, "\x00")
+}
+
+// dedupe merges duplicate findings into the first of them, duplicateOf is the index of the finding
+// each one was merged into or -1
+func (c *Commenter) dedupe(findings []Finding) (merged []Finding, duplicateOf []int) {
+	merged = append([]Finding(nil), findings...)
+	duplicateOf = make([]int, len(findings))
+	if c.opts.dedupKey == nil {
+		for i := range duplicateOf {
+			duplicateOf[i] = -1
+		}
+		return merged, duplicateOf
+	}
+
+	first := map[string]int{}
+	reporters := map[int][]string{}
+	for i, finding := range findings {
+		duplicateOf[i] = -1
+		key := c.opts.dedupKey(finding)
+		j, seen := first[key]
+		if !seen {
+			first[key] = i
+			reporters[i] = []string{reporter(finding)}
+			continue
+		}
+		duplicateOf[i] = j
+		if severity := finding.Severity; severity > merged[j].Severity {
+			merged[j].Severity = severity
+		}
+		if r := reporter(finding); !containsString(reporters[j], r) {
+			reporters[j] = append(reporters[j], r)
+		}
+	}
+	for i, names := range reporters {
+		if len(names) < 2 {
+			continue
+		}
+		merged[i].RuleID = ""
+		merged[i].Tool = ""
+		merged[i].Message = findings[i].Message + "\n\n" + c.text(MessageReportedBy, strings.Join(names, ", "))
+	}
+	return merged, duplicateOf
+}
+
+// reporter names the tool and rule of a finding, such as "tfsec (AWS002)"
+func reporter(f Finding) string {
+	switch {
+	case f.Tool != "" && f.RuleID != "":
+		return fmt.Sprintf("%s (%s)", f.Tool, f.RuleID)
+	case f.Tool != "":
+		return f.Tool
+	case f.RuleID != "":
+		return f.RuleID
+	}
+	return "unknown"
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package commenter
+
+import (
+	"context"
+	"fmt"
+)
+
+// EditReviewComment replaces the body of the commenter's comment with id, such as the CommentID of
+// a Result, for callers managing their comments directly
+func (c *Commenter) EditReviewComment(id int64, body string) error {
+	return c.EditReviewCommentContext(context.Background(), id, body)
+}
+
+// EditReviewCommentContext is EditReviewComment using ctx for the API calls
+func (c *Commenter) EditReviewCommentContext(ctx context.Context, id int64, body string) error {
+	comment, err := c.ownComment(ctx, id)
+	if err != nil {
+		return err
+	}
+	return c.provider.UpdateComment(ctx, comment, body)
+}
+
+// DeleteReviewComment deletes the commenter's comment with id, such as the CommentID of a Result
+func (c *Commenter) DeleteReviewComment(id int64) error {
+	return c.DeleteReviewCommentContext(context.Background(), id)
+}
+
+// DeleteReviewCommentContext is DeleteReviewComment using ctx for the API calls
+func (c *Commenter) DeleteReviewCommentContext(ctx context.Context, id int64) error {
+	comment, err := c.ownComment(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := c.deleteComment(ctx, comment); err != nil {
+		return err
+	}
+	c.forgetComments(map[int64]bool{id: true})
+	return nil
+}
+
+// ownComment finds the commenter's comment with id among the existing comments, listing them again
+// for comments written since the PR info was loaded
+func (c *Commenter) ownComment(ctx context.Context, id int64) (*Comment, error) {
+	if err := c.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+	for _, comment := range c.snapshotExistingComments() {
+		if comment.ID == id {
+			return comment, nil
+		}
+	}
+	comments, err := c.provider.ListComments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, comment := range comments {
+		if comment.ID == id && comment.Author == c.commenterName() {
+			return comment, nil
+		}
+	}
+	return nil, fmt.Errorf("comment %d: %w", id, ErrCommentNotFound)
+}
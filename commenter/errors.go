@@ -1,6 +1,41 @@
 package commenter
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// Sentinel errors to branch on failure causes with errors.Is, the typed errors below match them
+var (
+	// ErrPRNotFound matches PRDoesNotExistError and NoOpenPRError
+	ErrPRNotFound = errors.New("pull request not found")
+	// ErrIssueNotFound matches IssueDoesNotExistError
+	ErrIssueNotFound = errors.New("issue not found")
+	// ErrRepoNotAccessible matches RepoNotAccessibleError
+	ErrRepoNotAccessible = errors.New("repository not accessible")
+	// ErrCommentOutsideDiff matches CommentNotValidError
+	ErrCommentOutsideDiff = errors.New("comment is outside the diff")
+	// ErrSuppressed is the cause of ResultSuppressed results
+	ErrSuppressed = errors.New("finding suppressed")
+	// ErrRateLimited matches PrimaryRateLimitError, AbuseRateLimitError and RateLimitBudgetError
+	ErrRateLimited = errors.New("rate limited")
+	// ErrForbidden matches an APIError for a 403 response
+	ErrForbidden = errors.New("forbidden")
+	// ErrSSORequired matches SSOAuthorizationError
+	ErrSSORequired = errors.New("SAML SSO authorization required")
+	// ErrInsufficientPermissions matches InsufficientPermissionsError and PreflightError
+	ErrInsufficientPermissions = errors.New("insufficient permissions")
+	// ErrPRStateChanged matches PRStateChangedError
+	ErrPRStateChanged = errors.New("pull request changed")
+	// ErrCommentNotFound is returned for an id which isn't one of the commenter's comments on the PR
+	ErrCommentNotFound = errors.New("comment not found")
+)
 
 // CommentAlreadyWrittenError returned when the error can't be written as it already exists
 type CommentAlreadyWrittenError struct {
@@ -14,26 +49,355 @@ type CommentNotValidError struct {
 	lineNo   int
 }
 
-// PRDoesNotExistError returned when the PR can't be found, either as 401 or not existing
+// PRDoesNotExistError returned when GitHub answers the PR lookup with a 404, other failures of the
+// lookup are returned as they are
 type PRDoesNotExistError struct {
 	owner    string
 	repo     string
 	prNumber int
+	err      error
+}
+
+// IssueDoesNotExistError returned when the issue can't be found
+type IssueDoesNotExistError struct {
+	owner       string
+	repo        string
+	issueNumber int
+}
+
+// RepoNotAccessibleError returned by Preflight when GitHub answers 403 or 404 for the repository, it
+// doesn't exist or the token can't see it
+type RepoNotAccessibleError struct {
+	owner      string
+	repo       string
+	StatusCode int
+	err        error
 }
 
-// AbuseRateLimitError return when the GitHub abuse rate limit is hit
+// NoOpenPRError returned when no open PR can be found for a commit or branch
+type NoOpenPRError struct {
+	owner string
+	repo  string
+	ref   string
+}
+
+// InsufficientPermissionsError returned when the token isn't allowed to comment on the PR, typically
+// because it comes from a fork. Fallback names the sink the review was written to instead
+type InsufficientPermissionsError struct {
+	target   string
+	Fallback string
+	err      error
+}
+
+// PreflightError returned by Preflight when the token can't be used on the PR. Missing lists the
+// scopes or permissions it lacks, when they are known
+type PreflightError struct {
+	owner    string
+	repo     string
+	prNumber int
+	action   string
+	Missing  []string
+	err      error
+}
+
+// PRStateChangedError returned when the PR was closed, merged or pushed to while comments were being
+// written. State is now open, closed or merged and HeadSHA is the head GitHub reports now
+type PRStateChangedError struct {
+	owner       string
+	repo        string
+	prNumber    int
+	State       string
+	previousSHA string
+	HeadSHA     string
+}
+
+// AbuseRateLimitError return when a GitHub secondary rate limit, formerly the abuse rate limit, is
+// hit, Metadata identifies the request which hit it
 type AbuseRateLimitError struct {
 	owner            string
 	repo             string
 	prNumber         int
 	BackoffInSeconds int
+	Metadata         RequestMetadata
+	err              error
+}
+
+// PrimaryRateLimitError returned when the token has no requests of its GitHub primary rate limit
+// left until Reset, Metadata identifies the request which hit it
+type PrimaryRateLimitError struct {
+	owner    string
+	repo     string
+	prNumber int
+	Reset    time.Time
+	Metadata RequestMetadata
+	err      error
+}
+
+// RateLimitBudgetError returned when the remaining primary rate limit drops below the configured budget
+type RateLimitBudgetError struct {
+	Remaining int
+	Threshold int
+	Reset     time.Time
+}
+
+// BatchError returned when some comments of a batch failed to be written, the rest were still attempted
+type BatchError struct {
+	Failed []Result
+	Total  int
+}
+
+// APIError returned when a GitHub API call fails for a reason without a more specific error
+type APIError struct {
+	StatusCode int
+	Message    string
+	Metadata   RequestMetadata
+	err        error
+}
+
+// SSOAuthorizationError returned when an organization enforces SAML SSO and the token hasn't been
+// authorized for it, visiting AuthorizationURL grants the token access
+type SSOAuthorizationError struct {
+	AuthorizationURL string
+	Metadata         RequestMetadata
+	err              error
+}
+
+// ValidationError returned when GitHub rejects a request as unprocessable (422)
+type ValidationError struct {
+	Message  string
+	Fields   []FieldError
+	Metadata RequestMetadata
+	err      error
 }
 
-func newPRDoesNotExistError(owner, repo string, prNumber int) PRDoesNotExistError {
+// RequestMetadata identifies a failed request when raising it with GitHub support
+type RequestMetadata struct {
+	Method     string
+	URL        string
+	StatusCode int
+	// RequestID is the X-GitHub-Request-Id response header
+	RequestID string
+	// RateLimitRemaining is -1 when the response didn't report it
+	RateLimitRemaining int
+}
+
+// FieldError is the detail GitHub gives for a single invalid field of a request
+type FieldError struct {
+	Resource string
+	Field    string
+	Code     string
+	Message  string
+}
+
+func newPRDoesNotExistError(owner, repo string, prNumber int, err error) PRDoesNotExistError {
 	return PRDoesNotExistError{
 		owner:    owner,
 		repo:     repo,
 		prNumber: prNumber,
+		err:      err,
+	}
+}
+
+func newIssueDoesNotExistError(owner, repo string, issueNumber int) IssueDoesNotExistError {
+	return IssueDoesNotExistError{
+		owner:       owner,
+		repo:        repo,
+		issueNumber: issueNumber,
+	}
+}
+
+func newNoOpenPRError(owner, repo, ref string) NoOpenPRError {
+	return NoOpenPRError{
+		owner: owner,
+		repo:  repo,
+		ref:   ref,
+	}
+}
+
+func newInsufficientPermissionsError(target, fallback string, err error) InsufficientPermissionsError {
+	return InsufficientPermissionsError{
+		target:   target,
+		Fallback: fallback,
+		err:      err,
+	}
+}
+
+func newPreflightError(owner, repo string, prNumber int, action string, missing []string, err error) PreflightError {
+	return PreflightError{
+		owner:    owner,
+		repo:     repo,
+		prNumber: prNumber,
+		action:   action,
+		Missing:  missing,
+		err:      err,
+	}
+}
+
+func newRepoNotAccessibleError(owner, repo string, statusCode int, err error) RepoNotAccessibleError {
+	return RepoNotAccessibleError{
+		owner:      owner,
+		repo:       repo,
+		StatusCode: statusCode,
+		err:        err,
+	}
+}
+
+func newPRStateChangedError(owner, repo string, prNumber int, state, previousSHA, headSHA string) PRStateChangedError {
+	return PRStateChangedError{
+		owner:       owner,
+		repo:        repo,
+		prNumber:    prNumber,
+		State:       state,
+		previousSHA: previousSHA,
+		HeadSHA:     headSHA,
+	}
+}
+
+func newAbuseRateLimitError(owner, repo string, prNumber int, backoffInSeconds int, err error) AbuseRateLimitError {
+	return AbuseRateLimitError{
+		owner:            owner,
+		repo:             repo,
+		prNumber:         prNumber,
+		BackoffInSeconds: backoffInSeconds,
+		Metadata:         errorMetadata(err),
+		err:              wrapAPIError(err),
+	}
+}
+
+func newPrimaryRateLimitError(owner, repo string, prNumber int, reset time.Time, err error) PrimaryRateLimitError {
+	return PrimaryRateLimitError{
+		owner:    owner,
+		repo:     repo,
+		prNumber: prNumber,
+		Reset:    reset,
+		Metadata: errorMetadata(err),
+		err:      wrapAPIError(err),
+	}
+}
+
+func newCommentNotValidError(filepath string, lineNo int) CommentNotValidError {
+	return CommentNotValidError{
+		filepath: filepath,
+		lineNo:   lineNo,
+	}
+}
+
+// newBatchError returns nil when none of the results failed
+func newBatchError(results []Result) error {
+	var failed []Result
+	for _, result := range results {
+		if result.Status == ResultFailed {
+			failed = append(failed, result)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return BatchError{
+		Failed: failed,
+		Total:  len(results),
+	}
+}
+
+// wrapAPIError converts go-github error responses into APIError or ValidationError, other errors are returned as is
+func wrapAPIError(err error) error {
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return err
+	}
+	metadata := newRequestMetadata(errResp.Response)
+	if errResp.Response.StatusCode == http.StatusUnprocessableEntity {
+		fields := make([]FieldError, 0, len(errResp.Errors))
+		for _, e := range errResp.Errors {
+			fields = append(fields, FieldError{
+				Resource: e.Resource,
+				Field:    e.Field,
+				Code:     e.Code,
+				Message:  e.Message,
+			})
+		}
+		return ValidationError{
+			Message:  errResp.Message,
+			Fields:   fields,
+			Metadata: metadata,
+			err:      err,
+		}
+	}
+	if errResp.Response.StatusCode == http.StatusForbidden {
+		if authURL, ok := ssoAuthorizationURL(errResp.Response.Header.Get("X-GitHub-SSO")); ok {
+			return SSOAuthorizationError{
+				AuthorizationURL: authURL,
+				Metadata:         metadata,
+				err:              err,
+			}
+		}
+	}
+	return APIError{
+		StatusCode: errResp.Response.StatusCode,
+		Message:    errResp.Message,
+		Metadata:   metadata,
+		err:        err,
+	}
+}
+
+// ssoAuthorizationURL parses an X-GitHub-SSO header of the form "required; url=https://..."
+func ssoAuthorizationURL(header string) (string, bool) {
+	parts := strings.Split(header, ";")
+	if strings.TrimSpace(parts[0]) != "required" {
+		return "", false
+	}
+	for _, part := range parts[1:] {
+		if part = strings.TrimSpace(part); strings.HasPrefix(part, "url=") {
+			return strings.TrimPrefix(part, "url="), true
+		}
+	}
+	return "", true
+}
+
+// errorMetadata returns the RequestMetadata of the response err carries, go-github's rate limit
+// errors aren't ErrorResponses but keep theirs too
+func errorMetadata(err error) RequestMetadata {
+	var (
+		errResp  *github.ErrorResponse
+		rateErr  *github.RateLimitError
+		abuseErr *github.AbuseRateLimitError
+		response *http.Response
+	)
+	switch {
+	case errors.As(err, &errResp):
+		response = errResp.Response
+	case errors.As(err, &rateErr):
+		response = rateErr.Response
+	case errors.As(err, &abuseErr):
+		response = abuseErr.Response
+	}
+	if response == nil {
+		return RequestMetadata{RateLimitRemaining: -1}
+	}
+	return newRequestMetadata(response)
+}
+
+func newRequestMetadata(resp *http.Response) RequestMetadata {
+	metadata := RequestMetadata{
+		StatusCode:         resp.StatusCode,
+		RequestID:          resp.Header.Get("X-GitHub-Request-Id"),
+		RateLimitRemaining: -1,
+	}
+	if resp.Request != nil {
+		metadata.Method = resp.Request.Method
+		metadata.URL = resp.Request.URL.Path
+	}
+	if remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil {
+		metadata.RateLimitRemaining = remaining
+	}
+	return metadata
+}
+
+func newRateLimitBudgetError(remaining, threshold int, reset time.Time) RateLimitBudgetError {
+	return RateLimitBudgetError{
+		Remaining: remaining,
+		Threshold: threshold,
+		Reset:     reset,
 	}
 }
 
@@ -49,6 +413,213 @@ func (e PRDoesNotExistError) Error() string {
 	return fmt.Sprintf("PR number [%d] not found for %s/%s", e.prNumber, e.owner, e.repo)
 }
 
+func (e IssueDoesNotExistError) Error() string {
+	return fmt.Sprintf("Issue number [%d] not found for %s/%s", e.issueNumber, e.owner, e.repo)
+}
+
+func (e RepoNotAccessibleError) Error() string {
+	return fmt.Sprintf("Repository %s/%s can not be read with the token, answered [%d]: %s", e.owner, e.repo, e.StatusCode, e.err)
+}
+
+func (e NoOpenPRError) Error() string {
+	return fmt.Sprintf("No open PR found for [%s] in %s/%s", e.ref, e.owner, e.repo)
+}
+
+func (e InsufficientPermissionsError) Error() string {
+	msg := "The token is not allowed to comment on the PR"
+	if e.target != "" {
+		msg = fmt.Sprintf("The token is not allowed to comment on PR [%s]", e.target)
+	}
+	if e.Fallback != "" {
+		msg += fmt.Sprintf(", the review was written to the %s instead", e.Fallback)
+	}
+	return msg
+}
+
+func (e PreflightError) Error() string {
+	msg := fmt.Sprintf("The token can not %s for PR [%d] in %s/%s", e.action, e.prNumber, e.owner, e.repo)
+	if len(e.Missing) > 0 {
+		msg += fmt.Sprintf(", it is missing [%s]", strings.Join(e.Missing, ", "))
+	}
+	if e.err != nil {
+		msg += fmt.Sprintf(": %s", e.err)
+	}
+	return msg
+}
+
+func (e PRStateChangedError) Error() string {
+	if e.State != "open" {
+		return fmt.Sprintf("PR number [%d] in %s/%s was %s while comments were being written", e.prNumber, e.owner, e.repo, e.State)
+	}
+	return fmt.Sprintf("The head of PR number [%d] in %s/%s moved from [%s] to [%s] while comments were being written", e.prNumber, e.owner, e.repo, e.previousSHA, e.HeadSHA)
+}
+
 func (e AbuseRateLimitError) Error() string {
-	return fmt.Sprintf("Abuse limit reached on PR [%d] not found for %s/%s", e.prNumber, e.owner, e.repo)
+	return fmt.Sprintf("Secondary rate limit reached on PR [%d] for %s/%s, retry after [%d] seconds", e.prNumber, e.owner, e.repo, e.BackoffInSeconds)
+}
+
+func (e PrimaryRateLimitError) Error() string {
+	return fmt.Sprintf("Primary rate limit reached on PR [%d] for %s/%s until %s", e.prNumber, e.owner, e.repo, e.Reset.Format(time.RFC3339))
+}
+
+func (e RateLimitBudgetError) Error() string {
+	return fmt.Sprintf("Rate limit budget exhausted, [%d] requests remaining is below the threshold [%d] until %s", e.Remaining, e.Threshold, e.Reset.Format(time.RFC3339))
+}
+
+func (e BatchError) Error() string {
+	msgs := make([]string, 0, len(e.Failed))
+	for _, result := range e.Failed {
+		msgs = append(msgs, result.Err.Error())
+	}
+	return fmt.Sprintf("%d of %d comments could not be written.\n%s", len(e.Failed), e.Total, strings.Join(msgs, "\n"))
+}
+
+// Errors returns the individual errors of the failed comments
+func (e BatchError) Errors() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, result := range e.Failed {
+		errs = append(errs, result.Err)
+	}
+	return errs
+}
+
+// Is matches ErrCommentOutsideDiff and any other CommentNotValidError
+func (e CommentNotValidError) Is(target error) bool {
+	if _, ok := target.(CommentNotValidError); ok {
+		return true
+	}
+	return target == ErrCommentOutsideDiff
+}
+
+// Is matches ErrPRNotFound
+func (e PRDoesNotExistError) Is(target error) bool {
+	return target == ErrPRNotFound
+}
+
+func (e PRDoesNotExistError) Unwrap() error {
+	return e.err
+}
+
+// Is matches ErrIssueNotFound
+func (e IssueDoesNotExistError) Is(target error) bool {
+	return target == ErrIssueNotFound
+}
+
+// Is matches ErrRepoNotAccessible
+func (e RepoNotAccessibleError) Is(target error) bool {
+	return target == ErrRepoNotAccessible
+}
+
+func (e RepoNotAccessibleError) Unwrap() error {
+	return e.err
+}
+
+// Is matches ErrPRNotFound
+func (e NoOpenPRError) Is(target error) bool {
+	return target == ErrPRNotFound
+}
+
+// Is matches ErrInsufficientPermissions
+func (e InsufficientPermissionsError) Is(target error) bool {
+	return target == ErrInsufficientPermissions
+}
+
+func (e InsufficientPermissionsError) Unwrap() error {
+	return e.err
+}
+
+// Is matches ErrInsufficientPermissions
+func (e PreflightError) Is(target error) bool {
+	return target == ErrInsufficientPermissions
+}
+
+func (e PreflightError) Unwrap() error {
+	return e.err
+}
+
+// Is matches ErrPRStateChanged
+func (e PRStateChangedError) Is(target error) bool {
+	return target == ErrPRStateChanged
+}
+
+// Is matches ErrRateLimited
+func (e AbuseRateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+func (e AbuseRateLimitError) Unwrap() error {
+	return e.err
+}
+
+// Is matches ErrRateLimited
+func (e PrimaryRateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+func (e PrimaryRateLimitError) Unwrap() error {
+	return e.err
+}
+
+// Is matches ErrRateLimited
+func (e RateLimitBudgetError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// Is reports whether any of the failed comments matches target
+func (e BatchError) Is(target error) bool {
+	for _, result := range e.Failed {
+		if errors.Is(result.Err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e APIError) Error() string {
+	return fmt.Sprintf("GitHub API call failed with status [%d]: %s %s", e.StatusCode, e.Message, e.Metadata)
+}
+
+// Is matches ErrForbidden for responses with a 403 status
+func (e APIError) Is(target error) bool {
+	return target == ErrForbidden && e.StatusCode == http.StatusForbidden
+}
+
+func (e APIError) Unwrap() error {
+	return e.err
+}
+
+func (e SSOAuthorizationError) Error() string {
+	if e.AuthorizationURL == "" {
+		return fmt.Sprintf("The token must be authorized for the organization's SAML SSO %s", e.Metadata)
+	}
+	return fmt.Sprintf("The token must be authorized for the organization's SAML SSO at %s %s", e.AuthorizationURL, e.Metadata)
+}
+
+// Is matches ErrSSORequired and ErrForbidden
+func (e SSOAuthorizationError) Is(target error) bool {
+	return target == ErrSSORequired || target == ErrForbidden
+}
+
+func (e SSOAuthorizationError) Unwrap() error {
+	return e.err
+}
+
+func (e ValidationError) Error() string {
+	details := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		detail := fmt.Sprintf("%s.%s %s", f.Resource, f.Field, f.Code)
+		if f.Message != "" {
+			detail = fmt.Sprintf("%s (%s)", detail, f.Message)
+		}
+		details = append(details, detail)
+	}
+	return fmt.Sprintf("GitHub rejected the request as invalid: %s [%s] %s", e.Message, strings.Join(details, ", "), e.Metadata)
+}
+
+func (e ValidationError) Unwrap() error {
+	return e.err
+}
+
+func (m RequestMetadata) String() string {
+	return fmt.Sprintf("(%s %s, status %d, request id %s, rate limit remaining %d)", m.Method, m.URL, m.StatusCode, m.RequestID, m.RateLimitRemaining)
 }
@@ -0,0 +1,152 @@
+// Package eslint reads the results of eslint -f json as findings, with ESLint's fixes as suggestions
+package eslint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+)
+
+// Tool names the findings of Parse
+const Tool = "eslint"
+
+// Source returns the contents of a file ESLint checked, ioutil.ReadFile reads them from disk
+type Source func(file string) ([]byte, error)
+
+type fix struct {
+	Range [2]int `json:"range"`
+	Text  string `json:"text"`
+}
+
+type message struct {
+	RuleID      *string `json:"ruleId"`
+	Severity    int     `json:"severity"`
+	Fatal       bool    `json:"fatal"`
+	Message     string  `json:"message"`
+	Line        int     `json:"line"`
+	EndLine     int     `json:"endLine"`
+	Fix         *fix    `json:"fix"`
+	Suggestions []struct {
+		Desc string `json:"desc"`
+		Fix  *fix   `json:"fix"`
+	} `json:"suggestions"`
+}
+
+type result struct {
+	FilePath string    `json:"filePath"`
+	Messages []message `json:"messages"`
+	// Source is written for the files with problems which ESLint didn't fix
+	Source *string `json:"source"`
+}
+
+// Parse reads the output of eslint -f json as findings with the rule linked to its documentation.
+// The fix of a problem, or its first suggestion when ESLint has no fix, is proposed as a suggestion
+// made to the source ESLint included in its output, or else the file read with source. The files
+// are absolute, see commenter.WithRepoRoot
+func Parse(r io.Reader, source Source) ([]commenter.Finding, error) {
+	var results []result
+	if err := json.NewDecoder(r).Decode(&results); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("decode eslint output: %w", err)
+	}
+	var findings []commenter.Finding
+	for _, res := range results {
+		var contents *string
+		if res.Source != nil {
+			contents = res.Source
+		} else if source != nil && len(res.Messages) > 0 {
+			if data, err := source(res.FilePath); err == nil {
+				s := string(data)
+				contents = &s
+			}
+		}
+		for _, m := range res.Messages {
+			finding := commenter.Finding{
+				Tool:      Tool,
+				Severity:  severity(m),
+				Path:      res.FilePath,
+				StartLine: m.Line,
+				EndLine:   m.EndLine,
+				Message:   m.Message,
+			}
+			if m.RuleID != nil {
+				finding.RuleID = *m.RuleID
+				if url := DocsURL(*m.RuleID); url != "" {
+					finding.Message += fmt.Sprintf(" ([%s](%s))", *m.RuleID, url)
+				}
+			}
+			f := m.Fix
+			if f == nil && len(m.Suggestions) > 0 && m.Suggestions[0].Fix != nil {
+				f = m.Suggestions[0].Fix
+				finding.Message += "\n\n" + m.Suggestions[0].Desc
+			}
+			if f != nil && contents != nil {
+				if start, end, suggestion, ok := apply(*contents, *f); ok {
+					finding.StartLine, finding.EndLine, finding.Suggestion = start, end, &suggestion
+				}
+			}
+			findings = append(findings, finding)
+		}
+	}
+	return findings, nil
+}
+
+// DocsURL returns the documentation of the ESLint core and typescript-eslint rules, "" for the rules
+// of other plugins
+func DocsURL(ruleID string) string {
+	switch {
+	case strings.HasPrefix(ruleID, "@typescript-eslint/"):
+		return "https://typescript-eslint.io/rules/" + strings.TrimPrefix(ruleID, "@typescript-eslint/")
+	case ruleID != "" && !strings.Contains(ruleID, "/"):
+		return "https://eslint.org/docs/latest/rules/" + ruleID
+	default:
+		return ""
+	}
+}
+
+// apply makes the fix to contents and returns the lines it touches after it. The range of a fix is
+// in UTF-16 code units, as JavaScript indexes strings
+func apply(contents string, f fix) (int, int, string, bool) {
+	units := utf16.Encode([]rune(contents))
+	from, to := f.Range[0], f.Range[1]
+	if from < 0 || from > to || to > len(units) {
+		return 0, 0, "", false
+	}
+	lineStart, lineEnd := from, to
+	for lineStart > 0 && units[lineStart-1] != '\n' {
+		lineStart--
+	}
+	for lineEnd < len(units) && units[lineEnd] != '\n' {
+		lineEnd++
+	}
+	start := 1 + countNewlines(units[:lineStart])
+	end := start + countNewlines(units[lineStart:lineEnd])
+
+	replaced := append(append(append([]uint16(nil), units[lineStart:from]...), utf16.Encode([]rune(f.Text))...), units[to:lineEnd]...)
+	suggestion := strings.Replace(string(utf16.Decode(replaced)), "\r\n", "\n", -1)
+	return start, end, strings.TrimSuffix(suggestion, "\r"), true
+}
+
+func countNewlines(units []uint16) int {
+	n := 0
+	for _, u := range units {
+		if u == '\n' {
+			n++
+		}
+	}
+	return n
+}
+
+func severity(m message) commenter.Severity {
+	switch {
+	case m.Fatal || m.Severity == 2:
+		return commenter.SeverityError
+	case m.Severity == 1:
+		return commenter.SeverityWarning
+	default:
+		return commenter.SeverityUnknown
+	}
+}
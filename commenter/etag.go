@@ -0,0 +1,114 @@
+package commenter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// ETagEntry is a cached GET response which can be revalidated with If-None-Match
+type ETagEntry struct {
+	ETag   string
+	Header http.Header
+	Body   []byte
+}
+
+// ETagCache stores responses keyed by request and token, implement it to share the cache across runs
+type ETagCache interface {
+	Get(key string) (*ETagEntry, bool)
+	Set(key string, entry *ETagEntry)
+}
+
+// WithETagCache sends conditional requests for the PR, its files and comments so that
+// unchanged data comes back as a 304, which doesn't count against the rate limit
+func WithETagCache(cache ETagCache) Option {
+	return func(o *options) {
+		o.etagCache = cache
+	}
+}
+
+// NewMemoryETagCache creates an ETagCache which lives as long as the process
+func NewMemoryETagCache() ETagCache {
+	return &memoryETagCache{entries: map[string]*ETagEntry{}}
+}
+
+type memoryETagCache struct {
+	mu      sync.Mutex
+	entries map[string]*ETagEntry
+}
+
+func (m *memoryETagCache) Get(key string) (*ETagEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[key]
+	return entry, ok
+}
+
+func (m *memoryETagCache) Set(key string, entry *ETagEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = entry
+}
+
+type etagTransport struct {
+	cache ETagCache
+	base  http.RoundTripper
+}
+
+func (t *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+
+	// responses differ between tokens, a hash of the token keeps them apart without storing it
+	auth := sha256.Sum256([]byte(req.Header.Get("Authorization")))
+	key := req.Header.Get("Accept") + " " + hex.EncodeToString(auth[:]) + " " + req.URL.String()
+	cached, ok := t.cache.Get(key)
+	if ok {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case ok && resp.StatusCode == http.StatusNotModified:
+		resp.Body.Close()
+		header := cached.Header.Clone()
+		for _, h := range []string{"X-Ratelimit-Limit", "X-Ratelimit-Remaining", "X-Ratelimit-Reset"} {
+			if v := resp.Header.Get(h); v != "" {
+				header.Set(h, v)
+			}
+		}
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader(cached.Body)),
+			ContentLength: int64(len(cached.Body)),
+			Request:       req,
+		}, nil
+	case resp.StatusCode == http.StatusOK && resp.Header.Get("ETag") != "":
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		t.cache.Set(key, &ETagEntry{
+			ETag:   resp.Header.Get("ETag"),
+			Header: resp.Header.Clone(),
+			Body:   body,
+		})
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	return resp, nil
+}
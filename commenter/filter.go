@@ -0,0 +1,211 @@
+package commenter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FilterMode decides which findings are commented inline, as reviewdog's -filter-mode does
+type FilterMode int
+
+const (
+	// FilterDiffContext comments on lines in a diff hunk, context lines included. It is the default
+	FilterDiffContext FilterMode = iota
+	// FilterAdded only comments on added lines
+	FilterAdded
+	// FilterFile comments inline when the line is in the diff and otherwise mentions findings of
+	// changed files in the summary
+	FilterFile
+	// FilterNone reports everything, findings which can't be commented inline are mentioned in the summary
+	FilterNone
+)
+
+// WithFilterMode sets which findings are commented inline, the rest are skipped or mentioned in the summary
+func WithFilterMode(mode FilterMode) Option {
+	return func(o *options) {
+		o.filterMode = mode
+	}
+}
+
+// ParseFilterMode reads the reviewdog names "added", "diff_context", "file" and "nofilter"
+func ParseFilterMode(s string) (FilterMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "diff_context":
+		return FilterDiffContext, nil
+	case "added":
+		return FilterAdded, nil
+	case "file":
+		return FilterFile, nil
+	case "nofilter":
+		return FilterNone, nil
+	default:
+		return FilterDiffContext, fmt.Errorf("unknown filter mode %q", s)
+	}
+}
+
+func (m FilterMode) String() string {
+	switch m {
+	case FilterAdded:
+		return "added"
+	case FilterFile:
+		return "file"
+	case FilterNone:
+		return "nofilter"
+	default:
+		return "diff_context"
+	}
+}
+
+// WithNearestLine anchors a comment whose line isn't in the diff of a changed file to the nearest
+// added line within distance lines instead of rejecting it, the body notes the original line
+func WithNearestLine(distance int) Option {
+	return func(o *options) {
+		o.nearestLine = distance
+	}
+}
+
+// WithPatchlessFileSummary mentions comments on files GitHub shows no patch for, such as binary or very
+// large files, in the summary instead of skipping them, as they can't be commented inline
+func WithPatchlessFileSummary() Option {
+	return func(o *options) {
+		o.patchlessSummary = true
+	}
+}
+
+// placement returns the comment to write inline and its hunk, or whether the comment should be
+// mentioned in the summary instead when there is none
+func (c *Commenter) placement(comment PRReviewComment) (PRReviewComment, *CommitFileInfo, bool) {
+	info := c.fileInfoFor(comment.FileName, comment.StartLine, comment.EndLine)
+	if info != nil && c.opts.filterMode == FilterAdded && !info.addedRange(comment.StartLine, comment.EndLine) {
+		info = nil
+	}
+	if info != nil {
+		return comment, info, false
+	}
+	if anchored, info := c.nearestLine(comment); info != nil {
+		return anchored, info, false
+	}
+	if c.opts.patchlessSummary && c.isPatchless(comment.FileName) {
+		return comment, nil, true
+	}
+	// a file missing from a truncated listing may well be changed by the PR
+	if c.ghConnector != nil && c.ghConnector.truncatedFiles() && !c.fileChanged(comment.FileName) {
+		return comment, nil, true
+	}
+	switch c.opts.filterMode {
+	case FilterFile:
+		return comment, nil, c.fileChanged(comment.FileName)
+	case FilterNone:
+		return comment, nil, true
+	}
+	return comment, nil, false
+}
+
+// nearestLine moves the comment to the closest added line within WithNearestLine of its end line
+func (c *Commenter) nearestLine(comment PRReviewComment) (PRReviewComment, *CommitFileInfo) {
+	for distance := 1; distance <= c.opts.nearestLine; distance++ {
+		for _, line := range []int{comment.EndLine - distance, comment.EndLine + distance} {
+			info := c.fileInfoFor(comment.FileName, line, line)
+			if info == nil || !info.added[line] {
+				continue
+			}
+			anchored := comment
+			anchored.StartLine, anchored.EndLine = line, line
+			anchored.Body = comment.Body + "\n\n" + c.text(MessageNotInDiff, comment.EndLine)
+			return anchored, info
+		}
+	}
+	return comment, nil
+}
+
+func (c *Commenter) fileChanged(filename string) bool {
+	return len(c.hunksOf(filename)) > 0 || c.isPatchless(filename)
+}
+
+func (c *Commenter) isPatchless(filename string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.patchless[filename]
+}
+
+// AddedLines returns the lines of file the PR adds in ascending order, for integrations reporting on
+// new code only such as coverage. The PR info is loaded first if it hasn't been
+func (c *Commenter) AddedLines(file string) ([]int, error) {
+	return c.AddedLinesContext(context.Background(), file)
+}
+
+// AddedLinesContext is AddedLines using ctx for loading the PR info
+func (c *Commenter) AddedLinesContext(ctx context.Context, file string) ([]int, error) {
+	if err := c.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+	var lines []int
+	for _, hunk := range c.hunksOf(c.normalizePath(file)) {
+		for line := range hunk.added {
+			lines = append(lines, line)
+		}
+	}
+	sort.Ints(lines)
+	return lines, nil
+}
+
+// addedRange reports whether every line from start to end was added
+func (f *CommitFileInfo) addedRange(start, end int) bool {
+	if start == 0 || start > end {
+		start = end
+	}
+	for line := start; line <= end; line++ {
+		if !f.added[line] {
+			return false
+		}
+	}
+	return true
+}
+
+// mention keeps a comment drafted outside the diff for the body of the next review
+func (c *Commenter) mention(comment PRReviewComment) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mentions = append(c.mentions, comment)
+}
+
+// takeMentions returns and forgets the comments kept by mention
+func (c *Commenter) takeMentions() []PRReviewComment {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	mentions := c.mentions
+	c.mentions = nil
+	return mentions
+}
+
+// writeMentions writes the comments which can't be inline as one summary comment
+func (c *Commenter) writeMentions(ctx context.Context, results []Result, indexes []int) {
+	if len(indexes) == 0 {
+		return
+	}
+	mentions := make([]PRReviewComment, 0, len(indexes))
+	for _, i := range indexes {
+		mentions = append(mentions, results[i].Comment)
+	}
+	created, err := c.writeSummary(ctx, c.outsideDiffSummary(mentions))
+	for _, i := range indexes {
+		if err != nil {
+			results[i].Status, results[i].Err = ResultFailed, fmt.Errorf("write summary comment: %w", err)
+			continue
+		}
+		results[i].Status, results[i].CommentID, results[i].URL = ResultSummarized, created.ID, created.URL
+	}
+}
+
+// outsideDiffSummary lists comments on lines outside the diff
+func (c *Commenter) outsideDiffSummary(comments []PRReviewComment) string {
+	var b strings.Builder
+	b.WriteString(c.text(MessageOutsideDiff) + "\n")
+	for _, comment := range comments {
+		location := commentLocation(InlineComment{Path: comment.FileName, StartLine: comment.StartLine, EndLine: comment.EndLine})
+		fmt.Fprintf(&b, "\n- `%s`: %s", location, strings.ReplaceAll(comment.Body, "\n", " "))
+	}
+	return b.String()
+}
@@ -0,0 +1,168 @@
+package commenter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Finding is a single result of a scanner or linter to report on the PR
+type Finding struct {
+	// Tool is the scanner which reported the finding
+	Tool      string
+	RuleID    string
+	Severity  Severity
+	Path      string
+	StartLine int
+	EndLine   int
+	Message   string
+	// Snippet is code to show below the message, it is fenced and highlighted as the file's language
+	Snippet string
+	// Suggestion replaces lines StartLine to EndLine, the PR author can apply it from the GitHub UI. An
+	// empty one deletes the lines, nil proposes no change
+	Suggestion *string
+}
+
+// Comment is the review comment posted for the finding
+func (f Finding) Comment() PRReviewComment {
+	body := f.Message
+	if f.RuleID != "" {
+		body = fmt.Sprintf("**%s**: %s", f.RuleID, f.Message)
+	}
+	if f.Snippet != "" {
+		body += "\n\n" + CodeFence(f.Path, f.Snippet)
+	}
+	if f.Suggestion != nil && *f.Suggestion == "" {
+		body += "\n\n```suggestion\n```"
+	} else if f.Suggestion != nil {
+		body += fmt.Sprintf("\n\n```suggestion\n%s\n```", *f.Suggestion)
+	}
+	start, end := f.StartLine, f.EndLine
+	if end < start {
+		end = start
+	}
+	if start == 0 {
+		start = end
+	}
+	return PRReviewComment{
+		FileName:  f.Path,
+		StartLine: start,
+		EndLine:   end,
+		Body:      body,
+	}
+}
+
+// WriteFindings posts the findings as individual review comments like WriteComments, after dropping
+// the ones suppressed by the options such as WithBaseline, WithSuppressionDirectives or WithMinSeverity
+// and merging those found by WithDeduplication. With WithThreadContinuation findings of a rule already
+// commented on in the file are replied to that thread. The results line up with findings
+func (c *Commenter) WriteFindings(findings []Finding) ([]Result, error) {
+	return c.WriteFindingsContext(context.Background(), findings)
+}
+
+// WriteFindingsContext is WriteFindings using ctx for the API calls
+func (c *Commenter) WriteFindingsContext(ctx context.Context, findings []Finding) ([]Result, error) {
+	if err := c.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+	results, err := c.writeFindings(ctx, c.normalizeFindings(findings), false)
+	if err != nil {
+		return results, err
+	}
+	if clean(results) {
+		if err := c.approveClean(ctx); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// writeFindings filters and posts findings, marked comments carry the fingerprint Sync matches them by
+func (c *Commenter) writeFindings(ctx context.Context, findings []Finding, marked bool) ([]Result, error) {
+	if err := c.opts.findingTemplateErr; err != nil {
+		return nil, fmt.Errorf("parse finding template: %w", err)
+	}
+	merged, duplicateOf := c.dedupe(findings)
+	results := make([]Result, len(findings))
+	roots := c.threadRoots()
+	var (
+		comments []PRReviewComment
+		indexes  []int
+		low      []Finding
+		replies  []int
+	)
+	for i, finding := range merged {
+		if j := duplicateOf[i]; j >= 0 {
+			results[i] = Result{Comment: finding.Comment(), Status: ResultSuppressed, Err: fmt.Errorf("finding is a duplicate of finding %d: %w", j, ErrSuppressed)}
+			continue
+		}
+		if c.belowMinSeverity(finding) {
+			c.logger().Info("suppressed finding", "file", finding.Path, "line", finding.StartLine, "rule", finding.RuleID, "reason", "below the minimum severity")
+			c.metrics().Add(MetricCommentsSkipped, 1)
+			results[i] = Result{Comment: finding.Comment(), Status: ResultSuppressed, Err: fmt.Errorf("finding is below the %s severity: %w", c.opts.minSeverity, ErrSuppressed)}
+			low = append(low, finding)
+			continue
+		}
+		// suppression applies to the finding as reported, not as merged with its duplicates
+		if reason := c.suppressed(ctx, findings[i]); reason != nil {
+			c.logger().Info("suppressed finding", "file", finding.Path, "line", finding.StartLine, "rule", finding.RuleID, "reason", reason)
+			c.metrics().Add(MetricCommentsSkipped, 1)
+			results[i] = Result{Comment: finding.Comment(), Status: ResultSuppressed, Err: reason}
+			continue
+		}
+		comment, err := c.findingComment(finding)
+		if err != nil {
+			results[i] = Result{Comment: comment, Status: ResultFailed, Err: err}
+			continue
+		}
+		comment = c.mentionAuthor(comment, finding)
+		if marked {
+			comment.Body += "\n\n" + findingMarker(Fingerprint(findings[i]))
+		}
+		if root := roots[threadKey{path: finding.Path, rule: finding.RuleID}]; root != nil && c.pathAllowed(finding.Path) {
+			if _, info, _ := c.placement(comment); info != nil {
+				results[i] = Result{Comment: comment}
+				replies = append(replies, i)
+				continue
+			}
+		}
+		comments = append(comments, comment)
+		indexes = append(indexes, i)
+	}
+
+	// failed comments don't stop the replies and the summary, they are reported together afterwards
+	written, err := c.WriteCommentsContext(ctx, comments)
+	for i, result := range written {
+		results[indexes[i]] = result
+	}
+	var batchErr BatchError
+	if err != nil && !errors.As(err, &batchErr) {
+		return results, err
+	}
+	for _, i := range replies {
+		results[i] = c.continueThread(ctx, roots[threadKey{path: merged[i].Path, rule: merged[i].RuleID}], results[i].Comment)
+	}
+	all := results
+	if len(low) > 0 && c.opts.lowSeveritySummary {
+		summary := c.lowSeveritySummary(low)
+		if _, err := c.writeSummary(ctx, summary); err != nil {
+			if newBatchError(results) == nil {
+				return results, fmt.Errorf("write low severity summary: %w", err)
+			}
+			// the summary failing too is one more failure of the batch
+			all = append(all[:len(all):len(all)], Result{Comment: PRReviewComment{Body: summary}, Status: ResultFailed, Err: fmt.Errorf("write low severity summary: %w", err)})
+		}
+	}
+	return results, newBatchError(all)
+}
+
+// suppressed returns why the finding shouldn't be posted, nil when it should
+func (c *Commenter) suppressed(ctx context.Context, finding Finding) error {
+	if c.opts.baseline != nil && c.opts.baseline.Contains(finding) {
+		return fmt.Errorf("finding is in the baseline: %w", ErrSuppressed)
+	}
+	if c.suppressedByDirective(ctx, finding) {
+		return fmt.Errorf("finding is suppressed by a directive in the code: %w", ErrSuppressed)
+	}
+	return nil
+}
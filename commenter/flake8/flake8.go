@@ -0,0 +1,54 @@
+// Package flake8 reads the errors flake8 prints in its default format as findings
+package flake8
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+)
+
+// Tool names the findings of Parse
+const Tool = "flake8"
+
+// lineRegex matches flake8's default "%(path)s:%(row)d:%(col)d: %(code)s %(text)s" format
+var lineRegex = regexp.MustCompile(`^(.+?):(\d+):(\d+): ([A-Z]+\d+) (.*)$`)
+
+// Parse reads the errors of flake8's default format as findings of the code. pyflakes' F codes and
+// pycodestyle's E9 syntax errors are SeverityError, the style codes SeverityWarning. Other lines,
+// such as those of --show-source, are left out
+func Parse(r io.Reader) ([]commenter.Finding, error) {
+	var findings []commenter.Finding
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		groups := lineRegex.FindStringSubmatch(strings.TrimRight(scanner.Text(), "\r"))
+		if groups == nil {
+			continue
+		}
+		line, _ := strconv.Atoi(groups[2])
+		findings = append(findings, commenter.Finding{
+			Tool:      Tool,
+			RuleID:    groups[4],
+			Severity:  severity(groups[4]),
+			Path:      groups[1],
+			StartLine: line,
+			EndLine:   line,
+			Message:   groups[5],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read flake8 output: %w", err)
+	}
+	return findings, nil
+}
+
+func severity(code string) commenter.Severity {
+	if strings.HasPrefix(code, "F") || strings.HasPrefix(code, "E9") {
+		return commenter.SeverityError
+	}
+	return commenter.SeverityWarning
+}
@@ -0,0 +1,136 @@
+package commenter
+
+import (
+	"path"
+	"strings"
+)
+
+// languages maps file extensions, and names of files without one, to the GitHub markdown language
+var languages = map[string]string{
+	".go":         "go",
+	".mod":        "go",
+	".py":         "python",
+	".rb":         "ruby",
+	".js":         "javascript",
+	".jsx":        "jsx",
+	".mjs":        "javascript",
+	".ts":         "typescript",
+	".tsx":        "tsx",
+	".java":       "java",
+	".kt":         "kotlin",
+	".scala":      "scala",
+	".swift":      "swift",
+	".c":          "c",
+	".h":          "c",
+	".cc":         "cpp",
+	".cpp":        "cpp",
+	".hpp":        "cpp",
+	".cs":         "csharp",
+	".rs":         "rust",
+	".php":        "php",
+	".sh":         "shell",
+	".bash":       "shell",
+	".ps1":        "powershell",
+	".sql":        "sql",
+	".tf":         "hcl",
+	".hcl":        "hcl",
+	".yml":        "yaml",
+	".yaml":       "yaml",
+	".json":       "json",
+	".toml":       "toml",
+	".xml":        "xml",
+	".html":       "html",
+	".css":        "css",
+	".scss":       "scss",
+	".md":         "markdown",
+	".proto":      "protobuf",
+	".lua":        "lua",
+	".dart":       "dart",
+	"Dockerfile":  "dockerfile",
+	"Makefile":    "makefile",
+	"Gemfile":     "ruby",
+	"Jenkinsfile": "groovy",
+}
+
+// LanguageFor returns the language GitHub highlights a file's code as, guessed from its extension or
+// name, "" when it isn't known
+func LanguageFor(filename string) string {
+	base := path.Base(filename)
+	if lang, ok := languages[base]; ok {
+		return lang
+	}
+	if strings.HasPrefix(base, "Dockerfile.") {
+		return "dockerfile"
+	}
+	return languages[strings.ToLower(path.Ext(base))]
+}
+
+// CodeFence wraps code from filename in a fenced code block highlighted as the file's language. The
+// fence is made longer than any run of backticks in the code so the code can't end it early
+func CodeFence(filename, code string) string {
+	return fenced(LanguageFor(filename), code)
+}
+
+// fenced wraps code in a code block of lang with a fence it doesn't contain
+func fenced(lang, code string) string {
+	fence := strings.Repeat("`", longestRun(code, '`')+1)
+	if len(fence) < 3 {
+		fence = "```"
+	}
+	return fence + lang + "\n" + strings.TrimRight(code, "\n") + "\n" + fence
+}
+
+// longestRun returns the length of the longest run of r in s
+func longestRun(s string, r byte) int {
+	longest, run := 0, 0
+	for i := 0; i < len(s); i++ {
+		if s[i] != r {
+			run = 0
+			continue
+		}
+		run++
+		if run > longest {
+			longest = run
+		}
+	}
+	return longest
+}
+
+// maxSnippetLines bounds the diff snippet WithDiffSnippet adds to a comment
+const maxSnippetLines = 10
+
+// WithDiffSnippet adds the patch lines a comment is on to its body as a diff block, so the code is
+// visible where the diff isn't, such as in email notifications. Long ranges show their first lines
+func WithDiffSnippet() Option {
+	return func(o *options) {
+		o.diffSnippet = true
+	}
+}
+
+// withSnippet returns the comment with the WithDiffSnippet snippet of its lines in info appended
+func (c *Commenter) withSnippet(comment PRReviewComment, info *CommitFileInfo) PRReviewComment {
+	if !c.opts.diffSnippet || info == nil {
+		return comment
+	}
+	var lines []string
+	for n := comment.StartLine; n <= comment.EndLine; n++ {
+		line, ok := info.lines[n]
+		if !ok {
+			continue
+		}
+		if len(lines) == maxSnippetLines {
+			lines = append(lines, " …")
+			break
+		}
+		prefix := " "
+		if info.added[n] {
+			prefix = "+"
+		}
+		lines = append(lines, prefix+line)
+	}
+	if len(lines) == 0 {
+		return comment
+	}
+	comment.Body += "\n\n" + fenced("diff", strings.Join(lines, "\n"))
+	return comment
+}
@@ -0,0 +1,257 @@
+// Package gitea provides a commenter.Provider writing Gitea and Forgejo pull request review comments
+package gitea
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/internal/rest"
+)
+
+const pageSize = 50
+
+// Provider implements commenter.Provider for a single Gitea or Forgejo pull request. Every inline
+// comment is posted as its own single comment review, which is what deleting it removes
+type Provider struct {
+	api     *rest.Client
+	owner   string
+	repo    string
+	index   int
+	headSHA string
+	login   string
+}
+
+var (
+	_ commenter.Provider   = (*Provider)(nil)
+	_ commenter.Identifier = (*Provider)(nil)
+)
+
+// Option configures the provider
+type Option func(*Provider)
+
+// WithHTTPClient sets the client used for API calls
+func WithHTTPClient(client *http.Client) Option {
+	return func(p *Provider) {
+		p.api.HTTP = client
+	}
+}
+
+type user struct {
+	Login string `json:"login"`
+}
+
+type pullRequest struct {
+	Head struct {
+		SHA string `json:"sha"`
+	} `json:"head"`
+}
+
+type review struct {
+	ID   int64 `json:"id"`
+	User user  `json:"user"`
+}
+
+type reviewComment struct {
+	ID       int64  `json:"id"`
+	Body     string `json:"body"`
+	User     user   `json:"user"`
+	Path     string `json:"path"`
+	Position int    `json:"position"`
+	HTMLURL  string `json:"html_url"`
+}
+
+type issueComment struct {
+	ID      int64  `json:"id"`
+	Body    string `json:"body"`
+	User    user   `json:"user"`
+	HTMLURL string `json:"html_url"`
+}
+
+type draftComment struct {
+	Path        string `json:"path"`
+	Body        string `json:"body"`
+	NewPosition int    `json:"new_position"`
+}
+
+type reviewRequest struct {
+	CommitID string         `json:"commit_id"`
+	Event    string         `json:"event"`
+	Body     string         `json:"body"`
+	Comments []draftComment `json:"comments"`
+}
+
+type commentRequest struct {
+	Body string `json:"body"`
+}
+
+// New creates a provider for pull request index of owner/repo on the instance at baseURL, such as
+// https://gitea.example.com. The pull request and the token's user are looked up straight away
+func New(ctx context.Context, baseURL, token, owner, repo string, index int, opts ...Option) (*Provider, error) {
+	p := &Provider{
+		api: &rest.Client{
+			Forge:   "Gitea",
+			BaseURL: strings.TrimSuffix(baseURL, "/") + "/api/v1",
+			Header:  http.Header{"Authorization": []string{"token " + token}},
+		},
+		owner: owner,
+		repo:  repo,
+		index: index,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	var pr pullRequest
+	if _, err := p.api.Do(ctx, http.MethodGet, p.repoPath("/pulls/%d", index), nil, nil, &pr); err != nil {
+		var restErr *rest.Error
+		if errors.As(err, &restErr) && restErr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("PR number [%d] not found for %s/%s: %w", index, owner, repo, commenter.ErrPRNotFound)
+		}
+		return nil, err
+	}
+	p.headSHA = pr.Head.SHA
+
+	var u user
+	if _, err := p.api.Do(ctx, http.MethodGet, "/user", nil, nil, &u); err != nil {
+		return nil, fmt.Errorf("resolve the token's user: %w", err)
+	}
+	p.login = u.Login
+	return p, nil
+}
+
+// CommenterName implements commenter.Identifier with the login of the token's user
+func (p *Provider) CommenterName() string {
+	return p.login
+}
+
+// ListChangedFiles implements commenter.Provider from the pull request diff
+func (p *Provider) ListChangedFiles(ctx context.Context) ([]*commenter.ChangedFile, error) {
+	diff, err := p.api.Raw(ctx, p.repoPath("/pulls/%d.diff", p.index), nil)
+	if err != nil {
+		return nil, err
+	}
+	return commenter.ParseUnifiedDiff(bytes.NewReader(diff), p.headSHA)
+}
+
+// ListComments implements commenter.Provider with the comments of every review
+func (p *Provider) ListComments(ctx context.Context) ([]*commenter.Comment, error) {
+	var reviews []*review
+	for page := 1; ; page++ {
+		var batch []*review
+		query := url.Values{"page": {strconv.Itoa(page)}, "limit": {strconv.Itoa(pageSize)}}
+		if _, err := p.api.Do(ctx, http.MethodGet, p.repoPath("/pulls/%d/reviews", p.index), query, nil, &batch); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, batch...)
+		if len(batch) < pageSize {
+			break
+		}
+	}
+
+	var comments []*commenter.Comment
+	for _, r := range reviews {
+		var batch []*reviewComment
+		if _, err := p.api.Do(ctx, http.MethodGet, p.repoPath("/pulls/%d/reviews/%d/comments", p.index, r.ID), nil, nil, &batch); err != nil {
+			return nil, err
+		}
+		for _, c := range batch {
+			comments = append(comments, toComment(r.ID, c))
+		}
+	}
+	return comments, nil
+}
+
+// CreateInlineComment implements commenter.Provider, Gitea anchors the comment at the end line
+func (p *Provider) CreateInlineComment(ctx context.Context, c commenter.InlineComment) (*commenter.Comment, error) {
+	req := reviewRequest{
+		CommitID: c.CommitSHA,
+		Event:    "COMMENT",
+		Comments: []draftComment{{Path: c.Path, Body: c.Body, NewPosition: c.EndLine}},
+	}
+	var created review
+	if _, err := p.api.Do(ctx, http.MethodPost, p.repoPath("/pulls/%d/reviews", p.index), nil, req, &created); err != nil {
+		return nil, fmt.Errorf("create comment on %s line %d: %w", c.Path, c.EndLine, err)
+	}
+
+	var batch []*reviewComment
+	if _, err := p.api.Do(ctx, http.MethodGet, p.repoPath("/pulls/%d/reviews/%d/comments", p.index, created.ID), nil, nil, &batch); err != nil {
+		return nil, fmt.Errorf("read back comment on %s line %d: %w", c.Path, c.EndLine, err)
+	}
+	result := &commenter.Comment{
+		NodeID:    strconv.FormatInt(created.ID, 10),
+		Path:      c.Path,
+		StartLine: c.StartLine,
+		Line:      c.EndLine,
+		Body:      c.Body,
+		Author:    p.login,
+	}
+	if len(batch) > 0 {
+		result.ID = batch[0].ID
+		result.URL = batch[0].HTMLURL
+	}
+	return result, nil
+}
+
+// CreateSummaryComment implements commenter.Provider with a comment on the pull request conversation
+func (p *Provider) CreateSummaryComment(ctx context.Context, body string) (*commenter.Comment, error) {
+	var created issueComment
+	if _, err := p.api.Do(ctx, http.MethodPost, p.repoPath("/issues/%d/comments", p.index), nil, commentRequest{Body: body}, &created); err != nil {
+		return nil, fmt.Errorf("create summary comment: %w", err)
+	}
+	return &commenter.Comment{
+		ID:     created.ID,
+		Body:   body,
+		Author: created.User.Login,
+		URL:    created.HTMLURL,
+	}, nil
+}
+
+// UpdateComment implements commenter.Provider
+func (p *Provider) UpdateComment(ctx context.Context, c *commenter.Comment, body string) error {
+	if _, err := p.api.Do(ctx, http.MethodPatch, p.repoPath("/issues/comments/%d", c.ID), nil, commentRequest{Body: body}, nil); err != nil {
+		return fmt.Errorf("update existing comment %d: %w", c.ID, err)
+	}
+	return nil
+}
+
+// DeleteComment implements commenter.Provider, deleting the review holding an inline comment
+func (p *Provider) DeleteComment(ctx context.Context, c *commenter.Comment) error {
+	path := p.repoPath("/issues/comments/%d", c.ID)
+	if c.NodeID != "" {
+		reviewID, err := strconv.ParseInt(c.NodeID, 10, 64)
+		if err != nil {
+			return fmt.Errorf("delete existing comment %d: review id %q: %w", c.ID, c.NodeID, err)
+		}
+		path = p.repoPath("/pulls/%d/reviews/%d", p.index, reviewID)
+	}
+	if _, err := p.api.Do(ctx, http.MethodDelete, path, nil, nil, nil); err != nil {
+		return fmt.Errorf("delete existing comment %d: %w", c.ID, err)
+	}
+	return nil
+}
+
+func toComment(reviewID int64, c *reviewComment) *commenter.Comment {
+	return &commenter.Comment{
+		ID:        c.ID,
+		NodeID:    strconv.FormatInt(reviewID, 10),
+		Path:      c.Path,
+		StartLine: c.Position,
+		Line:      c.Position,
+		// comments on removed lines only have an original position
+		Outdated: c.Position == 0,
+		Body:     c.Body,
+		Author:   c.User.Login,
+		URL:      c.HTMLURL,
+	}
+}
+
+func (p *Provider) repoPath(format string, args ...interface{}) string {
+	return fmt.Sprintf("/repos/%s/%s", url.PathEscape(p.owner), url.PathEscape(p.repo)) + fmt.Sprintf(format, args...)
+}
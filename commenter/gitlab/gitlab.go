@@ -0,0 +1,364 @@
+// Package gitlab provides a commenter.Provider writing GitLab merge request discussions
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/internal/rest"
+)
+
+// DefaultBaseURL is the API of gitlab.com, use WithBaseURL for self-managed instances
+const DefaultBaseURL = "https://gitlab.com/api/v4"
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+(\d+)`)
+
+// Provider implements commenter.Provider for a single GitLab merge request, inline comments are
+// position based diff notes each starting their own discussion
+type Provider struct {
+	api      *rest.Client
+	project  string
+	iid      int
+	refs     diffRefs
+	webURL   string
+	username string
+	// patchMu guards patches, the diffs of the changed files by path, which ListChangedFiles replaces
+	// while comments may be written
+	patchMu sync.Mutex
+	patches map[string]string
+}
+
+var (
+	_ commenter.Provider   = (*Provider)(nil)
+	_ commenter.Identifier = (*Provider)(nil)
+)
+
+// Option configures the provider
+type Option func(*Provider)
+
+// WithBaseURL points the provider at the API of a self-managed instance, e.g. https://gitlab.example.com/api/v4
+func WithBaseURL(baseURL string) Option {
+	return func(p *Provider) {
+		p.api.BaseURL = baseURL
+	}
+}
+
+// WithHTTPClient sets the client used for API calls
+func WithHTTPClient(client *http.Client) Option {
+	return func(p *Provider) {
+		p.api.HTTP = client
+	}
+}
+
+type diffRefs struct {
+	BaseSHA  string `json:"base_sha"`
+	HeadSHA  string `json:"head_sha"`
+	StartSHA string `json:"start_sha"`
+}
+
+type mergeRequest struct {
+	IID      int      `json:"iid"`
+	WebURL   string   `json:"web_url"`
+	DiffRefs diffRefs `json:"diff_refs"`
+}
+
+type user struct {
+	Username string `json:"username"`
+}
+
+type diff struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	Diff        string `json:"diff"`
+	NewFile     bool   `json:"new_file"`
+	RenamedFile bool   `json:"renamed_file"`
+	DeletedFile bool   `json:"deleted_file"`
+}
+
+type position struct {
+	PositionType string `json:"position_type"`
+	BaseSHA      string `json:"base_sha"`
+	HeadSHA      string `json:"head_sha"`
+	StartSHA     string `json:"start_sha"`
+	OldPath      string `json:"old_path,omitempty"`
+	NewPath      string `json:"new_path,omitempty"`
+	OldLine      int    `json:"old_line,omitempty"`
+	NewLine      int    `json:"new_line,omitempty"`
+}
+
+type note struct {
+	ID       int64     `json:"id"`
+	Type     string    `json:"type"`
+	Body     string    `json:"body"`
+	System   bool      `json:"system"`
+	Author   user      `json:"author"`
+	Position *position `json:"position"`
+}
+
+type discussion struct {
+	ID    string  `json:"id"`
+	Notes []*note `json:"notes"`
+}
+
+type noteRequest struct {
+	Body     string    `json:"body"`
+	Position *position `json:"position,omitempty"`
+}
+
+// New creates a provider for merge request iid of project, the full path (group/project) or numeric id.
+// The merge request and the user the token belongs to are looked up straight away
+func New(ctx context.Context, token, project string, iid int, opts ...Option) (*Provider, error) {
+	p := &Provider{
+		api: &rest.Client{
+			Forge:   "GitLab",
+			BaseURL: DefaultBaseURL,
+			Header:  http.Header{"Authorization": []string{"Bearer " + token}},
+		},
+		project: project,
+		iid:     iid,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	var mr mergeRequest
+	if _, err := p.api.Do(ctx, http.MethodGet, p.mrPath(""), nil, nil, &mr); err != nil {
+		var restErr *rest.Error
+		if errors.As(err, &restErr) && restErr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("Merge request [%d] not found for %s: %w", iid, project, commenter.ErrPRNotFound)
+		}
+		return nil, err
+	}
+	p.refs = mr.DiffRefs
+	p.webURL = mr.WebURL
+
+	var u user
+	if _, err := p.api.Do(ctx, http.MethodGet, "/user", nil, nil, &u); err != nil {
+		return nil, fmt.Errorf("resolve the token's user: %w", err)
+	}
+	p.username = u.Username
+	return p, nil
+}
+
+// CommenterName implements commenter.Identifier with the username of the token's user
+func (p *Provider) CommenterName() string {
+	return p.username
+}
+
+// ListChangedFiles implements commenter.Provider
+func (p *Provider) ListChangedFiles(ctx context.Context) ([]*commenter.ChangedFile, error) {
+	var diffs []*diff
+	err := p.paginate(ctx, p.mrPath("/diffs"), func() interface{} { return &[]*diff{} }, func(page interface{}) {
+		diffs = append(diffs, *page.(*[]*diff)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	patches := map[string]string{}
+	files := make([]*commenter.ChangedFile, 0, len(diffs))
+	for _, d := range diffs {
+		status := "modified"
+		switch {
+		case d.DeletedFile:
+			status = "deleted"
+		case d.RenamedFile:
+			status = "renamed"
+		case d.NewFile:
+			status = "added"
+		}
+		patches[d.NewPath] = d.Diff
+		files = append(files, &commenter.ChangedFile{
+			Filename:  d.NewPath,
+			Status:    status,
+			Patch:     d.Diff,
+			Changes:   strings.Count(d.Diff, "\n"),
+			CommitSHA: p.refs.HeadSHA,
+		})
+	}
+	p.patchMu.Lock()
+	p.patches = patches
+	p.patchMu.Unlock()
+	return files, nil
+}
+
+// ListComments implements commenter.Provider with the notes of every diff discussion, the later notes
+// are InReplyTo the first one
+func (p *Provider) ListComments(ctx context.Context) ([]*commenter.Comment, error) {
+	var discussions []*discussion
+	err := p.paginate(ctx, p.mrPath("/discussions"), func() interface{} { return &[]*discussion{} }, func(page interface{}) {
+		discussions = append(discussions, *page.(*[]*discussion)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []*commenter.Comment
+	for _, d := range discussions {
+		if len(d.Notes) == 0 {
+			continue
+		}
+		first := d.Notes[0]
+		if first.System || first.Position == nil {
+			continue
+		}
+		for _, n := range d.Notes {
+			if n.System {
+				continue
+			}
+			comment := &commenter.Comment{
+				ID:        n.ID,
+				NodeID:    d.ID,
+				Path:      first.Position.NewPath,
+				StartLine: first.Position.NewLine,
+				Line:      first.Position.NewLine,
+				// notes on removed lines have no new line to anchor to
+				Outdated: first.Position.NewLine == 0,
+				Body:     n.Body,
+				Author:   n.Author.Username,
+				URL:      p.noteURL(n.ID),
+			}
+			if n != first {
+				comment.InReplyTo = first.ID
+			}
+			comments = append(comments, comment)
+		}
+	}
+	return comments, nil
+}
+
+// CreateInlineComment implements commenter.Provider, GitLab anchors the note at the end line
+func (p *Provider) CreateInlineComment(ctx context.Context, comment commenter.InlineComment) (*commenter.Comment, error) {
+	pos := &position{
+		PositionType: "text",
+		BaseSHA:      p.refs.BaseSHA,
+		HeadSHA:      p.refs.HeadSHA,
+		StartSHA:     p.refs.StartSHA,
+		OldPath:      comment.Path,
+		NewPath:      comment.Path,
+		NewLine:      comment.EndLine,
+	}
+	// unchanged lines must be given on both sides of the diff
+	p.patchMu.Lock()
+	patch := p.patches[comment.Path]
+	p.patchMu.Unlock()
+	if oldLine, ok := oldLineFor(patch, comment.EndLine); ok {
+		pos.OldLine = oldLine
+	}
+
+	var created discussion
+	req := noteRequest{Body: comment.Body, Position: pos}
+	if _, err := p.api.Do(ctx, http.MethodPost, p.mrPath("/discussions"), nil, req, &created); err != nil {
+		return nil, fmt.Errorf("create comment on %s line %d: %w", comment.Path, comment.EndLine, err)
+	}
+	result := &commenter.Comment{
+		NodeID:    created.ID,
+		Path:      comment.Path,
+		StartLine: comment.StartLine,
+		Line:      comment.EndLine,
+		Body:      comment.Body,
+		Author:    p.username,
+	}
+	if len(created.Notes) > 0 {
+		result.ID = created.Notes[0].ID
+		result.URL = p.noteURL(result.ID)
+	}
+	return result, nil
+}
+
+// CreateSummaryComment implements commenter.Provider with a merge request note
+func (p *Provider) CreateSummaryComment(ctx context.Context, body string) (*commenter.Comment, error) {
+	var created note
+	if _, err := p.api.Do(ctx, http.MethodPost, p.mrPath("/notes"), nil, noteRequest{Body: body}, &created); err != nil {
+		return nil, fmt.Errorf("create summary comment: %w", err)
+	}
+	return &commenter.Comment{
+		ID:     created.ID,
+		Body:   body,
+		Author: p.username,
+		URL:    p.noteURL(created.ID),
+	}, nil
+}
+
+// UpdateComment implements commenter.Provider
+func (p *Provider) UpdateComment(ctx context.Context, comment *commenter.Comment, body string) error {
+	path := p.mrPath("/notes/" + strconv.FormatInt(comment.ID, 10))
+	if _, err := p.api.Do(ctx, http.MethodPut, path, nil, noteRequest{Body: body}, nil); err != nil {
+		return fmt.Errorf("update existing comment %d: %w", comment.ID, err)
+	}
+	return nil
+}
+
+// DeleteComment implements commenter.Provider
+func (p *Provider) DeleteComment(ctx context.Context, comment *commenter.Comment) error {
+	path := p.mrPath("/notes/" + strconv.FormatInt(comment.ID, 10))
+	if _, err := p.api.Do(ctx, http.MethodDelete, path, nil, nil, nil); err != nil {
+		return fmt.Errorf("delete existing comment %d: %w", comment.ID, err)
+	}
+	return nil
+}
+
+func (p *Provider) mrPath(suffix string) string {
+	return fmt.Sprintf("/projects/%s/merge_requests/%d%s", url.PathEscape(p.project), p.iid, suffix)
+}
+
+// noteURL links note id on the merge request's page, which is built from the web_url GitLab reports as
+// the project may be given as a numeric id
+func (p *Provider) noteURL(id int64) string {
+	if p.webURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s#note_%d", p.webURL, id)
+}
+
+// paginate follows the X-Next-Page header, calling collect with each decoded page
+func (p *Provider) paginate(ctx context.Context, path string, newPage func() interface{}, collect func(interface{})) error {
+	next := "1"
+	for next != "" {
+		page := newPage()
+		resp, err := p.api.Do(ctx, http.MethodGet, path, url.Values{"page": {next}, "per_page": {"100"}}, nil, page)
+		if err != nil {
+			return err
+		}
+		collect(page)
+		next = resp.Header.Get("X-Next-Page")
+	}
+	return nil
+}
+
+// oldLineFor returns the old side line of newLine when it is an unchanged line of patch
+func oldLineFor(patch string, newLine int) (int, bool) {
+	var oldLine, line int
+	for _, text := range strings.Split(patch, "\n") {
+		if groups := hunkHeaderRegex.FindStringSubmatch(text); groups != nil {
+			oldLine, _ = strconv.Atoi(groups[1])
+			line, _ = strconv.Atoi(groups[2])
+			continue
+		}
+		switch {
+		case strings.HasPrefix(text, "+"):
+			if line == newLine {
+				return 0, false
+			}
+			line++
+		case strings.HasPrefix(text, "-"):
+			oldLine++
+		case strings.HasPrefix(text, "\\"):
+		default:
+			if line == newLine {
+				return oldLine, true
+			}
+			line++
+			oldLine++
+		}
+	}
+	return 0, false
+}
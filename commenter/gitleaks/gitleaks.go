@@ -0,0 +1,79 @@
+// Package gitleaks reads the leaks of a gitleaks JSON report as findings, with the secrets redacted
+package gitleaks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+)
+
+// Tool names the findings of Parse
+const Tool = "gitleaks"
+
+// Redacted replaces the secrets in the findings of Parse, as gitleaks --redact does
+const Redacted = "REDACTED"
+
+type leak struct {
+	Description string `json:"Description"`
+	RuleID      string `json:"RuleID"`
+	File        string `json:"File"`
+	StartLine   int    `json:"StartLine"`
+	EndLine     int    `json:"EndLine"`
+	Match       string `json:"Match"`
+	Secret      string `json:"Secret"`
+	Commit      string `json:"Commit"`
+}
+
+// Parse reads the report gitleaks writes with --report-format json as SeverityCritical findings of
+// the rule. The line gitleaks matched is shown with the secret replaced by Redacted, so the comment
+// doesn't publish the credential again, it is left out when the report doesn't tell the secret
+func Parse(r io.Reader) ([]commenter.Finding, error) {
+	var leaks []leak
+	if err := json.NewDecoder(r).Decode(&leaks); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("decode gitleaks report: %w", err)
+	}
+	var findings []commenter.Finding
+	for _, l := range leaks {
+		message := fmt.Sprintf("%s detected, revoke the secret and remove it from the history", l.Description)
+		if l.Commit != "" {
+			message += fmt.Sprintf(" (introduced in %s)", l.Commit)
+		}
+		findings = append(findings, commenter.Finding{
+			Tool:      Tool,
+			RuleID:    l.RuleID,
+			Severity:  commenter.SeverityCritical,
+			Path:      l.File,
+			StartLine: l.StartLine,
+			EndLine:   l.EndLine,
+			Message:   Redact(message, l.Secret),
+			Snippet:   snippet(l),
+		})
+	}
+	return findings, nil
+}
+
+// Redact replaces every occurrence of secret, including its lines on their own for a secret
+// spanning several such as a private key, in s with Redacted
+func Redact(s, secret string) string {
+	if strings.TrimSpace(secret) == "" {
+		return s
+	}
+	s = strings.Replace(s, secret, Redacted, -1)
+	for _, line := range strings.Split(secret, "\n") {
+		if line = strings.TrimSpace(line); len(line) >= 8 {
+			s = strings.Replace(s, line, Redacted, -1)
+		}
+	}
+	return s
+}
+
+// snippet is the redacted match of the leak, "" when the secret isn't known and so can't be redacted
+func snippet(l leak) string {
+	if l.Match == "" || (l.Secret == "" && l.Match != Redacted) {
+		return ""
+	}
+	return Redact(l.Match, l.Secret)
+}
@@ -0,0 +1,171 @@
+// Package gocover reports the lines a PR adds which a Go cover profile shows no test ran
+package gocover
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+)
+
+// Tool and RuleID name the findings of Uncovered
+const (
+	Tool   = "go-cover"
+	RuleID = "uncovered"
+)
+
+// Block is a block of statements of a cover profile, as written by go test -coverprofile
+type Block struct {
+	// File is the import path of the file, such as "github.com/owner/repo/pkg/a.go"
+	File       string
+	StartLine  int
+	EndLine    int
+	Statements int
+	// Count is how many times the block ran, or whether it did in the set mode
+	Count int
+}
+
+// ParseProfile reads the blocks of a cover profile in any of the set, count and atomic modes
+func ParseProfile(r io.Reader) ([]Block, error) {
+	var blocks []Block
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		block, err := parseBlock(line)
+		if err != nil {
+			return nil, fmt.Errorf("cover profile line %d: %w", n, err)
+		}
+		blocks = append(blocks, block)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read cover profile: %w", err)
+	}
+	return blocks, nil
+}
+
+// parseBlock reads a "file:startLine.startCol,endLine.endCol statements count" line
+func parseBlock(line string) (Block, error) {
+	colon := strings.LastIndex(line, ":")
+	if colon < 0 {
+		return Block{}, fmt.Errorf("malformed block %q", line)
+	}
+	fields := strings.Fields(line[colon+1:])
+	if len(fields) != 3 {
+		return Block{}, fmt.Errorf("malformed block %q", line)
+	}
+	span := strings.Split(fields[0], ",")
+	if len(span) != 2 {
+		return Block{}, fmt.Errorf("malformed block %q", line)
+	}
+	block := Block{File: line[:colon]}
+	var err error
+	if block.StartLine, err = lineOf(span[0]); err != nil {
+		return Block{}, err
+	}
+	if block.EndLine, err = lineOf(span[1]); err != nil {
+		return Block{}, err
+	}
+	if block.Statements, err = strconv.Atoi(fields[1]); err != nil {
+		return Block{}, fmt.Errorf("malformed statement count %q", fields[1])
+	}
+	if block.Count, err = strconv.Atoi(fields[2]); err != nil {
+		return Block{}, fmt.Errorf("malformed count %q", fields[2])
+	}
+	return block, nil
+}
+
+// lineOf reads the line of a "line.column" position
+func lineOf(position string) (int, error) {
+	line, err := strconv.Atoi(strings.SplitN(position, ".", 2)[0])
+	if err != nil {
+		return 0, fmt.Errorf("malformed position %q", position)
+	}
+	return line, nil
+}
+
+// Uncovered returns a finding for each run of lines the PR adds to a file which are in a block that no
+// test ran, and in none that one did. modulePath, the module the profile is of, is trimmed from the
+// files so they are relative to the module, use commenter.WithPathPrefix when it isn't the repository's
+// root. Write the findings inline with WriteFindings, or as a table with Summary
+func Uncovered(ctx context.Context, c *commenter.Commenter, blocks []Block, modulePath string) ([]commenter.Finding, error) {
+	covered, uncovered := map[string]map[int]bool{}, map[string]map[int]bool{}
+	var files []string
+	for _, block := range blocks {
+		if block.Statements == 0 {
+			continue
+		}
+		file := strings.TrimPrefix(block.File, strings.TrimSuffix(modulePath, "/")+"/")
+		lines := uncovered
+		if block.Count > 0 {
+			lines = covered
+		}
+		if _, ok := covered[file]; !ok {
+			covered[file], uncovered[file] = map[int]bool{}, map[int]bool{}
+			files = append(files, file)
+		}
+		for line := block.StartLine; line <= block.EndLine; line++ {
+			lines[file][line] = true
+		}
+	}
+	sort.Strings(files)
+
+	var findings []commenter.Finding
+	for _, file := range files {
+		added, err := c.AddedLinesContext(ctx, file)
+		if err != nil {
+			return nil, err
+		}
+		var current *commenter.Finding
+		for _, line := range added {
+			if !uncovered[file][line] || covered[file][line] {
+				current = nil
+				continue
+			}
+			if current != nil && current.EndLine == line-1 {
+				current.EndLine = line
+				continue
+			}
+			findings = append(findings, commenter.Finding{
+				Tool:      Tool,
+				RuleID:    RuleID,
+				Severity:  commenter.SeverityInfo,
+				Path:      file,
+				StartLine: line,
+				EndLine:   line,
+			})
+			current = &findings[len(findings)-1]
+		}
+	}
+	for i := range findings {
+		if findings[i].StartLine == findings[i].EndLine {
+			findings[i].Message = "This added line isn't covered by tests"
+		} else {
+			findings[i].Message = "These added lines aren't covered by tests"
+		}
+	}
+	return findings, nil
+}
+
+// Summary renders the findings of Uncovered as a comment with a table of them
+func Summary(findings []commenter.Finding) string {
+	if len(findings) == 0 {
+		return "Every added line is covered by tests."
+	}
+	var lines int
+	for _, finding := range findings {
+		lines += finding.EndLine - finding.StartLine + 1
+	}
+	noun := "lines aren't"
+	if lines == 1 {
+		noun = "line isn't"
+	}
+	return fmt.Sprintf("**%d added %s covered by tests**\n\n%s", lines, noun, commenter.RenderReport(findings))
+}
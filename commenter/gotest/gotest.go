@@ -0,0 +1,217 @@
+// Package gotest reports the tests failing in the output of go test -json on the lines they failed at
+package gotest
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+)
+
+// Tool names the findings of Findings, and StickyKey is the WriteStickyComment key of Write's summary
+const (
+	Tool      = "go-test"
+	StickyKey = "go-test"
+)
+
+var (
+	// messageRegex matches the file and line t.Error and friends prefix their output with
+	messageRegex = regexp.MustCompile(`^\s+([^\s:]+\.go):(\d+): `)
+	// traceRegex matches a test file's frame in the stack trace of a panic
+	traceRegex = regexp.MustCompile(`^\s+(\S+_test\.go):(\d+)`)
+	// noiseRegex matches the lines go test prints around the output of every test
+	noiseRegex = regexp.MustCompile(`^\s*(=== (RUN|PAUSE|CONT|NAME)|--- (FAIL|PASS|SKIP):)`)
+)
+
+// event is a line of go test -json output, as documented by go doc test2json
+type event struct {
+	Action  string
+	Package string
+	Test    string
+	Output  string
+	Elapsed float64
+}
+
+// Failure is a test, or a package when Test is "", which go test reported failing
+type Failure struct {
+	Package string
+	Test    string
+	// File and Line are where the test failed, File is relative to the package's directory unless
+	// it comes from a panic's stack trace. Line is 0 when the output doesn't tell
+	File    string
+	Line    int
+	Output  string
+	Elapsed float64
+}
+
+// Parse reads go test -json output and returns the failures in the order go test reported them. A
+// test failing because a subtest did is left out, the subtest is the failure
+func Parse(r io.Reader) ([]Failure, error) {
+	type key struct{ pkg, test string }
+	outputs := map[key]*strings.Builder{}
+	var failures []Failure
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var e event
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return nil, fmt.Errorf("go test output line %d: %w", n, err)
+		}
+		k := key{e.Package, e.Test}
+		switch e.Action {
+		case "output":
+			if outputs[k] == nil {
+				outputs[k] = &strings.Builder{}
+			}
+			outputs[k].WriteString(e.Output)
+		case "fail":
+			var output string
+			if b := outputs[k]; b != nil {
+				output = b.String()
+			}
+			failure := Failure{Package: e.Package, Test: e.Test, Output: cleanOutput(output), Elapsed: e.Elapsed}
+			failure.File, failure.Line = location(output)
+			failures = append(failures, failure)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read go test output: %w", err)
+	}
+	return leaves(failures), nil
+}
+
+// location finds where a test failed in its output, the first t.Error line or else the test file
+// frame of a panic
+func location(output string) (string, int) {
+	var file string
+	var line int
+	for _, text := range strings.Split(output, "\n") {
+		if groups := messageRegex.FindStringSubmatch(text); groups != nil {
+			line, _ = strconv.Atoi(groups[2])
+			return groups[1], line
+		}
+		if groups := traceRegex.FindStringSubmatch(text); groups != nil && file == "" {
+			file = groups[1]
+			line, _ = strconv.Atoi(groups[2])
+		}
+	}
+	return file, line
+}
+
+// cleanOutput drops the lines go test prints around the output of every test
+func cleanOutput(output string) string {
+	var lines []string
+	for _, text := range strings.Split(output, "\n") {
+		if strings.TrimSpace(text) == "" || noiseRegex.MatchString(text) {
+			continue
+		}
+		lines = append(lines, text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// leaves drops the failures of tests whose subtests failed, and of packages whose tests failed
+func leaves(failures []Failure) []Failure {
+	parents := map[string]bool{}
+	for _, failure := range failures {
+		if failure.Test == "" {
+			continue
+		}
+		parents[failure.Package+"\x00"] = true
+		for name := failure.Test; strings.Contains(name, "/"); {
+			name = name[:strings.LastIndex(name, "/")]
+			parents[failure.Package+"\x00"+name] = true
+		}
+	}
+	var kept []Failure
+	for _, failure := range failures {
+		if !parents[failure.Package+"\x00"+failure.Test] {
+			kept = append(kept, failure)
+		}
+	}
+	return kept
+}
+
+// Findings returns a finding on the line each test failed at, failures without a location are left
+// out and only appear in the Summary. modulePath is trimmed from the packages so the files are
+// relative to the module, stack trace files are absolute and need commenter.WithRepoRoot
+func Findings(failures []Failure, modulePath string) []commenter.Finding {
+	var findings []commenter.Finding
+	for _, failure := range failures {
+		if failure.Test == "" || failure.File == "" {
+			continue
+		}
+		findings = append(findings, commenter.Finding{
+			Tool:      Tool,
+			RuleID:    failure.Test,
+			Severity:  commenter.SeverityError,
+			Path:      failure.path(modulePath),
+			StartLine: failure.Line,
+			EndLine:   failure.Line,
+			Message:   "test failed\n\n```\n" + failure.Output + "\n```",
+		})
+	}
+	return findings
+}
+
+// path is the file the test failed in relative to the module, or absolute from a stack trace
+func (f Failure) path(modulePath string) string {
+	if strings.Contains(f.File, "/") {
+		return f.File
+	}
+	dir := strings.TrimPrefix(strings.TrimPrefix(f.Package, strings.TrimSuffix(modulePath, "/")), "/")
+	return path.Join(dir, f.File)
+}
+
+// Summary renders the failures as a comment listing them, with the output of each in a collapsible
+// section
+func Summary(failures []Failure) string {
+	var b strings.Builder
+	b.WriteString("### Test results\n\n")
+	if len(failures) == 0 {
+		b.WriteString("All tests passed.\n")
+		return b.String()
+	}
+	sorted := append([]Failure(nil), failures...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Package < sorted[j].Package })
+	noun := "failures"
+	if len(sorted) == 1 {
+		noun = "failure"
+	}
+	fmt.Fprintf(&b, "**%d %s**\n", len(sorted), noun)
+	for _, failure := range sorted {
+		name := failure.Test
+		if name == "" {
+			name = "package"
+		}
+		location := ""
+		if failure.File != "" {
+			location = fmt.Sprintf(" at `%s:%d`", failure.File, failure.Line)
+		}
+		fmt.Fprintf(&b, "\n<details><summary>❌ <code>%s</code> %s%s</summary>\n\n", failure.Package, name, location)
+		fmt.Fprintf(&b, "```\n%s\n```\n\n</details>\n", failure.Output)
+	}
+	return b.String()
+}
+
+// Write posts the Findings of the failures as review comments and keeps their Summary in a single
+// comment on the PR, edited on every run
+func Write(ctx context.Context, c *commenter.Commenter, failures []Failure, modulePath string) ([]commenter.Result, error) {
+	results, err := c.WriteFindingsContext(ctx, Findings(failures, modulePath))
+	if err != nil {
+		return results, err
+	}
+	return results, c.WriteStickyCommentContext(ctx, StickyKey, Summary(failures))
+}
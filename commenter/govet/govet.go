@@ -0,0 +1,112 @@
+// Package govet reads the diagnostics of go vet -json as findings
+package govet
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+)
+
+// Tool names the findings of Parse
+const Tool = "go vet"
+
+var posnRegex = regexp.MustCompile(`^(.*?):(\d+)(?::\d+)?$`)
+
+type diagnostic struct {
+	Posn    string `json:"posn"`
+	Message string `json:"message"`
+}
+
+// analyzerError is what go vet reports in place of the diagnostics of an analyzer which failed
+type analyzerError struct {
+	Error string `json:"error"`
+}
+
+// Parse reads the output of go vet -json, a JSON object of diagnostics by analyzer for each package
+// interleaved with "# package" lines, as findings of the analyzer. The files are as go vet reports
+// them, usually absolute, see commenter.WithRepoRoot
+func Parse(r io.Reader) ([]commenter.Finding, error) {
+	// go vet writes a "# package" line ahead of each package's object, drop them to decode the rest
+	// as a stream of objects
+	var b strings.Builder
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if !strings.HasPrefix(scanner.Text(), "#") {
+			b.WriteString(scanner.Text())
+			b.WriteByte('\n')
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read go vet output: %w", err)
+	}
+
+	var findings []commenter.Finding
+	dec := json.NewDecoder(strings.NewReader(b.String()))
+	for {
+		var packages map[string]map[string]json.RawMessage
+		err := dec.Decode(&packages)
+		if err == io.EOF {
+			return findings, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode go vet output: %w", err)
+		}
+		var names []string
+		for pkg := range packages {
+			names = append(names, pkg)
+		}
+		sort.Strings(names)
+		for _, pkg := range names {
+			for _, analyzer := range sortedKeys(packages[pkg]) {
+				raw := packages[pkg][analyzer]
+				var failed analyzerError
+				if json.Unmarshal(raw, &failed) == nil && failed.Error != "" {
+					return nil, fmt.Errorf("go vet analyzer %s failed on %s: %s", analyzer, pkg, failed.Error)
+				}
+				var diagnostics []diagnostic
+				if err := json.Unmarshal(raw, &diagnostics); err != nil {
+					return nil, fmt.Errorf("decode go vet %s diagnostics of %s: %w", analyzer, pkg, err)
+				}
+				for _, d := range diagnostics {
+					file, line := position(d.Posn)
+					findings = append(findings, commenter.Finding{
+						Tool:      Tool,
+						RuleID:    analyzer,
+						Severity:  commenter.SeverityWarning,
+						Path:      file,
+						StartLine: line,
+						EndLine:   line,
+						Message:   d.Message,
+					})
+				}
+			}
+		}
+	}
+}
+
+// position splits a "file:line:column" posn, a posn without a line is all file
+func position(posn string) (string, int) {
+	groups := posnRegex.FindStringSubmatch(posn)
+	if groups == nil {
+		return posn, 0
+	}
+	line, _ := strconv.Atoi(groups[2])
+	return groups[1], line
+}
+
+func sortedKeys(m map[string]json.RawMessage) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,89 @@
+package commenter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+)
+
+type graphqlRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// graphqlError is returned for a response with GraphQL errors, notFound is set when one of them
+// reports an object the query asked for doesn't exist
+type graphqlError struct {
+	message  string
+	notFound bool
+}
+
+func (e graphqlError) Error() string {
+	return e.message
+}
+
+const minimizeCommentMutation = `mutation($id: ID!, $classifier: ReportedContentClassifiers!) {
+  minimizeComment(input: {subjectId: $id, classifier: $classifier}) {
+    minimizedComment { isMinimized }
+  }
+}`
+
+// graphql posts the query to the GitHub GraphQL endpoint and decodes the response data into data
+// unless it is nil, GraphQL errors are returned as a go error
+func (c *connector) graphql(ctx context.Context, query string, variables map[string]interface{}, data interface{}) error {
+	var resp graphqlResponse
+	err := c.withRetry(ctx, graphqlOperation(query), func() (*github.Response, error) {
+		req, err := c.client.NewRequest("POST", c.graphqlURL(), &graphqlRequest{Query: query, Variables: variables})
+		if err != nil {
+			return nil, err
+		}
+		return c.client.Do(ctx, req, &resp)
+	})
+	if err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		var msgs []string
+		notFound := false
+		for _, e := range resp.Errors {
+			msgs = append(msgs, e.Message)
+			notFound = notFound || e.Type == "NOT_FOUND" || strings.HasPrefix(e.Message, "Could not resolve to a")
+		}
+		return graphqlError{message: strings.Join(msgs, "\n"), notFound: notFound}
+	}
+	if data != nil && len(resp.Data) > 0 {
+		return json.Unmarshal(resp.Data, data)
+	}
+	return nil
+}
+
+// graphqlURL resolves the GraphQL endpoint, GitHub Enterprise Server serves it from /api/graphql next to /api/v3
+func (c *connector) graphqlURL() string {
+	if strings.HasSuffix(c.client.BaseURL.Path, "/api/v3/") {
+		u := *c.client.BaseURL
+		u.Path = strings.TrimSuffix(u.Path, "v3/") + "graphql"
+		return u.String()
+	}
+	return "graphql"
+}
+
+func (c *connector) MinimizeComment(ctx context.Context, nodeID *string, classifier string) error {
+	if nodeID == nil {
+		return errors.New("the comment has no node id to minimize")
+	}
+	return c.graphql(ctx, minimizeCommentMutation, map[string]interface{}{
+		"id":         *nodeID,
+		"classifier": classifier,
+	}, nil)
+}
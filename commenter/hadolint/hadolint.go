@@ -0,0 +1,78 @@
+// Package hadolint reads the diagnostics of hadolint -f json as findings
+package hadolint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/shellcheck"
+)
+
+// Tool names the findings of Parse
+const Tool = "hadolint"
+
+type diagnostic struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Code    string `json:"code"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// Parse reads the output of hadolint -f json as findings with the rule linked to its documentation,
+// the ShellCheck rules hadolint runs on RUN instructions link to the ShellCheck wiki
+func Parse(r io.Reader) ([]commenter.Finding, error) {
+	var diagnostics []diagnostic
+	if err := json.NewDecoder(r).Decode(&diagnostics); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("decode hadolint output: %w", err)
+	}
+	var findings []commenter.Finding
+	for _, d := range diagnostics {
+		message := d.Message
+		if url := DocsURL(d.Code); url != "" {
+			message = fmt.Sprintf("%s ([%s](%s))", message, d.Code, url)
+		}
+		findings = append(findings, commenter.Finding{
+			Tool:      Tool,
+			RuleID:    d.Code,
+			Severity:  severity(d.Level),
+			Path:      d.File,
+			StartLine: d.Line,
+			EndLine:   d.Line,
+			Message:   message,
+		})
+	}
+	return findings, nil
+}
+
+// DocsURL returns the documentation of the rule with code, such as DL3008 or SC2086, "" for codes
+// of neither
+func DocsURL(code string) string {
+	switch {
+	case strings.HasPrefix(code, "DL"):
+		return "https://github.com/hadolint/hadolint/wiki/" + code
+	case strings.HasPrefix(code, "SC"):
+		if n, err := strconv.Atoi(code[2:]); err == nil {
+			return shellcheck.WikiURL(n)
+		}
+	}
+	return ""
+}
+
+func severity(level string) commenter.Severity {
+	switch level {
+	case "error":
+		return commenter.SeverityError
+	case "warning":
+		return commenter.SeverityWarning
+	case "info", "style":
+		return commenter.SeverityInfo
+	default:
+		return commenter.SeverityUnknown
+	}
+}
@@ -0,0 +1,59 @@
+package commenter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var idempotencyMarkerRegex = regexp.MustCompile(`<!-- pr-commenter:idempotency ([0-9a-f]+) -->`)
+
+// WithIdempotencyKey marks the comments WriteComments and WriteFindings write with key, a run ID which
+// stays the same when a CI job is retried. A retried run then leaves the comments the failed attempt
+// already wrote as ResultUnchanged and only writes the missing ones, instead of duplicating them all
+func WithIdempotencyKey(key string) Option {
+	return func(o *options) {
+		o.idempotencyKey = key
+	}
+}
+
+// IdempotencyKeyFromEnv returns a WithIdempotencyKey key for the current GitHub Actions job, shared
+// by all attempts of a job and different for every workflow run. It is "" outside GitHub Actions
+func IdempotencyKeyFromEnv() string {
+	runID := os.Getenv("GITHUB_RUN_ID")
+	if runID == "" {
+		return ""
+	}
+	return strings.Join([]string{os.Getenv("GITHUB_REPOSITORY"), runID, os.Getenv("GITHUB_JOB")}, "/")
+}
+
+// idempotency hands out the markers of a batch's comments
+type idempotency struct {
+	key      string
+	existing map[string]*Comment
+}
+
+// newIdempotency indexes the commenter's comments on the PR by their marker, nil without WithIdempotencyKey
+func (c *Commenter) newIdempotency() *idempotency {
+	if c.opts.idempotencyKey == "" {
+		return nil
+	}
+	existing := map[string]*Comment{}
+	for _, comment := range c.snapshotExistingComments() {
+		if groups := idempotencyMarkerRegex.FindStringSubmatch(comment.Body); groups != nil {
+			existing[groups[1]] = comment
+		}
+	}
+	return &idempotency{key: c.opts.idempotencyKey, existing: existing}
+}
+
+// marker returns the marker of the comment with the commentKeys key and the comment an earlier attempt
+// wrote with it, if any
+func (m *idempotency) marker(key string) (string, *Comment) {
+	sum := sha256.Sum256([]byte(m.key + "\x00" + key))
+	id := hex.EncodeToString(sum[:16])
+	return fmt.Sprintf("<!-- pr-commenter:idempotency %s -->", id), m.existing[id]
+}
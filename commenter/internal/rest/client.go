@@ -0,0 +1,115 @@
+// Package rest is the JSON over HTTP client shared by the non-GitHub providers
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+)
+
+// Client calls a forge's REST API below BaseURL, adding Header to every request
+type Client struct {
+	Forge   string
+	BaseURL string
+	Header  http.Header
+	HTTP    *http.Client
+}
+
+// Error returned when the forge responds with a non 2xx status
+type Error struct {
+	Forge      string
+	Method     string
+	URL        string
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s API call failed with status [%d]: %s (%s %s)", e.Forge, e.StatusCode, e.Message, e.Method, e.URL)
+}
+
+// Is matches commenter.ErrForbidden for responses with a 403 status
+func (e *Error) Is(target error) bool {
+	return target == commenter.ErrForbidden && e.StatusCode == http.StatusForbidden
+}
+
+// Do sends in as the JSON body of the request and decodes the response into out, either may be nil
+func (c *Client) Do(ctx context.Context, method, path string, query url.Values, in, out interface{}) (*http.Response, error) {
+	resp, err := c.send(ctx, method, path, query, in)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil && err != io.EOF {
+			return resp, fmt.Errorf("decode %s %s response: %w", method, path, err)
+		}
+	}
+	return resp, nil
+}
+
+// Raw returns the response body of a GET request as is, for endpoints serving diffs
+func (c *Client) Raw(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	resp, err := c.send(ctx, http.MethodGet, path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, in interface{}) (*http.Response, error) {
+	u := strings.TrimSuffix(c.BaseURL, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := c.HTTP
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, &Error{
+			Forge:      c.Forge,
+			Method:     method,
+			URL:        req.URL.Path,
+			StatusCode: resp.StatusCode,
+			Message:    strings.TrimSpace(string(msg)),
+		}
+	}
+	return resp, nil
+}
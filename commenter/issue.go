@@ -0,0 +1,188 @@
+package commenter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// NewIssueCommenter creates a Commenter for an issue rather than a PR, for bots reporting results on
+// a tracking issue. Only general comments can be written, an issue has no files to comment on
+func NewIssueCommenter(token, owner, repo string, issueNumber int, opts ...Option) (*Commenter, error) {
+	return NewIssueCommenterContext(context.Background(), token, owner, repo, issueNumber, opts...)
+}
+
+// NewIssueCommenterContext is NewIssueCommenter using ctx for checking the issue exists
+func NewIssueCommenterContext(ctx context.Context, token, owner, repo string, issueNumber int, opts ...Option) (*Commenter, error) {
+	o := newOptions(opts)
+	if len(token) == 0 && o.tokenSource == nil && o.client == nil {
+		return nil, errors.New("the GITHUB_TOKEN has not been set")
+	}
+	client := o.client
+	if client == nil {
+		var err error
+		if client, err = newGithubClient(token, o); err != nil {
+			return nil, err
+		}
+	}
+	gh := &connector{client: client, comments: client.Issues, owner: owner, repo: repo, prNumber: issueNumber, opts: o, rate: &rateTracker{}}
+
+	err := gh.withRetry(ctx, "Issues.Get", func() (*github.Response, error) {
+		_, resp, err := gh.comments.Get(ctx, owner, repo, issueNumber)
+		return resp, err
+	})
+	if err != nil {
+		var apiErr APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, newIssueDoesNotExistError(owner, repo, issueNumber)
+		}
+		return nil, err
+	}
+	return &Commenter{
+		provider: &issueProvider{gh: gh},
+		opts:     o,
+	}, nil
+}
+
+// WriteGeneralComment writes body as a comment on the PR or issue itself rather than on a file, a body
+// too long for one comment is split into several or overflows to a Gist with WithGistOverflow. Like
+// review comments, a comment the commenter already wrote with the same body isn't written again
+func (c *Commenter) WriteGeneralComment(body string) error {
+	return c.WriteGeneralCommentContext(context.Background(), body)
+}
+
+// WriteGeneralCommentContext is WriteGeneralComment using ctx for the API calls
+func (c *Commenter) WriteGeneralCommentContext(ctx context.Context, body string) error {
+	existing, err := c.existingSummaries(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = c.writeSummaryChunks(ctx, body, existing)
+	return err
+}
+
+// WriteGeneralCommentOnce writes body as WriteGeneralComment does unless the commenter already wrote
+// a comment with key on the PR or issue, for messages such as a welcome note which are posted once
+// rather than kept up to date. It reports whether the comment was written
+func (c *Commenter) WriteGeneralCommentOnce(key, body string) (bool, error) {
+	return c.WriteGeneralCommentOnceContext(context.Background(), key, body)
+}
+
+// WriteGeneralCommentOnceContext is WriteGeneralCommentOnce using ctx for the API calls
+func (c *Commenter) WriteGeneralCommentOnceContext(ctx context.Context, key, body string) (bool, error) {
+	lister, ok := c.provider.(summaryLister)
+	if !ok {
+		return false, fmt.Errorf("write general comment once: %w", ErrNotSupported)
+	}
+	comments, err := lister.listSummaryComments(ctx)
+	if err != nil {
+		return false, err
+	}
+	marker := onceMarker(key)
+	for _, comment := range comments {
+		if comment.Author == c.commenterName() && strings.Contains(comment.Body, marker) {
+			c.logger().Info("skipping general comment already written", "key", key, "comment_id", comment.ID)
+			return false, nil
+		}
+	}
+	if _, err := c.writeSummary(ctx, body+"\n\n"+marker); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// WriteStickyComment keeps a single general comment with key on the PR or issue: the commenter's
+// comment with key is edited to body when there is one, otherwise body is written as a new comment.
+// Reports such as benchmark results are kept up to date this way rather than posted on every push
+func (c *Commenter) WriteStickyComment(key, body string) error {
+	return c.WriteStickyCommentContext(context.Background(), key, body)
+}
+
+// WriteStickyCommentContext is WriteStickyComment using ctx for the API calls
+func (c *Commenter) WriteStickyCommentContext(ctx context.Context, key, body string) error {
+	lister, ok := c.provider.(summaryLister)
+	if !ok {
+		return fmt.Errorf("write sticky comment: %w", ErrNotSupported)
+	}
+	comments, err := lister.listSummaryComments(ctx)
+	if err != nil {
+		return err
+	}
+	marker := stickyMarker(key)
+	body += "\n\n" + marker
+	for _, comment := range comments {
+		if comment.Author != c.commenterName() || !strings.Contains(comment.Body, marker) {
+			continue
+		}
+		if comment.Body == body {
+			c.logger().Info("skipping unchanged sticky comment", "key", key, "comment_id", comment.ID)
+			return nil
+		}
+		return c.provider.UpdateComment(ctx, comment, body)
+	}
+	_, err = c.writeSummary(ctx, body)
+	return err
+}
+
+// onceMarker is the hidden marker of the WriteGeneralCommentOnce comment with key
+func onceMarker(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return fmt.Sprintf("<!-- pr-commenter:once %s -->", hex.EncodeToString(sum[:16]))
+}
+
+// stickyMarker is the hidden marker of the WriteStickyComment comment with key
+func stickyMarker(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return fmt.Sprintf("<!-- pr-commenter:sticky %s -->", hex.EncodeToString(sum[:16]))
+}
+
+// issueProvider implements Provider for an issue, which has no files and so no inline comments
+type issueProvider struct {
+	gh *connector
+}
+
+var _ Provider = (*issueProvider)(nil)
+
+func (p *issueProvider) githubClient() *github.Client {
+	return p.gh.client
+}
+
+// ListChangedFiles implements Provider, an issue changes no files
+func (p *issueProvider) ListChangedFiles(context.Context) ([]*ChangedFile, error) {
+	return nil, nil
+}
+
+// ListComments implements Provider, an issue has no inline comments
+func (p *issueProvider) ListComments(context.Context) ([]*Comment, error) {
+	return nil, nil
+}
+
+// CreateInlineComment implements Provider, an issue has no lines to comment on
+func (p *issueProvider) CreateInlineComment(_ context.Context, comment InlineComment) (*Comment, error) {
+	return nil, fmt.Errorf("comment on %s line %d: %w", comment.Path, comment.EndLine, ErrNotSupported)
+}
+
+// CreateSummaryComment implements Provider
+func (p *issueProvider) CreateSummaryComment(ctx context.Context, body string) (*Comment, error) {
+	return p.gh.CreateSummaryComment(ctx, body)
+}
+
+func (p *issueProvider) listSummaryComments(ctx context.Context) ([]*Comment, error) {
+	return p.gh.listSummaryComments(ctx)
+}
+
+// UpdateComment implements Provider
+func (p *issueProvider) UpdateComment(ctx context.Context, comment *Comment, body string) error {
+	return p.gh.UpdateComment(ctx, comment, body)
+}
+
+// DeleteComment implements Provider
+func (p *issueProvider) DeleteComment(ctx context.Context, comment *Comment) error {
+	return p.gh.DeleteComment(ctx, comment)
+}
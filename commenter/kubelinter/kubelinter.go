@@ -0,0 +1,114 @@
+// Package kubelinter reads the reports of kube-linter lint --format json as findings
+package kubelinter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+)
+
+// Tool names the findings of Parse
+const Tool = "kube-linter"
+
+// Source returns the contents of a manifest kube-linter checked, ioutil.ReadFile reads them from disk
+type Source func(file string) ([]byte, error)
+
+var (
+	documentRegex = regexp.MustCompile(`(?m)^---`)
+	kindRegex     = regexp.MustCompile(`^kind:\s*(\S+)\s*$`)
+	nameRegex     = regexp.MustCompile(`^\s+name:\s*["']?([^"'\s]+)["']?\s*$`)
+)
+
+type lintReport struct {
+	Diagnostic struct {
+		Message string `json:"Message"`
+	} `json:"Diagnostic"`
+	Check       string `json:"Check"`
+	Remediation string `json:"Remediation"`
+	Object      struct {
+		Metadata struct {
+			FilePath string `json:"FilePath"`
+		} `json:"Metadata"`
+		K8sObject struct {
+			GroupVersionKind struct {
+				Kind string `json:"Kind"`
+			} `json:"GroupVersionKind"`
+			Namespace string `json:"Namespace"`
+			Name      string `json:"Name"`
+		} `json:"K8sObject"`
+	} `json:"Object"`
+}
+
+// Parse reads the output of kube-linter lint --format json as SeverityError findings of the check
+// linked to its documentation. kube-linter reports objects rather than lines, the findings are on
+// the line of the object's kind in the manifest read with source, or on line 1 when it can't be found
+func Parse(r io.Reader, source Source) ([]commenter.Finding, error) {
+	var output struct {
+		Reports []lintReport `json:"Reports"`
+	}
+	if err := json.NewDecoder(r).Decode(&output); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("decode kube-linter output: %w", err)
+	}
+	sources := map[string]string{}
+	var findings []commenter.Finding
+	for _, rep := range output.Reports {
+		file, object := rep.Object.Metadata.FilePath, rep.Object.K8sObject
+		contents, ok := sources[file]
+		if !ok && source != nil {
+			if data, err := source(file); err == nil {
+				contents = string(data)
+			}
+			sources[file] = contents
+		}
+		line := objectLine(contents, object.GroupVersionKind.Kind, object.Name)
+
+		message := rep.Diagnostic.Message
+		if rep.Remediation != "" {
+			message += ". " + rep.Remediation
+		}
+		message += fmt.Sprintf(" ([%s](%s))", rep.Check, DocsURL(rep.Check))
+		findings = append(findings, commenter.Finding{
+			Tool:      Tool,
+			RuleID:    rep.Check,
+			Severity:  commenter.SeverityError,
+			Path:      file,
+			StartLine: line,
+			EndLine:   line,
+			Message:   message,
+		})
+	}
+	return findings, nil
+}
+
+// DocsURL returns the documentation of the kube-linter check
+func DocsURL(check string) string {
+	return "https://docs.kubelinter.io/#/generated/checks?id=" + check
+}
+
+// objectLine finds the line of the kind of the object with name among the documents of contents,
+// 1 when there is none
+func objectLine(contents, kind, name string) int {
+	base := 1
+	for _, document := range documentRegex.Split(contents, -1) {
+		lines := strings.Split(document, "\n")
+		kindLine, named := 0, false
+		for i, text := range lines {
+			if groups := kindRegex.FindStringSubmatch(text); groups != nil && groups[1] == kind {
+				kindLine = base + i
+			}
+			if groups := nameRegex.FindStringSubmatch(text); groups != nil && groups[1] == name {
+				named = true
+			}
+		}
+		if kindLine > 0 && named {
+			return kindLine
+		}
+		// the last line of a document is the "---" line the next one starts on
+		base += len(lines) - 1
+	}
+	return 1
+}
@@ -0,0 +1,66 @@
+package commenter
+
+import (
+	"github.com/google/go-github/v38/github"
+)
+
+// WithLegacyPositions writes inline comments against their position in the file's patch instead of
+// their line and side, for older GitHub Enterprise Server versions which reject line based comments.
+// As with commit comments a comment can't span lines, so it is placed at its end line, and existing
+// comments are read back at the line of their position
+func WithLegacyPositions() Option {
+	return func(o *options) {
+		o.legacyPositions = true
+	}
+}
+
+// keepPatches remembers the patches of files for WithLegacyPositions
+func (c *connector) keepPatches(files []*ChangedFile) {
+	patches := make(map[string]string, len(files))
+	for _, file := range files {
+		patches[file.Filename] = file.Patch
+	}
+	c.patchMu.Lock()
+	defer c.patchMu.Unlock()
+	c.patches = patches
+}
+
+func (c *connector) patch(path string) string {
+	c.patchMu.Lock()
+	defer c.patchMu.Unlock()
+	return c.patches[path]
+}
+
+// toPosition replaces the line and side of comment with its diff position
+func (c *connector) toPosition(comment *github.PullRequestComment) error {
+	position, ok := diffPosition(c.patch(comment.GetPath()), comment.GetLine())
+	if !ok {
+		return newCommentNotValidError(comment.GetPath(), comment.GetLine())
+	}
+	comment.Position = &position
+	comment.Line, comment.Side, comment.StartLine, comment.StartSide = nil, nil, nil, nil
+	return nil
+}
+
+// draftToPosition is toPosition for a comment of a review
+func (c *connector) draftToPosition(draft *github.DraftReviewComment) error {
+	position, ok := diffPosition(c.patch(draft.GetPath()), draft.GetLine())
+	if !ok {
+		return newCommentNotValidError(draft.GetPath(), draft.GetLine())
+	}
+	draft.Position = &position
+	draft.Line, draft.Side, draft.StartLine, draft.StartSide = nil, nil, nil, nil
+	return nil
+}
+
+// lineOf returns the line of an existing comment, from its position with WithLegacyPositions. ok is
+// false when the comment is no longer on the diff
+func (c *connector) lineOf(comment *github.PullRequestComment) (line int, ok bool) {
+	if !c.opts.legacyPositions || comment.Line != nil {
+		return comment.GetLine(), comment.Position != nil && comment.Line != nil
+	}
+	if comment.Position == nil {
+		return 0, false
+	}
+	return linePosition(c.patch(comment.GetPath()), comment.GetPosition())
+}
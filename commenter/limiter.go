@@ -0,0 +1,13 @@
+package commenter
+
+import (
+	"golang.org/x/time/rate"
+)
+
+// WithRateLimiter throttles every GitHub API call through limiter, e.g. rate.NewLimiter(1, 1)
+// for one call per second, to avoid triggering secondary rate limits when posting many comments
+func WithRateLimiter(limiter *rate.Limiter) Option {
+	return func(o *options) {
+		o.limiter = limiter
+	}
+}
@@ -0,0 +1,121 @@
+package commenter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Message identifies one of the texts the commenter writes to PRs itself, such as summary headings
+type Message string
+
+const (
+	// MessageApprove is the body of approving reviews
+	MessageApprove Message = "approve"
+	// MessageRequestChanges is the body of reviews requesting changes
+	MessageRequestChanges Message = "request_changes"
+	// MessageDismissal is the reason given for dismissing reviews after a clean run
+	MessageDismissal Message = "dismissal"
+	// MessageOutsideDiff heads the summary of comments outside the diff
+	MessageOutsideDiff Message = "outside_diff"
+	// MessageNotInDiff notes the line a comment moved by WithNearestLine was reported on, a %d
+	MessageNotInDiff Message = "not_in_diff"
+	// MessageLowSeverity heads the summary of findings below WithMinSeverity, taking their count
+	MessageLowSeverity Message = "low_severity"
+	// MessageReportedBy lists the tools which reported a merged finding, a %s
+	MessageReportedBy Message = "reported_by"
+	// MessageContinued heads the later comments of a split summary, taking the part and the part count
+	MessageContinued Message = "continued"
+	// MessageTruncated ends a summary cut at WithMaxSummaryComments
+	MessageTruncated Message = "truncated"
+	// MessageGistOverflow links the WithGistOverflow gist of a summary, a %s
+	MessageGistOverflow Message = "gist_overflow"
+	// MessageGistDescription is the description of WithGistOverflow gists
+	MessageGistDescription Message = "gist_description"
+	// MessageFixed replies to the comments of fixed findings, taking the %s commit they were fixed in
+	MessageFixed Message = "fixed"
+	// MessageMention is the WithMentionAuthor mention, taking the %s login
+	MessageMention Message = "mention"
+	// MessageFindingCount is the default summary of a check run, taking the finding count
+	MessageFindingCount Message = "finding_count"
+	// MessageReportHeader is the header row of the RenderReport table, with its four columns
+	MessageReportHeader Message = "report_header"
+	// MessageMoreInFile heads the comment listing the findings over WithMaxCommentsPerFile, taking their count
+	MessageMoreInFile Message = "more_in_file"
+	// MessageRejectedComments is the body given to the WithPermissionFallback sink for rejected comments
+	MessageRejectedComments Message = "rejected_comments"
+)
+
+// Locale is a catalog of Messages in one language as fmt formats, taking the same arguments in the
+// same order as the English ones. Messages missing from it are written in English
+type Locale map[Message]string
+
+// LocaleEnglish is the catalog used by default
+var LocaleEnglish = Locale{
+	MessageApprove:          ApproveBody,
+	MessageRequestChanges:   RequestChangesBody,
+	MessageDismissal:        "Dismissed as the latest run has no findings",
+	MessageOutsideDiff:      "Findings outside the diff:",
+	MessageNotInDiff:        "_Reported on line %d, which is not part of the diff._",
+	MessageLowSeverity:      "%d lower severity findings were not commented inline:",
+	MessageReportedBy:       "Reported by %s",
+	MessageContinued:        "_(continued %d/%d)_",
+	MessageTruncated:        "_The report was truncated._",
+	MessageGistOverflow:     "_The report is too large for a comment, see the [full report](%s)._",
+	MessageGistDescription:  "Full report",
+	MessageFixed:            "✅ fixed in %s",
+	MessageMention:          "cc @%s",
+	MessageFindingCount:     "%d findings",
+	MessageReportHeader:     "| Severity | Location | Rule | Message |",
+	MessageMoreInFile:       "%d more findings in this file:",
+	MessageRejectedComments: "Comments rejected by the PR",
+}
+
+// LocaleJapanese is a Japanese catalog
+var LocaleJapanese = Locale{
+	MessageApprove:          "承認します:tada:",
+	MessageRequestChanges:   "修正をお願いします:rotating_light:",
+	MessageDismissal:        "最新の実行で指摘がなくなったため取り下げます",
+	MessageOutsideDiff:      "差分の外の指摘:",
+	MessageNotInDiff:        "_%d 行目の指摘ですが、この行は差分に含まれていません。_",
+	MessageLowSeverity:      "重要度の低い %d 件の指摘はインラインでコメントしていません:",
+	MessageReportedBy:       "%s による指摘",
+	MessageContinued:        "_(続き %d/%d)_",
+	MessageTruncated:        "_レポートは途中で省略されています。_",
+	MessageGistOverflow:     "_レポートがコメントに収まらないため、[レポート全文](%s)を参照してください。_",
+	MessageGistDescription:  "レポート全文",
+	MessageFixed:            "✅ %s で修正済み",
+	MessageMention:          "cc @%s",
+	MessageFindingCount:     "%d 件の指摘",
+	MessageReportHeader:     "| 重要度 | 場所 | ルール | メッセージ |",
+	MessageMoreInFile:       "このファイルの他の %d 件の指摘:",
+	MessageRejectedComments: "PR に書き込めなかったコメント",
+}
+
+// WithLocale writes the commenter's own texts, such as summary headings and review bodies, from
+// locale instead of in English. Comments and findings are written as given
+func WithLocale(locale Locale) Option {
+	return func(o *options) {
+		o.locale = locale
+	}
+}
+
+// text formats message from the WithLocale catalog with args
+func (c *Commenter) text(message Message, args ...interface{}) string {
+	format, ok := c.opts.locale[message]
+	if !ok {
+		format = LocaleEnglish[message]
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// textPrefix returns the part of message before its first argument, which doesn't change between uses
+func (c *Commenter) textPrefix(message Message) string {
+	format := c.text(message)
+	if i := strings.Index(format, "%"); i >= 0 {
+		return format[:i]
+	}
+	return format
+}
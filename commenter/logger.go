@@ -0,0 +1,23 @@
+package commenter
+
+// Logger receives the commenter's structured logs, args are alternating keys and values.
+// *slog.Logger satisfies it
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+}
+
+// WithLogger sends debug logs for every API call and info logs for retries, skipped comments and deletions to logger
+func WithLogger(logger Logger) Option {
+	return func(o *options) {
+		if logger != nil {
+			o.logger = logger
+		}
+	}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+
+func (nopLogger) Info(string, ...interface{}) {}
@@ -0,0 +1,95 @@
+package commenter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// Manager hands out Commenters for many PRs which share one client, rate limit budget, rate limiter
+// and caches, for bots processing webhook events rather than a single PR per process. It is safe for
+// concurrent use by multiple goroutines
+type Manager struct {
+	token string
+	opts  []Option
+	rate  *rateTracker
+
+	mu         sync.Mutex
+	commenters map[string]*Commenter
+}
+
+// NewManager creates a Manager whose commenters are configured with opts. Unless opts set one, every
+// commenter shares an in-memory ETag cache, pass WithSnapshotCache to also share their view of a PR
+func NewManager(token string, opts ...Option) (*Manager, error) {
+	o := newOptions(opts)
+	if len(token) == 0 && o.tokenSource == nil && o.client == nil && o.pullRequests == nil {
+		return nil, errors.New("the GITHUB_TOKEN has not been set")
+	}
+
+	var shared []Option
+	if o.etagCache == nil && o.client == nil {
+		o.etagCache = NewMemoryETagCache()
+		shared = append(shared, WithETagCache(o.etagCache))
+	}
+	if o.client == nil {
+		client, err := newGithubClient(token, o)
+		if err != nil {
+			return nil, err
+		}
+		shared = append(shared, WithClient(client))
+	}
+
+	m := &Manager{
+		token:      token,
+		rate:       &rateTracker{},
+		commenters: map[string]*Commenter{},
+	}
+	m.opts = append(append(append([]Option(nil), opts...), shared...), func(o *options) {
+		o.rate = m.rate
+	})
+	return m, nil
+}
+
+// Commenter returns the commenter for PR prNumber of owner/repo, creating it on first use. Call Refresh
+// on it when an event says the PR changed since it was loaded
+func (m *Manager) Commenter(ctx context.Context, owner, repo string, prNumber int) (*Commenter, error) {
+	key := managerKey(owner, repo, prNumber)
+	m.mu.Lock()
+	c, ok := m.commenters[key]
+	m.mu.Unlock()
+	if ok {
+		return c, nil
+	}
+
+	c, err := NewCommenterContext(ctx, m.token, owner, repo, prNumber, m.opts...)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// another goroutine may have created it meanwhile, keep the first one
+	if existing, ok := m.commenters[key]; ok {
+		return existing, nil
+	}
+	m.commenters[key] = c
+	return c, nil
+}
+
+// Forget drops the commenter of a PR, e.g. once it is closed, the next Commenter call creates a new one
+func (m *Manager) Forget(owner, repo string, prNumber int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.commenters, managerKey(owner, repo, prNumber))
+}
+
+// RateLimit returns the primary rate limit as last reported to any of the commenters
+func (m *Manager) RateLimit() github.Rate {
+	return m.rate.last()
+}
+
+func managerKey(owner, repo string, prNumber int) string {
+	return fmt.Sprintf("%s/%s#%d", owner, repo, prNumber)
+}
@@ -0,0 +1,106 @@
+// Package markdownlint reads the results of markdownlint and markdownlint-cli2 JSON output as findings
+package markdownlint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+)
+
+// Tool names the findings of Parse
+const Tool = "markdownlint"
+
+type result struct {
+	FileName        string   `json:"fileName"`
+	LineNumber      int      `json:"lineNumber"`
+	RuleNames       []string `json:"ruleNames"`
+	RuleDescription string   `json:"ruleDescription"`
+	RuleInformation string   `json:"ruleInformation"`
+	ErrorDetail     string   `json:"errorDetail"`
+	ErrorContext    string   `json:"errorContext"`
+	// Severity is only written by markdownlint-cli2, markdownlint reports everything the same
+	Severity string `json:"severity"`
+}
+
+// Parse reads the results markdownlint --json and markdownlint-cli2's JSON formatter write, an array
+// of them, or the results of the markdownlint library keyed by file, as findings of the rule linked
+// to its documentation
+func Parse(r io.Reader) ([]commenter.Finding, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read markdownlint output: %w", err)
+	}
+	var results []result
+	switch data = bytes.TrimSpace(data); {
+	case len(data) == 0:
+	case data[0] == '{':
+		var byFile map[string][]result
+		if err := json.Unmarshal(data, &byFile); err != nil {
+			return nil, fmt.Errorf("decode markdownlint output: %w", err)
+		}
+		var files []string
+		for file := range byFile {
+			files = append(files, file)
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			for _, res := range byFile[file] {
+				res.FileName = file
+				results = append(results, res)
+			}
+		}
+	default:
+		if err := json.Unmarshal(data, &results); err != nil {
+			return nil, fmt.Errorf("decode markdownlint output: %w", err)
+		}
+	}
+
+	var findings []commenter.Finding
+	for _, res := range results {
+		var rule string
+		if len(res.RuleNames) > 0 {
+			rule = res.RuleNames[0]
+		}
+		findings = append(findings, commenter.Finding{
+			Tool:      Tool,
+			RuleID:    rule,
+			Severity:  severity(res.Severity),
+			Path:      res.FileName,
+			StartLine: res.LineNumber,
+			EndLine:   res.LineNumber,
+			Message:   message(res),
+		})
+	}
+	return findings, nil
+}
+
+// message describes the result as markdownlint prints it, with the rule's aliases and documentation
+func message(res result) string {
+	var b strings.Builder
+	b.WriteString(res.RuleDescription)
+	if res.ErrorDetail != "" {
+		fmt.Fprintf(&b, " [%s]", res.ErrorDetail)
+	}
+	if res.ErrorContext != "" {
+		fmt.Fprintf(&b, " [Context: %q]", res.ErrorContext)
+	}
+	if len(res.RuleNames) > 1 && res.RuleInformation != "" {
+		fmt.Fprintf(&b, " ([%s](%s))", strings.Join(res.RuleNames[1:], "/"), res.RuleInformation)
+	} else if res.RuleInformation != "" {
+		fmt.Fprintf(&b, " ([docs](%s))", res.RuleInformation)
+	}
+	return b.String()
+}
+
+func severity(s string) commenter.Severity {
+	if s == "error" {
+		return commenter.SeverityError
+	}
+	return commenter.SeverityWarning
+}
@@ -0,0 +1,65 @@
+package commenter
+
+import (
+	"net/http"
+	"time"
+)
+
+// Names of the counters and durations reported to Metrics
+const (
+	MetricCommentsCreated = "comments_created"
+	MetricCommentsUpdated = "comments_updated"
+	MetricCommentsSkipped = "comments_skipped"
+	MetricCommentsDeleted = "comments_deleted"
+	MetricAPICalls        = "api_calls"
+	MetricRetries         = "retries"
+	MetricRateLimitWaits  = "rate_limit_waits"
+
+	MetricAPICallDuration   = "api_call_duration"
+	MetricRateLimitWaitTime = "rate_limit_wait_duration"
+)
+
+// Metrics receives the commenter's counters and latency observations, e.g. to expose them to Prometheus
+type Metrics interface {
+	// Add increments the named counter by delta
+	Add(name string, delta int)
+	// Observe records a duration in the named histogram
+	Observe(name string, d time.Duration)
+}
+
+// WithMetrics reports counters for comments and API calls, retries and rate limit waits to metrics
+func WithMetrics(metrics Metrics) Option {
+	return func(o *options) {
+		if metrics != nil {
+			o.metrics = metrics
+		}
+	}
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) Add(string, int) {}
+
+func (nopMetrics) Observe(string, time.Duration) {}
+
+// instrumentedTransport logs and measures every GitHub API call
+type instrumentedTransport struct {
+	logger  Logger
+	metrics Metrics
+	base    http.RoundTripper
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	duration := time.Since(start)
+	t.metrics.Add(MetricAPICalls, 1)
+	t.metrics.Observe(MetricAPICallDuration, duration)
+	if err != nil {
+		t.logger.Debug("github api call failed", "method", req.Method, "path", req.URL.Path, "duration", duration, "error", err)
+		return nil, err
+	}
+	t.logger.Debug("github api call", "method", req.Method, "path", req.URL.Path, "status", resp.StatusCode,
+		"duration", duration, "request_id", resp.Header.Get("X-GitHub-Request-Id"))
+	return resp, nil
+}
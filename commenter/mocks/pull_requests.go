@@ -0,0 +1,155 @@
+// Package mocks provides test doubles for the GitHub API interfaces used by the commenter
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// PullRequestsAPI implements commenter.PullRequestsAPI by delegating to the XxxFunc fields and records
+// every call. Calling a method whose func is nil returns zero values, so only the calls under test need stubbing
+type PullRequestsAPI struct {
+	GetFunc                 func(ctx context.Context, owner string, repo string, number int) (*github.PullRequest, *github.Response, error)
+	ListFunc                func(ctx context.Context, owner string, repo string, opts *github.PullRequestListOptions) ([]*github.PullRequest, *github.Response, error)
+	ListWithCommitFunc      func(ctx context.Context, owner, repo, sha string, opts *github.PullRequestListOptions) ([]*github.PullRequest, *github.Response, error)
+	ListFilesFunc           func(ctx context.Context, owner string, repo string, number int, opts *github.ListOptions) ([]*github.CommitFile, *github.Response, error)
+	ListCommentsFunc        func(ctx context.Context, owner string, repo string, number int, opts *github.PullRequestListCommentsOptions) ([]*github.PullRequestComment, *github.Response, error)
+	ListReviewsFunc         func(ctx context.Context, owner, repo string, number int, opts *github.ListOptions) ([]*github.PullRequestReview, *github.Response, error)
+	CreateReviewFunc        func(ctx context.Context, owner string, repo string, number int, review *github.PullRequestReviewRequest) (*github.PullRequestReview, *github.Response, error)
+	DismissReviewFunc       func(ctx context.Context, owner, repo string, number int, reviewID int64, review *github.PullRequestReviewDismissalRequest) (*github.PullRequestReview, *github.Response, error)
+	DeletePendingReviewFunc func(ctx context.Context, owner, repo string, number int, reviewID int64) (*github.PullRequestReview, *github.Response, error)
+	CreateCommentFunc       func(ctx context.Context, owner string, repo string, number int, comment *github.PullRequestComment) (*github.PullRequestComment, *github.Response, error)
+	EditCommentFunc         func(ctx context.Context, owner string, repo string, commentID int64, comment *github.PullRequestComment) (*github.PullRequestComment, *github.Response, error)
+	DeleteCommentFunc       func(ctx context.Context, owner string, repo string, commentID int64) (*github.Response, error)
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+// Call is a recorded method call, Args holds the arguments after the context
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// Calls returns the calls made so far in order
+func (m *PullRequestsAPI) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// CallsTo returns the calls made to method so far in order
+func (m *PullRequestsAPI) CallsTo(method string) []Call {
+	var calls []Call
+	for _, call := range m.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func (m *PullRequestsAPI) record(method string, args ...interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Method: method, Args: args})
+}
+
+func (m *PullRequestsAPI) Get(ctx context.Context, owner string, repo string, number int) (*github.PullRequest, *github.Response, error) {
+	m.record("Get", owner, repo, number)
+	if m.GetFunc == nil {
+		return &github.PullRequest{Number: &number}, nil, nil
+	}
+	return m.GetFunc(ctx, owner, repo, number)
+}
+
+func (m *PullRequestsAPI) List(ctx context.Context, owner string, repo string, opts *github.PullRequestListOptions) ([]*github.PullRequest, *github.Response, error) {
+	m.record("List", owner, repo, opts)
+	if m.ListFunc == nil {
+		return nil, nil, nil
+	}
+	return m.ListFunc(ctx, owner, repo, opts)
+}
+
+func (m *PullRequestsAPI) ListPullRequestsWithCommit(ctx context.Context, owner, repo, sha string, opts *github.PullRequestListOptions) ([]*github.PullRequest, *github.Response, error) {
+	m.record("ListPullRequestsWithCommit", owner, repo, sha, opts)
+	if m.ListWithCommitFunc == nil {
+		return nil, nil, nil
+	}
+	return m.ListWithCommitFunc(ctx, owner, repo, sha, opts)
+}
+
+func (m *PullRequestsAPI) ListFiles(ctx context.Context, owner string, repo string, number int, opts *github.ListOptions) ([]*github.CommitFile, *github.Response, error) {
+	m.record("ListFiles", owner, repo, number, opts)
+	if m.ListFilesFunc == nil {
+		return nil, nil, nil
+	}
+	return m.ListFilesFunc(ctx, owner, repo, number, opts)
+}
+
+func (m *PullRequestsAPI) ListComments(ctx context.Context, owner string, repo string, number int, opts *github.PullRequestListCommentsOptions) ([]*github.PullRequestComment, *github.Response, error) {
+	m.record("ListComments", owner, repo, number, opts)
+	if m.ListCommentsFunc == nil {
+		return nil, nil, nil
+	}
+	return m.ListCommentsFunc(ctx, owner, repo, number, opts)
+}
+
+func (m *PullRequestsAPI) CreateReview(ctx context.Context, owner string, repo string, number int, review *github.PullRequestReviewRequest) (*github.PullRequestReview, *github.Response, error) {
+	m.record("CreateReview", owner, repo, number, review)
+	if m.CreateReviewFunc == nil {
+		return &github.PullRequestReview{}, nil, nil
+	}
+	return m.CreateReviewFunc(ctx, owner, repo, number, review)
+}
+
+func (m *PullRequestsAPI) CreateComment(ctx context.Context, owner string, repo string, number int, comment *github.PullRequestComment) (*github.PullRequestComment, *github.Response, error) {
+	m.record("CreateComment", owner, repo, number, comment)
+	if m.CreateCommentFunc == nil {
+		return comment, nil, nil
+	}
+	return m.CreateCommentFunc(ctx, owner, repo, number, comment)
+}
+
+func (m *PullRequestsAPI) EditComment(ctx context.Context, owner string, repo string, commentID int64, comment *github.PullRequestComment) (*github.PullRequestComment, *github.Response, error) {
+	m.record("EditComment", owner, repo, commentID, comment)
+	if m.EditCommentFunc == nil {
+		return comment, nil, nil
+	}
+	return m.EditCommentFunc(ctx, owner, repo, commentID, comment)
+}
+
+func (m *PullRequestsAPI) DeleteComment(ctx context.Context, owner string, repo string, commentID int64) (*github.Response, error) {
+	m.record("DeleteComment", owner, repo, commentID)
+	if m.DeleteCommentFunc == nil {
+		return nil, nil
+	}
+	return m.DeleteCommentFunc(ctx, owner, repo, commentID)
+}
+
+func (m *PullRequestsAPI) ListReviews(ctx context.Context, owner, repo string, number int, opts *github.ListOptions) ([]*github.PullRequestReview, *github.Response, error) {
+	m.record("ListReviews", owner, repo, number, opts)
+	if m.ListReviewsFunc == nil {
+		return nil, nil, nil
+	}
+	return m.ListReviewsFunc(ctx, owner, repo, number, opts)
+}
+
+func (m *PullRequestsAPI) DismissReview(ctx context.Context, owner, repo string, number int, reviewID int64, review *github.PullRequestReviewDismissalRequest) (*github.PullRequestReview, *github.Response, error) {
+	m.record("DismissReview", owner, repo, number, reviewID, review)
+	if m.DismissReviewFunc == nil {
+		return &github.PullRequestReview{ID: &reviewID}, nil, nil
+	}
+	return m.DismissReviewFunc(ctx, owner, repo, number, reviewID, review)
+}
+
+func (m *PullRequestsAPI) DeletePendingReview(ctx context.Context, owner, repo string, number int, reviewID int64) (*github.PullRequestReview, *github.Response, error) {
+	m.record("DeletePendingReview", owner, repo, number, reviewID)
+	if m.DeletePendingReviewFunc == nil {
+		return &github.PullRequestReview{ID: &reviewID}, nil, nil
+	}
+	return m.DeletePendingReviewFunc(ctx, owner, repo, number, reviewID)
+}
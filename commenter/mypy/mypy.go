@@ -0,0 +1,88 @@
+// Package mypy reads the errors of mypy as findings
+package mypy
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+)
+
+// Tool names the findings of Parse
+const Tool = "mypy"
+
+// lineRegex matches mypy's "file:line[:column]: severity: message  [code]" lines
+var lineRegex = regexp.MustCompile(`^(.+?):(\d+)(?::\d+)?: (error|warning|note): (.*?)(?:  \[([\w-]+)\])?$`)
+
+type jsonError struct {
+	File     string  `json:"file"`
+	Line     int     `json:"line"`
+	Message  string  `json:"message"`
+	Hint     *string `json:"hint"`
+	Code     *string `json:"code"`
+	Severity string  `json:"severity"`
+}
+
+// Parse reads mypy's default output, or its --output json output, as findings of the error code.
+// The notes mypy prints below an error are added to its message, notes on their own are SeverityInfo
+func Parse(r io.Reader) ([]commenter.Finding, error) {
+	var findings []commenter.Finding
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		var e jsonError
+		if strings.HasPrefix(text, "{") {
+			if err := json.Unmarshal([]byte(text), &e); err != nil {
+				return nil, fmt.Errorf("mypy output line %d: %w", n, err)
+			}
+			if e.Hint != nil && *e.Hint != "" {
+				e.Message += "\n" + *e.Hint
+			}
+		} else if groups := lineRegex.FindStringSubmatch(text); groups != nil {
+			e.File, e.Severity, e.Message = groups[1], groups[3], groups[4]
+			e.Line, _ = strconv.Atoi(groups[2])
+			if groups[5] != "" {
+				e.Code = &groups[5]
+			}
+		} else {
+			continue
+		}
+
+		if last := len(findings) - 1; e.Severity == "note" && last >= 0 && findings[last].Path == e.File && findings[last].StartLine == e.Line {
+			findings[last].Message += "\n" + e.Message
+			continue
+		}
+		finding := commenter.Finding{
+			Tool:      Tool,
+			Severity:  severity(e.Severity),
+			Path:      e.File,
+			StartLine: e.Line,
+			EndLine:   e.Line,
+			Message:   e.Message,
+		}
+		if e.Code != nil {
+			finding.RuleID = *e.Code
+		}
+		findings = append(findings, finding)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read mypy output: %w", err)
+	}
+	return findings, nil
+}
+
+func severity(s string) commenter.Severity {
+	switch s {
+	case "error":
+		return commenter.SeverityError
+	case "warning":
+		return commenter.SeverityWarning
+	default:
+		return commenter.SeverityInfo
+	}
+}
@@ -0,0 +1,280 @@
+package commenter
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v38/github"
+)
+
+const offlineSHA = "offline"
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// NewOfflineCommenter creates a Commenter which plans comments against a local unified diff, such as
+// the output of `git diff`, instead of a live PR. Nothing is sent to GitHub, the comments and reviews
+// which would have been written are printed to out
+func NewOfflineCommenter(diff io.Reader, out io.Writer, opts ...Option) (*Commenter, error) {
+	files, err := parseUnifiedDiff(diff)
+	if err != nil {
+		return nil, err
+	}
+	w := &lockedWriter{w: out}
+	api := &offlinePullRequests{files: files, out: w}
+	issues := func(o *options) {
+		o.issueComments = &offlineIssueComments{out: w}
+	}
+	return NewCommenter("", "offline", "offline", 0, append(opts, WithPullRequestsAPI(api), issues)...)
+}
+
+// NewOfflineCommenterFromFile is NewOfflineCommenter reading the diff from path and printing to stdout
+func NewOfflineCommenterFromFile(path string, opts ...Option) (*Commenter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewOfflineCommenter(f, os.Stdout, opts...)
+}
+
+// ParseUnifiedDiff splits a unified diff into the files it changes, each reported at sha. It is for
+// providers whose forge serves a PR as one diff rather than per file patches
+func ParseUnifiedDiff(r io.Reader, sha string) ([]*ChangedFile, error) {
+	commitFiles, err := parseUnifiedDiff(r)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]*ChangedFile, 0, len(commitFiles))
+	for _, file := range commitFiles {
+		files = append(files, &ChangedFile{
+			Filename:  file.GetFilename(),
+			Status:    file.GetStatus(),
+			Patch:     file.GetPatch(),
+			Changes:   file.GetChanges(),
+			CommitSHA: sha,
+		})
+	}
+	return files, nil
+}
+
+// parseUnifiedDiff splits a unified diff into the per file patches GitHub would report for a PR
+func parseUnifiedDiff(r io.Reader) ([]*github.CommitFile, error) {
+	var (
+		files            []*github.CommitFile
+		current          *github.CommitFile
+		patch            []string
+		oldPath          string
+		oldLeft, newLeft int
+	)
+	flush := func() {
+		if current != nil {
+			current.Patch = github.String(strings.Join(patch, "\n"))
+			current.Changes = github.Int(len(patch))
+			files = append(files, current)
+		}
+		current, patch = nil, nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// inside a hunk every line belongs to the patch until its line counts are used up
+		if oldLeft > 0 || newLeft > 0 {
+			patch = append(patch, line)
+			switch {
+			case strings.HasPrefix(line, "+"):
+				newLeft--
+			case strings.HasPrefix(line, "-"):
+				oldLeft--
+			case strings.HasPrefix(line, "\\"):
+			default:
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			oldPath = ""
+		case strings.HasPrefix(line, "--- "):
+			flush()
+			oldPath = diffPath(strings.TrimPrefix(line, "--- "), "a/")
+		case strings.HasPrefix(line, "+++ "):
+			newPath := diffPath(strings.TrimPrefix(line, "+++ "), "b/")
+			status, name := "modified", newPath
+			switch {
+			case newPath == "":
+				status, name = "deleted", oldPath
+			case oldPath == "":
+				status = "added"
+			}
+			current = &github.CommitFile{
+				Filename:    github.String(name),
+				Status:      github.String(status),
+				ContentsURL: github.String(fmt.Sprintf("offline/%s?ref=%s", name, offlineSHA)),
+			}
+		case current != nil && strings.HasPrefix(line, "@@"):
+			groups := hunkHeaderRegex.FindStringSubmatch(line)
+			if groups == nil {
+				return nil, fmt.Errorf("malformed hunk header in %s: %s", current.GetFilename(), line)
+			}
+			oldLeft, newLeft = hunkCount(groups[2]), hunkCount(groups[4])
+			patch = append(patch, line)
+		case current != nil && strings.HasPrefix(line, "\\"):
+			patch = append(patch, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read diff: %w", err)
+	}
+	flush()
+	return files, nil
+}
+
+// hunkCount parses the optional line count of a hunk header, an omitted count means one line
+func hunkCount(count string) int {
+	if count == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(count)
+	return n
+}
+
+// diffPath strips the a/ or b/ prefix and any timestamp from a diff header path, /dev/null becomes ""
+func diffPath(header, prefix string) string {
+	if i := strings.Index(header, "\t"); i >= 0 {
+		header = header[:i]
+	}
+	if header == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(header, prefix)
+}
+
+// offlinePullRequests serves the PR from the parsed diff and prints every write instead of making it
+type offlinePullRequests struct {
+	files []*github.CommitFile
+	out   io.Writer
+}
+
+func (o *offlinePullRequests) Get(_ context.Context, _ string, _ string, number int) (*github.PullRequest, *github.Response, error) {
+	return &github.PullRequest{Number: &number, Head: &github.PullRequestBranch{SHA: github.String(offlineSHA)}}, nil, nil
+}
+
+func (o *offlinePullRequests) List(context.Context, string, string, *github.PullRequestListOptions) ([]*github.PullRequest, *github.Response, error) {
+	return nil, nil, nil
+}
+
+func (o *offlinePullRequests) ListPullRequestsWithCommit(context.Context, string, string, string, *github.PullRequestListOptions) ([]*github.PullRequest, *github.Response, error) {
+	return nil, nil, nil
+}
+
+func (o *offlinePullRequests) ListFiles(context.Context, string, string, int, *github.ListOptions) ([]*github.CommitFile, *github.Response, error) {
+	return o.files, nil, nil
+}
+
+func (o *offlinePullRequests) ListComments(context.Context, string, string, int, *github.PullRequestListCommentsOptions) ([]*github.PullRequestComment, *github.Response, error) {
+	return nil, nil, nil
+}
+
+func (o *offlinePullRequests) CreateReview(_ context.Context, _ string, _ string, _ int, review *github.PullRequestReviewRequest) (*github.PullRequestReview, *github.Response, error) {
+	fmt.Fprintf(o.out, "review %s: %s\n", review.GetEvent(), review.GetBody())
+	for _, comment := range review.Comments {
+		start := comment.GetLine()
+		if comment.StartLine != nil {
+			start = comment.GetStartLine()
+		}
+		printOfflineComment(o.out, comment.GetPath(), start, comment.GetLine(), comment.GetBody())
+	}
+	return &github.PullRequestReview{State: review.Event, Body: review.Body}, nil, nil
+}
+
+func (o *offlinePullRequests) ListReviews(context.Context, string, string, int, *github.ListOptions) ([]*github.PullRequestReview, *github.Response, error) {
+	return nil, nil, nil
+}
+
+func (o *offlinePullRequests) DismissReview(_ context.Context, _ string, _ string, _ int, reviewID int64, review *github.PullRequestReviewDismissalRequest) (*github.PullRequestReview, *github.Response, error) {
+	fmt.Fprintf(o.out, "dismiss review %d: %s\n", reviewID, review.GetMessage())
+	return &github.PullRequestReview{ID: &reviewID}, nil, nil
+}
+
+func (o *offlinePullRequests) DeletePendingReview(_ context.Context, _ string, _ string, _ int, reviewID int64) (*github.PullRequestReview, *github.Response, error) {
+	return &github.PullRequestReview{ID: &reviewID}, nil, nil
+}
+
+func (o *offlinePullRequests) CreateComment(_ context.Context, _ string, _ string, _ int, comment *github.PullRequestComment) (*github.PullRequestComment, *github.Response, error) {
+	start := comment.GetLine()
+	if comment.StartLine != nil {
+		start = comment.GetStartLine()
+	}
+	printOfflineComment(o.out, comment.GetPath(), start, comment.GetLine(), comment.GetBody())
+	return comment, nil, nil
+}
+
+func (o *offlinePullRequests) EditComment(_ context.Context, _ string, _ string, commentID int64, comment *github.PullRequestComment) (*github.PullRequestComment, *github.Response, error) {
+	fmt.Fprintf(o.out, "edit %d: %s\n", commentID, comment.GetBody())
+	return comment, nil, nil
+}
+
+func (o *offlinePullRequests) DeleteComment(context.Context, string, string, int64) (*github.Response, error) {
+	return nil, nil
+}
+
+// offlineIssueComments prints the comments on the PR itself instead of writing them, there are never
+// any to list
+type offlineIssueComments struct {
+	out io.Writer
+}
+
+func (o *offlineIssueComments) Get(_ context.Context, _ string, _ string, number int) (*github.Issue, *github.Response, error) {
+	return &github.Issue{Number: &number}, nil, nil
+}
+
+func (o *offlineIssueComments) ListComments(context.Context, string, string, int, *github.IssueListCommentsOptions) ([]*github.IssueComment, *github.Response, error) {
+	return nil, nil, nil
+}
+
+func (o *offlineIssueComments) CreateComment(_ context.Context, _ string, _ string, _ int, comment *github.IssueComment) (*github.IssueComment, *github.Response, error) {
+	fmt.Fprintf(o.out, "comment: %s\n", comment.GetBody())
+	return comment, nil, nil
+}
+
+func (o *offlineIssueComments) EditComment(_ context.Context, _ string, _ string, commentID int64, comment *github.IssueComment) (*github.IssueComment, *github.Response, error) {
+	fmt.Fprintf(o.out, "edit %d: %s\n", commentID, comment.GetBody())
+	return comment, nil, nil
+}
+
+func (o *offlineIssueComments) DeleteComment(context.Context, string, string, int64) (*github.Response, error) {
+	return nil, nil
+}
+
+// lockedWriter serializes the writes of comments written concurrently
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+func printOfflineComment(out io.Writer, path string, start, end int, body string) {
+	location := fmt.Sprintf("%s:%d", path, end)
+	if start < end {
+		location = fmt.Sprintf("%s:%d-%d", path, start, end)
+	}
+	fmt.Fprintf(out, "%s: %s\n", location, body)
+}
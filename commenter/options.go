@@ -0,0 +1,104 @@
+package commenter
+
+import (
+	"io"
+	"net/http"
+	"regexp"
+	"text/template"
+	"time"
+
+	"github.com/google/go-github/v38/github"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/oauth2"
+	"golang.org/x/time/rate"
+)
+
+// Option configures the optional behaviour of a Commenter
+type Option func(*options)
+
+type options struct {
+	rateLimitThreshold    int
+	rateLimitAction       RateLimitAction
+	retryPolicy           RetryPolicy
+	limiter               *rate.Limiter
+	etagCache             ETagCache
+	concurrency           int
+	postInterval          time.Duration
+	progress              ProgressFunc
+	logger                Logger
+	metrics               Metrics
+	tracer                trace.Tracer
+	debugWriter           io.Writer
+	userAgent             string
+	apiVersion            string
+	transport             http.RoundTripper
+	timeout               time.Duration
+	client                *github.Client
+	tokenSource           oauth2.TokenSource
+	pullRequests          PullRequestsAPI
+	issueComments         issueCommentsAPI
+	baseURL               string
+	graphqlFetch          bool
+	snapshotStore         SnapshotStore
+	snapshotTTL           time.Duration
+	commitFallback        bool
+	fallbackSink          FallbackSink
+	baseline              *Baseline
+	suppressionDirectives []string
+	contentsFetch         bool
+	minSeverity           Severity
+	lowSeveritySummary    bool
+	mentionAuthor         bool
+	mentionSeverity       Severity
+	locale                Locale
+	findingTemplate       *template.Template
+	findingTemplateErr    error
+	audit                 *auditLog
+	idempotencyKey        string
+	resumeState           *ResumeState
+	botLogin              string
+	legacyPositions       bool
+	patchlessSummary      bool
+	repoRoot              string
+	stripPrefixes         []string
+	pathPrefix            string
+	caseInsensitivePaths  bool
+	pathAliases           map[string]string
+	includePaths          []*regexp.Regexp
+	excludePaths          []*regexp.Regexp
+	filterMode            FilterMode
+	nearestLine           int
+	dedupKey              DedupKeyFunc
+	maxPerFile            int
+	maxSummaryComments    int
+	gistOverflow          bool
+	gistClient            *github.Client
+	autoApprove           AutoApproveMode
+	acknowledgements      bool
+	resolvedMode          ResolvedMode
+	pruneOutdated         bool
+	pruneMode             PruneMode
+	reviewCommit          string
+	threadContinuation    bool
+	diffSnippet           bool
+	// rate is shared by the commenters of a Manager
+	rate *rateTracker
+}
+
+func defaultOptions() *options {
+	return &options{
+		retryPolicy: DefaultRetryPolicy(),
+		logger:      nopLogger{},
+		metrics:     nopMetrics{},
+		tracer:      trace.NewNoopTracerProvider().Tracer(tracerName),
+		audit:       &auditLog{},
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
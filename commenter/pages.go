@@ -0,0 +1,72 @@
+package commenter
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/go-github/v38/github"
+)
+
+const (
+	// pageSize is the most items GitHub returns in a page of a REST listing
+	pageSize = 100
+	// maxListedFiles is the most files GitHub lists for a PR, the rest are only in its diff
+	maxListedFiles = 3000
+)
+
+// fetchPages fetches the first page of the named listing with fetch and the remaining ones, known from
+// the first page's Link header, concurrently within WithConcurrency. It returns the number of pages,
+// fetch must keep what each page returned
+func (c *connector) fetchPages(ctx context.Context, name string, fetch func(page int) (*github.Response, error)) (int, error) {
+	var last int
+	err := c.withRetry(ctx, name, func() (*github.Response, error) {
+		resp, err := fetch(1)
+		if resp != nil {
+			last = resp.LastPage
+		}
+		return resp, err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if last <= 1 {
+		return 1, nil
+	}
+
+	concurrency := c.opts.concurrency
+	if concurrency < 1 {
+		concurrency = defaultConcurrency
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	slots := make(chan struct{}, concurrency)
+	for page := 2; page <= last; page++ {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(page int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			err := c.withRetry(ctx, name, func() (*github.Response, error) {
+				return fetch(page)
+			})
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}(page)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return 0, firstErr
+	}
+	return last, nil
+}
@@ -0,0 +1,201 @@
+package commenter
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// WithIncludePaths restricts commenting to files matching one of the globs, such as "internal/**".
+// "**" matches any number of directories, "*" and "?" stay within one
+func WithIncludePaths(globs ...string) Option {
+	return func(o *options) {
+		o.includePaths = append(o.includePaths, compileGlobs(globs)...)
+	}
+}
+
+// WithExcludePaths stops commenting on files matching one of the globs, such as "vendor/**" or
+// "**/*_test.go", even when they are included
+func WithExcludePaths(globs ...string) Option {
+	return func(o *options) {
+		o.excludePaths = append(o.excludePaths, compileGlobs(globs)...)
+	}
+}
+
+// WithRepoRoot makes absolute paths under dir, the checkout the tools ran in such as GITHUB_WORKSPACE,
+// relative to the repository, so findings of tools reporting absolute paths match the PR's files
+func WithRepoRoot(dir string) Option {
+	return func(o *options) {
+		o.repoRoot = strings.TrimSuffix(strings.ReplaceAll(dir, "\\", "/"), "/")
+	}
+}
+
+// WithStripPathPrefixes removes the first of prefixes a path starts with, such as the "/workspace/src/"
+// of a container the tools ran in, after the separators are made forward slashes
+func WithStripPathPrefixes(prefixes ...string) Option {
+	return func(o *options) {
+		for _, prefix := range prefixes {
+			o.stripPrefixes = append(o.stripPrefixes, strings.ReplaceAll(prefix, "\\", "/"))
+		}
+	}
+}
+
+// WithPathPrefix joins prefix, such as "services/api/", to the relative paths of findings made by tools
+// run in that directory of a monorepo, so they name the repository's files as GitHub does. Paths which
+// already start with prefix are left as they are
+func WithPathPrefix(prefix string) Option {
+	return func(o *options) {
+		o.pathPrefix = strings.Trim(path.Clean(strings.ReplaceAll(prefix, "\\", "/")), "/")
+		if o.pathPrefix == "." {
+			o.pathPrefix = ""
+		}
+	}
+}
+
+// WithCaseInsensitivePaths matches a path to the PR's file differing from it only in case when no file
+// has the exact path, for tools run on a case-insensitive filesystem which report "README.MD" for
+// "README.md"
+func WithCaseInsensitivePaths() Option {
+	return func(o *options) {
+		o.caseInsensitivePaths = true
+	}
+}
+
+// WithPathAliases maps paths tools report, after normalization, to the repository paths they stand
+// for, such as a generated file to its source
+func WithPathAliases(aliases map[string]string) Option {
+	return func(o *options) {
+		if o.pathAliases == nil {
+			o.pathAliases = map[string]string{}
+		}
+		for alias, file := range aliases {
+			o.pathAliases[path.Clean(strings.ReplaceAll(alias, "\\", "/"))] = file
+		}
+	}
+}
+
+// normalizePath turns a path reported by a tool into the repository relative form GitHub names files
+// by. Backslashes become forward slashes and "./" is dropped, then WithRepoRoot, WithStripPathPrefixes,
+// WithPathPrefix, WithPathAliases and WithCaseInsensitivePaths apply
+func (c *Commenter) normalizePath(file string) string {
+	if file == "" {
+		return file
+	}
+	file = strings.ReplaceAll(file, "\\", "/")
+	if root := c.opts.repoRoot; root != "" && strings.HasPrefix(file, root+"/") {
+		file = file[len(root)+1:]
+	}
+	for _, prefix := range c.opts.stripPrefixes {
+		if strings.HasPrefix(file, prefix) {
+			file = strings.TrimPrefix(file, prefix)
+			break
+		}
+	}
+	if prefix := c.opts.pathPrefix; prefix != "" && !absolutePath(file) && !strings.HasPrefix(path.Clean(file)+"/", prefix+"/") {
+		file = path.Join(prefix, file)
+	}
+	file = path.Clean(file)
+	if alias, ok := c.opts.pathAliases[file]; ok {
+		file = alias
+	}
+	if c.opts.caseInsensitivePaths && !c.fileChanged(file) {
+		c.mu.RLock()
+		folded, ok := c.foldedFiles[strings.ToLower(file)]
+		c.mu.RUnlock()
+		if ok {
+			file = folded
+		}
+	}
+	return file
+}
+
+// absolutePath reports whether file is absolute on Unix or, with a drive letter, on Windows
+func absolutePath(file string) bool {
+	return path.IsAbs(file) || len(file) > 1 && file[1] == ':'
+}
+
+// normalizeComments returns a copy of comments with their paths normalized
+func (c *Commenter) normalizeComments(comments []PRReviewComment) []PRReviewComment {
+	normalized := make([]PRReviewComment, len(comments))
+	for i, comment := range comments {
+		comment.FileName = c.normalizePath(comment.FileName)
+		normalized[i] = comment
+	}
+	return normalized
+}
+
+// normalizeFindings returns a copy of findings with their paths normalized
+func (c *Commenter) normalizeFindings(findings []Finding) []Finding {
+	normalized := make([]Finding, len(findings))
+	for i, finding := range findings {
+		finding.Path = c.normalizePath(finding.Path)
+		normalized[i] = finding
+	}
+	return normalized
+}
+
+// pathAllowed reports whether the path filters let comments be written on file
+func (c *Commenter) pathAllowed(file string) bool {
+	file = strings.TrimPrefix(path.Clean(strings.ReplaceAll(file, "\\", "/")), "./")
+	if len(c.opts.includePaths) > 0 && !matchAny(c.opts.includePaths, file) {
+		return false
+	}
+	return !matchAny(c.opts.excludePaths, file)
+}
+
+func matchAny(globs []*regexp.Regexp, file string) bool {
+	for _, glob := range globs {
+		if glob.MatchString(file) {
+			return true
+		}
+	}
+	return false
+}
+
+func compileGlobs(globs []string) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, 0, len(globs))
+	for _, glob := range globs {
+		compiled = append(compiled, globRegexp(glob))
+	}
+	return compiled
+}
+
+// globRegexp translates a path glob into an anchored regular expression
+func globRegexp(glob string) *regexp.Regexp {
+	glob = strings.TrimPrefix(glob, "./")
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch ch := glob[i]; ch {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+				continue
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			if end := strings.IndexByte(glob[i:], ']'); end > 0 {
+				class := glob[i+1 : i+end]
+				if strings.HasPrefix(class, "!") {
+					class = "^" + class[1:]
+				}
+				b.WriteString("[" + class + "]")
+				i += end
+				continue
+			}
+			b.WriteString(regexp.QuoteMeta("["))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
@@ -0,0 +1,42 @@
+package commenter
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Permalink returns a link to lines startLine to endLine of file at the commit being commented on,
+// which keeps pointing at the same code after later pushes. It links to the GitHub Enterprise Server
+// web UI when WithBaseURL points at one, and is "" for providers other than GitHub
+func (c *Commenter) Permalink(file string, startLine, endLine int) string {
+	gh := c.repoConnector()
+	sha := c.headSHA()
+	if gh == nil || sha == "" {
+		return ""
+	}
+	segments := strings.Split(strings.TrimPrefix(file, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	link := fmt.Sprintf("%s%s/%s/blob/%s/%s", gh.webURL(), gh.owner, gh.repo, sha, strings.Join(segments, "/"))
+	if startLine == 0 || startLine == endLine {
+		return fmt.Sprintf("%s#L%d", link, endLine)
+	}
+	return fmt.Sprintf("%s#L%d-L%d", link, startLine, endLine)
+}
+
+// webURL returns the root of the web UI belonging to the API the connector talks to, GitHub Enterprise
+// Server serves it from the host of its /api/v3 API
+func (c *connector) webURL() string {
+	u := *c.client.BaseURL
+	switch {
+	case u.Host == "api.github.com":
+		return "https://github.com/"
+	case strings.HasSuffix(u.Path, "/api/v3/"):
+		u.Path = strings.TrimSuffix(u.Path, "api/v3/")
+	default:
+		u.Path = "/"
+	}
+	return u.String()
+}
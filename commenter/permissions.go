@@ -0,0 +1,251 @@
+package commenter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// FallbackReview is the review which couldn't be written to the PR
+type FallbackReview struct {
+	Event    string
+	Body     string
+	HeadSHA  string
+	Comments []InlineComment
+}
+
+// FallbackSink receives the review when the token isn't allowed to write it to the PR, as happens
+// for pull_request events from forks
+type FallbackSink interface {
+	// Name describes the sink in InsufficientPermissionsError
+	Name() string
+	WriteFallback(ctx context.Context, review FallbackReview) error
+}
+
+// WithPermissionFallback writes reviews and comments rejected with a 403 to sink instead, the write
+// still returns an InsufficientPermissionsError
+func WithPermissionFallback(sink FallbackSink) Option {
+	return func(o *options) {
+		o.fallbackSink = sink
+	}
+}
+
+// StepSummarySink appends the review as markdown to the job summary file at path, an empty path uses
+// GITHUB_STEP_SUMMARY
+func StepSummarySink(path string) FallbackSink {
+	return stepSummarySink{path: path}
+}
+
+// LogSink writes the review to w as workflow commands, so comments still show as annotations
+func LogSink(w io.Writer) FallbackSink {
+	return logSink{w: w}
+}
+
+// CheckRunSink creates a check run called name on the head commit with the comments as annotations.
+// client needs checks write access, such as a GitHub App installation token
+func CheckRunSink(client *github.Client, owner, repo, name string) FallbackSink {
+	return checkRunSink{client: client, owner: owner, repo: repo, name: name}
+}
+
+type stepSummarySink struct {
+	path string
+}
+
+func (s stepSummarySink) Name() string {
+	return "step summary"
+}
+
+func (s stepSummarySink) WriteFallback(_ context.Context, review FallbackReview) error {
+	path := s.path
+	if path == "" {
+		path = os.Getenv("GITHUB_STEP_SUMMARY")
+	}
+	if path == "" {
+		return errors.New("GITHUB_STEP_SUMMARY has not been set")
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s\n\n", review.Body)
+	for _, comment := range review.Comments {
+		fmt.Fprintf(&b, "- `%s`: %s\n", commentLocation(comment), strings.ReplaceAll(comment.Body, "\n", " "))
+	}
+	b.WriteString("\n")
+	if _, err := f.WriteString(b.String()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+type logSink struct {
+	w io.Writer
+}
+
+func (s logSink) Name() string {
+	return "log"
+}
+
+func (s logSink) WriteFallback(_ context.Context, review FallbackReview) error {
+	level := annotationLevel(review.Event)
+	if level == "failure" {
+		level = "error"
+	}
+	if _, err := fmt.Fprintf(s.w, "::notice::%s\n", escapeWorkflowData(review.Body)); err != nil {
+		return err
+	}
+	for _, comment := range review.Comments {
+		start := comment.StartLine
+		if start == 0 {
+			start = comment.EndLine
+		}
+		_, err := fmt.Fprintf(s.w, "::%s file=%s,line=%d,endLine=%d::%s\n", level, escapeWorkflowProperty(comment.Path), start, comment.EndLine, escapeWorkflowData(comment.Body))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type checkRunSink struct {
+	client *github.Client
+	owner  string
+	repo   string
+	name   string
+}
+
+func (s checkRunSink) Name() string {
+	return "check run"
+}
+
+func (s checkRunSink) WriteFallback(ctx context.Context, review FallbackReview) error {
+	annotations := make([]*github.CheckRunAnnotation, 0, len(review.Comments))
+	for _, comment := range review.Comments {
+		start := comment.StartLine
+		if start == 0 {
+			start = comment.EndLine
+		}
+		annotations = append(annotations, &github.CheckRunAnnotation{
+			Path:            github.String(comment.Path),
+			StartLine:       github.Int(start),
+			EndLine:         github.Int(comment.EndLine),
+			AnnotationLevel: github.String(annotationLevel(review.Event)),
+			Message:         github.String(comment.Body),
+		})
+	}
+	conclusion := "neutral"
+	switch review.Event {
+	case Approve:
+		conclusion = "success"
+	case RequestChanges:
+		conclusion = "failure"
+	}
+
+	_, err := createCheckRun(ctx, nil, s.client, s.owner, s.repo, github.CreateCheckRunOptions{
+		Name:       s.name,
+		HeadSHA:    review.HeadSHA,
+		Status:     github.String("completed"),
+		Conclusion: github.String(conclusion),
+		Output: &github.CheckRunOutput{
+			Title:   github.String(s.name),
+			Summary: github.String(review.Body),
+		},
+	}, annotations)
+	return err
+}
+
+// fallback writes the review to the configured sink after the PR rejected it with cause
+func (c *Commenter) fallback(ctx context.Context, event, body string, comments []InlineComment, cause error) error {
+	review := FallbackReview{
+		Event:    event,
+		Body:     body,
+		HeadSHA:  c.headSHA(),
+		Comments: comments,
+	}
+	sink := c.opts.fallbackSink
+	if sink == nil {
+		return newInsufficientPermissionsError(c.target(), "", cause)
+	}
+	c.logger().Info("token can't write to the PR, using the fallback", "fallback", sink.Name(), "comments", len(comments))
+	if err := sink.WriteFallback(ctx, review); err != nil {
+		return fmt.Errorf("write fallback %s: %w", sink.Name(), err)
+	}
+	return newInsufficientPermissionsError(c.target(), sink.Name(), cause)
+}
+
+// headSHA is the commit the comments were planned against
+func (c *Commenter) headSHA() string {
+	if c.opts.reviewCommit != "" {
+		return c.opts.reviewCommit
+	}
+	if c.ghConnector != nil && c.ghConnector.headSHA != "" {
+		return c.ghConnector.headSHA
+	}
+	if p, ok := c.provider.(*commitProvider); ok {
+		return p.sha
+	}
+	for _, file := range c.snapshotFiles() {
+		return file.sha
+	}
+	return ""
+}
+
+// target names the PR for errors, empty for providers other than GitHub
+func (c *Commenter) target() string {
+	if c.ghConnector == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s#%d", c.ghConnector.owner, c.ghConnector.repo, c.ghConnector.prNumber)
+}
+
+// permissionDenied reports whether err is a 403 for the token's permissions, SSO authorization is
+// left to the caller as the fallback can't help with it
+func permissionDenied(err error) bool {
+	return errors.Is(err, ErrForbidden) && !errors.Is(err, ErrSSORequired)
+}
+
+func draftsToInline(drafts []*github.DraftReviewComment) []InlineComment {
+	comments := make([]InlineComment, 0, len(drafts))
+	for _, draft := range drafts {
+		comment := InlineComment{
+			Path:      draft.GetPath(),
+			StartLine: draft.GetLine(),
+			EndLine:   draft.GetLine(),
+			Body:      draft.GetBody(),
+		}
+		if draft.StartLine != nil {
+			comment.StartLine = draft.GetStartLine()
+		}
+		comments = append(comments, comment)
+	}
+	return comments
+}
+
+func annotationLevel(event string) string {
+	if event == RequestChanges {
+		return "failure"
+	}
+	return "warning"
+}
+
+func commentLocation(comment InlineComment) string {
+	if comment.StartLine > 0 && comment.StartLine < comment.EndLine {
+		return fmt.Sprintf("%s:%d-%d", comment.Path, comment.StartLine, comment.EndLine)
+	}
+	return fmt.Sprintf("%s:%d", comment.Path, comment.EndLine)
+}
+
+func escapeWorkflowData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeWorkflowProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,117 @@
+package commenter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// Policy decides whether the findings of a run fail it, for CI to turn into an exit code. A zero
+// threshold disables that check
+type Policy struct {
+	// FailOnErrors fails the run when at least this many findings are SeverityError or above
+	FailOnErrors int
+	// FailOnWarnings fails the run when at least this many findings are SeverityWarning
+	FailOnWarnings int
+}
+
+// PolicyResult is the verdict of a Policy
+type PolicyResult struct {
+	Passed   bool
+	Errors   int
+	Warnings int
+	Reason   string
+}
+
+// Evaluate counts the findings which weren't suppressed and compares them to the thresholds, results
+// are those WriteFindings returned for findings and may be nil to count every finding
+func (p Policy) Evaluate(findings []Finding, results []Result) PolicyResult {
+	var result PolicyResult
+	for i, finding := range findings {
+		if i < len(results) && results[i].Status == ResultSuppressed {
+			continue
+		}
+		switch {
+		case finding.Severity >= SeverityError:
+			result.Errors++
+		case finding.Severity == SeverityWarning:
+			result.Warnings++
+		}
+	}
+	switch {
+	case p.FailOnErrors > 0 && result.Errors >= p.FailOnErrors:
+		result.Reason = fmt.Sprintf("%d errors found, the limit is %d", result.Errors, p.FailOnErrors)
+	case p.FailOnWarnings > 0 && result.Warnings >= p.FailOnWarnings:
+		result.Reason = fmt.Sprintf("%d warnings found, the limit is %d", result.Warnings, p.FailOnWarnings)
+	default:
+		result.Passed = true
+		result.Reason = fmt.Sprintf("%d errors and %d warnings found", result.Errors, result.Warnings)
+	}
+	return result
+}
+
+// ExitCode is 0 when the policy passed and 1 when it failed
+func (r PolicyResult) ExitCode() int {
+	if r.Passed {
+		return 0
+	}
+	return 1
+}
+
+// SetCommitStatus reports the policy result as a commit status called statusContext on the head
+// commit, so branch protection can require it
+func (c *Commenter) SetCommitStatus(statusContext string, result PolicyResult) error {
+	return c.SetCommitStatusContext(context.Background(), statusContext, result)
+}
+
+// SetCommitStatusContext is SetCommitStatus using ctx for the API call
+func (c *Commenter) SetCommitStatusContext(ctx context.Context, statusContext string, result PolicyResult) error {
+	gh := c.repoConnector()
+	if gh == nil {
+		return fmt.Errorf("set commit status: %w", ErrNotSupported)
+	}
+	if err := c.ensureLoaded(ctx); err != nil {
+		return err
+	}
+	state := "success"
+	if !result.Passed {
+		state = "failure"
+	}
+	status := &github.RepoStatus{
+		State:       github.String(state),
+		Context:     github.String(statusContext),
+		Description: github.String(truncate(result.Reason, 140)),
+	}
+	err := gh.withRetry(ctx, "Repositories.CreateStatus", func() (*github.Response, error) {
+		_, resp, err := gh.client.Repositories.CreateStatus(ctx, gh.owner, gh.repo, c.headSHA(), status)
+		return resp, err
+	})
+	if err != nil {
+		return fmt.Errorf("set commit status %s: %w", statusContext, err)
+	}
+	return nil
+}
+
+// repoConnector returns the GitHub connector for repository wide calls, nil for other providers
+func (c *Commenter) repoConnector() *connector {
+	if c.ghConnector != nil {
+		return c.ghConnector
+	}
+	switch p := c.provider.(type) {
+	case *commitProvider:
+		return p.gh
+	case *issueProvider:
+		return p.gh
+	}
+	return nil
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,190 @@
+package commenter
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+)
+
+const reviewThreadsQuery = `query($owner: String!, $repo: String!, $number: Int!, $cursor: String) {
+  repository(owner: $owner, name: $repo) {
+    pullRequest(number: $number) {
+      headRefOid
+      author { login }
+      reviewThreads(first: 100, after: $cursor) {
+        pageInfo { hasNextPage endCursor }
+        nodes {
+          isOutdated
+          path
+          line
+          startLine
+          comments(first: 100) {
+            nodes { databaseId id body url diffHunk commit { oid } author { __typename login } }
+          }
+        }
+      }
+    }
+  }
+}`
+
+type reviewThreadsData struct {
+	Repository struct {
+		PullRequest *struct {
+			HeadRefOid string `json:"headRefOid"`
+			Author     struct {
+				Login string `json:"login"`
+			} `json:"author"`
+			ReviewThreads struct {
+				PageInfo struct {
+					HasNextPage bool   `json:"hasNextPage"`
+					EndCursor   string `json:"endCursor"`
+				} `json:"pageInfo"`
+				Nodes []struct {
+					IsOutdated bool   `json:"isOutdated"`
+					Path       string `json:"path"`
+					Line       int    `json:"line"`
+					StartLine  int    `json:"startLine"`
+					Comments   struct {
+						Nodes []struct {
+							DatabaseID int64  `json:"databaseId"`
+							ID         string `json:"id"`
+							Body       string `json:"body"`
+							URL        string `json:"url"`
+							DiffHunk   string `json:"diffHunk"`
+							Commit     struct {
+								Oid string `json:"oid"`
+							} `json:"commit"`
+							Author struct {
+								Typename string `json:"__typename"`
+								Login    string `json:"login"`
+							} `json:"author"`
+						} `json:"nodes"`
+					} `json:"comments"`
+				} `json:"nodes"`
+			} `json:"reviewThreads"`
+		} `json:"pullRequest"`
+	} `json:"repository"`
+}
+
+// threadPage is a page of review thread comments with the cursor of the next page, "" on the last
+type threadPage struct {
+	comments []*Comment
+	next     string
+	// headSHA and author are those of the PR as it was fetched
+	headSHA string
+	author  string
+}
+
+// WithGraphQLFetch loads the PR with one GraphQL query for its review threads and one request for its
+// diff, instead of paging through the REST files and comments. Only threads' first 100 comments are read
+func WithGraphQLFetch() Option {
+	return func(o *options) {
+		o.graphqlFetch = true
+	}
+}
+
+// prefetch checks the PR exists and keeps the first page of review threads for ListComments
+func (c *connector) prefetch(ctx context.Context) error {
+	page, err := c.fetchThreads(ctx, "")
+	if err != nil {
+		return err
+	}
+	// only set here, later pages are fetched while the files are being listed
+	c.prefetched, c.headSHA, c.author = page, page.headSHA, page.author
+	return nil
+}
+
+func (c *connector) fetchThreads(ctx context.Context, cursor string) (*threadPage, error) {
+	variables := map[string]interface{}{
+		"owner":  c.owner,
+		"repo":   c.repo,
+		"number": c.prNumber,
+	}
+	if cursor != "" {
+		variables["cursor"] = cursor
+	}
+	var data reviewThreadsData
+	if err := c.graphql(ctx, reviewThreadsQuery, variables, &data); err != nil {
+		var gqlErr graphqlError
+		if errors.As(err, &gqlErr) && gqlErr.notFound {
+			return nil, newPRDoesNotExistError(c.owner, c.repo, c.prNumber, err)
+		}
+		return nil, err
+	}
+	pr := data.Repository.PullRequest
+	if pr == nil {
+		return nil, newPRDoesNotExistError(c.owner, c.repo, c.prNumber, nil)
+	}
+	page := &threadPage{headSHA: pr.HeadRefOid, author: pr.Author.Login}
+	for _, thread := range pr.ReviewThreads.Nodes {
+		var first int64
+		for i, comment := range thread.Comments.Nodes {
+			author := comment.Author.Login
+			// GraphQL reports apps without the [bot] suffix REST logins carry
+			if comment.Author.Typename == "Bot" && !strings.HasSuffix(author, "[bot]") {
+				author += "[bot]"
+			}
+			page.comments = append(page.comments, &Comment{
+				ID:        comment.DatabaseID,
+				NodeID:    comment.ID,
+				Path:      thread.Path,
+				StartLine: thread.StartLine,
+				Line:      thread.Line,
+				Outdated:  thread.IsOutdated || thread.Line == 0,
+				Body:      comment.Body,
+				Author:    author,
+				URL:       comment.URL,
+				// every later comment of a thread replies to its first
+				InReplyTo: first,
+				CommitSHA: comment.Commit.Oid,
+				DiffHunk:  comment.DiffHunk,
+			})
+			if i == 0 {
+				first = comment.DatabaseID
+			}
+		}
+	}
+	if pr.ReviewThreads.PageInfo.HasNextPage {
+		page.next = pr.ReviewThreads.PageInfo.EndCursor
+	}
+	return page, nil
+}
+
+// listCommentsGraphQL starts from the prefetched page when there is one
+func (c *connector) listCommentsGraphQL(ctx context.Context) ([]*Comment, error) {
+	page := c.prefetched
+	c.prefetched = nil
+	if page == nil {
+		var err error
+		if page, err = c.fetchThreads(ctx, ""); err != nil {
+			return nil, err
+		}
+	}
+	comments := page.comments
+	for page.next != "" {
+		var err error
+		if page, err = c.fetchThreads(ctx, page.next); err != nil {
+			return nil, err
+		}
+		comments = append(comments, page.comments...)
+	}
+	return comments, nil
+}
+
+func (c *connector) listChangedFilesFromDiff(ctx context.Context) ([]*ChangedFile, error) {
+	var diff string
+	err := c.withRetry(ctx, "PullRequests.GetRaw", func() (*github.Response, error) {
+		var (
+			resp *github.Response
+			err  error
+		)
+		diff, resp, err = c.client.PullRequests.GetRaw(ctx, c.owner, c.repo, c.prNumber, github.RawOptions{Type: github.Diff})
+		return resp, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ParseUnifiedDiff(strings.NewReader(diff), c.headSHA)
+}
@@ -0,0 +1,107 @@
+package commenter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// Preflight checks the token can read the PR and is scoped to write comments on it, so that a
+// misconfigured token fails before anything is posted rather than halfway through. A repository the
+// token can't see is reported as a RepoNotAccessibleError
+func (c *Commenter) Preflight() error {
+	return c.PreflightContext(context.Background())
+}
+
+// PreflightContext is Preflight using ctx for the API calls
+func (c *Commenter) PreflightContext(ctx context.Context) error {
+	gh := c.ghConnector
+	if gh == nil {
+		return fmt.Errorf("preflight: %w", ErrNotSupported)
+	}
+
+	var (
+		repository *github.Repository
+		scopes     string
+		hasScopes  bool
+	)
+	err := gh.withRetry(ctx, "Repositories.Get", func() (*github.Response, error) {
+		var (
+			resp *github.Response
+			err  error
+		)
+		repository, resp, err = gh.client.Repositories.Get(ctx, gh.owner, gh.repo)
+		if resp != nil {
+			// only classic personal access tokens report their scopes
+			_, hasScopes = resp.Header[http.CanonicalHeaderKey("X-OAuth-Scopes")]
+			scopes = resp.Header.Get("X-OAuth-Scopes")
+		}
+		return resp, err
+	})
+	if err != nil {
+		var apiErr APIError
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusForbidden || apiErr.StatusCode == http.StatusNotFound) {
+			return newRepoNotAccessibleError(gh.owner, gh.repo, apiErr.StatusCode, err)
+		}
+		return preflightFailure(gh, "read the repository", err)
+	}
+	err = gh.withRetry(ctx, "PullRequests.Get", func() (*github.Response, error) {
+		_, resp, err := gh.prs.Get(ctx, gh.owner, gh.repo, gh.prNumber)
+		return resp, err
+	})
+	if err != nil {
+		return preflightFailure(gh, "read the PR", err)
+	}
+
+	var missing []string
+	if hasScopes && !hasRepoScope(scopes, repository.GetPrivate()) {
+		if repository.GetPrivate() {
+			missing = append(missing, "repo")
+		} else {
+			missing = append(missing, "public_repo")
+		}
+	}
+	// reading is enough to see the PR, writing review comments takes push access
+	if permissions := repository.GetPermissions(); permissions != nil && !permissions["push"] {
+		missing = append(missing, "push")
+	}
+	if len(missing) > 0 {
+		return newPreflightError(gh.owner, gh.repo, gh.prNumber, "write comments", missing, nil)
+	}
+	return nil
+}
+
+// preflightFailure turns a failed read into a PreflightError, GitHub answers 404 for resources the
+// token can't see
+func preflightFailure(gh *connector, action string, err error) error {
+	var apiErr APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	switch apiErr.StatusCode {
+	case http.StatusUnauthorized:
+		return newPreflightError(gh.owner, gh.repo, gh.prNumber, action, nil, err)
+	case http.StatusForbidden, http.StatusNotFound:
+		return newPreflightError(gh.owner, gh.repo, gh.prNumber, action, []string{"repo"}, err)
+	}
+	return err
+}
+
+// hasRepoScope reports whether the comma separated X-OAuth-Scopes allow commenting on the repository
+func hasRepoScope(scopes string, private bool) bool {
+	for _, scope := range strings.Split(scopes, ",") {
+		switch strings.TrimSpace(scope) {
+		case "repo":
+			return true
+		case "public_repo":
+			if !private {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+// Package prettier reads the files prettier --check reports as not formatted as findings
+package prettier
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+)
+
+// Tool and RuleID name the findings of Parse
+const (
+	Tool   = "prettier"
+	RuleID = "prettier"
+)
+
+// Parse reads the output of prettier --check as a SeverityWarning finding on line 1 of each file
+// it lists, Prettier doesn't tell which lines it would change. Its "Code style issues found" summary
+// and the lines of other levels are left out
+func Parse(r io.Reader) ([]commenter.Finding, error) {
+	var findings []commenter.Finding
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "[warn] ") {
+			continue
+		}
+		file := strings.TrimSpace(strings.TrimPrefix(line, "[warn] "))
+		if file == "" || strings.HasPrefix(file, "Code style issues") {
+			continue
+		}
+		findings = append(findings, commenter.Finding{
+			Tool:      Tool,
+			RuleID:    RuleID,
+			Severity:  commenter.SeverityWarning,
+			Path:      file,
+			StartLine: 1,
+			EndLine:   1,
+			Message:   fmt.Sprintf("This file isn't formatted with Prettier, run `prettier --write %s`", file),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read prettier output: %w", err)
+	}
+	return findings, nil
+}
@@ -0,0 +1,88 @@
+package commenter
+
+import (
+	"context"
+)
+
+// Provider is the forge specific backend a Commenter reads the PR from and writes comments through.
+// The GitHub provider is used by NewCommenter, implement it to support other forges without changing the Commenter
+type Provider interface {
+	// ListChangedFiles returns the files changed by the PR with their patches
+	ListChangedFiles(ctx context.Context) ([]*ChangedFile, error)
+	// ListComments returns the inline comments already on the PR, from every author
+	ListComments(ctx context.Context) ([]*Comment, error)
+	// CreateInlineComment writes a comment against lines of a changed file
+	CreateInlineComment(ctx context.Context, comment InlineComment) (*Comment, error)
+	// CreateSummaryComment writes a comment on the PR itself rather than a file
+	CreateSummaryComment(ctx context.Context, body string) (*Comment, error)
+	// UpdateComment replaces the body of an existing comment
+	UpdateComment(ctx context.Context, comment *Comment, body string) error
+	// DeleteComment removes an existing comment
+	DeleteComment(ctx context.Context, comment *Comment) error
+}
+
+// Identifier is implemented by providers whose comments aren't authored as CommenterName, the
+// commenter only treats comments by CommenterName() as its own
+type Identifier interface {
+	CommenterName() string
+}
+
+// ThreadResolver is implemented by providers which can mark the thread of a comment resolved without
+// hiding it, ResolvedMinimize does so on forges other than GitHub
+type ThreadResolver interface {
+	ResolveThread(ctx context.Context, comment *Comment) error
+}
+
+// ChangedFile is a file changed by the PR
+type ChangedFile struct {
+	Filename string
+	// Status is one of added, modified, removed, renamed or deleted
+	Status string
+	// Patch is the unified diff of the file, starting at the first hunk header
+	Patch string
+	// Changes is the number of changed lines, used when the provider omits the patch
+	Changes int
+	// CommitSHA is the commit inline comments on the file are made against
+	CommitSHA string
+}
+
+// InlineComment is a comment to write against lines of a changed file
+type InlineComment struct {
+	Path      string
+	StartLine int
+	EndLine   int
+	Body      string
+	CommitSHA string
+}
+
+// Comment is a comment stored by a Provider
+type Comment struct {
+	ID int64
+	// NodeID is a provider specific reference, such as the GitHub GraphQL node id
+	NodeID string
+	// Path, StartLine and Line are empty for summary comments
+	Path      string
+	StartLine int
+	Line      int
+	// Outdated is set when the provider no longer places the comment on the current diff
+	Outdated bool
+	Body     string
+	Author   string
+	URL      string
+	// InReplyTo is the id of the comment this one replies to, 0 when it starts a thread
+	InReplyTo int64
+	// CommitSHA is the commit an inline comment was made against and DiffHunk the patch up to its
+	// line, both are empty when the provider doesn't report them
+	CommitSHA string
+	DiffHunk  string
+}
+
+// NewCommenterWithProvider creates a Commenter writing through provider, GitHub specific
+// options such as the transport or rate limiting are left to the provider to honour. As with
+// NewCommenter the PR info is loaded on first use
+func NewCommenterWithProvider(ctx context.Context, provider Provider, opts ...Option) (*Commenter, error) {
+	return &Commenter{
+		provider: provider,
+		opts:     newOptions(opts),
+	}, nil
+}
@@ -0,0 +1,162 @@
+package commenter
+
+import (
+	"context"
+	"fmt"
+)
+
+// PruneMode controls what happens to comments which no longer point at the diff
+type PruneMode int
+
+const (
+	// PruneDelete deletes the outdated comments
+	PruneDelete PruneMode = iota
+	// PruneMinimize hides the outdated comments as OUTDATED, keeping the discussion
+	PruneMinimize
+)
+
+// WithPruneOutdated makes Sync prune the commenter's comments which no longer point at the diff, as
+// PruneOutdatedComments does with mode, once the comments of fixed findings are resolved. Comments of
+// the run's findings and those resolved are left to Sync
+func WithPruneOutdated(mode PruneMode) Option {
+	return func(o *options) {
+		o.pruneOutdated = true
+		o.pruneMode = mode
+	}
+}
+
+// PruneOutdatedComments finds the commenter's existing comments whose lines have left the diff
+// (e.g. after a force-push) and deletes or minimizes them depending on mode
+func (c *Commenter) PruneOutdatedComments(mode PruneMode) []error {
+	return c.PruneOutdatedCommentsContext(context.Background(), mode)
+}
+
+// PruneOutdatedCommentsContext is PruneOutdatedComments using ctx for the API calls
+func (c *Commenter) PruneOutdatedCommentsContext(ctx context.Context, mode PruneMode) []error {
+	if err := c.checkPruneMode(mode); err != nil {
+		return []error{err}
+	}
+	if err := c.ensureLoaded(ctx); err != nil {
+		return []error{err}
+	}
+	results, err := c.pruneOutdated(ctx, mode, nil)
+	if err != nil {
+		return []error{err}
+	}
+	var errs []error
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, result.Err)
+		}
+	}
+	return errs
+}
+
+// pruneOutdated deletes or minimizes the outdated comments but those in keep, returning a result for each
+func (c *Commenter) pruneOutdated(ctx context.Context, mode PruneMode, keep map[int64]bool) ([]Result, error) {
+	if err := c.checkPruneMode(mode); err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	deleted := map[int64]bool{}
+	for _, comment := range c.snapshotExistingComments() {
+		if keep[comment.ID] || !c.isOutdated(comment) {
+			continue
+		}
+		result := Result{
+			Comment:   PRReviewComment{FileName: comment.Path, StartLine: comment.StartLine, EndLine: comment.Line, Body: comment.Body},
+			Status:    ResultPruned,
+			CommentID: comment.ID,
+			URL:       comment.URL,
+		}
+		if mode == PruneMinimize {
+			if err := c.ghConnector.MinimizeComment(ctx, &comment.NodeID, "OUTDATED"); err != nil {
+				result.Status, result.Err = ResultFailed, fmt.Errorf("minimize existing comment %d: %w", comment.ID, err)
+			}
+		} else if err := c.deleteComment(ctx, comment); err != nil {
+			result.Status, result.Err = ResultFailed, err
+		} else {
+			deleted[comment.ID] = true
+		}
+		results = append(results, result)
+	}
+	c.forgetComments(deleted)
+	return results, nil
+}
+
+func (c *Commenter) checkPruneMode(mode PruneMode) error {
+	if mode != PruneDelete && mode != PruneMinimize {
+		return fmt.Errorf("prune mode %d is not supported", mode)
+	}
+	if mode == PruneMinimize && c.ghConnector == nil {
+		return fmt.Errorf("prune mode minimize: %w", ErrNotSupported)
+	}
+	return nil
+}
+
+// isOutdated reports whether the provider has lost the comment's position or its line is outside every hunk
+func (c *Commenter) isOutdated(comment *Comment) bool {
+	if comment.Outdated || comment.Path == "" || comment.Line == 0 {
+		return true
+	}
+	return c.fileInfoFor(comment.Path, comment.Line, comment.Line) == nil
+}
+
+// CleanPrevious deletes or minimizes, depending on mode, the commenter's comments from earlier runs
+// before fresh results are written, for a PR showing only the latest run. Only comments carrying the
+// commenter's marker are cleaned: those of WriteFindings and Sync, and of any batch written with
+// WithIdempotencyKey. Minimized comments are hidden as OUTDATED and written again when still reported
+func (c *Commenter) CleanPrevious(mode PruneMode) []error {
+	return c.CleanPreviousContext(context.Background(), mode)
+}
+
+// CleanPreviousContext is CleanPrevious using ctx for the API calls
+func (c *Commenter) CleanPreviousContext(ctx context.Context, mode PruneMode) []error {
+	if mode != PruneDelete && mode != PruneMinimize {
+		return []error{fmt.Errorf("clean mode %d is not supported", mode)}
+	}
+	if mode == PruneMinimize && c.ghConnector == nil {
+		return []error{fmt.Errorf("clean mode minimize: %w", ErrNotSupported)}
+	}
+	if err := c.ensureLoaded(ctx); err != nil {
+		return []error{err}
+	}
+	comments := c.snapshotExistingComments()
+	if lister, ok := c.provider.(summaryLister); ok {
+		summaries, err := lister.listSummaryComments(ctx)
+		if err != nil {
+			return []error{err}
+		}
+		for _, comment := range summaries {
+			if comment.Author == c.commenterName() {
+				comments = append(comments, comment)
+			}
+		}
+	}
+
+	var errs []error
+	cleaned := map[int64]bool{}
+	for _, comment := range comments {
+		if !hasRunMarker(comment.Body) {
+			continue
+		}
+		if mode == PruneMinimize {
+			if err := c.ghConnector.MinimizeComment(ctx, &comment.NodeID, "OUTDATED"); err != nil {
+				errs = append(errs, fmt.Errorf("minimize existing comment %d: %w", comment.ID, err))
+				continue
+			}
+		} else if err := c.deleteComment(ctx, comment); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		cleaned[comment.ID] = true
+	}
+	c.forgetComments(cleaned)
+	return errs
+}
+
+// hasRunMarker reports whether body carries the marker of a finding or an idempotent batch
+func hasRunMarker(body string) bool {
+	return findingMarkerRegex.MatchString(body) || idempotencyMarkerRegex.MatchString(body)
+}
@@ -0,0 +1,55 @@
+// Package pylint reads the messages of pylint --output-format=json as findings
+package pylint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+)
+
+// Tool names the findings of Parse
+const Tool = "pylint"
+
+type message struct {
+	Type      string `json:"type"`
+	Line      int    `json:"line"`
+	EndLine   *int   `json:"endLine"`
+	Path      string `json:"path"`
+	Symbol    string `json:"symbol"`
+	Message   string `json:"message"`
+	MessageID string `json:"message-id"`
+}
+
+// Parse reads the output of pylint --output-format=json as findings of the message's symbol, such as
+// missing-module-docstring, linked to its documentation. Conventions and refactors are SeverityInfo
+func Parse(r io.Reader) ([]commenter.Finding, error) {
+	var messages []message
+	if err := json.NewDecoder(r).Decode(&messages); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("decode pylint output: %w", err)
+	}
+	var findings []commenter.Finding
+	for _, m := range messages {
+		end := m.Line
+		if m.EndLine != nil && *m.EndLine > end {
+			end = *m.EndLine
+		}
+		severity, _ := commenter.ParseSeverity(m.Type)
+		findings = append(findings, commenter.Finding{
+			Tool:      Tool,
+			RuleID:    m.Symbol,
+			Severity:  severity,
+			Path:      m.Path,
+			StartLine: m.Line,
+			EndLine:   end,
+			Message:   fmt.Sprintf("%s ([%s](%s))", m.Message, m.MessageID, DocsURL(m.Type, m.Symbol)),
+		})
+	}
+	return findings, nil
+}
+
+// DocsURL returns the documentation of the pylint message with symbol of type, such as "convention"
+func DocsURL(messageType, symbol string) string {
+	return fmt.Sprintf("https://pylint.readthedocs.io/en/stable/user_guide/messages/%s/%s.html", messageType, symbol)
+}
@@ -0,0 +1,87 @@
+package commenter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// RateLimitAction is what the commenter does once the primary rate limit budget is used up
+type RateLimitAction int
+
+const (
+	// RateLimitPause waits until the rate limit window resets before making further calls
+	RateLimitPause RateLimitAction = iota
+	// RateLimitFail stops with a RateLimitBudgetError
+	RateLimitFail
+)
+
+// WithRateLimitBudget keeps at least threshold requests of the token's primary rate limit
+// spare for the rest of the pipeline, pausing or failing once the remaining budget drops below it
+func WithRateLimitBudget(threshold int, action RateLimitAction) Option {
+	return func(o *options) {
+		o.rateLimitThreshold = threshold
+		o.rateLimitAction = action
+	}
+}
+
+type rateTracker struct {
+	mu   sync.Mutex
+	rate github.Rate
+}
+
+func (t *rateTracker) update(resp *github.Response) {
+	if resp == nil || resp.Rate.Limit == 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rate = resp.Rate
+}
+
+func (t *rateTracker) last() github.Rate {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.rate
+}
+
+// checkBudget is called before every request and either waits for the reset or fails
+// when the last known remaining budget is below the configured threshold
+func (c *connector) checkBudget(ctx context.Context) error {
+	if c.opts.rateLimitThreshold <= 0 {
+		return nil
+	}
+	rate := c.rate.last()
+	if rate.Limit == 0 || rate.Remaining >= c.opts.rateLimitThreshold {
+		return nil
+	}
+	wait := untilReset(rate.Reset.Time)
+	if wait == 0 {
+		return nil
+	}
+	if c.opts.rateLimitAction == RateLimitFail {
+		return newRateLimitBudgetError(rate.Remaining, c.opts.rateLimitThreshold, rate.Reset.Time)
+	}
+
+	c.opts.logger.Info("pausing until the rate limit resets", "remaining", rate.Remaining, "threshold", c.opts.rateLimitThreshold, "wait", wait)
+	c.opts.metrics.Add(MetricRateLimitWaits, 1)
+	c.opts.metrics.Observe(MetricRateLimitWaitTime, wait)
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// RateLimit returns the primary rate limit as last reported by GitHub, zero for other providers
+func (c *Commenter) RateLimit() github.Rate {
+	if c.ghConnector == nil {
+		return github.Rate{}
+	}
+	return c.ghConnector.rate.last()
+}
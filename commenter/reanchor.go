@@ -0,0 +1,88 @@
+package commenter
+
+import (
+	"context"
+	"strings"
+)
+
+// matchComment picks which of the comments sharing the finding's fingerprint belongs to it: the one made
+// on a line with the same content as the finding's line, preferring the one made on the finding's line
+// when several are, else the one on the same line, else the first. Findings only differing by their
+// line share a fingerprint, so the line keeps them from taking each other's comments
+func (c *Commenter) matchComment(ctx context.Context, comments []*Comment, finding Finding) int {
+	wanted := finding.Comment()
+	text, hasText := c.lineAt(ctx, wanted.FileName, wanted.EndLine)
+	best, bestScore := 0, -1
+	for i, comment := range comments {
+		score := 0
+		if anchor, ok := anchorText(comment); hasText && ok && anchor == text {
+			score += 2
+		}
+		if !comment.Outdated && comment.Line == wanted.EndLine {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// anchorText returns the content of the line an inline comment was made on, the last line of its diff hunk
+func anchorText(comment *Comment) (string, bool) {
+	hunk := strings.TrimRight(comment.DiffHunk, "\n")
+	if hunk == "" {
+		return "", false
+	}
+	last := hunk[strings.LastIndex(hunk, "\n")+1:]
+	if last == "" || last[0] == '@' || last[0] == '-' {
+		return "", false
+	}
+	return last[1:], true
+}
+
+// stale reports whether the comment was made before the head changed and no longer sits on the
+// finding's lines, which GitHub can't follow on its own after a force push
+func (c *Commenter) stale(comment *Comment, finding Finding) bool {
+	// replies follow the comment which started their thread
+	if comment.Path == "" || comment.InReplyTo != 0 || comment.CommitSHA == "" || comment.CommitSHA == c.headSHA() {
+		return false
+	}
+	wanted := finding.Comment()
+	start := comment.StartLine
+	if start == 0 {
+		start = comment.Line
+	}
+	return comment.Outdated || comment.Line != wanted.EndLine || start != wanted.StartLine
+}
+
+// reanchor moves the comment to the finding's lines on the current diff. Review comments can't be moved,
+// so it is written again with the same body and the old one deleted, the discussion on it is lost.
+// Findings no longer inside the diff are left where they were
+func (c *Commenter) reanchor(ctx context.Context, comment *Comment, finding Finding) Result {
+	result := Result{Comment: finding.Comment(), Status: ResultUnchanged, CommentID: comment.ID, URL: comment.URL}
+	anchored, info, _ := c.placement(result.Comment)
+	if info == nil {
+		return result
+	}
+	created, err := c.provider.CreateInlineComment(ctx, InlineComment{
+		Path:      anchored.FileName,
+		StartLine: anchored.StartLine,
+		EndLine:   anchored.EndLine,
+		Body:      comment.Body,
+		CommitSHA: info.sha,
+	})
+	if err != nil {
+		result.Status, result.Err = ResultFailed, err
+		return result
+	}
+	c.rememberComment(created)
+	c.logger().Info("re-anchored comment", "file", comment.Path, "from_line", comment.Line, "to_line", anchored.EndLine, "comment_id", created.ID)
+	result.Status, result.CommentID, result.URL = ResultReanchored, created.ID, created.URL
+	if err := c.deleteComment(ctx, comment); err != nil {
+		result.Err = err
+		return result
+	}
+	c.forgetComments(map[int64]bool{comment.ID: true})
+	return result
+}
@@ -0,0 +1,99 @@
+package commenter
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"time"
+)
+
+const runReportVersion = 1
+
+// RunReport is a machine readable record of what a run wrote to the PR, for archiving as a CI artifact
+// or feeding dashboards
+type RunReport struct {
+	Version int `json:"version"`
+	// PullRequest is owner/repo#number, empty for providers other than GitHub
+	PullRequest string               `json:"pull_request,omitempty"`
+	HeadSHA     string               `json:"head_sha,omitempty"`
+	GeneratedAt time.Time            `json:"generated_at"`
+	Counts      map[ResultStatus]int `json:"counts"`
+	Comments    []RunReportEntry     `json:"comments"`
+}
+
+// RunReportEntry is the outcome of a single comment of the run
+type RunReportEntry struct {
+	Status    ResultStatus `json:"status"`
+	Path      string       `json:"path,omitempty"`
+	StartLine int          `json:"start_line,omitempty"`
+	EndLine   int          `json:"end_line,omitempty"`
+	Body      string       `json:"body"`
+	CommentID int64        `json:"comment_id,omitempty"`
+	URL       string       `json:"url,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	// Finding is the finding the comment was written for, if any
+	Finding *RunReportFinding `json:"finding,omitempty"`
+}
+
+// RunReportFinding is the finding behind a RunReportEntry
+type RunReportFinding struct {
+	Fingerprint string `json:"fingerprint"`
+	Tool        string `json:"tool,omitempty"`
+	RuleID      string `json:"rule_id,omitempty"`
+	Severity    string `json:"severity"`
+	Path        string `json:"path"`
+	StartLine   int    `json:"start_line,omitempty"`
+	EndLine     int    `json:"end_line,omitempty"`
+	Message     string `json:"message"`
+}
+
+// NewRunReport records the results of a run. findings are the ones the results of WriteFindings or
+// Sync line up with, nil for WriteComments. Results past the end of findings, such as the Resolved
+// ones of a SyncResult appended to its Results, have no finding
+func (c *Commenter) NewRunReport(results []Result, findings []Finding) *RunReport {
+	report := &RunReport{
+		Version:     runReportVersion,
+		PullRequest: c.target(),
+		HeadSHA:     c.headSHA(),
+		GeneratedAt: time.Now().UTC(),
+		Counts:      map[ResultStatus]int{},
+		Comments:    make([]RunReportEntry, 0, len(results)),
+	}
+	for i, result := range results {
+		entry := RunReportEntry{
+			Status:    result.Status,
+			Path:      result.Comment.FileName,
+			StartLine: result.Comment.StartLine,
+			EndLine:   result.Comment.EndLine,
+			Body:      result.Comment.Body,
+			CommentID: result.CommentID,
+			URL:       result.URL,
+		}
+		if result.Err != nil {
+			entry.Error = result.Err.Error()
+		}
+		if i < len(findings) {
+			finding := findings[i]
+			entry.Finding = &RunReportFinding{
+				Fingerprint: Fingerprint(finding),
+				Tool:        finding.Tool,
+				RuleID:      finding.RuleID,
+				Severity:    finding.Severity.String(),
+				Path:        filepath.ToSlash(finding.Path),
+				StartLine:   finding.StartLine,
+				EndLine:     finding.EndLine,
+				Message:     finding.Message,
+			}
+		}
+		report.Counts[result.Status]++
+		report.Comments = append(report.Comments, entry)
+	}
+	return report
+}
+
+// WriteJSON writes the report to w as indented JSON
+func (r *RunReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
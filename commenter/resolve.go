@@ -0,0 +1,74 @@
+package commenter
+
+import (
+	"context"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// NewCommenterForCommit creates a Commenter for the open PR containing commit sha, for push triggered
+// jobs which aren't told the PR number. A NoOpenPRError is returned when there is none, unless
+// WithCommitCommentFallback is set
+func NewCommenterForCommit(token, owner, repo, sha string, opts ...Option) (*Commenter, error) {
+	return NewCommenterForCommitContext(context.Background(), token, owner, repo, sha, opts...)
+}
+
+// NewCommenterForCommitContext is NewCommenterForCommit using ctx for the API calls
+func NewCommenterForCommitContext(ctx context.Context, token, owner, repo, sha string, opts ...Option) (*Commenter, error) {
+	return newCommenterForRef(ctx, token, owner, repo, sha, true, opts, func(prs PullRequestsAPI) ([]*github.PullRequest, *github.Response, error) {
+		return prs.ListPullRequestsWithCommit(ctx, owner, repo, sha, &github.PullRequestListOptions{State: "open"})
+	})
+}
+
+// NewCommenterForBranch creates a Commenter for the open PR from branch of owner/repo, a NoOpenPRError
+// is returned when there is none
+func NewCommenterForBranch(token, owner, repo, branch string, opts ...Option) (*Commenter, error) {
+	return NewCommenterForBranchContext(context.Background(), token, owner, repo, branch, opts...)
+}
+
+// NewCommenterForBranchContext is NewCommenterForBranch using ctx for the API calls
+func NewCommenterForBranchContext(ctx context.Context, token, owner, repo, branch string, opts ...Option) (*Commenter, error) {
+	return newCommenterForRef(ctx, token, owner, repo, branch, false, opts, func(prs PullRequestsAPI) ([]*github.PullRequest, *github.Response, error) {
+		return prs.List(ctx, owner, repo, &github.PullRequestListOptions{State: "open", Head: owner + ":" + branch})
+	})
+}
+
+func newCommenterForRef(ctx context.Context, token, owner, repo, ref string, isCommit bool, opts []Option, list func(PullRequestsAPI) ([]*github.PullRequest, *github.Response, error)) (*Commenter, error) {
+	o := newOptions(opts)
+	client := o.client
+	if client == nil && o.pullRequests == nil {
+		var err error
+		if client, err = newGithubClient(token, o); err != nil {
+			return nil, err
+		}
+		// reuse the client for the commenter rather than building a second one
+		opts = append(opts, WithClient(client))
+	}
+	lookup := &connector{client: client, owner: owner, repo: repo, opts: o, rate: &rateTracker{}}
+	lookup.prs = o.pullRequests
+	if lookup.prs == nil {
+		lookup.prs = client.PullRequests
+	}
+
+	var prs []*github.PullRequest
+	err := lookup.withRetry(ctx, "PullRequests.List", func() (*github.Response, error) {
+		var (
+			resp *github.Response
+			err  error
+		)
+		prs, resp, err = list(lookup.prs)
+		return resp, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, pr := range prs {
+		if pr.GetState() == "open" {
+			return NewCommenterContext(ctx, token, owner, repo, pr.GetNumber(), opts...)
+		}
+	}
+	if o.commitFallback && isCommit {
+		return NewCommitCommenterContext(ctx, token, owner, repo, ref, opts...)
+	}
+	return nil, newNoOpenPRError(owner, repo, ref)
+}
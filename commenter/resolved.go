@@ -0,0 +1,244 @@
+package commenter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// ResolvedMode controls what Sync does to the comment of a finding which is no longer reported
+type ResolvedMode int
+
+const (
+	// ResolvedKeep leaves the comments of fixed findings alone, it is the default
+	ResolvedKeep ResolvedMode = iota
+	// ResolvedDelete deletes the comments of fixed findings
+	ResolvedDelete
+	// ResolvedMinimize hides the comments of fixed findings as RESOLVED, keeping the discussion. On
+	// providers implementing ThreadResolver their threads are resolved instead
+	ResolvedMinimize
+	// ResolvedReply replies "✅ fixed in <sha>" to the comments of fixed findings and resolves their threads
+	ResolvedReply
+)
+
+const reviewThreadIDsQuery = `query($owner: String!, $repo: String!, $number: Int!, $cursor: String) {
+  repository(owner: $owner, name: $repo) {
+    pullRequest(number: $number) {
+      reviewThreads(first: 100, after: $cursor) {
+        pageInfo { hasNextPage endCursor }
+        nodes {
+          id
+          isResolved
+          comments(first: 1) { nodes { databaseId } }
+        }
+      }
+    }
+  }
+}`
+
+const resolveReviewThreadMutation = `mutation($id: ID!) {
+  resolveReviewThread(input: {threadId: $id}) {
+    thread { isResolved }
+  }
+}`
+
+type reviewThreadIDsData struct {
+	Repository struct {
+		PullRequest *struct {
+			ReviewThreads struct {
+				PageInfo struct {
+					HasNextPage bool   `json:"hasNextPage"`
+					EndCursor   string `json:"endCursor"`
+				} `json:"pageInfo"`
+				Nodes []struct {
+					ID         string `json:"id"`
+					IsResolved bool   `json:"isResolved"`
+					Comments   struct {
+						Nodes []struct {
+							DatabaseID int64 `json:"databaseId"`
+						} `json:"nodes"`
+					} `json:"comments"`
+				} `json:"nodes"`
+			} `json:"reviewThreads"`
+		} `json:"pullRequest"`
+	} `json:"repository"`
+}
+
+// WithResolvedFindings sets what Sync does to the comments of findings which were fixed since they were
+// reported, ResolvedMinimize is only supported on GitHub and ThreadResolver providers, ResolvedReply only
+// on GitHub
+func WithResolvedFindings(mode ResolvedMode) Option {
+	return func(o *options) {
+		o.resolvedMode = mode
+	}
+}
+
+// resolve applies WithResolvedFindings to the comments no finding of the run matched
+func (c *Commenter) resolve(ctx context.Context, comments []*Comment) ([]Result, error) {
+	mode := c.opts.resolvedMode
+	if mode == ResolvedKeep || len(comments) == 0 {
+		return nil, nil
+	}
+	if mode != ResolvedDelete && mode != ResolvedMinimize && mode != ResolvedReply {
+		return nil, fmt.Errorf("resolved mode %d is not supported", mode)
+	}
+	resolver, canResolve := c.provider.(ThreadResolver)
+	if mode != ResolvedDelete && c.ghConnector == nil && (mode != ResolvedMinimize || !canResolve) {
+		return nil, fmt.Errorf("resolved mode %d: %w", mode, ErrNotSupported)
+	}
+
+	var (
+		replies map[int64][]*Comment
+		threads map[int64]string
+	)
+	if mode == ResolvedReply {
+		var err error
+		if replies, err = c.listReplies(ctx); err != nil {
+			return nil, err
+		}
+		if threads, err = c.ghConnector.unresolvedThreads(ctx); err != nil {
+			return nil, fmt.Errorf("list review threads: %w", err)
+		}
+	}
+
+	sha := c.headSHA()
+	results := make([]Result, 0, len(comments))
+	deleted := map[int64]bool{}
+	for _, comment := range comments {
+		result := Result{
+			Comment:   PRReviewComment{FileName: comment.Path, StartLine: comment.StartLine, EndLine: comment.Line, Body: comment.Body},
+			Status:    ResultResolved,
+			CommentID: comment.ID,
+			URL:       comment.URL,
+		}
+		var err error
+		switch mode {
+		case ResolvedDelete:
+			if err = c.deleteComment(ctx, comment); err == nil {
+				deleted[comment.ID] = true
+			}
+		case ResolvedMinimize:
+			if c.ghConnector == nil {
+				err = resolver.ResolveThread(ctx, comment)
+			} else if err = c.ghConnector.MinimizeComment(ctx, &comment.NodeID, "RESOLVED"); err != nil {
+				err = fmt.Errorf("minimize existing comment %d: %w", comment.ID, err)
+			}
+		case ResolvedReply:
+			err = c.replyFixed(ctx, comment, replies[comment.ID], threads, sha)
+		}
+		if err != nil {
+			result.Status, result.Err = ResultFailed, err
+		}
+		c.logger().Info("resolved finding", "file", comment.Path, "line", comment.Line, "comment_id", comment.ID, "status", result.Status)
+		results = append(results, result)
+	}
+	c.forgetComments(deleted)
+	return results, newBatchError(results)
+}
+
+// replyFixed replies to the comment unless an earlier Sync already did, then resolves its thread
+func (c *Commenter) replyFixed(ctx context.Context, comment *Comment, replies []*Comment, threads map[int64]string, sha string) error {
+	if comment.Path == "" {
+		return fmt.Errorf("reply to summary comment %d: %w", comment.ID, ErrNotSupported)
+	}
+	replied := false
+	for _, reply := range replies {
+		if reply.Author == c.commenterName() && strings.HasPrefix(reply.Body, c.textPrefix(MessageFixed)) {
+			replied = true
+		}
+	}
+	if !replied {
+		reply, err := c.ghConnector.ReplyToComment(ctx, comment.ID, c.text(MessageFixed, sha))
+		if err != nil {
+			return err
+		}
+		c.rememberComment(reply)
+	}
+	if thread, ok := threads[comment.ID]; ok {
+		if err := c.ghConnector.graphql(ctx, resolveReviewThreadMutation, map[string]interface{}{"id": thread}, nil); err != nil {
+			return fmt.Errorf("resolve thread of comment %d: %w", comment.ID, err)
+		}
+	}
+	return nil
+}
+
+// listReplies returns the replies to each comment from every author, keyed by the id of the comment
+func (c *Commenter) listReplies(ctx context.Context) (map[int64][]*Comment, error) {
+	comments, err := c.provider.ListComments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list replies: %w", err)
+	}
+	replies := map[int64][]*Comment{}
+	for _, comment := range comments {
+		if comment.InReplyTo != 0 {
+			replies[comment.InReplyTo] = append(replies[comment.InReplyTo], comment)
+		}
+	}
+	return replies, nil
+}
+
+// ReplyToComment adds body to the thread of the review comment id
+func (c *connector) ReplyToComment(ctx context.Context, id int64, body string) (*Comment, error) {
+	var created *github.PullRequestComment
+	err := c.withRetry(ctx, "PullRequests.CreateComment", func() (*github.Response, error) {
+		var (
+			resp *github.Response
+			err  error
+		)
+		created, resp, err = c.prs.CreateComment(ctx, c.owner, c.repo, c.prNumber, &github.PullRequestComment{
+			Body:      &body,
+			InReplyTo: &id,
+		})
+		return resp, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reply to comment %d: %w", id, err)
+	}
+	c.opts.metrics.Add(MetricCommentsCreated, 1)
+	return &Comment{
+		ID:        created.GetID(),
+		NodeID:    created.GetNodeID(),
+		Path:      created.GetPath(),
+		Line:      created.GetLine(),
+		Body:      body,
+		Author:    created.GetUser().GetLogin(),
+		URL:       created.GetHTMLURL(),
+		InReplyTo: id,
+	}, nil
+}
+
+// unresolvedThreads returns the GraphQL ids of the PR's unresolved review threads, keyed by the id of
+// the comment which started each
+func (c *connector) unresolvedThreads(ctx context.Context) (map[int64]string, error) {
+	threads := map[int64]string{}
+	cursor := ""
+	for {
+		variables := map[string]interface{}{
+			"owner":  c.owner,
+			"repo":   c.repo,
+			"number": c.prNumber,
+		}
+		if cursor != "" {
+			variables["cursor"] = cursor
+		}
+		var data reviewThreadIDsData
+		if err := c.graphql(ctx, reviewThreadIDsQuery, variables, &data); err != nil {
+			return nil, err
+		}
+		pr := data.Repository.PullRequest
+		if pr == nil {
+			return threads, nil
+		}
+		for _, thread := range pr.ReviewThreads.Nodes {
+			if !thread.IsResolved && len(thread.Comments.Nodes) > 0 {
+				threads[thread.Comments.Nodes[0].DatabaseID] = thread.ID
+			}
+		}
+		if !pr.ReviewThreads.PageInfo.HasNextPage {
+			return threads, nil
+		}
+		cursor = pr.ReviewThreads.PageInfo.EndCursor
+	}
+}
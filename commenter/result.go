@@ -0,0 +1,66 @@
+package commenter
+
+import "sync"
+
+// ResultStatus describes what happened to a comment during a batch operation
+type ResultStatus string
+
+const (
+	ResultCreated ResultStatus = "created"
+	ResultSkipped ResultStatus = "skipped"
+	ResultFailed  ResultStatus = "failed"
+	// ResultSummarized comments couldn't be inline and were mentioned in a summary comment instead
+	ResultSummarized ResultStatus = "summarized"
+	// ResultSuppressed findings were deliberately not posted, such as those in the baseline
+	ResultSuppressed ResultStatus = "suppressed"
+	// ResultUnchanged comments were already written by an earlier Sync, or an earlier attempt with the
+	// same WithIdempotencyKey, and were left as is
+	ResultUnchanged ResultStatus = "unchanged"
+	// ResultResolved comments belonged to a finding the latest Sync no longer reported
+	ResultResolved ResultStatus = "resolved"
+	// ResultReanchored comments were moved to their finding's lines after the head of the PR changed
+	ResultReanchored ResultStatus = "reanchored"
+	// ResultPruned comments had left the diff and were deleted or minimized, see WithPruneOutdated
+	ResultPruned ResultStatus = "pruned"
+)
+
+// Result is the outcome of writing a single comment in a batch operation
+type Result struct {
+	Comment PRReviewComment
+	Status  ResultStatus
+	// CommentID and URL identify the comment on GitHub once it has been created
+	CommentID int64
+	URL       string
+	// Err is why the comment was skipped or failed
+	Err error
+}
+
+// ProgressFunc is told how many of the total comments have been handled after each one completes
+type ProgressFunc func(done, total int, last Result)
+
+// WithProgress registers fn to be called as batch operations proceed, calls are never concurrent
+func WithProgress(fn ProgressFunc) Option {
+	return func(o *options) {
+		o.progress = fn
+	}
+}
+
+type progressReporter struct {
+	mu    sync.Mutex
+	fn    ProgressFunc
+	done  int
+	total int
+}
+
+func newProgressReporter(fn ProgressFunc, total int) *progressReporter {
+	return &progressReporter{fn: fn, total: total}
+}
+
+func (p *progressReporter) report(result Result) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	if p.fn != nil {
+		p.fn(p.done, p.total, result)
+	}
+}
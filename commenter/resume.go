@@ -0,0 +1,196 @@
+package commenter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const resumeStateVersion = 1
+
+// ResumeState records the comments a run has written and deleted, so a job interrupted part way
+// through a large batch can be run again without writing or deleting them a second time
+type ResumeState struct {
+	mu      sync.Mutex
+	posted  map[string]ResumeEntry
+	deleted map[int64]bool
+	// path is where OpenResumeFile states are saved after every change, "" for other states
+	path string
+}
+
+// ResumeEntry is a comment written by an earlier run, keyed by the fingerprint of the comment
+type ResumeEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	CommentID   int64  `json:"comment_id"`
+	URL         string `json:"url,omitempty"`
+}
+
+type resumeFile struct {
+	Version int           `json:"version"`
+	Posted  []ResumeEntry `json:"posted"`
+	Deleted []int64       `json:"deleted"`
+}
+
+// WithResumeState makes WriteComments and WriteFindings skip the comments recorded in state as
+// ResultUnchanged, and the deletions of Sync, PruneOutdatedComments and WritePRReview skip those
+// recorded as deleted. Everything the commenter writes or deletes from then on is recorded in state
+func WithResumeState(state *ResumeState) Option {
+	return func(o *options) {
+		o.resumeState = state
+	}
+}
+
+// NewResumeState creates an empty state
+func NewResumeState() *ResumeState {
+	return &ResumeState{posted: map[string]ResumeEntry{}, deleted: map[int64]bool{}}
+}
+
+// ReadResumeState reads a state written by Write
+func ReadResumeState(r io.Reader) (*ResumeState, error) {
+	var file resumeFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("decode resume state: %w", err)
+	}
+	if file.Version != resumeStateVersion {
+		return nil, fmt.Errorf("unsupported resume state version %d", file.Version)
+	}
+	s := NewResumeState()
+	for _, entry := range file.Posted {
+		s.posted[entry.Fingerprint] = entry
+	}
+	for _, id := range file.Deleted {
+		s.deleted[id] = true
+	}
+	return s, nil
+}
+
+// OpenResumeFile reads the state saved at path, or starts an empty one when there is no file yet. The
+// state is saved back to path after every comment written or deleted, so it survives the job being killed
+func OpenResumeFile(path string) (*ResumeState, error) {
+	s := NewResumeState()
+	f, err := os.Open(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, fmt.Errorf("open resume state: %w", err)
+	default:
+		defer f.Close()
+		if s, err = ReadResumeState(f); err != nil {
+			return nil, err
+		}
+	}
+	s.path = path
+	return s, nil
+}
+
+// Write writes the state to w as JSON
+func (s *ResumeState) Write(w io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.write(w)
+}
+
+func (s *ResumeState) write(w io.Writer) error {
+	file := resumeFile{Version: resumeStateVersion, Posted: []ResumeEntry{}, Deleted: []int64{}}
+	for _, entry := range s.posted {
+		file.Posted = append(file.Posted, entry)
+	}
+	sort.Slice(file.Posted, func(i, j int) bool { return file.Posted[i].Fingerprint < file.Posted[j].Fingerprint })
+	for id := range s.deleted {
+		file.Deleted = append(file.Deleted, id)
+	}
+	sort.Slice(file.Deleted, func(i, j int) bool { return file.Deleted[i] < file.Deleted[j] })
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(file)
+}
+
+// save writes the state to its OpenResumeFile path through a temporary file, so an interrupted save
+// keeps the previous state. It is called with mu held
+func (s *ResumeState) save() error {
+	if s.path == "" {
+		return nil
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("save resume state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := s.write(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("save resume state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("save resume state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("save resume state: %w", err)
+	}
+	return nil
+}
+
+// postedAs returns the entry of the comment with fingerprint written by an earlier run
+func (s *ResumeState) postedAs(fingerprint string) (ResumeEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.posted[fingerprint]
+	return entry, ok
+}
+
+func (s *ResumeState) recordPosted(entry ResumeEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.posted[entry.Fingerprint] = entry
+	return s.save()
+}
+
+func (s *ResumeState) wasDeleted(id int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.deleted[id]
+}
+
+func (s *ResumeState) recordDeleted(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleted[id] = true
+	return s.save()
+}
+
+// commentKeys fingerprints the comments of a batch by their file, lines and body, numbering identical
+// comments apart so each of them is written once
+type commentKeys map[string]int
+
+func (k commentKeys) key(comment PRReviewComment) string {
+	identity := strings.Join([]string{comment.FileName, strconv.Itoa(comment.StartLine), strconv.Itoa(comment.EndLine), comment.Body}, "\x00")
+	k[identity]++
+	sum := sha256.Sum256([]byte(identity + "\x00" + strconv.Itoa(k[identity])))
+	return hex.EncodeToString(sum[:16])
+}
+
+// deleteComment deletes the comment unless WithResumeState records it was already, recording it if so
+func (c *Commenter) deleteComment(ctx context.Context, comment *Comment) error {
+	state := c.opts.resumeState
+	if state != nil && state.wasDeleted(comment.ID) {
+		return nil
+	}
+	if err := c.provider.DeleteComment(ctx, comment); err != nil {
+		return err
+	}
+	if state != nil {
+		if err := state.recordDeleted(comment.ID); err != nil {
+			c.logger().Info("could not save the resume state", "error", err)
+		}
+	}
+	return nil
+}
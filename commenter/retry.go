@@ -0,0 +1,298 @@
+package commenter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/go-github/v38/github"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// GitHub asks for at least a minute between retries when a secondary limit gives no Retry-After
+const defaultSecondaryRateLimitWait = time.Minute
+
+// RetryAttempt describes a failed call which may be retried
+type RetryAttempt struct {
+	// Attempt is the number of calls made so far, starting at 1
+	Attempt int
+	// Elapsed is the time since the first call was made
+	Elapsed time.Duration
+	// Err is the error returned by the last call
+	Err error
+	// RetryAfter is the wait GitHub instructed, zero when it gave no instruction
+	RetryAfter time.Duration
+}
+
+// RetryPolicy decides whether a failed call is retried and how long to wait beforehand
+type RetryPolicy interface {
+	NextDelay(attempt RetryAttempt) (time.Duration, bool)
+}
+
+// BackoffPolicy is an exponential backoff RetryPolicy with jitter, bounded by attempts and elapsed time
+type BackoffPolicy struct {
+	// MaxAttempts is the total number of calls including the first, zero means unlimited
+	MaxAttempts int
+	// MaxElapsed stops retrying once the next wait would exceed it, zero means unlimited
+	MaxElapsed time.Duration
+	// InitialInterval is the wait after the first failure
+	InitialInterval time.Duration
+	// MaxInterval caps the computed wait, zero means uncapped
+	MaxInterval time.Duration
+	// Multiplier grows the wait after every failure
+	Multiplier float64
+	// Jitter randomises the computed wait by up to this fraction in either direction
+	Jitter float64
+}
+
+// DefaultRetryPolicy returns the policy used when none is configured
+func DefaultRetryPolicy() *BackoffPolicy {
+	return &BackoffPolicy{
+		MaxAttempts:     6,
+		MaxElapsed:      10 * time.Minute,
+		InitialInterval: time.Second,
+		MaxInterval:     30 * time.Second,
+		Multiplier:      2,
+		Jitter:          0.2,
+	}
+}
+
+// NextDelay waits exactly as long as GitHub instructed, otherwise backs off exponentially
+func (p *BackoffPolicy) NextDelay(attempt RetryAttempt) (time.Duration, bool) {
+	if p.MaxAttempts > 0 && attempt.Attempt >= p.MaxAttempts {
+		return 0, false
+	}
+
+	delay := attempt.RetryAfter
+	if delay == 0 {
+		delay = p.backoff(attempt.Attempt)
+	}
+	if p.MaxElapsed > 0 && attempt.Elapsed+delay > p.MaxElapsed {
+		return 0, false
+	}
+	return delay, true
+}
+
+func (p *BackoffPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(p.InitialInterval) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxInterval > 0 && delay > float64(p.MaxInterval) {
+		delay = float64(p.MaxInterval)
+	}
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(delay)
+}
+
+// WithRetryPolicy replaces the DefaultRetryPolicy used for rate limited and transient failures
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *options) {
+		if policy != nil {
+			o.retryPolicy = policy
+		}
+	}
+}
+
+// withRetry runs the named call until it succeeds, asking the retry policy how long to wait whenever
+// a rate limit, or a transient 5xx or network error isTransient accepts, is hit. Any other error is
+// returned immediately
+func (c *connector) withRetry(ctx context.Context, name string, call func() (*github.Response, error)) (err error) {
+
+	ctx, span := c.opts.tracer.Start(ctx, "github."+name)
+	attempts := 0
+	defer func() {
+		span.SetAttributes(attribute.Int("commenter.attempts", attempts))
+		if err != nil {
+			recordSpanError(span, err)
+		}
+		span.End()
+	}()
+
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		attempts = attempt
+		if err := c.checkBudget(ctx); err != nil {
+			return err
+		}
+		if c.opts.limiter != nil {
+			if err := c.opts.limiter.Wait(ctx); err != nil {
+				return err
+			}
+		}
+		resp, err := call()
+		c.rate.update(resp)
+		c.opts.audit.record(name, resp, err)
+		if err == nil {
+			return nil
+		}
+
+		retryAfter, limited := rateLimitWait(err)
+		if !limited && !isTransient(ctx, err) {
+			return wrapAPIError(err)
+		}
+
+		wait, retry := c.opts.retryPolicy.NextDelay(RetryAttempt{
+			Attempt:    attempt,
+			Elapsed:    time.Since(start),
+			Err:        err,
+			RetryAfter: retryAfter,
+		})
+		if !retry {
+			if reset, ok := primaryRateLimitReset(err); ok && limited {
+				return newPrimaryRateLimitError(c.owner, c.repo, c.prNumber, reset, err)
+			}
+			if limited {
+				return newAbuseRateLimitError(c.owner, c.repo, c.prNumber, int(retryAfter.Seconds()), err)
+			}
+			return wrapAPIError(err)
+		}
+
+		c.opts.logger.Info("retrying github api call", "call", name, "attempt", attempt, "wait", wait, "rate_limited", limited, "error", err)
+		span.AddEvent("retry", trace.WithAttributes(
+			attribute.Int("commenter.attempt", attempt),
+			attribute.Int64("commenter.wait_ms", wait.Milliseconds()),
+			attribute.Bool("commenter.rate_limited", limited),
+			attribute.String("commenter.error", err.Error()),
+		))
+		c.opts.metrics.Add(MetricRetries, 1)
+		if limited {
+			c.opts.metrics.Add(MetricRateLimitWaits, 1)
+			c.opts.metrics.Observe(MetricRateLimitWaitTime, wait)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// isTransient reports whether err is a server side hiccup or a network failure worth retrying. Only
+// idempotent calls are retried unless the request provably never reached GitHub, so a timed out
+// POST doesn't write a second comment or review
+func isTransient(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var errResp *github.ErrorResponse
+	if errors.As(err, &errResp) && errResp.Response != nil {
+		switch errResp.Response.StatusCode {
+		case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return errResp.Response.Request != nil && isIdempotent(errResp.Response.Request.Method)
+		}
+		return false
+	}
+
+	var opErr *net.OpError
+	if (errors.As(err, &opErr) && opErr.Op == "dial") || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) || !isIdempotent(strings.ToUpper(urlErr.Op)) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+// isIdempotent reports whether repeating a request with method has the same effect as making it once
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// rateLimitWait reports whether err is a rate limit error and how long GitHub wants us to wait
+func rateLimitWait(err error) (time.Duration, bool) {
+
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		if abuseErr.RetryAfter != nil {
+			return *abuseErr.RetryAfter, true
+		}
+		return defaultSecondaryRateLimitWait, true
+	}
+
+	var rateErr *github.RateLimitError
+	if errors.As(err, &rateErr) {
+		return untilReset(rateErr.Rate.Reset.Time), true
+	}
+
+	// go-github only recognises the legacy abuse documentation url, secondary rate limits
+	// documented under the newer url arrive as plain 403/429 error responses
+	var errResp *github.ErrorResponse
+	if errors.As(err, &errResp) && errResp.Response != nil {
+		status := errResp.Response.StatusCode
+		if status != http.StatusForbidden && status != http.StatusTooManyRequests {
+			return 0, false
+		}
+		header := errResp.Response.Header
+		if v := header.Get("Retry-After"); v != "" {
+			if seconds, err := strconv.Atoi(v); err == nil {
+				return time.Duration(seconds) * time.Second, true
+			}
+		}
+		if header.Get("X-RateLimit-Remaining") == "0" {
+			if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+				return untilReset(time.Unix(reset, 0)), true
+			}
+		}
+		if strings.Contains(errResp.DocumentationURL, "secondary-rate-limits") {
+			return defaultSecondaryRateLimitWait, true
+		}
+	}
+	return 0, false
+}
+
+// primaryRateLimitReset returns when the primary rate limit err hit resets, it reports false for
+// secondary rate limits and other errors
+func primaryRateLimitReset(err error) (time.Time, bool) {
+	var rateErr *github.RateLimitError
+	if errors.As(err, &rateErr) {
+		return rateErr.Rate.Reset.Time, true
+	}
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return time.Time{}, false
+	}
+	header := errResp.Response.Header
+	if header.Get("X-RateLimit-Remaining") != "0" {
+		return time.Time{}, false
+	}
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(reset, 0), true
+}
+
+func untilReset(reset time.Time) time.Duration {
+	if wait := time.Until(reset); wait > 0 {
+		return wait
+	}
+	return 0
+}
@@ -0,0 +1,105 @@
+package commenter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var errReviewFinished = errors.New("the review was already submitted or discarded")
+
+// ReviewBuilder collects the comments of a single review which is only written to the PR by Submit,
+// so a caller can add comments as it goes and Discard them all when it fails part way
+type ReviewBuilder struct {
+	c        *Commenter
+	mu       sync.Mutex
+	comments []PRReviewComment
+	finished bool
+}
+
+// StartReview starts a review of the PR, loading the PR info to check comments against as they are added
+func (c *Commenter) StartReview() (*ReviewBuilder, error) {
+	return c.StartReviewContext(context.Background())
+}
+
+// StartReviewContext is StartReview using ctx for the API calls
+func (c *Commenter) StartReviewContext(ctx context.Context) (*ReviewBuilder, error) {
+	if err := c.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+	return &ReviewBuilder{c: c}, nil
+}
+
+// AddLineComment adds a comment on lines startLine to endLine of file, a CommentNotValidError is
+// returned when they can't be commented on
+func (b *ReviewBuilder) AddLineComment(file string, startLine, endLine int, body string) error {
+	return b.add(PRReviewComment{FileName: file, StartLine: startLine, EndLine: endLine, Body: body})
+}
+
+// AddSuggestion adds a comment proposing replacement for lines startLine to endLine of file, which
+// the PR author can apply from the GitHub UI. message is shown above the suggestion and may be empty
+func (b *ReviewBuilder) AddSuggestion(file string, startLine, endLine int, replacement, message string) error {
+	body := fmt.Sprintf("```suggestion\n%s\n```", replacement)
+	if message != "" {
+		body = message + "\n\n" + body
+	}
+	return b.add(PRReviewComment{FileName: file, StartLine: startLine, EndLine: endLine, Body: body})
+}
+
+func (b *ReviewBuilder) add(comment PRReviewComment) error {
+	comment.FileName = b.c.normalizePath(comment.FileName)
+	if comment.StartLine == 0 {
+		comment.StartLine = comment.EndLine
+	}
+	if !b.c.pathAllowed(comment.FileName) {
+		return fmt.Errorf("%s is excluded by the path filters: %w", comment.FileName, ErrSuppressed)
+	}
+	if _, info, mention := b.c.placement(comment); info == nil && !mention {
+		return newCommentNotValidError(comment.FileName, comment.StartLine)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.finished {
+		return errReviewFinished
+	}
+	b.comments = append(b.comments, comment)
+	return nil
+}
+
+// Len returns how many comments have been added
+func (b *ReviewBuilder) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.comments)
+}
+
+// Submit writes the comments as one review with event, as WritePRReview does. The builder can't be
+// used afterwards, even when writing the review fails
+func (b *ReviewBuilder) Submit(event string) error {
+	return b.SubmitContext(context.Background(), event)
+}
+
+// SubmitContext is Submit using ctx for the API calls
+func (b *ReviewBuilder) SubmitContext(ctx context.Context, event string) error {
+	b.mu.Lock()
+	if b.finished {
+		b.mu.Unlock()
+		return errReviewFinished
+	}
+	b.finished = true
+	comments := b.comments
+	b.comments = nil
+	b.mu.Unlock()
+
+	return b.c.WritePRReviewContext(ctx, b.c.CreateDraftPRReviewComments(comments), event)
+}
+
+// Discard drops the added comments without writing anything to the PR, it is safe to call after Submit
+func (b *ReviewBuilder) Discard() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.finished = true
+	b.comments = nil
+}
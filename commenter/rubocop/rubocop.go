@@ -0,0 +1,77 @@
+// Package rubocop reads the offenses of rubocop --format json as findings
+package rubocop
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+)
+
+// Tool names the findings of Parse
+const Tool = "rubocop"
+
+// departments are the departments of the cops documented on docs.rubocop.org with RuboCop itself,
+// the cops of plugins such as rubocop-rails are documented on their own sites
+var departments = map[string]bool{
+	"Bundler": true, "Gemspec": true, "Layout": true, "Lint": true, "Metrics": true,
+	"Migration": true, "Naming": true, "Security": true, "Style": true,
+}
+
+type offense struct {
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	CopName  string `json:"cop_name"`
+	Location struct {
+		StartLine int `json:"start_line"`
+		LastLine  int `json:"last_line"`
+	} `json:"location"`
+}
+
+// Parse reads the output of rubocop --format json as findings of the cop, linked to its
+// documentation for RuboCop's own cops. Conventions and refactors are SeverityInfo
+func Parse(r io.Reader) ([]commenter.Finding, error) {
+	var output struct {
+		Files []struct {
+			Path     string    `json:"path"`
+			Offenses []offense `json:"offenses"`
+		} `json:"files"`
+	}
+	if err := json.NewDecoder(r).Decode(&output); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("decode rubocop output: %w", err)
+	}
+	var findings []commenter.Finding
+	for _, file := range output.Files {
+		for _, o := range file.Offenses {
+			// RuboCop starts the message with the cop's name unless DisplayCopNames is off
+			message := strings.TrimPrefix(o.Message, o.CopName+": ")
+			if url := DocsURL(o.CopName); url != "" {
+				message += fmt.Sprintf(" ([%s](%s))", o.CopName, url)
+			}
+			severity, _ := commenter.ParseSeverity(o.Severity)
+			findings = append(findings, commenter.Finding{
+				Tool:      Tool,
+				RuleID:    o.CopName,
+				Severity:  severity,
+				Path:      file.Path,
+				StartLine: o.Location.StartLine,
+				EndLine:   o.Location.LastLine,
+				Message:   message,
+			})
+		}
+	}
+	return findings, nil
+}
+
+// DocsURL returns the documentation of a cop of RuboCop itself, such as Style/StringLiterals, ""
+// for the cops of plugins
+func DocsURL(cop string) string {
+	slash := strings.Index(cop, "/")
+	if slash < 0 || !departments[cop[:slash]] {
+		return ""
+	}
+	department := strings.ToLower(cop[:slash])
+	return fmt.Sprintf("https://docs.rubocop.org/rubocop/cops_%s.html#%s", department, strings.ToLower(strings.Replace(cop, "/", "", 1)))
+}
@@ -0,0 +1,105 @@
+package commenter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity ranks findings, findings without one are SeverityUnknown
+type Severity int
+
+const (
+	SeverityUnknown Severity = iota
+	SeverityInfo
+	SeverityWarning
+	SeverityError
+	SeverityCritical
+)
+
+// WithMinSeverity only posts findings at or above min inline, lower ones are suppressed. Findings
+// with SeverityUnknown are always posted
+func WithMinSeverity(min Severity) Option {
+	return func(o *options) {
+		o.minSeverity = min
+	}
+}
+
+// WithLowSeveritySummary rolls the findings below WithMinSeverity into one summary comment written
+// by WriteFindings, instead of dropping them
+func WithLowSeveritySummary() Option {
+	return func(o *options) {
+		o.lowSeveritySummary = true
+	}
+}
+
+// WithMentionAuthor @-mentions the PR author on the comments of findings at or above min, so blocking
+// findings notify them directly while the others don't. Only GitHub PRs are mentioned on
+func WithMentionAuthor(min Severity) Option {
+	return func(o *options) {
+		o.mentionAuthor = true
+		o.mentionSeverity = min
+	}
+}
+
+// ParseSeverity reads the severity names used by common scanners, such as "note", "medium" or "high"
+func ParseSeverity(s string) (Severity, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "none", "unknown":
+		return SeverityUnknown, nil
+	case "info", "information", "note", "notice", "low", "style", "convention", "refactor", "hint":
+		return SeverityInfo, nil
+	case "warning", "warn", "medium", "moderate":
+		return SeverityWarning, nil
+	case "error", "high", "failure", "major":
+		return SeverityError, nil
+	case "critical", "fatal", "blocker":
+		return SeverityCritical, nil
+	default:
+		return SeverityUnknown, fmt.Errorf("unknown severity %q", s)
+	}
+}
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+// belowMinSeverity reports whether the finding is filtered by WithMinSeverity
+func (c *Commenter) belowMinSeverity(finding Finding) bool {
+	return finding.Severity != SeverityUnknown && finding.Severity < c.opts.minSeverity
+}
+
+// mentionAuthor adds the WithMentionAuthor mention of the PR author to the comment of the finding
+func (c *Commenter) mentionAuthor(comment PRReviewComment, finding Finding) PRReviewComment {
+	if !c.opts.mentionAuthor || finding.Severity < c.opts.mentionSeverity || c.ghConnector == nil {
+		return comment
+	}
+	author := c.ghConnector.author
+	if author == "" || author == c.commenterName() {
+		return comment
+	}
+	comment.Body += "\n\n" + c.text(MessageMention, author)
+	return comment
+}
+
+// lowSeveritySummary lists the findings below WithMinSeverity for the summary comment
+func (c *Commenter) lowSeveritySummary(findings []Finding) string {
+	var b strings.Builder
+	b.WriteString(c.text(MessageLowSeverity, len(findings)) + "\n")
+	for _, finding := range findings {
+		comment := finding.Comment()
+		location := commentLocation(InlineComment{Path: comment.FileName, StartLine: comment.StartLine, EndLine: comment.EndLine})
+		fmt.Fprintf(&b, "\n- `%s` (%s) %s", location, finding.Severity, strings.ReplaceAll(comment.Body, "\n", " "))
+	}
+	return b.String()
+}
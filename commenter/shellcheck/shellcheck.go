@@ -0,0 +1,165 @@
+// Package shellcheck reads the diagnostics of shellcheck -f json as findings, with ShellCheck's fixes
+// as suggestions
+package shellcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+)
+
+// Tool names the findings of Parse
+const Tool = "shellcheck"
+
+// Source returns the contents of a file ShellCheck checked, ioutil.ReadFile reads them from disk
+type Source func(file string) ([]byte, error)
+
+type replacement struct {
+	Line        int    `json:"line"`
+	EndLine     int    `json:"endLine"`
+	Column      int    `json:"column"`
+	EndColumn   int    `json:"endColumn"`
+	Replacement string `json:"replacement"`
+}
+
+type diagnostic struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	EndLine int    `json:"endLine"`
+	Level   string `json:"level"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Fix     *struct {
+		Replacements []replacement `json:"replacements"`
+	} `json:"fix"`
+}
+
+// Parse reads the output of shellcheck -f json or -f json1 as findings with the SC code linked to
+// its wiki page. The fixes of diagnostics which have one are applied to the file read with source
+// and proposed as a suggestion, a nil source or one failing leaves the suggestion out. Prefer json1,
+// json counts a tab as up to 8 columns so lines with tabs get no suggestions
+func Parse(r io.Reader, source Source) ([]commenter.Finding, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read shellcheck output: %w", err)
+	}
+	var diagnostics []diagnostic
+	json1 := bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+	if json1 {
+		var output struct {
+			Comments []diagnostic `json:"comments"`
+		}
+		err = json.Unmarshal(data, &output)
+		diagnostics = output.Comments
+	} else if len(bytes.TrimSpace(data)) > 0 {
+		err = json.Unmarshal(data, &diagnostics)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode shellcheck output: %w", err)
+	}
+
+	sources := map[string][]string{}
+	var findings []commenter.Finding
+	for _, d := range diagnostics {
+		code := fmt.Sprintf("SC%d", d.Code)
+		finding := commenter.Finding{
+			Tool:      Tool,
+			RuleID:    code,
+			Severity:  severity(d.Level),
+			Path:      d.File,
+			StartLine: d.Line,
+			EndLine:   d.EndLine,
+			Message:   fmt.Sprintf("%s ([%s](%s))", d.Message, code, WikiURL(d.Code)),
+		}
+		if d.Fix != nil && len(d.Fix.Replacements) > 0 && source != nil {
+			lines, ok := sources[d.File]
+			if !ok {
+				if contents, err := source(d.File); err == nil {
+					lines = strings.Split(strings.Replace(string(contents), "\r\n", "\n", -1), "\n")
+				}
+				sources[d.File] = lines
+			}
+			if start, end, suggestion, ok := apply(lines, d.Fix.Replacements, !json1); ok {
+				finding.StartLine, finding.EndLine, finding.Suggestion = start, end, &suggestion
+			}
+		}
+		findings = append(findings, finding)
+	}
+	return findings, nil
+}
+
+// WikiURL returns the ShellCheck wiki page of the check with code, such as 2086 for SC2086
+func WikiURL(code int) string {
+	return fmt.Sprintf("https://www.shellcheck.net/wiki/SC%d", code)
+}
+
+// apply makes the replacements to the lines they are on and returns those lines after them, it
+// fails on replacements outside the lines or overlapping each other, and on lines with tabs when
+// ShellCheck expanded them to compute the columns
+func apply(lines []string, replacements []replacement, tabsExpanded bool) (int, int, string, bool) {
+	start, end := replacements[0].Line, replacements[0].EndLine
+	for _, r := range replacements {
+		if r.Line < start {
+			start = r.Line
+		}
+		if r.EndLine > end {
+			end = r.EndLine
+		}
+	}
+	if start < 1 || end > len(lines) || start > end {
+		return 0, 0, "", false
+	}
+	text := []rune(strings.Join(lines[start-1:end], "\n"))
+	if tabsExpanded && strings.ContainsRune(string(text), '\t') {
+		return 0, 0, "", false
+	}
+	offset := func(line, column int) int {
+		n := 0
+		for _, l := range lines[start-1 : line-1] {
+			n += len([]rune(l)) + 1
+		}
+		return n + column - 1
+	}
+
+	type edit struct {
+		from, to    int
+		replacement string
+	}
+	edits := make([]edit, 0, len(replacements))
+	for _, r := range replacements {
+		e := edit{offset(r.Line, r.Column), offset(r.EndLine, r.EndColumn), r.Replacement}
+		if e.from < 0 || e.from > e.to || e.to > len(text) {
+			return 0, 0, "", false
+		}
+		edits = append(edits, e)
+	}
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].from > edits[j].from })
+	for i := 1; i < len(edits); i++ {
+		if edits[i].to > edits[i-1].from {
+			return 0, 0, "", false
+		}
+	}
+	for _, e := range edits {
+		text = append(text[:e.from], append([]rune(e.replacement), text[e.to:]...)...)
+	}
+	return start, end, string(text), true
+}
+
+func severity(level string) commenter.Severity {
+	switch level {
+	case "error":
+		return commenter.SeverityError
+	case "warning":
+		return commenter.SeverityWarning
+	case "info", "style":
+		return commenter.SeverityInfo
+	default:
+		return commenter.SeverityUnknown
+	}
+}
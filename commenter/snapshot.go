@@ -0,0 +1,190 @@
+package commenter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Snapshot is the PR info a Commenter loaded, the changed files and its own existing comments
+type Snapshot struct {
+	HeadSHA   string
+	Files     []*ChangedFile
+	Comments  []*Comment
+	FetchedAt time.Time
+}
+
+// SnapshotStore stores snapshots keyed by owner/repo, PR number and head SHA, implement it to share
+// snapshots between processes
+type SnapshotStore interface {
+	Get(key string) (*Snapshot, bool)
+	Set(key string, snapshot *Snapshot)
+}
+
+// WithSnapshotCache reuses a snapshot of the PR younger than ttl instead of loading it again, so several
+// tools commenting on the same PR in one CI job only fetch it once. The stored snapshot is updated with
+// the comments the commenter writes and deletes, a review discards it. Refresh always loads from GitHub
+func WithSnapshotCache(store SnapshotStore, ttl time.Duration) Option {
+	return func(o *options) {
+		o.snapshotStore = store
+		o.snapshotTTL = ttl
+	}
+}
+
+// NewMemorySnapshotStore creates a SnapshotStore which lives as long as the process
+func NewMemorySnapshotStore() SnapshotStore {
+	return &memorySnapshotStore{snapshots: map[string]*Snapshot{}}
+}
+
+type memorySnapshotStore struct {
+	mu        sync.Mutex
+	snapshots map[string]*Snapshot
+}
+
+func (m *memorySnapshotStore) Get(key string) (*Snapshot, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot, ok := m.snapshots[key]
+	return snapshot, ok
+}
+
+func (m *memorySnapshotStore) Set(key string, snapshot *Snapshot) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.snapshots[key] = snapshot
+}
+
+// NewFileSnapshotStore creates a SnapshotStore keeping each snapshot as a JSON file in dir, which
+// is shared by every process of the job. Unreadable files are treated as missing
+func NewFileSnapshotStore(dir string) SnapshotStore {
+	return &fileSnapshotStore{dir: dir}
+}
+
+type fileSnapshotStore struct {
+	dir string
+}
+
+func (f *fileSnapshotStore) Get(key string) (*Snapshot, bool) {
+	b, err := ioutil.ReadFile(f.path(key))
+	if err != nil {
+		return nil, false
+	}
+	snapshot := new(Snapshot)
+	if err := json.Unmarshal(b, snapshot); err != nil {
+		return nil, false
+	}
+	return snapshot, true
+}
+
+func (f *fileSnapshotStore) Set(key string, snapshot *Snapshot) {
+	b, err := json.Marshal(snapshot)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(f.dir, 0o755); err != nil {
+		return
+	}
+	// write then rename so concurrent readers never see a partial file
+	tmp, err := ioutil.TempFile(f.dir, ".snapshot-*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		return
+	}
+	_ = os.Rename(tmp.Name(), f.path(key))
+}
+
+func (f *fileSnapshotStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(f.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// snapshotKeyer is implemented by providers which can identify the PR state they serve
+type snapshotKeyer interface {
+	// snapshotKey returns "" when the head SHA isn't known
+	snapshotKey() (key string, headSHA string)
+}
+
+// cachedSnapshot returns the stored snapshot for the provider's PR when caching is on and it is fresh
+func (c *Commenter) cachedSnapshot() (*Snapshot, bool) {
+	key, _ := c.snapshotKey()
+	if key == "" {
+		return nil, false
+	}
+	snapshot, ok := c.opts.snapshotStore.Get(key)
+	if !ok || time.Since(snapshot.FetchedAt) > c.opts.snapshotTTL {
+		return nil, false
+	}
+	return snapshot, true
+}
+
+func (c *Commenter) storeSnapshot(files []*ChangedFile, comments []*Comment) {
+	key, headSHA := c.snapshotKey()
+	if key == "" {
+		return
+	}
+	c.opts.snapshotStore.Set(key, &Snapshot{
+		HeadSHA:   headSHA,
+		Files:     files,
+		Comments:  comments,
+		FetchedAt: time.Now(),
+	})
+}
+
+// snapshotUpdateMu serializes the updates of stored snapshots by the commenters of the process
+var snapshotUpdateMu sync.Mutex
+
+// updateSnapshot rewrites the stored comments after the commenter wrote or removed some, keeping its age
+func (c *Commenter) updateSnapshot(update func([]*Comment) []*Comment) {
+	key, _ := c.snapshotKey()
+	if key == "" {
+		return
+	}
+	snapshotUpdateMu.Lock()
+	defer snapshotUpdateMu.Unlock()
+	if snapshot, ok := c.opts.snapshotStore.Get(key); ok {
+		updated := *snapshot
+		updated.Comments = update(snapshot.Comments)
+		c.opts.snapshotStore.Set(key, &updated)
+	}
+}
+
+// invalidate makes the next call load the PR again, after writes whose comments aren't known
+func (c *Commenter) invalidate() {
+	c.mu.Lock()
+	c.loaded = false
+	c.mu.Unlock()
+	key, _ := c.snapshotKey()
+	if key == "" {
+		return
+	}
+	snapshotUpdateMu.Lock()
+	defer snapshotUpdateMu.Unlock()
+	if snapshot, ok := c.opts.snapshotStore.Get(key); ok {
+		stale := *snapshot
+		stale.FetchedAt = time.Time{}
+		c.opts.snapshotStore.Set(key, &stale)
+	}
+}
+
+func (c *Commenter) snapshotKey() (string, string) {
+	if c.opts.snapshotStore == nil {
+		return "", ""
+	}
+	keyer, ok := c.provider.(snapshotKeyer)
+	if !ok {
+		return "", ""
+	}
+	return keyer.snapshotKey()
+}
@@ -0,0 +1,69 @@
+package commenter
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// stateChanged checks whether the PR was closed, merged or pushed to since it was loaded, returning a
+// PRStateChangedError if so. It is called once GitHub rejects a write, as it does for a PR it has moved past
+func (c *connector) stateChanged(ctx context.Context) error {
+	var pr *github.PullRequest
+	err := c.withRetry(ctx, "PullRequests.Get", func() (*github.Response, error) {
+		var (
+			resp *github.Response
+			err  error
+		)
+		pr, resp, err = c.prs.Get(ctx, c.owner, c.repo, c.prNumber)
+		return resp, err
+	})
+	if err != nil {
+		return nil
+	}
+	switch {
+	case pr.GetMerged():
+		return newPRStateChangedError(c.owner, c.repo, c.prNumber, "merged", c.headSHA, pr.GetHead().GetSHA())
+	case pr.GetState() != "" && pr.GetState() != "open":
+		return newPRStateChangedError(c.owner, c.repo, c.prNumber, pr.GetState(), c.headSHA, pr.GetHead().GetSHA())
+	// a WithReviewCommit commit can still be commented on after later pushes
+	case c.opts.reviewCommit == "" && c.headSHA != "" && pr.GetHead().GetSHA() != "" && pr.GetHead().GetSHA() != c.headSHA:
+		return newPRStateChangedError(c.owner, c.repo, c.prNumber, pr.GetState(), c.headSHA, pr.GetHead().GetSHA())
+	}
+	return nil
+}
+
+// stateWatch stops a batch the first time a rejected write turns out to be due to the PR changing
+type stateWatch struct {
+	gh     *connector
+	cancel context.CancelFunc
+	once   sync.Once
+	mu     sync.Mutex
+	err    error
+}
+
+// observe checks the PR state after the first write GitHub rejected as invalid, cancelling the batch
+// when it changed
+func (w *stateWatch) observe(ctx context.Context, result Result) {
+	var validationErr ValidationError
+	if w.gh == nil || result.Status != ResultFailed || !errors.As(result.Err, &validationErr) {
+		return
+	}
+	w.once.Do(func() {
+		if err := w.gh.stateChanged(ctx); err != nil {
+			w.mu.Lock()
+			w.err = err
+			w.mu.Unlock()
+			w.cancel()
+		}
+	})
+}
+
+// changed returns the PRStateChangedError which stopped the batch, nil while it goes on
+func (w *stateWatch) changed() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
@@ -0,0 +1,89 @@
+// Package staticcheck reads the diagnostics of staticcheck -f json as findings
+package staticcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+)
+
+// Tool names the findings of Parse
+const Tool = "staticcheck"
+
+// checkRegex matches the codes of the checks documented on staticcheck.dev, such as SA4006 or ST1003
+var checkRegex = regexp.MustCompile(`^(SA|S|ST|QF)\d+$`)
+
+type position struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+type diagnostic struct {
+	Code     string   `json:"code"`
+	Severity string   `json:"severity"`
+	Location position `json:"location"`
+	End      position `json:"end"`
+	Message  string   `json:"message"`
+}
+
+// Parse reads the diagnostics staticcheck -f json writes, one JSON object per line, as findings with
+// the check ID linked to its documentation. Diagnostics of ignored checks are left out. The files are
+// as staticcheck reports them, usually absolute, see commenter.WithRepoRoot
+func Parse(r io.Reader) ([]commenter.Finding, error) {
+	var findings []commenter.Finding
+	dec := json.NewDecoder(r)
+	for {
+		var d diagnostic
+		err := dec.Decode(&d)
+		if err == io.EOF {
+			return findings, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode staticcheck output: %w", err)
+		}
+		if d.Severity == "ignored" {
+			continue
+		}
+		end := d.End.Line
+		if end < d.Location.Line {
+			end = d.Location.Line
+		}
+		message := d.Message
+		if url := DocsURL(d.Code); url != "" {
+			message = fmt.Sprintf("%s ([%s](%s))", message, d.Code, url)
+		}
+		findings = append(findings, commenter.Finding{
+			Tool:      Tool,
+			RuleID:    d.Code,
+			Severity:  severity(d.Severity),
+			Path:      d.Location.File,
+			StartLine: d.Location.Line,
+			EndLine:   end,
+			Message:   message,
+		})
+	}
+}
+
+// DocsURL returns the staticcheck.dev documentation of the check with code, "" for codes which
+// aren't checks such as "compile"
+func DocsURL(code string) string {
+	if !checkRegex.MatchString(code) {
+		return ""
+	}
+	return "https://staticcheck.dev/docs/checks/#" + code
+}
+
+func severity(s string) commenter.Severity {
+	switch s {
+	case "error":
+		return commenter.SeverityError
+	case "warning":
+		return commenter.SeverityWarning
+	default:
+		return commenter.SeverityUnknown
+	}
+}
@@ -0,0 +1,189 @@
+package commenter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/google/go-github/v38/github"
+)
+
+const (
+	// maxCommentLength is GitHub's limit on the body of a comment
+	maxCommentLength = 65536
+	// continuationReserve leaves room in each chunk for its continuation header and truncation note
+	continuationReserve       = 128
+	defaultMaxSummaryComments = 5
+	gistPreviewLength         = 2000
+)
+
+// WithMaxSummaryComments sets how many comments a summary too long for one comment may be split into
+func WithMaxSummaryComments(n int) Option {
+	return func(o *options) {
+		o.maxSummaryComments = n
+	}
+}
+
+// WithGistOverflow uploads a summary which doesn't fit in WithMaxSummaryComments comments as a secret
+// Gist and links it from a short summary comment. client needs the gist scope, nil uses the
+// commenter's own client. Without it such summaries are truncated
+func WithGistOverflow(client *github.Client) Option {
+	return func(o *options) {
+		o.gistOverflow = true
+		o.gistClient = client
+	}
+}
+
+// writeSummary writes body as summary comments, split into several when it is too long for one,
+// and returns the first of them
+func (c *Commenter) writeSummary(ctx context.Context, body string) (*Comment, error) {
+	return c.writeSummaryChunks(ctx, body, nil)
+}
+
+// writeSummaryChunks is writeSummary leaving out the chunks existing already has a comment with
+// the same body for
+func (c *Commenter) writeSummaryChunks(ctx context.Context, body string, existing map[string]*Comment) (*Comment, error) {
+	max := c.opts.maxSummaryComments
+	if max < 1 {
+		max = defaultMaxSummaryComments
+	}
+	chunks := chunkBody(body, maxCommentLength-continuationReserve)
+	if len(chunks) > max {
+		if c.opts.gistOverflow {
+			url, err := c.createGist(ctx, body)
+			if err == nil {
+				return c.provider.CreateSummaryComment(ctx, c.gistSummary(body, url))
+			}
+			c.logger().Info("could not upload the summary as a gist, truncating it", "error", err)
+		}
+		chunks = chunks[:max]
+		chunks[max-1] += "\n\n" + c.text(MessageTruncated)
+	}
+
+	var (
+		first *Comment
+		err   error
+	)
+	for i, chunk := range chunks {
+		if i > 0 {
+			chunk = c.text(MessageContinued, i+1, len(chunks)) + "\n\n" + chunk
+		}
+		created, ok := existing[chunk]
+		if ok {
+			c.logger().Info("skipping duplicate general comment", "comment_id", created.ID)
+		} else if created, err = c.provider.CreateSummaryComment(ctx, chunk); err != nil {
+			return first, err
+		}
+		if first == nil {
+			first = created
+		}
+	}
+	return first, nil
+}
+
+// createGist uploads body as a secret Gist and returns its URL
+func (c *Commenter) createGist(ctx context.Context, body string) (string, error) {
+	client := c.opts.gistClient
+	if client == nil {
+		client = c.GitHubClient()
+	}
+	if client == nil {
+		return "", fmt.Errorf("gist overflow: %w", ErrNotSupported)
+	}
+	gist, resp, err := client.Gists.Create(ctx, &github.Gist{
+		Description: github.String(c.text(MessageGistDescription)),
+		Public:      github.Bool(false),
+		Files: map[github.GistFilename]github.GistFile{
+			"report.md": {Content: github.String(body)},
+		},
+	})
+	c.opts.audit.record("Gists.Create", resp, err)
+	if err != nil {
+		return "", wrapAPIError(err)
+	}
+	return gist.GetHTMLURL(), nil
+}
+
+// gistSummary is the start of body with a link to the full report
+func (c *Commenter) gistSummary(body, url string) string {
+	preview := chunkBody(body, gistPreviewLength)[0]
+	return preview + "\n\n" + c.text(MessageGistOverflow, url)
+}
+
+// chunkBody splits body into pieces of at most limit bytes, at line breaks where possible and never
+// inside a UTF-8 sequence
+func chunkBody(body string, limit int) []string {
+	var chunks []string
+	for len(body) > limit {
+		cut := strings.LastIndex(body[:limit], "\n")
+		if cut <= 0 {
+			cut = limit
+			for cut > 0 && !utf8.RuneStart(body[cut]) {
+				cut--
+			}
+		}
+		chunks = append(chunks, body[:cut])
+		body = strings.TrimPrefix(body[cut:], "\n")
+	}
+	return append(chunks, body)
+}
+
+// summaryLister is implemented by providers which can list the summary comments on the PR
+type summaryLister interface {
+	listSummaryComments(ctx context.Context) ([]*Comment, error)
+}
+
+func (c *connector) listSummaryComments(ctx context.Context) ([]*Comment, error) {
+	pages := map[int][]*github.IssueComment{}
+	var mu sync.Mutex
+	last, err := c.fetchPages(ctx, "Issues.ListComments", func(page int) (*github.Response, error) {
+		comments, resp, err := c.comments.ListComments(ctx, c.owner, c.repo, c.prNumber, &github.IssueListCommentsOptions{
+			ListOptions: github.ListOptions{Page: page, PerPage: pageSize},
+		})
+		mu.Lock()
+		pages[page] = comments
+		mu.Unlock()
+		return resp, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list existing general comments: %w", err)
+	}
+	var comments []*Comment
+	for page := 1; page <= last; page++ {
+		for _, comment := range pages[page] {
+			comments = append(comments, &Comment{
+				ID:     comment.GetID(),
+				NodeID: comment.GetNodeID(),
+				Body:   comment.GetBody(),
+				Author: comment.GetUser().GetLogin(),
+				URL:    comment.GetHTMLURL(),
+			})
+		}
+	}
+	return comments, nil
+}
+
+// existingSummaries indexes the commenter's own summary comments by their body, nil when the
+// provider can't list them
+func (c *Commenter) existingSummaries(ctx context.Context) (map[string]*Comment, error) {
+	lister, ok := c.provider.(summaryLister)
+	if !ok {
+		return nil, nil
+	}
+	comments, err := lister.listSummaryComments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existing := map[string]*Comment{}
+	for _, comment := range comments {
+		if comment.Author != c.commenterName() {
+			continue
+		}
+		if _, ok := existing[comment.Body]; !ok {
+			existing[comment.Body] = comment
+		}
+	}
+	return existing, nil
+}
@@ -0,0 +1,174 @@
+package commenter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// WithSuppressionDirectives skips findings whose line, or the line above it, carries one of the
+// directives such as "//nolint" or "#tfsec:ignore". A directive followed by ":" or "=" and a comma
+// separated list, like "//nolint:errcheck,gosec", only suppresses the listed rules
+func WithSuppressionDirectives(directives ...string) Option {
+	return func(o *options) {
+		o.suppressionDirectives = append(o.suppressionDirectives, directives...)
+	}
+}
+
+// WithContentsFetch reads lines the patch doesn't show from the file contents at the head commit,
+// so suppression directives just outside a hunk are still seen. Only GitHub supports it
+func WithContentsFetch() Option {
+	return func(o *options) {
+		o.contentsFetch = true
+	}
+}
+
+// contentsProvider is implemented by providers which can read a file at a commit
+type contentsProvider interface {
+	fileContents(ctx context.Context, path, sha string) (string, error)
+}
+
+func (c *connector) fileContents(ctx context.Context, path, sha string) (string, error) {
+	var file *github.RepositoryContent
+	err := c.withRetry(ctx, "Repositories.GetContents", func() (*github.Response, error) {
+		var (
+			resp *github.Response
+			err  error
+		)
+		file, _, resp, err = c.client.Repositories.GetContents(ctx, c.owner, c.repo, path, &github.RepositoryContentGetOptions{Ref: sha})
+		return resp, err
+	})
+	if err != nil {
+		return "", fmt.Errorf("get contents of %s: %w", path, err)
+	}
+	return file.GetContent()
+}
+
+// suppressedByDirective reports whether the finding's line or the one above carries a directive
+func (c *Commenter) suppressedByDirective(ctx context.Context, finding Finding) bool {
+	if len(c.opts.suppressionDirectives) == 0 {
+		return false
+	}
+	comment := finding.Comment()
+	for _, lineNo := range []int{comment.StartLine, comment.StartLine - 1} {
+		line, ok := c.lineAt(ctx, comment.FileName, lineNo)
+		if !ok {
+			continue
+		}
+		for _, directive := range c.opts.suppressionDirectives {
+			if directiveSuppresses(line, directive, finding.RuleID) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lineAt returns the content of a line of the file at the head commit, from the patch or from the
+// file contents when WithContentsFetch is set
+func (c *Commenter) lineAt(ctx context.Context, path string, lineNo int) (string, bool) {
+	if lineNo < 1 {
+		return "", false
+	}
+	var sha string
+	for _, file := range c.hunksOf(path) {
+		if line, ok := file.lines[lineNo]; ok {
+			return line, true
+		}
+		sha = file.sha
+	}
+	if !c.opts.contentsFetch || sha == "" {
+		return "", false
+	}
+	lines, ok := c.fileLines(ctx, path, sha)
+	if !ok || lineNo > len(lines) {
+		return "", false
+	}
+	return lines[lineNo-1], true
+}
+
+// fileLines fetches the lines of a file once per commenter, a failed fetch is remembered as empty
+func (c *Commenter) fileLines(ctx context.Context, path, sha string) ([]string, bool) {
+	provider, ok := c.provider.(contentsProvider)
+	if !ok {
+		return nil, false
+	}
+	c.mu.RLock()
+	lines, fetched := c.contents[path]
+	c.mu.RUnlock()
+	if fetched {
+		return lines, lines != nil
+	}
+
+	content, err := provider.fileContents(ctx, path, sha)
+	if err != nil {
+		c.logger().Info("could not fetch file contents", "file", path, "error", err)
+	} else {
+		lines = strings.Split(content, "\n")
+	}
+	c.mu.Lock()
+	if c.contents == nil {
+		c.contents = map[string][]string{}
+	}
+	c.contents[path] = lines
+	c.mu.Unlock()
+	return lines, lines != nil
+}
+
+// directiveSuppresses reports whether line carries directive for ruleID
+func directiveSuppresses(line, directive, ruleID string) bool {
+	i := strings.Index(line, directive)
+	if i < 0 {
+		return false
+	}
+	rest := line[i+len(directive):]
+	if rest == "" || (rest[0] != ':' && rest[0] != '=') {
+		return true
+	}
+	rules := rest[1:]
+	if end := strings.IndexAny(rules, " \t"); end >= 0 {
+		rules = rules[:end]
+	}
+	if ruleID == "" {
+		return true
+	}
+	for _, rule := range strings.Split(rules, ",") {
+		if strings.EqualFold(strings.TrimSpace(rule), ruleID) {
+			return true
+		}
+	}
+	return false
+}
+
+// patchLines maps the line numbers of the new side of a patch to their content, and reports which
+// of them were added
+func patchLines(patch string) (map[int]string, map[int]bool) {
+	lines, added := map[int]string{}, map[int]bool{}
+	lineNo := 0
+	for _, line := range strings.Split(patch, "\n") {
+		if groups := hunkHeaderRegex.FindStringSubmatch(line); groups != nil {
+			fmt.Sscanf(groups[3], "%d", &lineNo)
+			continue
+		}
+		if lineNo == 0 {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "-"), strings.HasPrefix(line, "\\"):
+		case strings.HasPrefix(line, "+"):
+			lines[lineNo] = line[1:]
+			added[lineNo] = true
+			lineNo++
+		case strings.HasPrefix(line, " "):
+			lines[lineNo] = line[1:]
+			lineNo++
+		case line == "":
+			// some tools strip the space of empty context lines
+			lines[lineNo] = ""
+			lineNo++
+		}
+	}
+	return lines, added
+}
@@ -0,0 +1,227 @@
+package commenter
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+)
+
+var findingMarkerRegex = regexp.MustCompile(`<!-- pr-commenter:finding ([0-9a-f]+) -->`)
+
+// SyncResult is the outcome of reconciling the PR's comments with a run's findings
+type SyncResult struct {
+	// Results line up with the findings
+	Results []Result
+	// Resolved are the comments of findings no longer reported, handled as set by WithResolvedFindings
+	Resolved []Result
+	// Pruned are the comments which left the diff, pruned as set by WithPruneOutdated
+	Pruned []Result
+}
+
+// WithAcknowledgements lets maintainers acknowledge a finding reported by Sync by reacting 👍 to its
+// comment or replying "ack". Acknowledged findings are suppressed by later Syncs, so they are neither
+// posted again nor counted by a Policy. Reactions only count on GitHub, and replies on the providers
+// which report them, Gitea review comments don't tell what they reply to
+func WithAcknowledgements() Option {
+	return func(o *options) {
+		o.acknowledgements = true
+	}
+}
+
+// Sync reconciles the PR with the findings of the latest run: findings already reported by an earlier
+// Sync keep their comment and are ResultUnchanged, the others are filtered and posted like WriteFindings.
+// Comments are matched to findings by the Fingerprint hidden in their body and the content of their line,
+// so line moves don't repost them; after a force push comments left on old lines are ResultReanchored to the
+// new diff. The comments left unmatched are of fixed findings, see WithResolvedFindings, and WithPruneOutdated
+// prunes the commenter's other comments left off the diff
+func (c *Commenter) Sync(findings []Finding) (*SyncResult, error) {
+	return c.SyncContext(context.Background(), findings)
+}
+
+// SyncContext is Sync using ctx for the API calls
+func (c *Commenter) SyncContext(ctx context.Context, findings []Finding) (*SyncResult, error) {
+	if err := c.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+	findings = c.normalizeFindings(findings)
+
+	reported := map[string][]*Comment{}
+	for _, comment := range c.snapshotExistingComments() {
+		if fingerprint := commentFingerprint(comment); fingerprint != "" {
+			reported[fingerprint] = append(reported[fingerprint], comment)
+		}
+	}
+
+	result := &SyncResult{Results: make([]Result, len(findings))}
+	var (
+		matched []*Comment
+		pending []Finding
+		indexes []int
+	)
+	owner := make([]*Comment, len(findings))
+	for i, finding := range findings {
+		fingerprint := Fingerprint(finding)
+		if comments := reported[fingerprint]; len(comments) > 0 {
+			j := c.matchComment(ctx, comments, finding)
+			owner[i] = comments[j]
+			reported[fingerprint] = append(comments[:j:j], comments[j+1:]...)
+			matched = append(matched, comments[j])
+			continue
+		}
+		pending = append(pending, finding)
+		indexes = append(indexes, i)
+	}
+
+	acknowledged := map[int64]string{}
+	if c.opts.acknowledgements && len(matched) > 0 {
+		var err error
+		if acknowledged, err = c.acknowledged(ctx, matched); err != nil {
+			return nil, err
+		}
+	}
+	for i, comment := range owner {
+		if comment == nil {
+			continue
+		}
+		res := Result{Comment: findings[i].Comment(), Status: ResultUnchanged, CommentID: comment.ID, URL: comment.URL}
+		if by, ok := acknowledged[comment.ID]; ok {
+			c.logger().Info("suppressed finding", "file", findings[i].Path, "line", findings[i].StartLine, "rule", findings[i].RuleID, "reason", "acknowledged")
+			res.Status = ResultSuppressed
+			res.Err = fmt.Errorf("finding was acknowledged by %s: %w", by, ErrSuppressed)
+		} else if c.stale(comment, findings[i]) {
+			res = c.reanchor(ctx, comment, findings[i])
+		}
+		result.Results[i] = res
+	}
+
+	written, err := c.writeFindings(ctx, pending, true)
+	for i, res := range written {
+		result.Results[indexes[i]] = res
+	}
+	if err != nil {
+		return result, err
+	}
+	var fixed []*Comment
+	for _, comments := range reported {
+		fixed = append(fixed, comments...)
+	}
+	sort.Slice(fixed, func(i, j int) bool { return fixed[i].ID < fixed[j].ID })
+	if result.Resolved, err = c.resolve(ctx, fixed); err != nil {
+		return result, err
+	}
+	if c.opts.pruneOutdated {
+		keep := map[int64]bool{}
+		for _, comment := range owner {
+			if comment != nil {
+				keep[comment.ID] = true
+			}
+		}
+		for _, res := range result.Resolved {
+			keep[res.CommentID] = true
+		}
+		if result.Pruned, err = c.pruneOutdated(ctx, c.opts.pruneMode, keep); err != nil {
+			return result, err
+		}
+		if err := newBatchError(result.Pruned); err != nil {
+			return result, err
+		}
+	}
+	if clean(result.Results) {
+		if err := c.approveClean(ctx); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// findingMarker is the hidden part of a comment body naming the fingerprint of its finding
+func findingMarker(fingerprint string) string {
+	return fmt.Sprintf("<!-- pr-commenter:finding %s -->", fingerprint)
+}
+
+// commentFingerprint returns the fingerprint marked in the comment, "" for comments not made by Sync
+func commentFingerprint(comment *Comment) string {
+	groups := findingMarkerRegex.FindStringSubmatch(comment.Body)
+	if groups == nil {
+		return ""
+	}
+	return groups[1]
+}
+
+// acknowledged returns who acknowledged the comments, keyed by the id of each acknowledged comment
+func (c *Commenter) acknowledged(ctx context.Context, comments []*Comment) (map[int64]string, error) {
+	ids := make(map[int64]bool, len(comments))
+	for _, comment := range comments {
+		ids[comment.ID] = true
+	}
+	own := c.commenterName()
+
+	acknowledged := map[int64]string{}
+	replies, err := c.listReplies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for id := range ids {
+		for _, reply := range replies[id] {
+			if reply.Author != own && strings.EqualFold(strings.TrimSpace(reply.Body), "ack") {
+				acknowledged[id] = reply.Author
+			}
+		}
+	}
+
+	// reactions are only read from the GitHub API, on GitLab, Bitbucket and Azure DevOps and in offline
+	// runs findings are acknowledged by replying
+	gh := c.repoConnector()
+	if gh == nil || gh.opts.pullRequests != nil {
+		return acknowledged, nil
+	}
+	for _, comment := range comments {
+		if _, ok := acknowledged[comment.ID]; ok || comment.Path == "" {
+			continue
+		}
+		var reactions []*github.Reaction
+		err := gh.withRetry(ctx, "Reactions.ListPullRequestCommentReactions", func() (*github.Response, error) {
+			var (
+				resp *github.Response
+				err  error
+			)
+			reactions, resp, err = gh.client.Reactions.ListPullRequestCommentReactions(ctx, gh.owner, gh.repo, comment.ID, nil)
+			return resp, err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("list reactions of comment %d: %w", comment.ID, err)
+		}
+		for _, reaction := range reactions {
+			if reaction.GetContent() == "+1" && reaction.GetUser().GetLogin() != own {
+				acknowledged[comment.ID] = reaction.GetUser().GetLogin()
+				break
+			}
+		}
+	}
+	return acknowledged, nil
+}
+
+// WithBotLogin sets the login the commenter's comments are authored by, such as the bot user of a
+// GitHub App. Pruning, syncing and deduplication only ever change or delete comments by this login,
+// leaving those of people and other bots alone. It defaults to CommenterName, or the provider's
+// Identifier
+func WithBotLogin(login string) Option {
+	return func(o *options) {
+		o.botLogin = login
+	}
+}
+
+// commenterName is the author of the commenter's own comments on the provider
+func (c *Commenter) commenterName() string {
+	if c.opts.botLogin != "" {
+		return c.opts.botLogin
+	}
+	if identifier, ok := c.provider.(Identifier); ok {
+		return identifier.CommenterName()
+	}
+	return CommenterName
+}
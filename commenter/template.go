@@ -0,0 +1,105 @@
+package commenter
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// FindingData is what WithFindingTemplate templates are executed with
+type FindingData struct {
+	Finding
+	// Body is the comment the finding is written as without the template
+	Body string
+}
+
+// severityBadges are the severityBadge emoji
+var severityBadges = map[Severity]string{
+	SeverityInfo:     "🔵",
+	SeverityWarning:  "🟡",
+	SeverityError:    "🟠",
+	SeverityCritical: "🔴",
+}
+
+// WithFindingTemplate writes the comments of WriteFindings and Sync from a text/template executed with
+// FindingData, instead of as "**rule**: message". It can use the functions NewTemplate adds. A template
+// which doesn't parse makes WriteFindings fail, one which fails for a finding fails its comment
+func WithFindingTemplate(text string) Option {
+	return func(o *options) {
+		o.findingTemplate, o.findingTemplateErr = template.New("finding").Funcs(templateFuncs(nil)).Parse(text)
+	}
+}
+
+// NewTemplate parses text as a text/template for rendering comments, with these functions besides the
+// standard ones:
+//
+//	severityBadge .Severity             an emoji and the severity's name, such as "🔴 critical"
+//	fileLink .Path .StartLine .EndLine  the location linked to its Permalink where there is one
+//	truncate 80 .Message                the text cut to at most that many characters with an ellipsis
+//	pluralize 3 "finding" "findings"    the count and the word for it, "3 findings"
+//	table .Findings                     a markdown table of a []Finding as Commenter.RenderReport makes
+func (c *Commenter) NewTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs(c)).Parse(text)
+}
+
+// templateFuncs returns the NewTemplate functions, fileLink only links to permalinks when c is set
+func templateFuncs(c *Commenter) template.FuncMap {
+	return template.FuncMap{
+		"severityBadge": func(severity Severity) string {
+			if badge, ok := severityBadges[severity]; ok {
+				return badge + " " + severity.String()
+			}
+			return "⚪ " + severity.String()
+		},
+		"fileLink": func(path string, startLine, endLine int) string {
+			if endLine < startLine {
+				endLine = startLine
+			}
+			location := commentLocation(InlineComment{Path: path, StartLine: startLine, EndLine: endLine})
+			if c == nil {
+				return "`" + location + "`"
+			}
+			link := c.Permalink(path, startLine, endLine)
+			if link == "" {
+				return "`" + location + "`"
+			}
+			return fmt.Sprintf("[`%s`](%s)", location, link)
+		},
+		"truncate": func(n int, s string) string {
+			if n < 1 {
+				return s
+			}
+			return truncate(s, n)
+		},
+		"pluralize": func(n int, singular, plural string) string {
+			if n == 1 {
+				return fmt.Sprintf("%d %s", n, singular)
+			}
+			return fmt.Sprintf("%d %s", n, plural)
+		},
+		"table": func(findings []Finding) string {
+			if c == nil {
+				return RenderReport(findings)
+			}
+			return c.RenderReport(findings)
+		},
+	}
+}
+
+// findingComment returns the comment written for the finding, rendered from WithFindingTemplate if set
+func (c *Commenter) findingComment(finding Finding) (PRReviewComment, error) {
+	comment := finding.Comment()
+	if c.opts.findingTemplate == nil {
+		return comment, nil
+	}
+	tmpl, err := c.opts.findingTemplate.Clone()
+	if err != nil {
+		return comment, err
+	}
+	var b strings.Builder
+	if err := tmpl.Funcs(templateFuncs(c)).Execute(&b, FindingData{Finding: finding, Body: comment.Body}); err != nil {
+		return comment, fmt.Errorf("render finding template: %w", err)
+	}
+	comment.Body = b.String()
+	return comment, nil
+}
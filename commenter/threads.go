@@ -0,0 +1,58 @@
+package commenter
+
+import (
+	"context"
+	"regexp"
+)
+
+// ruleBodyRegex matches the rule a Finding's comment body starts with
+var ruleBodyRegex = regexp.MustCompile(`^\*\*([^*\n]+)\*\*: `)
+
+// threadKey identifies the thread follow-ups of a rule on a file are replied to
+type threadKey struct {
+	path string
+	rule string
+}
+
+// WithThreadContinuation makes WriteFindings and Sync reply to the PR's earlier thread about the same
+// rule on the same file, instead of starting a new thread for every finding, so the discussion of a
+// rule stays in one place across runs. Replies are only written on GitHub
+func WithThreadContinuation() Option {
+	return func(o *options) {
+		o.threadContinuation = true
+	}
+}
+
+// threadRoots returns the first comment the commenter made about each rule on each file, nil when
+// thread continuation isn't enabled or the provider can't reply
+func (c *Commenter) threadRoots() map[threadKey]*Comment {
+	if !c.opts.threadContinuation || c.ghConnector == nil {
+		return nil
+	}
+	roots := map[threadKey]*Comment{}
+	for _, comment := range c.snapshotExistingComments() {
+		if comment.Path == "" || comment.InReplyTo != 0 {
+			continue
+		}
+		groups := ruleBodyRegex.FindStringSubmatch(comment.Body)
+		if groups == nil {
+			continue
+		}
+		key := threadKey{path: comment.Path, rule: groups[1]}
+		if root, ok := roots[key]; !ok || comment.ID < root.ID {
+			roots[key] = comment
+		}
+	}
+	return roots
+}
+
+// continueThread replies the comment of a finding to root, the start of the thread about its rule
+func (c *Commenter) continueThread(ctx context.Context, root *Comment, comment PRReviewComment) Result {
+	reply, err := c.ghConnector.ReplyToComment(ctx, root.ID, comment.Body)
+	if err != nil {
+		return Result{Comment: comment, Status: ResultFailed, Err: err}
+	}
+	c.rememberComment(reply)
+	c.logger().Info("continued thread", "file", comment.FileName, "line", comment.EndLine, "thread", root.ID, "comment_id", reply.ID)
+	return Result{Comment: comment, Status: ResultCreated, CommentID: reply.ID, URL: reply.URL}
+}
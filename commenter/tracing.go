@@ -0,0 +1,33 @@
+package commenter
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/mugioka/go-github-pr-commenter/commenter"
+
+// WithTracerProvider records OpenTelemetry spans for loading the PR and writing comments, as children
+// of the span in the caller's context. Only the GitHub provider also records a span for each comment
+// and each API call within them (including retries)
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(o *options) {
+		if provider != nil {
+			o.tracer = provider.Tracer(tracerName)
+		}
+	}
+}
+
+func (c *connector) spanAttributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("github.owner", c.owner),
+		attribute.String("github.repo", c.repo),
+		attribute.Int("github.pr_number", c.prNumber),
+	}
+}
+
+func recordSpanError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,78 @@
+package commenter
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/go-github/v38/github"
+	"golang.org/x/oauth2"
+)
+
+// WithClient makes the commenter share an already configured client, which is used as is. The token,
+// WithTokenSource, WithBaseURL, WithTransport, WithTimeout, WithUserAgent, WithAPIVersion, WithHTTPDebug
+// and WithETagCache are then ignored and WithMetrics doesn't see the API calls, configure those on the
+// client instead. Retries, rate limiting and the options above the HTTP layer still apply
+func WithClient(client *github.Client) Option {
+	return func(o *options) {
+		o.client = client
+	}
+}
+
+// WithTokenSource authenticates with tokens from ts instead of the static token, for short lived
+// tokens such as App installation or OIDC minted ones. A token is reused until shortly before its
+// Expiry and ts is then asked for a new one, so long running bots keep working
+func WithTokenSource(ts oauth2.TokenSource) Option {
+	return func(o *options) {
+		o.tokenSource = ts
+	}
+}
+
+// WithBaseURL points the commenter at another API root, such as a GitHub Enterprise Server
+// "https://github.example.com/api/v3/" or a fake server in tests
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) {
+		o.baseURL = baseURL
+	}
+}
+
+// WithTransport makes all requests to GitHub through transport instead of http.DefaultTransport,
+// e.g. an *http.Transport with a corporate Proxy or a TLSClientConfig trusting a GHES private CA
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = transport
+	}
+}
+
+// WithTimeout bounds every individual request to GitHub, a timed out read is retried like other network errors
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithUserAgent sets the User-Agent sent to GitHub so the integrating tool is identifiable in audit logs
+func WithUserAgent(userAgent string) Option {
+	return func(o *options) {
+		o.userAgent = userAgent
+	}
+}
+
+// WithAPIVersion pins the REST API version with the X-GitHub-Api-Version header, e.g. "2022-11-28"
+func WithAPIVersion(version string) Option {
+	return func(o *options) {
+		o.apiVersion = version
+	}
+}
+
+type headerTransport struct {
+	headers http.Header
+	base    http.RoundTripper
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header[k] = v
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,69 @@
+// Package vale reads the alerts of vale --output=JSON as findings
+package vale
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+)
+
+// Tool names the findings of Parse
+const Tool = "vale"
+
+type alert struct {
+	Check    string `json:"Check"`
+	Message  string `json:"Message"`
+	Link     string `json:"Link"`
+	Severity string `json:"Severity"`
+	Line     int    `json:"Line"`
+}
+
+// Parse reads the alerts vale --output=JSON writes by file as findings of the check, linked to its
+// documentation when the style gives one. Vale's suggestions are SeverityInfo
+func Parse(r io.Reader) ([]commenter.Finding, error) {
+	var byFile map[string][]alert
+	if err := json.NewDecoder(r).Decode(&byFile); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("decode vale output: %w", err)
+	}
+	var files []string
+	for file := range byFile {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var findings []commenter.Finding
+	for _, file := range files {
+		for _, a := range byFile[file] {
+			message := a.Message
+			if a.Link != "" {
+				message = fmt.Sprintf("%s ([%s](%s))", message, a.Check, a.Link)
+			}
+			findings = append(findings, commenter.Finding{
+				Tool:      Tool,
+				RuleID:    a.Check,
+				Severity:  severity(a.Severity),
+				Path:      file,
+				StartLine: a.Line,
+				EndLine:   a.Line,
+				Message:   message,
+			})
+		}
+	}
+	return findings, nil
+}
+
+func severity(s string) commenter.Severity {
+	switch s {
+	case "error":
+		return commenter.SeverityError
+	case "warning":
+		return commenter.SeverityWarning
+	case "suggestion":
+		return commenter.SeverityInfo
+	default:
+		return commenter.SeverityUnknown
+	}
+}
@@ -6,5 +6,8 @@ require (
 	github.com/google/go-github/v38 v38.1.0
 	github.com/owenrumney/go-github-pr-commenter v0.0.13
 	github.com/stretchr/testify v1.7.0
+	go.opentelemetry.io/otel v1.3.0
+	go.opentelemetry.io/otel/trace v1.3.0
 	golang.org/x/oauth2 v0.0.0-20210819190943-2bc19b11175f
+	golang.org/x/time v0.0.0-20210723032227-1f47c861a9ac
 )
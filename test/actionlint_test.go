@@ -0,0 +1,38 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/actionlint"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_actionlint_errors_are_commented_on_workflows(t *testing.T) {
+	output := `[{"message":"property \"nope\" is not defined in object type {os: string}","filepath":"./.github/workflows/ci.yml","line":5,"column":21,"kind":"expression","snippet":"      - run: echo ${{ runner.nope }}\n                    ^~~~~~~~~~~","end_column":31},
+{"message":"label \"ubuntu-99.04\" is unknown","filepath":".github/workflows/release.yml","line":3,"column":14,"kind":"runner-label","snippet":"    runs-on: ubuntu-99.04\n             ^~~~~~~~~~~~","end_column":25}]`
+	findings, err := actionlint.Parse(strings.NewReader(output))
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	assert.Equal(t, "expression", findings[0].RuleID)
+	assert.Equal(t, commenter.SeverityError, findings[0].Severity)
+	assert.Equal(t, 5, findings[0].StartLine)
+
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile(".github/workflows/ci.yml", "@@ -3,2 +3,3 @@\n     steps:\n       - uses: actions/checkout@v4\n+      - run: echo ${{ runner.nope }}")
+	c, err := server.NewCommenter(commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	results, err := c.WriteFindings(findings)
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultCreated, results[0].Status)
+	assert.Equal(t, commenter.ResultSkipped, results[1].Status)
+
+	comments := server.Comments()
+	require.Len(t, comments, 1)
+	assert.Equal(t, ".github/workflows/ci.yml", comments[0].GetPath())
+	assert.Contains(t, comments[0].GetBody(), "```yaml\n      - run: echo ${{ runner.nope }}\n")
+}
@@ -0,0 +1,38 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_audit_log_records_mutating_requests(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+
+	c, err := server.NewCommenter(commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	_, err = c.WriteComments([]commenter.PRReviewComment{
+		{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "first"},
+		{FileName: "main.go", StartLine: 3, EndLine: 3, Body: "second"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.WriteGeneralComment("summary"))
+
+	entries := c.AuditLog()
+	require.Len(t, entries, 3)
+	assert.Equal(t, "PullRequests.CreateComment", entries[0].Operation)
+	assert.Equal(t, "PullRequests.CreateComment", entries[1].Operation)
+	assert.Equal(t, "Issues.CreateComment", entries[2].Operation)
+	for _, entry := range entries {
+		assert.True(t, strings.HasPrefix(entry.RequestID, "FAKE:"), entry.RequestID)
+		assert.Equal(t, 201, entry.StatusCode)
+		assert.NoError(t, entry.Err)
+		assert.False(t, entry.Time.IsZero())
+	}
+}
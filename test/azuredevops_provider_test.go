@@ -0,0 +1,171 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/azuredevops"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_azure_devops_provider_writes_threads(t *testing.T) {
+	const pr = "/proj/_apis/git/repositories/repo/pullRequests/8"
+	var (
+		threads  []map[string]interface{}
+		statuses []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pat, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "secret", pat)
+		switch r.Method + " " + r.URL.Path {
+		case "GET " + pr:
+			w.Write([]byte(`{"lastMergeSourceCommit":{"commitId":"head"}}`))
+		case "GET /_apis/connectionData":
+			w.Write([]byte(`{"authenticatedUser":{"id":"bot-id"}}`))
+		case "GET " + pr + "/iterations":
+			w.Write([]byte(`{"value":[{"id":1},{"id":2,"sourceRefCommit":{"commitId":"head"}}]}`))
+		case "GET " + pr + "/iterations/2/changes":
+			w.Write([]byte(`{"changeEntries":[{"changeType":"edit","item":{"path":"/main.go"}},{"changeType":"delete","item":{"path":"/old.go"}}]}`))
+		case "GET /proj/_apis/git/repositories/repo/items":
+			assert.Equal(t, "/main.go", r.URL.Query().Get("path"))
+			assert.Equal(t, "head", r.URL.Query().Get("versionDescriptor.version"))
+			w.Write([]byte(`{"content":"a\nb\nc\n"}`))
+		case "GET " + pr + "/threads":
+			w.Write([]byte(`{"value":[
+				{"id":5,"threadContext":{"filePath":"/main.go","rightFileStart":{"line":2,"offset":1},"rightFileEnd":{"line":2,"offset":1}},"comments":[{"id":1,"content":"old","author":{"id":"bot-id"}}]},
+				{"id":6,"comments":[{"id":1,"content":"summary","author":{"id":"bot-id"}}]}
+			]}`))
+		case "POST " + pr + "/threads":
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			threads = append(threads, body)
+			w.Write([]byte(`{"id":9,"comments":[{"id":1,"content":"new","author":{"id":"bot-id"}}]}`))
+		case "PATCH " + pr + "/threads/5":
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			statuses = append(statuses, body["status"].(string))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	provider, err := azuredevops.New(ctx, "secret", "org", "proj", "repo", 8, azuredevops.WithBaseURL(server.URL))
+	require.NoError(t, err)
+	c, err := commenter.NewCommenterWithProvider(ctx, provider)
+	require.NoError(t, err)
+
+	results, err := c.WriteComments([]commenter.PRReviewComment{
+		{FileName: "main.go", StartLine: 1, EndLine: 3, Body: "new"},
+		{FileName: "main.go", StartLine: 4, EndLine: 4, Body: "past the end of the file"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(9), results[0].CommentID)
+	assert.Equal(t, commenter.ResultSkipped, results[1].Status)
+
+	require.Len(t, threads, 1)
+	threadContext := threads[0]["threadContext"].(map[string]interface{})
+	assert.Equal(t, "/main.go", threadContext["filePath"])
+	assert.Equal(t, float64(1), threadContext["rightFileStart"].(map[string]interface{})["line"])
+	assert.Equal(t, float64(3), threadContext["rightFileEnd"].(map[string]interface{})["line"])
+
+	comments, err := provider.ListComments(ctx)
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	require.NoError(t, provider.SetStatus(ctx, comments[0], azuredevops.StatusFixed))
+	assert.Equal(t, []string{"fixed"}, statuses)
+}
+
+func Test_azure_devops_provider_lists_thread_replies(t *testing.T) {
+	const pr = "/proj/_apis/git/repositories/repo/pullRequests/8"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET " + pr:
+			w.Write([]byte(`{"lastMergeSourceCommit":{"commitId":"head"}}`))
+		case "GET /_apis/connectionData":
+			w.Write([]byte(`{"authenticatedUser":{"id":"bot-id"}}`))
+		case "GET " + pr + "/threads":
+			w.Write([]byte(`{"value":[{"id":5,"threadContext":{"filePath":"/main.go","rightFileStart":{"line":2,"offset":1},"rightFileEnd":{"line":2,"offset":1}},"comments":[
+				{"id":1,"content":"finding","commentType":1,"author":{"id":"bot-id"}},
+				{"id":2,"content":"ack","commentType":1,"parentCommentId":1,"author":{"id":"alice-id"}},
+				{"id":3,"content":"alice-id voted","commentType":3,"author":{"id":"alice-id"}},
+				{"id":4,"content":"oops","commentType":1,"parentCommentId":1,"isDeleted":true,"author":{"id":"alice-id"}}
+			]}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := azuredevops.New(context.Background(), "secret", "org", "proj", "repo", 8, azuredevops.WithBaseURL(server.URL))
+	require.NoError(t, err)
+	comments, err := provider.ListComments(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, comments, 2)
+	assert.Equal(t, int64(0), comments[0].InReplyTo)
+	assert.Equal(t, "finding", comments[0].Body)
+	reply := comments[1]
+	assert.Equal(t, int64(5), reply.ID)
+	assert.Equal(t, int64(5), reply.InReplyTo)
+	assert.Equal(t, "2", reply.NodeID)
+	assert.Equal(t, "ack", reply.Body)
+	assert.Equal(t, "alice-id", reply.Author)
+	assert.Equal(t, "main.go", reply.Path)
+}
+
+func Test_azure_devops_provider_resolves_threads_of_fixed_findings(t *testing.T) {
+	const pr = "/proj/_apis/git/repositories/repo/pullRequests/8"
+	fixed := commenter.Finding{RuleID: "G101", Path: "main.go", StartLine: 2, Message: "hardcoded credentials"}
+	marked, err := json.Marshal("**G101**: hardcoded credentials\n\n<!-- pr-commenter:finding " + commenter.Fingerprint(fixed) + " -->")
+	require.NoError(t, err)
+	var statuses []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET " + pr:
+			w.Write([]byte(`{"lastMergeSourceCommit":{"commitId":"head"}}`))
+		case "GET /_apis/connectionData":
+			w.Write([]byte(`{"authenticatedUser":{"id":"bot-id"}}`))
+		case "GET " + pr + "/iterations":
+			w.Write([]byte(`{"value":[{"id":1,"sourceRefCommit":{"commitId":"head"}}]}`))
+		case "GET " + pr + "/iterations/1/changes":
+			w.Write([]byte(`{"changeEntries":[{"changeType":"edit","item":{"path":"/main.go"}}]}`))
+		case "GET /proj/_apis/git/repositories/repo/items":
+			w.Write([]byte(`{"content":"a\nb\nc\n"}`))
+		case "GET " + pr + "/threads":
+			w.Write([]byte(`{"value":[{"id":5,"threadContext":{"filePath":"/main.go","rightFileStart":{"line":2,"offset":1},"rightFileEnd":{"line":2,"offset":1}},"comments":[{"id":1,"content":` + string(marked) + `,"author":{"id":"bot-id"}}]}]}`))
+		case "PATCH " + pr + "/threads/5":
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			statuses = append(statuses, body["status"].(string))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	provider, err := azuredevops.New(ctx, "secret", "org", "proj", "repo", 8, azuredevops.WithBaseURL(server.URL))
+	require.NoError(t, err)
+	c, err := commenter.NewCommenterWithProvider(ctx, provider, commenter.WithResolvedFindings(commenter.ResolvedMinimize))
+	require.NoError(t, err)
+
+	result, err := c.SyncContext(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, result.Resolved, 1)
+	assert.Equal(t, commenter.ResultResolved, result.Resolved[0].Status)
+	assert.Equal(t, []string{"fixed"}, statuses)
+
+	// replying is still GitHub only
+	c, err = commenter.NewCommenterWithProvider(ctx, provider, commenter.WithResolvedFindings(commenter.ResolvedReply))
+	require.NoError(t, err)
+	_, err = c.SyncContext(ctx, nil)
+	assert.ErrorIs(t, err, commenter.ErrNotSupported)
+}
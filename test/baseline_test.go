@@ -0,0 +1,61 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_baseline_round_trips_and_ignores_lines(t *testing.T) {
+	known := []commenter.Finding{
+		{RuleID: "G101", Path: "main.go", StartLine: 3, Message: "hardcoded credentials"},
+		{RuleID: "G101", Path: "main.go", StartLine: 9, Message: "hardcoded credentials"},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, commenter.WriteBaseline(&buf, known))
+	baseline, err := commenter.ReadBaseline(&buf)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, baseline.Len())
+	assert.True(t, baseline.Contains(commenter.Finding{RuleID: "G101", Path: "main.go", StartLine: 40, Message: "hardcoded credentials"}))
+	assert.False(t, baseline.Contains(commenter.Finding{RuleID: "G102", Path: "main.go", StartLine: 3, Message: "hardcoded credentials"}))
+
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	missing, err := commenter.LoadBaselineFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 0, missing.Len())
+	require.NoError(t, commenter.SaveBaselineFile(path, known))
+	loaded, err := commenter.LoadBaselineFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, loaded.Len())
+}
+
+func Test_write_findings_skips_baselined_findings(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+
+	baseline := commenter.NewBaseline([]commenter.Finding{{RuleID: "G101", Path: "main.go", Message: "hardcoded credentials"}})
+	c, err := server.NewCommenter(commenter.WithBaseline(baseline))
+	require.NoError(t, err)
+
+	results, err := c.WriteFindings([]commenter.Finding{
+		{RuleID: "G101", Path: "main.go", StartLine: 2, Message: "hardcoded credentials"},
+		{RuleID: "G104", Path: "main.go", StartLine: 3, Message: "errors unhandled"},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, commenter.ResultSuppressed, results[0].Status)
+	assert.True(t, errors.Is(results[0].Err, commenter.ErrSuppressed))
+	assert.Equal(t, commenter.ResultCreated, results[1].Status)
+
+	comments := server.Comments()
+	require.Len(t, comments, 1)
+	assert.Equal(t, "**G104**: errors unhandled", comments[0].GetBody())
+}
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// inFlightTransport holds every new comment for a moment and records how many were in flight at once
+type inFlightTransport struct {
+	mu       sync.Mutex
+	inFlight int
+	max      int
+}
+
+func (f *inFlightTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodPost || !strings.HasSuffix(req.URL.Path, "/comments") {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	f.mu.Lock()
+	f.inFlight++
+	if f.inFlight > f.max {
+		f.max = f.inFlight
+	}
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+	time.Sleep(10 * time.Millisecond)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func Test_concurrent_comments_stay_within_the_limit_and_keep_their_order(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,9 @@\n a\n-b\n+c\n+d\n+e\n+f\n+g\n+h\n+i\n+j")
+	transport := &inFlightTransport{}
+	c, err := server.NewCommenter(commenter.WithTransport(transport), commenter.WithConcurrency(3))
+	require.NoError(t, err)
+
+	// given back to front, so the posting order sorted by line differs from the input order
+	var comments []commenter.PRReviewComment
+	for line := 9; line >= 2; line-- {
+		comments = append(comments, commenter.PRReviewComment{FileName: "main.go", StartLine: line, EndLine: line, Body: fmt.Sprintf("line %d", line)})
+	}
+	results, err := c.WriteComments(comments)
+
+	require.NoError(t, err)
+	require.Len(t, results, len(comments))
+	for i, result := range results {
+		assert.Equal(t, commenter.ResultCreated, result.Status)
+		assert.Equal(t, comments[i].Body, result.Comment.Body)
+	}
+	assert.Len(t, server.Comments(), len(comments))
+	assert.LessOrEqual(t, transport.max, 3)
+	assert.Greater(t, transport.max, 1)
+}
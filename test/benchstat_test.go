@@ -0,0 +1,66 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter/benchstat"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const benchstatOutput = `name        old time/op    new time/op    delta
+Encode-8      10.0ns ± 1%    12.0ns ± 2%  +20.00%  (p=0.000 n=10+10)
+Decode-8      5.00ns ± 0%    5.01ns ± 1%     ~     (p=0.500 n=10+10)
+[Geo mean]    7.07ns         7.75ns        +9.54%
+
+name        old alloc/op   new alloc/op   delta
+Encode-8       64.0B ± 0%     48.0B ± 0%  -25.00%  (p=0.000 n=10+10)
+
+name        old speed      new speed      delta
+Decode-8     100MB/s ± 1%    90MB/s ± 1%  -10.00%  (p=0.000 n=10+10)
+`
+
+func Test_benchstat_comparisons_are_parsed(t *testing.T) {
+	comparisons, err := benchstat.Parse(strings.NewReader(benchstatOutput))
+	require.NoError(t, err)
+	require.Len(t, comparisons, 4)
+	assert.Equal(t, benchstat.Comparison{
+		Name: "Encode-8", Unit: "time/op", Old: "10.0ns ± 1%", New: "12.0ns ± 2%",
+		Delta: 20, Significant: true, Samples: "10+10",
+	}, comparisons[0])
+	assert.False(t, comparisons[1].Significant)
+	assert.Equal(t, 0.5, comparisons[1].P)
+
+	var regressed []string
+	for _, comparison := range comparisons {
+		if comparison.Regressed(5) {
+			regressed = append(regressed, comparison.Name+" "+comparison.Unit)
+		}
+	}
+	assert.Equal(t, []string{"Encode-8 time/op", "Decode-8 speed"}, regressed)
+}
+
+func Test_benchstat_summary_is_kept_in_one_comment(t *testing.T) {
+	comparisons, err := benchstat.Parse(strings.NewReader(benchstatOutput))
+	require.NoError(t, err)
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+	require.NoError(t, benchstat.Write(context.Background(), c, comparisons, 5))
+	require.NoError(t, benchstat.Write(context.Background(), c, comparisons[1:2], 5))
+
+	comments := server.IssueComments()
+	require.Len(t, comments, 1)
+	assert.Contains(t, comments[0].GetBody(), "No benchmark regressed by more than 5%.")
+	assert.Contains(t, comments[0].GetBody(), "<details><summary>🟢 <code>Decode-8</code></summary>")
+
+	summary := benchstat.Summary(comparisons, 5)
+	assert.Contains(t, summary, "**2 regressions by more than 5%**")
+	assert.Contains(t, summary, "| `Encode-8` | time/op | 10.0ns ± 1% | 12.0ns ± 2% | +20.00% |")
+	assert.Contains(t, summary, "<details><summary>🔴 <code>Decode-8</code></summary>")
+}
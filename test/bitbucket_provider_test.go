@@ -0,0 +1,147 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/bitbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const bitbucketDiff = `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -10,3 +10,4 @@ func main()
+ a
++b
+ c
+ d
+`
+
+func Test_bitbucket_provider_replaces_its_inline_comments(t *testing.T) {
+	const pr = "/repositories/team/repo/pullrequests/5"
+	var (
+		posted  []map[string]interface{}
+		deleted []string
+	)
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "bot:app-password", user+":"+pass)
+		switch r.Method + " " + r.URL.Path {
+		case "GET " + pr:
+			w.Write([]byte(`{"id":5,"source":{"commit":{"hash":"head"}}}`))
+		case "GET /user":
+			w.Write([]byte(`{"uuid":"{bot}"}`))
+		case "GET " + pr + "/diff":
+			w.Write([]byte(bitbucketDiff))
+		case "GET " + pr + "/comments":
+			if r.URL.Query().Get("page") == "" {
+				w.Write([]byte(`{"values":[{"id":1,"content":{"raw":"old"},"user":{"uuid":"{bot}"},"inline":{"path":"main.go","to":11}}],"next":"` + server.URL + pr + `/comments?page=2"}`))
+				return
+			}
+			w.Write([]byte(`{"values":[
+				{"id":2,"content":{"raw":"human"},"user":{"uuid":"{alice}"},"inline":{"path":"main.go","to":11}},
+				{"id":3,"content":{"raw":"gone"},"user":{"uuid":"{bot}"},"inline":{"path":"main.go","to":11},"deleted":true}
+			]}`))
+		case "POST " + pr + "/comments":
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			posted = append(posted, body)
+			w.Write([]byte(`{"id":9,"content":{"raw":"x"},"user":{"uuid":"{bot}"},"links":{"html":{"href":"https://bitbucket.org/c/9"}}}`))
+		case "DELETE " + pr + "/comments/1":
+			deleted = append(deleted, "1")
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	provider, err := bitbucket.New(ctx, "", "team", "repo", 5, bitbucket.WithBaseURL(server.URL), bitbucket.WithAppPassword("bot", "app-password"))
+	require.NoError(t, err)
+	c, err := commenter.NewCommenterWithProvider(ctx, provider)
+	require.NoError(t, err)
+
+	drafts := c.CreateDraftPRReviewComments([]commenter.PRReviewComment{
+		{FileName: "main.go", StartLine: 10, EndLine: 12, Body: "range"},
+		{FileName: "main.go", StartLine: 40, EndLine: 40, Body: "outside the diff"},
+	})
+	require.NoError(t, c.WritePRReview(drafts, commenter.Approve))
+
+	assert.Equal(t, []string{"1"}, deleted)
+	require.Len(t, posted, 2)
+	inline := posted[0]["inline"].(map[string]interface{})
+	assert.Equal(t, "main.go", inline["path"])
+	assert.Equal(t, float64(12), inline["to"])
+	assert.Equal(t, float64(10), inline["start_to"])
+	assert.Nil(t, posted[1]["inline"])
+	assert.Equal(t, commenter.ApproveBody, posted[1]["content"].(map[string]interface{})["raw"])
+}
+
+func Test_bitbucket_provider_threads_replies_to_inline_comments(t *testing.T) {
+	const pr = "/repositories/team/repo/pullrequests/5"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET " + pr:
+			w.Write([]byte(`{"id":5,"source":{"commit":{"hash":"head"}}}`))
+		case "GET /user":
+			w.Write([]byte(`{"uuid":"{bot}"}`))
+		case "GET " + pr + "/comments":
+			w.Write([]byte(`{"values":[
+				{"id":1,"content":{"raw":"finding"},"user":{"uuid":"{bot}"},"inline":{"path":"main.go","to":11}},
+				{"id":2,"content":{"raw":"why?"},"user":{"uuid":"{alice}"},"inline":{"path":"main.go","to":11},"parent":{"id":1}},
+				{"id":3,"content":{"raw":"ack"},"user":{"uuid":"{bob}"},"parent":{"id":2}},
+				{"id":4,"content":{"raw":"summary"},"user":{"uuid":"{bot}"}},
+				{"id":5,"content":{"raw":"thanks"},"user":{"uuid":"{alice}"},"parent":{"id":4}}
+			]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := bitbucket.New(context.Background(), "token", "team", "repo", 5, bitbucket.WithBaseURL(server.URL))
+	require.NoError(t, err)
+	comments, err := provider.ListComments(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, comments, 3)
+	assert.Equal(t, int64(0), comments[0].InReplyTo)
+	assert.Equal(t, int64(1), comments[1].InReplyTo)
+	assert.Equal(t, int64(1), comments[2].InReplyTo)
+	assert.Equal(t, "main.go", comments[2].Path)
+	assert.Equal(t, 11, comments[2].Line)
+}
+
+func Test_bitbucket_provider_fails_on_a_malformed_next_page_link(t *testing.T) {
+	const pr = "/repositories/team/repo/pullrequests/5"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET " + pr:
+			w.Write([]byte(`{"id":5,"source":{"commit":{"hash":"head"}}}`))
+		case "GET /user":
+			w.Write([]byte(`{"uuid":"{bot}"}`))
+		case "GET " + pr + "/comments":
+			w.Write([]byte(`{"values":[{"id":1,"content":{"raw":"old"},"user":{"uuid":"{bot}"},"inline":{"path":"main.go","to":11}}],"next":"http://[::1%zz]/comments?page=2"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	provider, err := bitbucket.New(ctx, "secret", "team", "repo", 5, bitbucket.WithBaseURL(server.URL))
+	require.NoError(t, err)
+	_, err = provider.ListComments(ctx)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "parse next page link")
+}
@@ -0,0 +1,34 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/checkov"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_checkov_failed_checks_become_findings(t *testing.T) {
+	output := `[{"check_type":"terraform","results":{"passed_checks":[{"check_id":"CKV_AWS_21"}],"failed_checks":[
+{"check_id":"CKV_AWS_20","check_name":"S3 Bucket has an ACL defined which allows public READ access.","file_path":"/main.tf","repo_file_path":"/infra/main.tf",
+"file_line_range":[1,8],"resource":"aws_s3_bucket.data","severity":"HIGH","guideline":"https://docs.prismacloud.io/en/policy/CKV_AWS_20"}]}},
+{"check_type":"dockerfile","results":{"failed_checks":[{"check_id":"CKV_DOCKER_2","check_name":"Ensure that HEALTHCHECK instructions have been added","file_path":"/Dockerfile",
+"file_line_range":[1,3],"resource":"/Dockerfile.","severity":null,"guideline":""}]}}]`
+	findings, err := checkov.Parse(strings.NewReader(output))
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	assert.Equal(t, commenter.Finding{
+		Tool: checkov.Tool, RuleID: "CKV_AWS_20", Severity: commenter.SeverityError, Path: "infra/main.tf", StartLine: 1, EndLine: 8,
+		Message: "S3 Bucket has an ACL defined which allows public READ access. (`aws_s3_bucket.data`) ([guideline](https://docs.prismacloud.io/en/policy/CKV_AWS_20))",
+	}, findings[0])
+	assert.Equal(t, "Dockerfile", findings[1].Path)
+	assert.Equal(t, commenter.SeverityUnknown, findings[1].Severity)
+}
+
+func Test_checkov_summary_only_output_has_no_findings(t *testing.T) {
+	findings, err := checkov.Parse(strings.NewReader(`{"passed":0,"failed":0,"skipped":0,"parsing_errors":0,"resource_count":0}`))
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+}
@@ -0,0 +1,39 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/clangtidy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const clangTidyOutput = `2 warnings generated.
+/work/repo/src/a.cpp:12:5: warning: 'v' used after it was moved [bugprone-use-after-move]
+    v.push_back(1);
+    ^
+/work/repo/src/a.cpp:11:5: note: move occurred here
+/work/repo/include/util.h:3:1: error: use of undeclared identifier 'x' [clang-diagnostic-error]
+/work/repo/include/util.h:4:8: warning: Called C++ object pointer is null [clang-analyzer-core.CallAndMessage,-warnings-as-errors]
+/work/repo/include/util.h:2:3: note: Assuming 'p' is null
+/work/repo/include/util.h:4:8: warning: Called C++ object pointer is null [clang-analyzer-core.CallAndMessage,-warnings-as-errors]
+/work/repo/include/util.h:2:3: note: Assuming 'p' is null
+`
+
+func Test_clang_tidy_diagnostics_become_findings(t *testing.T) {
+	findings, err := clangtidy.Parse(strings.NewReader(clangTidyOutput))
+	require.NoError(t, err)
+	require.Len(t, findings, 3)
+	assert.Equal(t, commenter.Finding{
+		Tool: clangtidy.Tool, RuleID: "bugprone-use-after-move", Severity: commenter.SeverityWarning, Path: "/work/repo/src/a.cpp", StartLine: 12, EndLine: 12,
+		Message: "'v' used after it was moved ([bugprone-use-after-move](https://clang.llvm.org/extra/clang-tidy/checks/bugprone/use-after-move.html))" +
+			"\n\nnote: move occurred here (`/work/repo/src/a.cpp:11`)",
+	}, findings[0])
+	assert.Equal(t, commenter.SeverityError, findings[1].Severity)
+	assert.Equal(t, "use of undeclared identifier 'x'", findings[1].Message)
+	assert.Equal(t, "clang-analyzer-core.CallAndMessage", findings[2].RuleID)
+	assert.Contains(t, findings[2].Message, "(https://clang.llvm.org/extra/clang-tidy/checks/clang-analyzer/core.CallAndMessage.html)")
+	assert.Equal(t, 1, strings.Count(findings[2].Message, "note:"))
+}
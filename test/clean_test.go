@@ -0,0 +1,66 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_clean_previous_deletes_only_marked_comments(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,4 @@\n a\n-b\n+c\n+d\n+e")
+	server.AddComment("alice", "main.go", 2, "quoting <!-- pr-commenter:finding 0123abcd -->")
+
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+	_, err = c.Sync([]commenter.Finding{
+		{RuleID: "G101", Path: "main.go", StartLine: 2, Message: "hardcoded credentials"},
+		{RuleID: "G104", Path: "main.go", StartLine: 3, Message: "errors unhandled"},
+	})
+	require.NoError(t, err)
+	_, err = c.WriteComments([]commenter.PRReviewComment{{FileName: "main.go", StartLine: 4, EndLine: 4, Body: "plain"}})
+	require.NoError(t, err)
+	require.NoError(t, c.WriteGeneralComment("summary"))
+
+	c, err = server.NewCommenter()
+	require.NoError(t, err)
+	assert.Empty(t, c.CleanPrevious(commenter.PruneDelete))
+	assert.Len(t, server.DeletedCommentIDs(), 2)
+	comments := server.Comments()
+	require.Len(t, comments, 2)
+	assert.Equal(t, "alice", comments[0].GetUser().GetLogin())
+	assert.Equal(t, "plain", comments[1].GetBody())
+	assert.Len(t, server.IssueComments(), 1)
+}
+
+func Test_clean_previous_minimizes_and_rewrites_findings(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,4 @@\n a\n-b\n+c\n+d\n+e")
+	findings := []commenter.Finding{{RuleID: "G101", Path: "main.go", StartLine: 2, Message: "hardcoded credentials"}}
+
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+	_, err = c.Sync(findings)
+	require.NoError(t, err)
+
+	c, err = server.NewCommenter()
+	require.NoError(t, err)
+	assert.Empty(t, c.CleanPrevious(commenter.PruneMinimize))
+	var minimized int
+	for _, request := range server.GraphQLRequests() {
+		if strings.Contains(request, "minimizeComment") {
+			minimized++
+		}
+	}
+	assert.Equal(t, 1, minimized)
+	result, err := c.Sync(findings)
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultCreated, result.Results[0].Status)
+	assert.Len(t, server.Comments(), 2)
+}
@@ -0,0 +1,56 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_deduplication_collapses_findings_of_several_tools(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.tf", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+
+	findings := []commenter.Finding{
+		{Tool: "tfsec", RuleID: "AWS002", Path: "main.tf", StartLine: 2, Message: "Bucket has logging disabled"},
+		{Tool: "checkov", RuleID: "CKV_AWS_18", Path: "main.tf", StartLine: 2, Message: "bucket has  logging disabled"},
+		{Tool: "tfsec", RuleID: "AWS017", Path: "main.tf", StartLine: 3, Message: "Bucket is not encrypted"},
+	}
+	c, err := server.NewCommenter(commenter.WithDeduplication(nil), commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	results, err := c.WriteFindings(findings)
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultCreated, results[0].Status)
+	assert.Equal(t, commenter.ResultSuppressed, results[1].Status)
+	assert.Equal(t, commenter.ResultCreated, results[2].Status)
+
+	comments := server.Comments()
+	require.Len(t, comments, 2)
+	assert.Equal(t, "Bucket has logging disabled\n\nReported by tfsec (AWS002), checkov (CKV_AWS_18)", comments[0].GetBody())
+	assert.Equal(t, "**AWS017**: Bucket is not encrypted", comments[1].GetBody())
+}
+
+func Test_deduplication_uses_the_normalization_hook(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.tf", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+
+	// collapse everything reported for a line regardless of the wording
+	byLine := func(f commenter.Finding) string {
+		return fmt.Sprintf("%s:%d", f.Path, f.StartLine)
+	}
+	c, err := server.NewCommenter(commenter.WithDeduplication(byLine))
+	require.NoError(t, err)
+	_, err = c.WriteFindings([]commenter.Finding{
+		{Tool: "tfsec", Path: "main.tf", StartLine: 2, Message: "logging disabled"},
+		{Tool: "checkov", Path: "main.tf", StartLine: 2, Message: "access logs are off"},
+	})
+	require.NoError(t, err)
+	comments := server.Comments()
+	require.Len(t, comments, 1)
+	assert.Contains(t, comments[0].GetBody(), "Reported by tfsec, checkov")
+}
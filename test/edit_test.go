@@ -0,0 +1,37 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_review_comments_are_edited_and_deleted_by_id(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+	other := server.AddComment("alice", "main.go", 2, "looks odd")
+
+	c, err := server.NewCommenter(commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	results, err := c.WriteComments([]commenter.PRReviewComment{
+		{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "first"},
+		{FileName: "main.go", StartLine: 3, EndLine: 3, Body: "second"},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, c.EditReviewComment(results[0].CommentID, "first, edited"))
+	require.NoError(t, c.DeleteReviewComment(results[1].CommentID))
+	err = c.EditReviewComment(other, "hijacked")
+	assert.True(t, errors.Is(err, commenter.ErrCommentNotFound))
+
+	comments := server.Comments()
+	require.Len(t, comments, 2)
+	assert.Equal(t, "looks odd", comments[0].GetBody())
+	assert.Equal(t, "first, edited", comments[1].GetBody())
+	assert.Equal(t, []int64{results[1].CommentID}, server.DeletedCommentIDs())
+}
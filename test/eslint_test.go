@@ -0,0 +1,55 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/mugioka/go-github-pr-commenter/commenter/eslint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const eslintOutput = `[{"filePath":"/work/repo/src/app.ts","messages":[
+{"ruleId":"prefer-const","severity":2,"message":"'x' is never reassigned. Use 'const' instead.","line":2,"column":5,"endLine":2,"endColumn":6,"fix":{"range":[15,18],"text":"const"}},
+{"ruleId":"@typescript-eslint/no-unused-vars","severity":1,"message":"'y' is defined but never used.","line":3,"column":7,"endLine":3,"endColumn":8,
+ "suggestions":[{"messageId":"removeUnusedVar","desc":"Remove unused variable 'y'.","fix":{"range":[25,37],"text":""}}]},
+{"ruleId":"react/jsx-key","severity":2,"message":"Missing \"key\" prop","line":4,"column":1}],
+"errorCount":2,"warningCount":1,"source":"// 🎉 greeting\nlet x = 1\nconst y = 2\nrender()\n"},
+{"filePath":"/work/repo/src/broken.js","messages":[{"ruleId":null,"fatal":true,"severity":2,"message":"Parsing error: Unexpected token","line":1,"column":5}],"errorCount":1}]`
+
+func Test_eslint_results_become_findings_with_fixes(t *testing.T) {
+	findings, err := eslint.Parse(strings.NewReader(eslintOutput), nil)
+	require.NoError(t, err)
+	require.Len(t, findings, 4)
+
+	assert.Equal(t, "prefer-const", findings[0].RuleID)
+	assert.Equal(t, commenter.SeverityError, findings[0].Severity)
+	assert.Equal(t, "'x' is never reassigned. Use 'const' instead. ([prefer-const](https://eslint.org/docs/latest/rules/prefer-const))", findings[0].Message)
+	require.NotNil(t, findings[0].Suggestion)
+	assert.Equal(t, "const x = 1", *findings[0].Suggestion)
+	assert.Equal(t, []int{2, 2}, []int{findings[0].StartLine, findings[0].EndLine})
+
+	assert.Equal(t, commenter.SeverityWarning, findings[1].Severity)
+	assert.Contains(t, findings[1].Message, "(https://typescript-eslint.io/rules/no-unused-vars)")
+	assert.True(t, strings.HasSuffix(findings[1].Message, "\n\nRemove unused variable 'y'."))
+	require.NotNil(t, findings[1].Suggestion)
+	assert.Equal(t, "render()", *findings[1].Suggestion)
+	assert.Equal(t, []int{3, 4}, []int{findings[1].StartLine, findings[1].EndLine})
+
+	assert.Equal(t, "Missing \"key\" prop", findings[2].Message)
+	assert.Nil(t, findings[2].Suggestion)
+	assert.Equal(t, "", findings[3].RuleID)
+	assert.Equal(t, commenter.SeverityError, findings[3].Severity)
+
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("src/app.ts", "@@ -1,2 +1,4 @@\n // 🎉 greeting\n-var x = 1\n+let x = 1\n+const y = 2\n render()")
+	c, err := server.NewCommenter(commenter.WithRepoRoot("/work/repo"), commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	results, err := c.WriteFindings(findings[:1])
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultCreated, results[0].Status)
+	assert.Contains(t, server.Comments()[0].GetBody(), "```suggestion\nconst x = 1\n```")
+}
@@ -0,0 +1,66 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// etagServer serves PR 8 with an ETag and answers a matching If-None-Match with an empty 304
+type etagServer struct {
+	mu           sync.Mutex
+	notModified  int
+	conditionals map[string]bool
+}
+
+func (s *etagServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	conditional := r.Header.Get("If-None-Match") != ""
+	s.conditionals[r.Header.Get("Authorization")] = s.conditionals[r.Header.Get("Authorization")] || conditional
+	if r.Header.Get("If-None-Match") == `"v1"` {
+		s.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", `"v1"`)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&github.PullRequest{Number: github.Int(8), Head: &github.PullRequestBranch{SHA: github.String("0000000")}})
+}
+
+func Test_etag_cache_answers_a_304_with_the_cached_body(t *testing.T) {
+	handler := &etagServer{conditionals: map[string]bool{}}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	cache := commenter.NewMemoryETagCache()
+
+	for i := 0; i < 2; i++ {
+		// a 304 with an empty body would fail to decode, so the PR must come from the cache
+		_, err := commenter.NewCommenter("fake-token", "owner", "repo", 8, commenter.WithBaseURL(server.URL), commenter.WithETagCache(cache))
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 1, handler.notModified)
+}
+
+func Test_etag_cache_keeps_the_responses_of_different_tokens_apart(t *testing.T) {
+	handler := &etagServer{conditionals: map[string]bool{}}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	cache := commenter.NewMemoryETagCache()
+
+	for _, token := range []string{"first-token", "second-token"} {
+		_, err := commenter.NewCommenter(token, "owner", "repo", 8, commenter.WithBaseURL(server.URL), commenter.WithETagCache(cache))
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 0, handler.notModified)
+	assert.False(t, handler.conditionals["Bearer second-token"])
+}
@@ -0,0 +1,315 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func Test_fake_server_records_a_pr_review(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,4 @@")
+	oldID := server.AddComment(commenter.CommenterName, "main.go", 2, "previous run")
+
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+
+	drafts := c.CreateDraftPRReviewComments([]commenter.PRReviewComment{
+		{FileName: "main.go", StartLine: 1, EndLine: 2, Body: "multi line"},
+		{FileName: "other.go", StartLine: 1, EndLine: 1, Body: "not in the pr"},
+	})
+	require.NoError(t, c.WritePRReview(drafts, commenter.RequestChanges))
+
+	reviews := server.Reviews()
+	require.Len(t, reviews, 1)
+	assert.Equal(t, commenter.RequestChanges, reviews[0].GetEvent())
+	assert.Len(t, reviews[0].Comments, 1)
+	assert.Equal(t, []int64{oldID}, server.DeletedCommentIDs())
+}
+
+func Test_fake_server_reports_missing_pr(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+
+	_, err := commenter.NewCommenter("fake-token", "owner", "repo", 8, server.Options()...)
+
+	assert.True(t, errors.Is(err, commenter.ErrPRNotFound))
+}
+
+func Test_only_a_404_pr_lookup_is_reported_as_missing(t *testing.T) {
+	status := http.StatusNotFound
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-GitHub-Request-Id", "ABCD:1234")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"message":"failed"}`))
+	}))
+	defer server.Close()
+
+	_, err := commenter.NewCommenter("fake-token", "owner", "repo", 8, commenter.WithBaseURL(server.URL))
+	assert.True(t, errors.Is(err, commenter.ErrPRNotFound))
+	var apiErr commenter.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "ABCD:1234", apiErr.Metadata.RequestID)
+
+	status = http.StatusUnauthorized
+	_, err = commenter.NewCommenter("fake-token", "owner", "repo", 8, commenter.WithBaseURL(server.URL))
+	assert.False(t, errors.Is(err, commenter.ErrPRNotFound))
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = commenter.NewCommenterContext(ctx, "fake-token", "owner", "repo", 8, commenter.WithBaseURL(server.URL))
+	assert.False(t, errors.Is(err, commenter.ErrPRNotFound))
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func Test_manager_shares_one_client_between_commenters(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,4 @@")
+
+	manager, err := commenter.NewManager("fake-token", server.Options()...)
+	require.NoError(t, err)
+
+	first, err := manager.Commenter(context.Background(), "owner", "repo", 7)
+	require.NoError(t, err)
+	again, err := manager.Commenter(context.Background(), "owner", "repo", 7)
+	require.NoError(t, err)
+	assert.Same(t, first, again)
+
+	manager.Forget("owner", "repo", 7)
+	fresh, err := manager.Commenter(context.Background(), "owner", "repo", 7)
+	require.NoError(t, err)
+	assert.NotSame(t, first, fresh)
+	assert.Same(t, first.GitHubClient(), fresh.GitHubClient())
+
+	_, err = manager.Commenter(context.Background(), "owner", "repo", 8)
+	assert.True(t, errors.Is(err, commenter.ErrPRNotFound))
+}
+
+func Test_manager_sync_twice_on_one_pr_writes_one_comment(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,4 @@\n a\n-b\n+c\n+d\n+e")
+
+	manager, err := commenter.NewManager("fake-token", server.Options()...)
+	require.NoError(t, err)
+	findings := []commenter.Finding{{RuleID: "G101", Path: "main.go", StartLine: 2, Message: "hardcoded credentials"}}
+
+	c, err := manager.Commenter(context.Background(), "owner", "repo", 7)
+	require.NoError(t, err)
+	_, err = c.Sync(findings)
+	require.NoError(t, err)
+	result, err := c.Sync(findings)
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultUnchanged, result.Results[0].Status)
+
+	manager.Forget("owner", "repo", 7)
+	c, err = manager.Commenter(context.Background(), "owner", "repo", 7)
+	require.NoError(t, err)
+	result, err = c.Sync(findings)
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultUnchanged, result.Results[0].Status)
+	assert.Len(t, server.Comments(), 1)
+}
+
+func Test_commit_comments_are_written_when_the_commit_has_no_pr(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+
+	opts := append(server.Options(), commenter.WithCommitCommentFallback())
+	_, err := commenter.NewCommenterForCommit("fake-token", "owner", "repo", "0000000", server.Options()...)
+	assert.True(t, errors.Is(err, commenter.ErrPRNotFound))
+	c, err := commenter.NewCommenterForCommit("fake-token", "owner", "repo", "0000000", opts...)
+	require.NoError(t, err)
+
+	drafts := c.CreateDraftPRReviewComments([]commenter.PRReviewComment{{FileName: "main.go", StartLine: 3, EndLine: 3, Body: "finding"}})
+	require.NoError(t, c.WritePRReview(drafts, commenter.RequestChanges))
+
+	comments := server.CommitComments()
+	require.Len(t, comments, 2)
+	assert.Equal(t, "main.go", comments[0].GetPath())
+	assert.Equal(t, 4, comments[0].GetPosition())
+	assert.Equal(t, "0000000", comments[0].GetCommitID())
+	assert.Equal(t, commenter.RequestChangesBody, comments[1].GetBody())
+	assert.Empty(t, server.Reviews())
+
+	again, err := commenter.NewCommitCommenter("fake-token", "owner", "repo", "0000000", server.Options()...)
+	require.NoError(t, err)
+	require.NoError(t, again.WritePRReview(nil, commenter.Approve))
+	assert.Equal(t, []int64{comments[0].GetID()}, server.DeletedCommentIDs())
+}
+
+func Test_general_comments_are_written_on_an_issue(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddIssue(12)
+
+	_, err := commenter.NewIssueCommenter("fake-token", "owner", "repo", 13, server.Options()...)
+	assert.True(t, errors.Is(err, commenter.ErrIssueNotFound))
+
+	c, err := commenter.NewIssueCommenter("fake-token", "owner", "repo", 12, server.Options()...)
+	require.NoError(t, err)
+	require.NoError(t, c.WriteGeneralComment("nightly scan found 3 issues"))
+	results, err := c.WriteComments([]commenter.PRReviewComment{{FileName: "main.go", StartLine: 1, EndLine: 1, Body: "finding"}})
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultSkipped, results[0].Status)
+
+	comments := server.IssueComments()
+	require.Len(t, comments, 1)
+	assert.Equal(t, "nightly scan found 3 issues", comments[0].GetBody())
+	assert.Contains(t, comments[0].GetIssueURL(), "/issues/12")
+	assert.Empty(t, server.Reviews())
+}
+
+func Test_review_falls_back_when_the_token_cannot_comment(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+	server.ReadOnly = true
+
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+	drafts := c.CreateDraftPRReviewComments([]commenter.PRReviewComment{{FileName: "main.go", StartLine: 3, EndLine: 3, Body: "finding"}})
+	err = c.WritePRReview(drafts, commenter.RequestChanges)
+	assert.True(t, errors.Is(err, commenter.ErrInsufficientPermissions))
+	assert.True(t, errors.Is(err, commenter.ErrForbidden))
+
+	var log bytes.Buffer
+	summary := filepath.Join(t.TempDir(), "summary.md")
+	c, err = server.NewCommenter(commenter.WithPermissionFallback(commenter.LogSink(&log)))
+	require.NoError(t, err)
+	err = c.WritePRReview(drafts, commenter.RequestChanges)
+	var permErr commenter.InsufficientPermissionsError
+	require.True(t, errors.As(err, &permErr))
+	assert.Equal(t, "log", permErr.Fallback)
+	assert.Contains(t, log.String(), "::error file=main.go,line=3,endLine=3::finding")
+
+	c, err = server.NewCommenter(commenter.WithPermissionFallback(commenter.StepSummarySink(summary)))
+	require.NoError(t, err)
+	_, err = c.WriteComments([]commenter.PRReviewComment{{FileName: "main.go", StartLine: 2, EndLine: 3, Body: "finding"}})
+	assert.True(t, errors.Is(err, commenter.ErrInsufficientPermissions))
+	written, err := ioutil.ReadFile(summary)
+	require.NoError(t, err)
+	assert.Contains(t, string(written), "- `main.go:2-3`: finding")
+	assert.Empty(t, server.Comments())
+}
+
+func Test_preflight_reports_the_missing_scope(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+	require.NoError(t, c.Preflight())
+
+	server.Scopes = "read:org, public_repo"
+	err = c.Preflight()
+	var preflightErr commenter.PreflightError
+	require.True(t, errors.As(err, &preflightErr))
+	assert.Equal(t, []string{"repo"}, preflightErr.Missing)
+	assert.True(t, errors.Is(err, commenter.ErrInsufficientPermissions))
+
+	server.Scopes = "repo"
+	assert.NoError(t, c.Preflight())
+}
+
+func Test_preflight_requires_push_access(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+
+	server.ReadOnly = true
+	err = c.Preflight()
+
+	var preflightErr commenter.PreflightError
+	require.True(t, errors.As(err, &preflightErr))
+	assert.Equal(t, []string{"push"}, preflightErr.Missing)
+}
+
+func Test_preflight_reports_a_repository_the_token_cant_see(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	fault := &faultTransport{method: http.MethodGet, suffix: "/repos/owner/repo", status: http.StatusNotFound}
+	c, err := server.NewCommenter(commenter.WithTransport(fault))
+	require.NoError(t, err)
+
+	err = c.Preflight()
+
+	var repoErr commenter.RepoNotAccessibleError
+	require.True(t, errors.As(err, &repoErr))
+	assert.Equal(t, http.StatusNotFound, repoErr.StatusCode)
+	assert.True(t, errors.Is(err, commenter.ErrRepoNotAccessible))
+	assert.False(t, errors.As(err, &commenter.PreflightError{}))
+}
+
+type countingTokenSource struct {
+	calls int
+}
+
+func (s *countingTokenSource) Token() (*oauth2.Token, error) {
+	s.calls++
+	// already within the refresh window so every request asks for a new token
+	return &oauth2.Token{AccessToken: fmt.Sprintf("token-%d", s.calls), Expiry: time.Now()}, nil
+}
+
+type authRecorder struct {
+	headers []string
+}
+
+func (r *authRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.headers = append(r.headers, req.Header.Get("Authorization"))
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func Test_token_source_is_refreshed_between_requests(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+
+	_, err := commenter.NewCommenter("", "owner", "repo", 7, server.Options()...)
+	assert.Error(t, err)
+
+	ts := &countingTokenSource{}
+	recorder := &authRecorder{}
+	c, err := commenter.NewCommenter("", "owner", "repo", 7, append(server.Options(), commenter.WithTokenSource(ts), commenter.WithTransport(recorder))...)
+	require.NoError(t, err)
+	require.NoError(t, c.WriteGeneralComment("done"))
+
+	require.True(t, len(recorder.headers) >= 2)
+	assert.Equal(t, "Bearer token-1", recorder.headers[0])
+	assert.Equal(t, fmt.Sprintf("Bearer token-%d", ts.calls), recorder.headers[len(recorder.headers)-1])
+	assert.NotEqual(t, recorder.headers[0], recorder.headers[len(recorder.headers)-1])
+}
+
+func Test_saml_sso_errors_carry_the_authorization_url(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.SSOURL = "https://github.com/orgs/owner/sso?authorization_request=abc"
+
+	_, err := server.NewCommenter()
+	var ssoErr commenter.SSOAuthorizationError
+	require.True(t, errors.As(err, &ssoErr))
+	assert.Equal(t, server.SSOURL, ssoErr.AuthorizationURL)
+	assert.True(t, errors.Is(err, commenter.ErrSSORequired))
+	assert.True(t, errors.Is(err, commenter.ErrForbidden))
+	assert.False(t, errors.Is(err, commenter.ErrPRNotFound))
+	assert.Contains(t, err.Error(), server.SSOURL)
+}
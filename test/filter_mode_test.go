@@ -0,0 +1,143 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_filter_modes(t *testing.T) {
+	comments := []commenter.PRReviewComment{
+		{FileName: "main.go", StartLine: 1, EndLine: 1, Body: "context line"},
+		{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "added line"},
+		{FileName: "main.go", StartLine: 40, EndLine: 40, Body: "outside the hunk"},
+		{FileName: "other.go", StartLine: 3, EndLine: 3, Body: "unchanged file"},
+	}
+	for _, tt := range []struct {
+		mode     string
+		statuses []commenter.ResultStatus
+	}{
+		{"diff_context", []commenter.ResultStatus{commenter.ResultCreated, commenter.ResultCreated, commenter.ResultSkipped, commenter.ResultSkipped}},
+		{"added", []commenter.ResultStatus{commenter.ResultSkipped, commenter.ResultCreated, commenter.ResultSkipped, commenter.ResultSkipped}},
+		{"file", []commenter.ResultStatus{commenter.ResultCreated, commenter.ResultCreated, commenter.ResultSummarized, commenter.ResultSkipped}},
+		{"nofilter", []commenter.ResultStatus{commenter.ResultCreated, commenter.ResultCreated, commenter.ResultSummarized, commenter.ResultSummarized}},
+	} {
+		t.Run(tt.mode, func(t *testing.T) {
+			server := commentertest.NewServer("owner", "repo", 7)
+			defer server.Close()
+			server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+
+			mode, err := commenter.ParseFilterMode(tt.mode)
+			require.NoError(t, err)
+			assert.Equal(t, tt.mode, mode.String())
+			c, err := server.NewCommenter(commenter.WithFilterMode(mode))
+			require.NoError(t, err)
+			results, _ := c.WriteComments(comments)
+			for i, result := range results {
+				assert.Equal(t, tt.statuses[i], result.Status, comments[i].Body)
+			}
+		})
+	}
+}
+
+func Test_file_filter_mode_mentions_in_the_review_body(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+
+	c, err := server.NewCommenter(commenter.WithFilterMode(commenter.FilterFile))
+	require.NoError(t, err)
+	drafts := c.CreateDraftPRReviewComments([]commenter.PRReviewComment{
+		{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "inline"},
+		{FileName: "main.go", StartLine: 40, EndLine: 40, Body: "far away"},
+	})
+	require.Len(t, drafts, 1)
+	require.NoError(t, c.WritePRReview(drafts, commenter.RequestChanges))
+
+	reviews := server.Reviews()
+	require.Len(t, reviews, 1)
+	assert.Contains(t, reviews[0].GetBody(), commenter.RequestChangesBody)
+	assert.Contains(t, reviews[0].GetBody(), "- `main.go:40`: far away")
+}
+
+func Test_nearest_line_anchors_comments_outside_the_diff(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -10,2 +10,4 @@\n a\n-b\n+c\n+d\n e")
+
+	c, err := server.NewCommenter(commenter.WithNearestLine(3))
+	require.NoError(t, err)
+	results, err := c.WriteComments([]commenter.PRReviewComment{
+		{FileName: "main.go", StartLine: 14, EndLine: 14, Body: "just below"},
+		{FileName: "main.go", StartLine: 20, EndLine: 20, Body: "too far"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultCreated, results[0].Status)
+	assert.Equal(t, 14, results[0].Comment.EndLine)
+	assert.Equal(t, commenter.ResultSkipped, results[1].Status)
+
+	comments := server.Comments()
+	require.Len(t, comments, 1)
+	assert.Equal(t, 12, comments[0].GetLine())
+	assert.Contains(t, comments[0].GetBody(), "Reported on line 14")
+}
+
+func Test_comments_are_posted_sorted_by_path_and_line(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("a.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+	server.AddFile("b.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+
+	comments := []commenter.PRReviewComment{
+		{FileName: "b.go", StartLine: 2, EndLine: 2, Body: "b2"},
+		{FileName: "a.go", StartLine: 3, EndLine: 3, Body: "a3"},
+		{FileName: "a.go", StartLine: 2, EndLine: 2, Body: "a2"},
+	}
+	c, err := server.NewCommenter(commenter.WithConcurrency(1))
+	require.NoError(t, err)
+
+	drafts := c.CreateDraftPRReviewComments(comments)
+	var drafted []string
+	for _, draft := range drafts {
+		drafted = append(drafted, draft.GetBody())
+	}
+	assert.Equal(t, []string{"a2", "a3", "b2"}, drafted)
+
+	results, err := c.WriteComments(comments)
+	require.NoError(t, err)
+	assert.Equal(t, "b2", results[0].Comment.Body)
+	var posted []string
+	for _, comment := range server.Comments() {
+		posted = append(posted, comment.GetBody())
+	}
+	assert.Equal(t, []string{"a2", "a3", "b2"}, posted)
+}
+
+func Test_comments_over_the_per_file_cap_are_listed_together(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("noisy.go", "@@ -1,1 +1,4 @@\n a\n+b\n+c\n+d")
+
+	var comments []commenter.PRReviewComment
+	for line := 1; line <= 4; line++ {
+		comments = append(comments, commenter.PRReviewComment{FileName: "noisy.go", StartLine: line, EndLine: line, Body: fmt.Sprintf("finding %d", line)})
+	}
+	c, err := server.NewCommenter(commenter.WithMaxCommentsPerFile(2), commenter.WithConcurrency(1))
+	require.NoError(t, err)
+
+	drafts := c.CreateDraftPRReviewComments(comments)
+	require.Len(t, drafts, 3)
+	assert.Equal(t, 1, drafts[2].GetLine())
+	assert.Equal(t, "2 more findings in this file:\n\n- `noisy.go:3`: finding 3\n- `noisy.go:4`: finding 4", drafts[2].GetBody())
+
+	results, err := c.WriteComments(comments)
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultCreated, results[1].Status)
+	assert.Equal(t, commenter.ResultSummarized, results[2].Status)
+	assert.Equal(t, results[2].CommentID, results[3].CommentID)
+	assert.Len(t, server.Comments(), 3)
+}
@@ -0,0 +1,25 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/flake8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_flake8_errors_become_findings(t *testing.T) {
+	output := "pkg/a.py:1:1: F401 'os' imported but unused\npkg/a.py:12:80: E501 line too long (88 > 79 characters)\n" +
+		"    x = 1\n    ^\npkg/b.py:3:5: E999 SyntaxError: invalid syntax\n"
+	findings, err := flake8.Parse(strings.NewReader(output))
+	require.NoError(t, err)
+	require.Len(t, findings, 3)
+	assert.Equal(t, commenter.Finding{
+		Tool: flake8.Tool, RuleID: "F401", Severity: commenter.SeverityError, Path: "pkg/a.py", StartLine: 1, EndLine: 1,
+		Message: "'os' imported but unused",
+	}, findings[0])
+	assert.Equal(t, commenter.SeverityWarning, findings[1].Severity)
+	assert.Equal(t, commenter.SeverityError, findings[2].Severity)
+}
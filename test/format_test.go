@@ -0,0 +1,41 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_code_fence_detects_the_language(t *testing.T) {
+	assert.Equal(t, "go", commenter.LanguageFor("cmd/main.go"))
+	assert.Equal(t, "typescript", commenter.LanguageFor("src/App.TS"))
+	assert.Equal(t, "dockerfile", commenter.LanguageFor("build/Dockerfile.dev"))
+	assert.Equal(t, "", commenter.LanguageFor("LICENSE"))
+
+	assert.Equal(t, "```go\nerr := f()\n```", commenter.CodeFence("main.go", "err := f()\n"))
+	assert.Equal(t, "````markdown\n```sh\nls\n```\n````", commenter.CodeFence("README.md", "```sh\nls\n```"))
+	assert.Equal(t, "```\nplain\n```", commenter.CodeFence("NOTICE", "plain"))
+}
+
+func Test_finding_snippet_is_fenced(t *testing.T) {
+	finding := commenter.Finding{RuleID: "G104", Path: "main.go", StartLine: 3, Message: "errors unhandled", Snippet: "f()"}
+	assert.Equal(t, "**G104**: errors unhandled\n\n```go\nf()\n```", finding.Comment().Body)
+}
+
+func Test_diff_snippet_is_added_to_comments(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+
+	c, err := server.NewCommenter(commenter.WithDiffSnippet())
+	require.NoError(t, err)
+	_, err = c.WriteComments([]commenter.PRReviewComment{{FileName: "main.go", StartLine: 1, EndLine: 2, Body: "unchecked"}})
+	require.NoError(t, err)
+
+	comments := server.Comments()
+	require.Len(t, comments, 1)
+	assert.Equal(t, "unchecked\n\n```diff\n a\n+c\n```", comments[0].GetBody())
+}
@@ -0,0 +1,74 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/gitea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_gitea_provider_posts_single_comment_reviews(t *testing.T) {
+	const repo = "/api/v1/repos/owner/repo"
+	var (
+		reviews []map[string]interface{}
+		deleted []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+		switch r.Method + " " + r.URL.Path {
+		case "GET " + repo + "/pulls/4":
+			w.Write([]byte(`{"head":{"sha":"head"}}`))
+		case "GET /api/v1/user":
+			w.Write([]byte(`{"login":"lint-bot"}`))
+		case "GET " + repo + "/pulls/4.diff":
+			w.Write([]byte(bitbucketDiff))
+		case "GET " + repo + "/pulls/4/reviews":
+			w.Write([]byte(`[{"id":1,"user":{"login":"lint-bot"}},{"id":2,"user":{"login":"alice"}}]`))
+		case "GET " + repo + "/pulls/4/reviews/1/comments":
+			w.Write([]byte(`[{"id":10,"body":"old","user":{"login":"lint-bot"},"path":"main.go","position":11}]`))
+		case "GET " + repo + "/pulls/4/reviews/2/comments":
+			w.Write([]byte(`[{"id":20,"body":"human","user":{"login":"alice"},"path":"main.go","position":11}]`))
+		case "POST " + repo + "/pulls/4/reviews":
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			reviews = append(reviews, body)
+			w.Write([]byte(`{"id":3}`))
+		case "GET " + repo + "/pulls/4/reviews/3/comments":
+			w.Write([]byte(`[{"id":30,"body":"new","user":{"login":"lint-bot"},"path":"main.go","position":12,"html_url":"https://gitea/c/30"}]`))
+		case "POST " + repo + "/issues/4/comments":
+			w.Write([]byte(`{"id":40,"body":"Approve:tada:","user":{"login":"lint-bot"}}`))
+		case "DELETE " + repo + "/pulls/4/reviews/1":
+			deleted = append(deleted, "review 1")
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	provider, err := gitea.New(ctx, server.URL, "secret", "owner", "repo", 4)
+	require.NoError(t, err)
+	c, err := commenter.NewCommenterWithProvider(ctx, provider)
+	require.NoError(t, err)
+
+	assert.Empty(t, c.PruneOutdatedComments(commenter.PruneDelete))
+	results, err := c.WriteComments([]commenter.PRReviewComment{{FileName: "main.go", StartLine: 12, EndLine: 12, Body: "new"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(30), results[0].CommentID)
+	assert.Equal(t, "https://gitea/c/30", results[0].URL)
+
+	require.Len(t, reviews, 1)
+	assert.Equal(t, "head", reviews[0]["commit_id"])
+	comment := reviews[0]["comments"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, float64(12), comment["new_position"])
+
+	require.NoError(t, c.WritePRReview(nil, commenter.Approve))
+	assert.Equal(t, []string{"review 1"}, deleted)
+}
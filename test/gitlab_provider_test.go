@@ -0,0 +1,197 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/gitlab"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newGitLabServer(t *testing.T, posted *[]map[string]interface{}, deleted *[]string) *httptest.Server {
+	const mr = "/projects/group%2Fproject/merge_requests/3"
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.EscapedPath() {
+		case "GET " + mr:
+			w.Write([]byte(`{"iid":3,"diff_refs":{"base_sha":"base","head_sha":"head","start_sha":"start"}}`))
+		case "GET /user":
+			w.Write([]byte(`{"username":"lint-bot"}`))
+		case "GET " + mr + "/diffs":
+			w.Write([]byte(`[{"old_path":"main.go","new_path":"main.go","diff":"@@ -10,3 +10,4 @@ func main()\n a\n+b\n c\n d\n"}]`))
+		case "GET " + mr + "/discussions":
+			w.Write([]byte(`[
+				{"id":"d1","notes":[{"id":11,"body":"old","author":{"username":"lint-bot"},"position":{"new_path":"main.go","new_line":11}}]},
+				{"id":"d2","notes":[{"id":12,"body":"human","author":{"username":"alice"},"position":{"new_path":"main.go","new_line":11}}]},
+				{"id":"d3","notes":[{"id":13,"body":"general","author":{"username":"lint-bot"}}]}
+			]`))
+		case "POST " + mr + "/discussions":
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			*posted = append(*posted, body)
+			w.Write([]byte(`{"id":"d9","notes":[{"id":99}]}`))
+		case "DELETE " + mr + "/notes/11":
+			*deleted = append(*deleted, "11")
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func Test_gitlab_provider_posts_position_based_notes(t *testing.T) {
+	var (
+		posted  []map[string]interface{}
+		deleted []string
+	)
+	server := newGitLabServer(t, &posted, &deleted)
+	defer server.Close()
+
+	ctx := context.Background()
+	provider, err := gitlab.New(ctx, "secret", "group/project", 3, gitlab.WithBaseURL(server.URL))
+	require.NoError(t, err)
+	assert.Equal(t, "lint-bot", provider.CommenterName())
+
+	c, err := commenter.NewCommenterWithProvider(ctx, provider, commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	results, err := c.WriteComments([]commenter.PRReviewComment{
+		{FileName: "main.go", StartLine: 11, EndLine: 11, Body: "added line"},
+		{FileName: "main.go", StartLine: 12, EndLine: 12, Body: "context line"},
+		{FileName: "main.go", StartLine: 40, EndLine: 40, Body: "outside the diff"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultCreated, results[0].Status)
+	assert.Equal(t, int64(99), results[0].CommentID)
+	assert.Equal(t, commenter.ResultSkipped, results[2].Status)
+
+	require.Len(t, posted, 2)
+	first := posted[0]["position"].(map[string]interface{})
+	assert.Equal(t, "head", first["head_sha"])
+	assert.Equal(t, float64(11), first["new_line"])
+	assert.Nil(t, first["old_line"])
+	second := posted[1]["position"].(map[string]interface{})
+	assert.Equal(t, float64(12), second["new_line"])
+	assert.Equal(t, float64(11), second["old_line"])
+
+	assert.Empty(t, c.PruneOutdatedComments(commenter.PruneDelete))
+	assert.Empty(t, deleted)
+}
+
+func Test_gitlab_provider_reports_missing_merge_requests(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err := gitlab.New(context.Background(), "secret", "group/project", 3, gitlab.WithBaseURL(server.URL))
+	assert.ErrorIs(t, err, commenter.ErrPRNotFound)
+}
+
+func Test_gitlab_provider_replies_acknowledge_findings(t *testing.T) {
+	const mr = "/projects/group%2Fproject/merge_requests/3"
+	finding := commenter.Finding{RuleID: "G101", Path: "main.go", StartLine: 11, Severity: commenter.SeverityError, Message: "hardcoded credentials"}
+	marked, err := json.Marshal("**G101**: hardcoded credentials\n\n<!-- pr-commenter:finding " + commenter.Fingerprint(finding) + " -->")
+	require.NoError(t, err)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.EscapedPath() {
+		case "GET " + mr:
+			w.Write([]byte(`{"iid":3,"diff_refs":{"base_sha":"base","head_sha":"head","start_sha":"start"}}`))
+		case "GET /user":
+			w.Write([]byte(`{"username":"lint-bot"}`))
+		case "GET " + mr + "/diffs":
+			w.Write([]byte(`[{"old_path":"main.go","new_path":"main.go","diff":"@@ -10,3 +10,4 @@ func main()\n a\n+b\n c\n d\n"}]`))
+		case "GET " + mr + "/discussions":
+			w.Write([]byte(`[{"id":"d1","notes":[
+				{"id":11,"body":` + string(marked) + `,"author":{"username":"lint-bot"},"position":{"new_path":"main.go","new_line":11}},
+				{"id":12,"body":"resolved the thread","system":true,"author":{"username":"alice"}},
+				{"id":13,"body":"ack","author":{"username":"alice"},"position":{"new_path":"main.go","new_line":11}}
+			]}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	provider, err := gitlab.New(ctx, "secret", "group/project", 3, gitlab.WithBaseURL(server.URL))
+	require.NoError(t, err)
+	comments, err := provider.ListComments(ctx)
+	require.NoError(t, err)
+	require.Len(t, comments, 2)
+	assert.Equal(t, int64(0), comments[0].InReplyTo)
+	assert.Equal(t, int64(11), comments[1].InReplyTo)
+	assert.Equal(t, "d1", comments[1].NodeID)
+
+	c, err := commenter.NewCommenterWithProvider(ctx, provider, commenter.WithAcknowledgements())
+	require.NoError(t, err)
+	result, err := c.SyncContext(ctx, []commenter.Finding{finding})
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultSuppressed, result.Results[0].Status)
+}
+
+func Test_gitlab_provider_links_notes_of_a_numeric_project(t *testing.T) {
+	const mr = "/projects/42/merge_requests/3"
+	const web = "https://gitlab.example.com/group/project/-/merge_requests/3"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.EscapedPath() {
+		case "GET " + mr:
+			w.Write([]byte(`{"iid":3,"web_url":"` + web + `","diff_refs":{"base_sha":"base","head_sha":"head","start_sha":"start"}}`))
+		case "GET /user":
+			w.Write([]byte(`{"username":"lint-bot"}`))
+		case "GET " + mr + "/discussions":
+			w.Write([]byte(`[{"id":"d1","notes":[{"id":11,"body":"finding","author":{"username":"lint-bot"},"position":{"new_path":"main.go","new_line":11}}]}]`))
+		case "POST " + mr + "/notes":
+			w.Write([]byte(`{"id":12}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	provider, err := gitlab.New(ctx, "secret", "42", 3, gitlab.WithBaseURL(server.URL))
+	require.NoError(t, err)
+	comments, err := provider.ListComments(ctx)
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	assert.Equal(t, web+"#note_11", comments[0].URL)
+	summary, err := provider.CreateSummaryComment(ctx, "summary")
+	require.NoError(t, err)
+	assert.Equal(t, web+"#note_12", summary.URL)
+}
+
+func Test_gitlab_provider_lists_files_while_comments_are_written(t *testing.T) {
+	var (
+		posted  []map[string]interface{}
+		deleted []string
+	)
+	server := newGitLabServer(t, &posted, &deleted)
+	defer server.Close()
+
+	ctx := context.Background()
+	provider, err := gitlab.New(ctx, "secret", "group/project", 3, gitlab.WithBaseURL(server.URL))
+	require.NoError(t, err)
+	_, err = provider.ListChangedFiles(ctx)
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 5; i++ {
+			_, err := provider.ListChangedFiles(ctx)
+			assert.NoError(t, err)
+		}
+	}()
+	for i := 0; i < 5; i++ {
+		_, err := provider.CreateInlineComment(ctx, commenter.InlineComment{Path: "main.go", StartLine: 12, EndLine: 12, Body: "context line"})
+		require.NoError(t, err)
+	}
+	<-done
+
+	require.Len(t, posted, 5)
+	assert.Equal(t, float64(11), posted[4]["position"].(map[string]interface{})["old_line"])
+}
@@ -0,0 +1,62 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/mugioka/go-github-pr-commenter/commenter/gocover"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const coverProfile = `mode: set
+github.com/owner/repo/pkg/a.go:3.14,5.2 2 1
+github.com/owner/repo/pkg/a.go:6.10,9.3 3 0
+github.com/owner/repo/pkg/a.go:11.2,12.20 1 0
+github.com/owner/repo/pkg/old.go:1.1,4.2 2 0
+`
+
+func Test_uncovered_added_lines_become_findings(t *testing.T) {
+	blocks, err := gocover.ParseProfile(strings.NewReader(coverProfile))
+	require.NoError(t, err)
+	require.Len(t, blocks, 4)
+	assert.Equal(t, gocover.Block{File: "github.com/owner/repo/pkg/a.go", StartLine: 6, EndLine: 9, Statements: 3, Count: 0}, blocks[1])
+
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("pkg/a.go", "@@ -1,2 +1,12 @@\n a\n b\n+c\n+d\n+e\n+f\n+g\n+h\n+i\n+j\n+k\n+l")
+
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+	findings, err := gocover.Uncovered(context.Background(), c, blocks, "github.com/owner/repo")
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	assert.Equal(t, "pkg/a.go", findings[0].Path)
+	assert.Equal(t, []int{6, 9}, []int{findings[0].StartLine, findings[0].EndLine})
+	assert.Equal(t, []int{11, 12}, []int{findings[1].StartLine, findings[1].EndLine})
+	assert.Equal(t, gocover.RuleID, findings[0].RuleID)
+
+	summary := gocover.Summary(findings)
+	assert.Contains(t, summary, "**6 added lines aren't covered by tests**")
+	assert.Contains(t, summary, "pkg/a.go")
+}
+
+func Test_malformed_cover_profile_lines_are_errors(t *testing.T) {
+	_, err := gocover.ParseProfile(strings.NewReader("mode: set\npkg/a.go:3.14,5.2 2\n"))
+	assert.EqualError(t, err, `cover profile line 2: malformed block "pkg/a.go:3.14,5.2 2"`)
+}
+
+func Test_added_lines_of_a_file(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,2 @@\n a\n-b\n+c\n@@ -10,0 +10,2 @@\n+d\n+e")
+
+	c, err := server.NewCommenter(commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	lines, err := c.AddedLines("main.go")
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 10, 11}, lines)
+}
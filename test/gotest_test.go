@@ -0,0 +1,73 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/mugioka/go-github-pr-commenter/commenter/gotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const goTestOutput = `{"Action":"run","Package":"github.com/owner/repo/pkg","Test":"TestParse"}
+{"Action":"output","Package":"github.com/owner/repo/pkg","Test":"TestParse","Output":"=== RUN   TestParse\n"}
+{"Action":"run","Package":"github.com/owner/repo/pkg","Test":"TestParse/empty"}
+{"Action":"output","Package":"github.com/owner/repo/pkg","Test":"TestParse/empty","Output":"=== RUN   TestParse/empty\n"}
+{"Action":"output","Package":"github.com/owner/repo/pkg","Test":"TestParse/empty","Output":"    parse_test.go:14: got 1, want 0\n"}
+{"Action":"output","Package":"github.com/owner/repo/pkg","Test":"TestParse/empty","Output":"    --- FAIL: TestParse/empty (0.00s)\n"}
+{"Action":"fail","Package":"github.com/owner/repo/pkg","Test":"TestParse/empty","Elapsed":0}
+{"Action":"output","Package":"github.com/owner/repo/pkg","Test":"TestParse","Output":"--- FAIL: TestParse (0.00s)\n"}
+{"Action":"fail","Package":"github.com/owner/repo/pkg","Test":"TestParse","Elapsed":0}
+{"Action":"pass","Package":"github.com/owner/repo/pkg","Test":"TestOK","Elapsed":0}
+{"Action":"output","Package":"github.com/owner/repo/pkg","Test":"TestPanic","Output":"=== RUN   TestPanic\n"}
+{"Action":"output","Package":"github.com/owner/repo/pkg","Test":"TestPanic","Output":"panic: boom\n"}
+{"Action":"output","Package":"github.com/owner/repo/pkg","Test":"TestPanic","Output":"\t/work/repo/pkg/panic_test.go:9 +0x1d\n"}
+{"Action":"fail","Package":"github.com/owner/repo/pkg","Test":"TestPanic","Elapsed":0.01}
+{"Action":"output","Package":"github.com/owner/repo/pkg","Output":"FAIL\tgithub.com/owner/repo/pkg\t0.01s\n"}
+{"Action":"fail","Package":"github.com/owner/repo/pkg","Elapsed":0.01}
+{"Action":"output","Package":"github.com/owner/repo/broken","Output":"broken/a.go:3:1: syntax error\n"}
+{"Action":"fail","Package":"github.com/owner/repo/broken","Elapsed":0}
+`
+
+func Test_go_test_failures_are_parsed(t *testing.T) {
+	failures, err := gotest.Parse(strings.NewReader(goTestOutput))
+	require.NoError(t, err)
+	require.Len(t, failures, 3)
+	assert.Equal(t, gotest.Failure{
+		Package: "github.com/owner/repo/pkg", Test: "TestParse/empty", File: "parse_test.go", Line: 14,
+		Output: "    parse_test.go:14: got 1, want 0",
+	}, failures[0])
+	assert.Equal(t, "/work/repo/pkg/panic_test.go", failures[1].File)
+	assert.Equal(t, 9, failures[1].Line)
+	assert.Equal(t, "", failures[2].Test)
+
+	findings := gotest.Findings(failures, "github.com/owner/repo")
+	require.Len(t, findings, 2)
+	assert.Equal(t, "pkg/parse_test.go", findings[0].Path)
+	assert.Equal(t, "TestParse/empty", findings[0].RuleID)
+	assert.Equal(t, "/work/repo/pkg/panic_test.go", findings[1].Path)
+}
+
+func Test_go_test_failures_are_commented_inline_and_summarized(t *testing.T) {
+	failures, err := gotest.Parse(strings.NewReader(goTestOutput))
+	require.NoError(t, err)
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("pkg/parse_test.go", "@@ -10,3 +10,6 @@\n a\n b\n c\n+d\n+e\n+f")
+
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+	_, err = gotest.Write(context.Background(), c, failures, "github.com/owner/repo")
+	require.NoError(t, err)
+
+	comments := server.Comments()
+	require.Len(t, comments, 1)
+	assert.Equal(t, "pkg/parse_test.go", comments[0].GetPath())
+	assert.Contains(t, comments[0].GetBody(), "got 1, want 0")
+	summaries := server.IssueComments()
+	require.Len(t, summaries, 1)
+	assert.Contains(t, summaries[0].GetBody(), "**3 failures**")
+	assert.Contains(t, summaries[0].GetBody(), "<code>github.com/owner/repo/broken</code> package")
+}
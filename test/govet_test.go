@@ -0,0 +1,50 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/govet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const goVetOutput = `# github.com/owner/repo/pkg
+{
+	"github.com/owner/repo/pkg": {
+		"printf": [
+			{
+				"posn": "/work/repo/pkg/a.go:10:2",
+				"message": "fmt.Sprintf format %d has arg x of wrong type string"
+			}
+		],
+		"copylocks": [
+			{
+				"posn": "C:\\work\\repo\\pkg\\b.go:4:9",
+				"message": "assignment copies lock value"
+			}
+		]
+	}
+}
+# github.com/owner/repo/other
+{}
+`
+
+func Test_go_vet_diagnostics_become_findings(t *testing.T) {
+	findings, err := govet.Parse(strings.NewReader(goVetOutput))
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	assert.Equal(t, commenter.Finding{
+		Tool: govet.Tool, RuleID: "copylocks", Severity: commenter.SeverityWarning,
+		Path: `C:\work\repo\pkg\b.go`, StartLine: 4, EndLine: 4, Message: "assignment copies lock value",
+	}, findings[0])
+	assert.Equal(t, "printf", findings[1].RuleID)
+	assert.Equal(t, "/work/repo/pkg/a.go", findings[1].Path)
+	assert.Equal(t, 10, findings[1].StartLine)
+}
+
+func Test_go_vet_analyzer_errors_are_returned(t *testing.T) {
+	_, err := govet.Parse(strings.NewReader(`{"pkg": {"printf": {"error": "boom"}}}`))
+	assert.EqualError(t, err, "go vet analyzer printf failed on pkg: boom")
+}
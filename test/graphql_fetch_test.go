@@ -0,0 +1,66 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_graphql_fetch_loads_the_pr_in_two_requests(t *testing.T) {
+	var requests, deletes int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		switch {
+		case r.URL.Path == "/graphql":
+			var req struct {
+				Variables map[string]interface{} `json:"variables"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			if req.Variables["cursor"] == nil {
+				w.Write([]byte(`{"data":{"repository":{"pullRequest":{"headRefOid":"head","reviewThreads":{
+					"pageInfo":{"hasNextPage":true,"endCursor":"c1"},
+					"nodes":[{"path":"main.go","line":11,"comments":{"nodes":[{"databaseId":7,"id":"N7","body":"old","author":{"__typename":"Bot","login":"github-actions"}}]}}]}}}}}`))
+				return
+			}
+			w.Write([]byte(`{"data":{"repository":{"pullRequest":{"headRefOid":"head","reviewThreads":{
+				"pageInfo":{"hasNextPage":false},
+				"nodes":[{"path":"main.go","line":11,"comments":{"nodes":[{"databaseId":8,"id":"N8","body":"human","author":{"__typename":"User","login":"alice"}}]}}]}}}}}`))
+		case r.URL.Path == "/repos/owner/repo/pulls/1" && r.Method == http.MethodGet:
+			assert.Equal(t, "application/vnd.github.v3.diff", r.Header.Get("Accept"))
+			w.Write([]byte(bitbucketDiff))
+		case r.URL.Path == "/repos/owner/repo/pulls/comments/7" && r.Method == http.MethodDelete:
+			atomic.AddInt32(&deletes, 1)
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Path == "/repos/owner/repo/pulls/1/reviews" && r.Method == http.MethodPost:
+			w.Write([]byte(`{"id":1}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c, err := commenter.NewCommenter("token", "owner", "repo", 1, commenter.WithBaseURL(server.URL), commenter.WithGraphQLFetch())
+	require.NoError(t, err)
+
+	drafts := c.CreateDraftPRReviewComments([]commenter.PRReviewComment{{FileName: "main.go", StartLine: 11, EndLine: 11, Body: "new"}})
+	require.Len(t, drafts, 1)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests), "two thread pages and the diff")
+	require.NoError(t, c.WritePRReview(drafts, commenter.Approve))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&deletes))
+}
+
+func Test_graphql_fetch_reports_missing_prs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"repository":{"pullRequest":null}},"errors":[{"message":"Could not resolve to a PullRequest with the number of 1."}]}`))
+	}))
+	defer server.Close()
+
+	_, err := commenter.NewCommenter("token", "owner", "repo", 1, commenter.WithBaseURL(server.URL), commenter.WithGraphQLFetch())
+	assert.ErrorIs(t, err, commenter.ErrPRNotFound)
+}
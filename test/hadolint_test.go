@@ -0,0 +1,41 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/mugioka/go-github-pr-commenter/commenter/hadolint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const hadolintOutput = `[{"code":"DL3008","column":1,"file":"build/Dockerfile","level":"warning","line":3,"message":"Pin versions in apt get install"},
+{"code":"SC2086","column":1,"file":"build/Dockerfile","level":"info","line":4,"message":"Double quote to prevent globbing and word splitting."},
+{"code":"DL4000","column":1,"file":"build/Dockerfile","level":"error","line":9,"message":"MAINTAINER is deprecated"}]`
+
+func Test_hadolint_diagnostics_become_inline_comments(t *testing.T) {
+	findings, err := hadolint.Parse(strings.NewReader(hadolintOutput))
+	require.NoError(t, err)
+	require.Len(t, findings, 3)
+	assert.Equal(t, commenter.Finding{
+		Tool: hadolint.Tool, RuleID: "DL3008", Severity: commenter.SeverityWarning, Path: "build/Dockerfile", StartLine: 3, EndLine: 3,
+		Message: "Pin versions in apt get install ([DL3008](https://github.com/hadolint/hadolint/wiki/DL3008))",
+	}, findings[0])
+	assert.Contains(t, findings[1].Message, "(https://www.shellcheck.net/wiki/SC2086)")
+	assert.Equal(t, commenter.SeverityError, findings[2].Severity)
+
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("build/Dockerfile", "@@ -1,2 +1,4 @@\n FROM debian\n RUN true\n+RUN apt-get install -y curl\n+RUN echo $HOME")
+	c, err := server.NewCommenter(commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	results, err := c.WriteFindings(findings)
+	require.NoError(t, err)
+	var statuses []commenter.ResultStatus
+	for _, result := range results {
+		statuses = append(statuses, result.Status)
+	}
+	assert.Equal(t, []commenter.ResultStatus{commenter.ResultCreated, commenter.ResultCreated, commenter.ResultSkipped}, statuses)
+}
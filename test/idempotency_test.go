@@ -0,0 +1,43 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_idempotency_key_converges_retried_runs(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+	comments := []commenter.PRReviewComment{
+		{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "same"},
+		{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "same"},
+		{FileName: "main.go", StartLine: 3, EndLine: 3, Body: "other"},
+	}
+
+	first, err := server.NewCommenter(commenter.WithIdempotencyKey("run-1"), commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	_, err = first.WriteComments(comments[:2])
+	require.NoError(t, err)
+	require.Len(t, server.Comments(), 2)
+
+	retry, err := server.NewCommenter(commenter.WithIdempotencyKey("run-1"), commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	results, err := retry.WriteComments(comments)
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultUnchanged, results[0].Status)
+	assert.Equal(t, commenter.ResultUnchanged, results[1].Status)
+	assert.NotEqual(t, results[0].CommentID, results[1].CommentID)
+	assert.Equal(t, commenter.ResultCreated, results[2].Status)
+	assert.Len(t, server.Comments(), 3)
+
+	next, err := server.NewCommenter(commenter.WithIdempotencyKey("run-2"))
+	require.NoError(t, err)
+	results, err = next.WriteComments(comments[2:])
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultCreated, results[0].Status)
+}
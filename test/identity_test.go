@@ -0,0 +1,33 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_bot_login_only_claims_its_own_comments(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.Bot = "acme-ci[bot]"
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+	other := server.AddComment(commenter.CommenterName, "main.go", 40, "stale")
+	own := server.AddComment("acme-ci[bot]", "main.go", 40, "stale")
+	server.AddComment(commenter.CommenterName, "main.go", 2, "duplicate")
+
+	c, err := server.NewCommenter(commenter.WithBotLogin("acme-ci[bot]"))
+	require.NoError(t, err)
+	assert.Empty(t, c.PruneOutdatedComments(commenter.PruneDelete))
+	assert.Equal(t, []int64{own}, server.DeletedCommentIDs())
+
+	results, err := c.WriteComments([]commenter.PRReviewComment{{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "duplicate"}})
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultCreated, results[0].Status)
+	comments := server.Comments()
+	require.Len(t, comments, 3)
+	assert.Equal(t, other, comments[0].GetID())
+	assert.Equal(t, "acme-ci[bot]", comments[2].GetUser().GetLogin())
+}
@@ -0,0 +1,42 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/kubelinter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const kubeLinterOutput = `{"Checks":[{"name":"no-read-only-root-fs"}],"Reports":[
+{"Diagnostic":{"Message":"container \"app\" does not have a read-only root file system"},"Check":"no-read-only-root-fs",
+"Remediation":"Set readOnlyRootFilesystem to true in the container securityContext.",
+"Object":{"Metadata":{"FilePath":"deploy/app.yaml"},"K8sObject":{"GroupVersionKind":{"Group":"apps","Version":"v1","Kind":"Deployment"},"Namespace":"","Name":"app"}}},
+{"Diagnostic":{"Message":"no pods found matching service labels"},"Check":"dangling-service","Remediation":"",
+"Object":{"Metadata":{"FilePath":"deploy/missing.yaml"},"K8sObject":{"GroupVersionKind":{"Kind":"Service"},"Name":"web"}}}],
+"Summary":{"ChecksStatus":"Failed"}}`
+
+func Test_kube_linter_reports_are_placed_on_their_object(t *testing.T) {
+	manifest := "apiVersion: v1\nkind: Service\nmetadata:\n  name: app\n---\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: app\n"
+	source := func(file string) ([]byte, error) {
+		if file == "deploy/app.yaml" {
+			return []byte(manifest), nil
+		}
+		return nil, assert.AnError
+	}
+	findings, err := kubelinter.Parse(strings.NewReader(kubeLinterOutput), source)
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	assert.Equal(t, commenter.Finding{
+		Tool: kubelinter.Tool, RuleID: "no-read-only-root-fs", Severity: commenter.SeverityError, Path: "deploy/app.yaml", StartLine: 7, EndLine: 7,
+		Message: `container "app" does not have a read-only root file system. Set readOnlyRootFilesystem to true in the container securityContext. ` +
+			"([no-read-only-root-fs](https://docs.kubelinter.io/#/generated/checks?id=no-read-only-root-fs))",
+	}, findings[0])
+	assert.Equal(t, 1, findings[1].StartLine)
+
+	findings, err = kubelinter.Parse(strings.NewReader(kubeLinterOutput), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, findings[0].StartLine)
+}
@@ -0,0 +1,71 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_legacy_positions_comment_by_diff_position(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,3 +1,4 @@\n a\n-b\n+c\n+d\n e")
+	comments := []commenter.PRReviewComment{
+		{FileName: "main.go", StartLine: 2, EndLine: 3, Body: "range"},
+		{FileName: "main.go", StartLine: 4, EndLine: 4, Body: "context"},
+	}
+
+	c, err := server.NewCommenter(commenter.WithLegacyPositions(), commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	results, err := c.WriteComments(comments)
+	require.NoError(t, err)
+	for _, result := range results {
+		assert.Equal(t, commenter.ResultCreated, result.Status)
+	}
+	written := server.Comments()
+	require.Len(t, written, 2)
+	assert.Equal(t, 4, written[0].GetPosition())
+	assert.Equal(t, 5, written[1].GetPosition())
+	for _, comment := range written {
+		assert.Nil(t, comment.Line)
+		assert.Nil(t, comment.Side)
+		assert.Nil(t, comment.StartLine)
+	}
+
+	// the comments are read back at their lines, so they aren't written again
+	c, err = server.NewCommenter(commenter.WithLegacyPositions())
+	require.NoError(t, err)
+	assert.Empty(t, c.PruneOutdatedComments(commenter.PruneDelete))
+	assert.Empty(t, server.DeletedCommentIDs())
+}
+
+func Test_comments_are_placed_in_every_hunk_of_a_file(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d\n@@ -20,2 +21,2 @@\n x\n-y\n+z")
+
+	c, err := server.NewCommenter(commenter.WithLegacyPositions(), commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	position, ok := c.PositionFor("main.go", 22)
+	require.True(t, ok)
+	assert.Equal(t, 8, position)
+	_, ok = c.PositionFor("main.go", 10)
+	assert.False(t, ok)
+
+	results, err := c.WriteComments([]commenter.PRReviewComment{
+		{FileName: "main.go", StartLine: 3, EndLine: 3, Body: "first hunk"},
+		{FileName: "main.go", StartLine: 21, EndLine: 22, Body: "second hunk"},
+		{FileName: "main.go", StartLine: 3, EndLine: 21, Body: "across hunks"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultCreated, results[0].Status)
+	assert.Equal(t, commenter.ResultCreated, results[1].Status)
+	assert.NotEqual(t, commenter.ResultCreated, results[2].Status)
+	written := server.Comments()
+	require.Len(t, written, 2)
+	assert.Equal(t, 4, written[0].GetPosition())
+	assert.Equal(t, 8, written[1].GetPosition())
+}
@@ -0,0 +1,92 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_locale_translates_built_in_texts(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+
+	c, err := server.NewCommenter(commenter.WithLocale(commenter.LocaleJapanese), commenter.WithMinSeverity(commenter.SeverityWarning), commenter.WithLowSeveritySummary())
+	require.NoError(t, err)
+	_, err = c.WriteFindings([]commenter.Finding{
+		{RuleID: "S2", Severity: commenter.SeverityInfo, Path: "main.go", StartLine: 3, Message: "style"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.WritePRReview(nil, commenter.RequestChanges))
+
+	summaries := server.IssueComments()
+	require.Len(t, summaries, 1)
+	assert.Contains(t, summaries[0].GetBody(), "重要度の低い 1 件の指摘はインラインでコメントしていません:\n")
+	reviews := server.Reviews()
+	require.Len(t, reviews, 1)
+	assert.Equal(t, "修正をお願いします:rotating_light:", reviews[0].GetBody())
+}
+
+func Test_locale_falls_back_to_english(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+
+	c, err := server.NewCommenter(commenter.WithLocale(commenter.Locale{commenter.MessageRequestChanges: "Bitte korrigieren"}))
+	require.NoError(t, err)
+	require.NoError(t, c.WritePRReview(nil, commenter.Approve))
+	require.NoError(t, c.WritePRReview(nil, commenter.RequestChanges))
+
+	reviews := server.Reviews()
+	require.Len(t, reviews, 2)
+	assert.Equal(t, commenter.ApproveBody, reviews[0].GetBody())
+	assert.Equal(t, "Bitte korrigieren", reviews[1].GetBody())
+}
+
+// recordingSink keeps the reviews given to it as a WithPermissionFallback sink
+type recordingSink struct {
+	reviews []commenter.FallbackReview
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) WriteFallback(_ context.Context, review commenter.FallbackReview) error {
+	s.reviews = append(s.reviews, review)
+	return nil
+}
+
+func Test_locale_translates_the_report_the_per_file_cap_and_rejected_comments(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,4 @@\n a\n-b\n+c\n+d\n+e")
+	c, err := server.NewCommenter(commenter.WithLocale(commenter.LocaleJapanese), commenter.WithMaxCommentsPerFile(1), commenter.WithConcurrency(1))
+	require.NoError(t, err)
+
+	findings := []commenter.Finding{{RuleID: "S2", Severity: commenter.SeverityInfo, Path: "main.go", StartLine: 3, Message: "style"}}
+	assert.True(t, strings.HasPrefix(c.RenderReport(findings), "| 重要度 | 場所 | ルール | メッセージ |\n"))
+	assert.True(t, strings.HasPrefix(commenter.RenderReport(findings), "| Severity | Location | Rule | Message |\n"))
+
+	_, err = c.WriteComments([]commenter.PRReviewComment{
+		{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "first"},
+		{FileName: "main.go", StartLine: 3, EndLine: 3, Body: "second"},
+	})
+	require.NoError(t, err)
+	comments := server.Comments()
+	require.Len(t, comments, 2)
+	assert.True(t, strings.HasPrefix(comments[1].GetBody(), "このファイルの他の 1 件の指摘:\n"))
+
+	server.ReadOnly = true
+	sink := &recordingSink{}
+	c, err = server.NewCommenter(commenter.WithLocale(commenter.LocaleJapanese), commenter.WithPermissionFallback(sink))
+	require.NoError(t, err)
+	_, err = c.WriteComments([]commenter.PRReviewComment{{FileName: "main.go", StartLine: 4, EndLine: 4, Body: "third"}})
+	assert.True(t, errors.Is(err, commenter.ErrInsufficientPermissions))
+	require.Len(t, sink.reviews, 1)
+	assert.Equal(t, "PR に書き込めなかったコメント", sink.reviews[0].Body)
+}
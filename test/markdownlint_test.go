@@ -0,0 +1,47 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/mugioka/go-github-pr-commenter/commenter/markdownlint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_markdownlint_results_become_inline_comments(t *testing.T) {
+	output := `[{"fileName":"docs/guide.md","lineNumber":3,"ruleNames":["MD022","blanks-around-headings"],
+"ruleDescription":"Headings should be surrounded by blank lines","ruleInformation":"https://github.com/DavidAnson/markdownlint/blob/v0.33.0/doc/md022.md",
+"errorDetail":"Expected: 1; Actual: 0; Below","errorContext":"## Install","errorRange":null,"fixInfo":null}]`
+	findings, err := markdownlint.Parse(strings.NewReader(output))
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, commenter.Finding{
+		Tool: markdownlint.Tool, RuleID: "MD022", Severity: commenter.SeverityWarning, Path: "docs/guide.md", StartLine: 3, EndLine: 3,
+		Message: `Headings should be surrounded by blank lines [Expected: 1; Actual: 0; Below] [Context: "## Install"] ` +
+			"([blanks-around-headings](https://github.com/DavidAnson/markdownlint/blob/v0.33.0/doc/md022.md))",
+	}, findings[0])
+
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("docs/guide.md", "@@ -1,2 +1,4 @@\n # Guide\n \n+## Install\n+Run it.")
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+	results, err := c.WriteFindings(findings)
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultCreated, results[0].Status)
+}
+
+func Test_markdownlint_library_results_are_keyed_by_file(t *testing.T) {
+	output := `{"b.md":[{"lineNumber":1,"ruleNames":["MD041"],"ruleDescription":"First line should be a top-level heading"}],
+"a.md":[{"lineNumber":7,"ruleNames":["MD013"],"ruleDescription":"Line length","severity":"error"}]}`
+	findings, err := markdownlint.Parse(strings.NewReader(output))
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	assert.Equal(t, "a.md", findings[0].Path)
+	assert.Equal(t, commenter.SeverityError, findings[0].Severity)
+	assert.Equal(t, "b.md", findings[1].Path)
+	assert.Equal(t, "First line should be a top-level heading", findings[1].Message)
+}
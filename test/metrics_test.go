@@ -0,0 +1,60 @@
+package test
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMetrics sums the counters and counts the observations it is given
+type recordingMetrics struct {
+	mu           sync.Mutex
+	counters     map[string]int
+	observations map[string]int
+}
+
+func (m *recordingMetrics) Add(name string, delta int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[name] += delta
+}
+
+func (m *recordingMetrics) Observe(name string, _ time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.observations[name]++
+}
+
+func Test_metrics_count_comments_calls_and_retries(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,4 @@\n a\n-b\n+c\n+d\n+e")
+	metrics := &recordingMetrics{counters: map[string]int{}, observations: map[string]int{}}
+	fault := &faultTransport{method: http.MethodGet, suffix: "/pulls/7", status: http.StatusBadGateway, times: 1}
+	c, err := server.NewCommenter(
+		commenter.WithMetrics(metrics),
+		commenter.WithTransport(fault),
+		commenter.WithRetryPolicy(&recordingPolicy{max: 2}),
+	)
+	require.NoError(t, err)
+
+	_, err = c.WriteComments([]commenter.PRReviewComment{
+		{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "inside"},
+		{FileName: "main.go", StartLine: 20, EndLine: 20, Body: "outside"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, metrics.counters[commenter.MetricCommentsCreated])
+	assert.Equal(t, 1, metrics.counters[commenter.MetricCommentsSkipped])
+	assert.Equal(t, 1, metrics.counters[commenter.MetricRetries])
+	assert.Equal(t, 0, metrics.counters[commenter.MetricRateLimitWaits])
+	// both attempts to load the PR are API calls, like the listings and the created comment
+	assert.Greater(t, metrics.counters[commenter.MetricAPICalls], fault.requests()+1)
+	assert.Equal(t, metrics.counters[commenter.MetricAPICalls], metrics.observations[commenter.MetricAPICallDuration])
+}
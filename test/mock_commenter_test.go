@@ -0,0 +1,233 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ commenter.PullRequestsAPI = (*mocks.PullRequestsAPI)(nil)
+
+func newMockPullRequests(files []*github.CommitFile, comments []*github.PullRequestComment) *mocks.PullRequestsAPI {
+	return &mocks.PullRequestsAPI{
+		ListFilesFunc: func(context.Context, string, string, int, *github.ListOptions) ([]*github.CommitFile, *github.Response, error) {
+			return files, nil, nil
+		},
+		ListCommentsFunc: func(context.Context, string, string, int, *github.PullRequestListCommentsOptions) ([]*github.PullRequestComment, *github.Response, error) {
+			return comments, nil, nil
+		},
+	}
+}
+
+func commitFile(name, patch string) *github.CommitFile {
+	return &github.CommitFile{
+		Filename:    github.String(name),
+		Status:      github.String("modified"),
+		Patch:       github.String(patch),
+		Changes:     github.Int(1),
+		ContentsURL: github.String("https://api.github.com/repos/owner/repo/contents/" + name + "?ref=abc123"),
+	}
+}
+
+func botComment(id int64, path string, line int) *github.PullRequestComment {
+	return &github.PullRequestComment{
+		ID:       github.Int64(id),
+		Path:     github.String(path),
+		Body:     github.String("old finding"),
+		Line:     github.Int(line),
+		Position: github.Int(line),
+		User:     &github.User{Login: github.String(commenter.CommenterName)},
+	}
+}
+
+func Test_write_comments_posts_relevant_comments_and_skips_the_rest(t *testing.T) {
+	prs := newMockPullRequests([]*github.CommitFile{commitFile("main.go", "@@ -1,3 +10,5 @@ func main()")}, nil)
+	c, err := commenter.NewCommenter("", "owner", "repo", 1, commenter.WithPullRequestsAPI(prs))
+	require.NoError(t, err)
+
+	results, err := c.WriteComments([]commenter.PRReviewComment{
+		{FileName: "main.go", StartLine: 11, EndLine: 12, Body: "in the diff"},
+		{FileName: "main.go", StartLine: 30, EndLine: 30, Body: "outside the diff"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultCreated, results[0].Status)
+	assert.Equal(t, commenter.ResultSkipped, results[1].Status)
+	assert.True(t, errors.Is(results[1].Err, commenter.ErrCommentOutsideDiff))
+
+	created := prs.CallsTo("CreateComment")
+	require.Len(t, created, 1)
+	comment := created[0].Args[3].(*github.PullRequestComment)
+	assert.Equal(t, "abc123", comment.GetCommitID())
+	assert.Equal(t, 11, comment.GetStartLine())
+	assert.Equal(t, 12, comment.GetLine())
+}
+
+func Test_write_comments_reports_failures_without_stopping(t *testing.T) {
+	prs := newMockPullRequests([]*github.CommitFile{commitFile("main.go", "@@ -1,3 +1,5 @@")}, nil)
+	prs.CreateCommentFunc = func(_ context.Context, _ string, _ string, _ int, comment *github.PullRequestComment) (*github.PullRequestComment, *github.Response, error) {
+		if comment.GetBody() == "bad" {
+			return nil, nil, errors.New("boom")
+		}
+		return comment, nil, nil
+	}
+	c, err := commenter.NewCommenter("", "owner", "repo", 1, commenter.WithPullRequestsAPI(prs))
+	require.NoError(t, err)
+
+	results, err := c.WriteComments([]commenter.PRReviewComment{
+		{FileName: "main.go", StartLine: 1, EndLine: 1, Body: "bad"},
+		{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "good"},
+	})
+
+	var batchErr commenter.BatchError
+	require.True(t, errors.As(err, &batchErr))
+	assert.Len(t, batchErr.Failed, 1)
+	assert.Equal(t, commenter.ResultFailed, results[0].Status)
+	assert.Equal(t, commenter.ResultCreated, results[1].Status)
+}
+
+func Test_prune_deletes_only_comments_outside_the_diff(t *testing.T) {
+	prs := newMockPullRequests(
+		[]*github.CommitFile{commitFile("main.go", "@@ -1,3 +1,5 @@")},
+		[]*github.PullRequestComment{botComment(1, "main.go", 2), botComment(2, "main.go", 40)},
+	)
+	c, err := commenter.NewCommenter("", "owner", "repo", 1, commenter.WithPullRequestsAPI(prs))
+	require.NoError(t, err)
+
+	errs := c.PruneOutdatedComments(commenter.PruneDelete)
+
+	assert.Empty(t, errs)
+	deleted := prs.CallsTo("DeleteComment")
+	require.Len(t, deleted, 1)
+	assert.Equal(t, int64(2), deleted[0].Args[2])
+}
+
+func Test_pr_info_is_loaded_on_first_use_and_on_refresh(t *testing.T) {
+	files := []*github.CommitFile{commitFile("main.go", "@@ -1,3 +10,5 @@ func main()")}
+	prs := newMockPullRequests(nil, nil)
+	prs.ListFilesFunc = func(context.Context, string, string, int, *github.ListOptions) ([]*github.CommitFile, *github.Response, error) {
+		return files, nil, nil
+	}
+	c, err := commenter.NewCommenter("", "owner", "repo", 1, commenter.WithPullRequestsAPI(prs))
+	require.NoError(t, err)
+	assert.Empty(t, prs.CallsTo("ListFiles"))
+
+	assert.Len(t, c.CreateDraftPRReviewComments([]commenter.PRReviewComment{{FileName: "main.go", StartLine: 11, EndLine: 11}}), 1)
+	assert.Len(t, c.CreateDraftPRReviewComments([]commenter.PRReviewComment{{FileName: "other.go", StartLine: 1, EndLine: 1}}), 0)
+	assert.Len(t, prs.CallsTo("ListFiles"), 1)
+
+	files = append(files, commitFile("other.go", "@@ -1,1 +1,2 @@"))
+	require.NoError(t, c.Refresh(context.Background()))
+	assert.Len(t, c.CreateDraftPRReviewComments([]commenter.PRReviewComment{{FileName: "other.go", StartLine: 1, EndLine: 1}}), 1)
+	assert.Len(t, prs.CallsTo("ListFiles"), 2)
+}
+
+func Test_snapshot_cache_is_shared_between_commenters_of_the_same_head(t *testing.T) {
+	prs := newMockPullRequests(
+		[]*github.CommitFile{commitFile("main.go", "@@ -1,3 +10,5 @@ func main()")},
+		[]*github.PullRequestComment{botComment(7, "main.go", 11)},
+	)
+	prs.GetFunc = func(_ context.Context, _ string, _ string, number int) (*github.PullRequest, *github.Response, error) {
+		return &github.PullRequest{Number: &number, Head: &github.PullRequestBranch{SHA: github.String("head")}}, nil, nil
+	}
+	store := commenter.NewFileSnapshotStore(t.TempDir())
+	newCommenter := func() *commenter.Commenter {
+		c, err := commenter.NewCommenter("", "owner", "repo", 1, commenter.WithPullRequestsAPI(prs), commenter.WithSnapshotCache(store, time.Minute))
+		require.NoError(t, err)
+		return c
+	}
+
+	first := newCommenter()
+	require.NoError(t, first.WritePRReview(nil, commenter.Approve))
+	assert.Len(t, prs.CallsTo("DeleteComment"), 1)
+
+	second := newCommenter()
+	assert.Len(t, second.CreateDraftPRReviewComments([]commenter.PRReviewComment{{FileName: "main.go", StartLine: 11, EndLine: 11}}), 1)
+	require.NoError(t, second.WritePRReview(nil, commenter.Approve))
+	assert.Len(t, prs.CallsTo("ListFiles"), 1)
+	assert.Len(t, prs.CallsTo("ListComments"), 1)
+	assert.Len(t, prs.CallsTo("DeleteComment"), 1, "the deleted comment is dropped from the snapshot")
+
+	require.NoError(t, second.Refresh(context.Background()))
+	assert.Len(t, prs.CallsTo("ListFiles"), 2)
+}
+
+func Test_pr_is_resolved_from_a_commit_or_branch(t *testing.T) {
+	prs := newMockPullRequests([]*github.CommitFile{commitFile("main.go", "@@ -1,3 +10,5 @@ func main()")}, nil)
+	prs.ListWithCommitFunc = func(_ context.Context, _, _, sha string, _ *github.PullRequestListOptions) ([]*github.PullRequest, *github.Response, error) {
+		if sha != "abc123" {
+			return nil, nil, nil
+		}
+		return []*github.PullRequest{
+			{Number: github.Int(3), State: github.String("closed")},
+			{Number: github.Int(4), State: github.String("open")},
+		}, nil, nil
+	}
+	prs.ListFunc = func(_ context.Context, _, _ string, opts *github.PullRequestListOptions) ([]*github.PullRequest, *github.Response, error) {
+		if opts.Head != "owner:feature" {
+			return nil, nil, nil
+		}
+		return []*github.PullRequest{{Number: github.Int(5), State: github.String("open")}}, nil, nil
+	}
+
+	_, err := commenter.NewCommenterForCommit("", "owner", "repo", "abc123", commenter.WithPullRequestsAPI(prs))
+	require.NoError(t, err)
+	_, err = commenter.NewCommenterForBranch("", "owner", "repo", "feature", commenter.WithPullRequestsAPI(prs))
+	require.NoError(t, err)
+	gets := prs.CallsTo("Get")
+	require.Len(t, gets, 2)
+	assert.Equal(t, 4, gets[0].Args[2])
+	assert.Equal(t, 5, gets[1].Args[2])
+
+	_, err = commenter.NewCommenterForBranch("", "owner", "repo", "gone", commenter.WithPullRequestsAPI(prs))
+	assert.True(t, errors.Is(err, commenter.ErrPRNotFound))
+	assert.Equal(t, "No open PR found for [gone] in owner/repo", err.Error())
+}
+
+type recordingLogger struct {
+	messages []string
+	args     [][]interface{}
+}
+
+func (l *recordingLogger) Debug(string, ...interface{}) {}
+
+func (l *recordingLogger) Info(msg string, args ...interface{}) {
+	l.messages = append(l.messages, msg)
+	l.args = append(l.args, args)
+}
+
+func Test_review_is_written_when_an_existing_comment_cant_be_deleted(t *testing.T) {
+	prs := newMockPullRequests(
+		[]*github.CommitFile{commitFile("main.go", "@@ -1,3 +1,5 @@")},
+		[]*github.PullRequestComment{botComment(1, "main.go", 2)},
+	)
+	prs.DeleteCommentFunc = func(context.Context, string, string, int64) (*github.Response, error) {
+		return nil, errors.New("boom")
+	}
+	prs.CreateReviewFunc = func(context.Context, string, string, int, *github.PullRequestReviewRequest) (*github.PullRequestReview, *github.Response, error) {
+		return &github.PullRequestReview{ID: github.Int64(3)}, nil, nil
+	}
+	logger := &recordingLogger{}
+	c, err := commenter.NewCommenter("", "owner", "repo", 1, commenter.WithPullRequestsAPI(prs), commenter.WithLogger(logger))
+	require.NoError(t, err)
+
+	require.NoError(t, c.WritePRReview(nil, commenter.Approve))
+
+	assert.Len(t, prs.CallsTo("CreateReview"), 1)
+	var args []interface{}
+	for i, message := range logger.messages {
+		if message == "delete existing comment" {
+			args = logger.args[i]
+		}
+	}
+	require.Len(t, args, 2)
+	assert.Equal(t, "error", args[0])
+	assert.Contains(t, args[1].(error).Error(), "boom")
+}
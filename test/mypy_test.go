@@ -0,0 +1,40 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/mypy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_mypy_errors_become_findings(t *testing.T) {
+	output := `pkg/a.py:3: error: Incompatible types in assignment (expression has type "str", variable has type "int")  [assignment]
+pkg/a.py:7:5: error: Argument 1 to "f" has incompatible type "str"; expected "int"  [arg-type]
+pkg/a.py:7:5: note: See https://mypy.rtfd.io/en/stable/_refs.html#code-arg-type for more info
+pkg/b.py:1: note: Revealed type is "builtins.int"
+Found 2 errors in 1 file (checked 2 source files)
+`
+	findings, err := mypy.Parse(strings.NewReader(output))
+	require.NoError(t, err)
+	require.Len(t, findings, 3)
+	assert.Equal(t, commenter.Finding{
+		Tool: mypy.Tool, RuleID: "assignment", Severity: commenter.SeverityError, Path: "pkg/a.py", StartLine: 3, EndLine: 3,
+		Message: `Incompatible types in assignment (expression has type "str", variable has type "int")`,
+	}, findings[0])
+	assert.Equal(t, "arg-type", findings[1].RuleID)
+	assert.True(t, strings.HasSuffix(findings[1].Message, "\nSee https://mypy.rtfd.io/en/stable/_refs.html#code-arg-type for more info"))
+	assert.Equal(t, commenter.SeverityInfo, findings[2].Severity)
+	assert.Equal(t, "", findings[2].RuleID)
+}
+
+func Test_mypy_json_output_is_read(t *testing.T) {
+	output := `{"file": "pkg/a.py", "line": 3, "column": 4, "message": "Name \"y\" is not defined", "hint": null, "code": "name-defined", "severity": "error"}` + "\n"
+	findings, err := mypy.Parse(strings.NewReader(output))
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "name-defined", findings[0].RuleID)
+	assert.Equal(t, `Name "y" is not defined`, findings[0].Message)
+}
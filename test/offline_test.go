@@ -0,0 +1,87 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_offline_commenter_plans_comments_against_a_local_diff(t *testing.T) {
+	diff, err := os.Open("testdata/offline.diff")
+	require.NoError(t, err)
+	defer diff.Close()
+
+	var out bytes.Buffer
+	c, err := commenter.NewOfflineCommenter(diff, &out)
+	require.NoError(t, err)
+
+	results, err := c.WriteComments([]commenter.PRReviewComment{
+		{FileName: "main.go", StartLine: 3, EndLine: 4, Body: "unused import"},
+		{FileName: "new.go", StartLine: 2, EndLine: 2, Body: "odd line"},
+		{FileName: "old.go", StartLine: 1, EndLine: 1, Body: "deleted file"},
+		{FileName: "main.go", StartLine: 20, EndLine: 20, Body: "outside"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultCreated, results[0].Status)
+	assert.Equal(t, commenter.ResultCreated, results[1].Status)
+	assert.Equal(t, commenter.ResultSkipped, results[2].Status)
+	assert.Equal(t, commenter.ResultSkipped, results[3].Status)
+	assert.Contains(t, out.String(), "main.go:3-4: unused import\n")
+	assert.Contains(t, out.String(), "new.go:2: odd line\n")
+}
+
+// failingTransport fails the test on any request which reaches the network
+type failingTransport struct {
+	t *testing.T
+}
+
+func (f failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.t.Errorf("unexpected request %s %s", req.Method, req.URL)
+	return nil, errors.New("offline")
+}
+
+func Test_offline_commenter_sends_nothing_to_github(t *testing.T) {
+	diff, err := ioutil.ReadFile("testdata/offline.diff")
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	c, err := commenter.NewOfflineCommenter(bytes.NewReader(diff), &out,
+		commenter.WithTransport(failingTransport{t}),
+		commenter.WithMinSeverity(commenter.SeverityWarning),
+		commenter.WithLowSeveritySummary(),
+		commenter.WithRetryPolicy(&commenter.BackoffPolicy{MaxAttempts: 1}),
+	)
+	require.NoError(t, err)
+
+	findings := []commenter.Finding{
+		{RuleID: "G101", Path: "main.go", StartLine: 3, Severity: commenter.SeverityError, Message: "hardcoded credentials"},
+		{RuleID: "S1000", Path: "new.go", StartLine: 1, Severity: commenter.SeverityInfo, Message: "style"},
+	}
+	_, err = c.WriteComments([]commenter.PRReviewComment{{FileName: "main.go", StartLine: 3, EndLine: 3, Body: "unused import"}})
+	assert.NoError(t, err)
+	_, err = c.WriteFindings(findings)
+	assert.NoError(t, err)
+	_, err = c.Sync(findings)
+	assert.NoError(t, err)
+	drafts := c.CreateDraftPRReviewComments([]commenter.PRReviewComment{{FileName: "new.go", StartLine: 2, EndLine: 2, Body: "odd line"}})
+	assert.NoError(t, c.WritePRReview(drafts, commenter.RequestChanges))
+	assert.NoError(t, c.WriteGeneralComment("general"))
+	_, err = c.WriteGeneralCommentOnce("once", "once")
+	assert.NoError(t, err)
+	assert.NoError(t, c.WriteStickyComment("sticky", "first"))
+	assert.NoError(t, c.WriteStickyComment("sticky", "second"))
+	assert.Empty(t, c.PruneOutdatedComments(commenter.PruneDelete))
+	assert.Empty(t, c.CleanPrevious(commenter.PruneDelete))
+
+	assert.Contains(t, out.String(), "comment: 1 lower severity findings were not commented inline")
+	assert.Contains(t, out.String(), "general")
+	assert.Contains(t, out.String(), "second")
+}
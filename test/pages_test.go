@@ -0,0 +1,64 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_every_page_of_files_and_comments_is_fetched(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.PageSize = 2
+	for i := 1; i <= 5; i++ {
+		name := fmt.Sprintf("file%d.go", i)
+		server.AddFile(name, "@@ -1,1 +1,2 @@\n a\n+b")
+		server.AddComment(commenter.CommenterName, name, 2, "old")
+	}
+
+	c, err := server.NewCommenter(commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	results, err := c.WriteComments([]commenter.PRReviewComment{{FileName: "file5.go", StartLine: 2, EndLine: 2, Body: "last page"}})
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultCreated, results[0].Status)
+	assert.Equal(t, 6, server.PageRequests())
+
+	// the comment written above is the commenter's too, like the five listed ones
+	require.NoError(t, c.WritePRReview(nil, commenter.Approve))
+	assert.Len(t, server.DeletedCommentIDs(), 6)
+}
+
+func Test_files_past_the_listing_limit_are_read_from_the_diff(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.ListFilesLimit = 2
+	server.AddFile("a.go", "@@ -1,1 +1,2 @@\n a\n+b")
+	server.AddFile("b.go", "@@ -1,1 +1,2 @@\n a\n+b")
+	server.AddFile("c.go", "@@ -1,1 +1,2 @@\n a\n+b")
+	comments := []commenter.PRReviewComment{
+		{FileName: "c.go", StartLine: 2, EndLine: 2, Body: "past the listing"},
+		{FileName: "d.go", StartLine: 2, EndLine: 2, Body: "not in the PR"},
+	}
+
+	c, err := server.NewCommenter(commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	results, err := c.WriteComments(comments)
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultCreated, results[0].Status)
+	assert.Equal(t, commenter.ResultSkipped, results[1].Status)
+
+	server.DiffTooLarge = true
+	c, err = server.NewCommenter(commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	results, err = c.WriteComments([]commenter.PRReviewComment{
+		{FileName: "a.go", StartLine: 2, EndLine: 2, Body: "listed"},
+		{FileName: "c.go", StartLine: 1, EndLine: 1, Body: "maybe changed"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultCreated, results[0].Status)
+	assert.Equal(t, commenter.ResultSummarized, results[1].Status)
+}
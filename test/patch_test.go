@@ -0,0 +1,63 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_hunk_headers_without_a_count_are_single_lines(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("VERSION", "@@ -0,0 +1 @@\n+1.2.3\n\\ No newline at end of file")
+	server.AddFile("main.go", "@@ -3 +3,2 @@\n-a\n+b\n+c\n@@ -10,0 +12 @@\n+d")
+	server.AddFile("empty.txt", "")
+
+	c, err := server.NewCommenter(commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	results, err := c.WriteComments([]commenter.PRReviewComment{
+		{FileName: "VERSION", StartLine: 1, EndLine: 1, Body: "bump"},
+		{FileName: "VERSION", StartLine: 2, EndLine: 2, Body: "past the end"},
+		{FileName: "main.go", StartLine: 3, EndLine: 4, Body: "first hunk"},
+		{FileName: "main.go", StartLine: 12, EndLine: 12, Body: "second hunk"},
+		{FileName: "empty.txt", StartLine: 1, EndLine: 1, Body: "empty"},
+	})
+	require.NoError(t, err)
+	var statuses []commenter.ResultStatus
+	for _, result := range results {
+		statuses = append(statuses, result.Status)
+	}
+	assert.Equal(t, []commenter.ResultStatus{
+		commenter.ResultCreated, commenter.ResultSkipped, commenter.ResultCreated, commenter.ResultCreated, commenter.ResultSkipped,
+	}, statuses)
+}
+
+func Test_files_without_a_patch_are_skipped_or_summarized(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddBinaryFile("logo.png")
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+	comments := []commenter.PRReviewComment{
+		{FileName: "logo.png", StartLine: 1, EndLine: 1, Body: "image is 4MB"},
+		{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "inline"},
+	}
+
+	c, err := server.NewCommenter(commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	results, err := c.WriteComments(comments)
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultSkipped, results[0].Status)
+	assert.Equal(t, commenter.ResultCreated, results[1].Status)
+
+	c, err = server.NewCommenter(commenter.WithPatchlessFileSummary())
+	require.NoError(t, err)
+	results, err = c.WriteComments(comments[:1])
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultSummarized, results[0].Status)
+	summaries := server.IssueComments()
+	require.Len(t, summaries, 1)
+	assert.Contains(t, summaries[0].GetBody(), "`logo.png:1`: image is 4MB")
+}
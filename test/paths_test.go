@@ -0,0 +1,125 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_path_filters_limit_comments(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	patch := "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d"
+	server.AddFile("internal/app/app.go", patch)
+	server.AddFile("internal/app/app_test.go", patch)
+	server.AddFile("vendor/lib/lib.go", patch)
+	server.AddFile("main.go", patch)
+
+	c, err := server.NewCommenter(
+		commenter.WithIncludePaths("internal/**", "vendor/**"),
+		commenter.WithExcludePaths("vendor/**", "**/*_test.go"),
+	)
+	require.NoError(t, err)
+
+	var comments []commenter.PRReviewComment
+	for _, file := range []string{"internal/app/app.go", "internal/app/app_test.go", "vendor/lib/lib.go", "main.go"} {
+		comments = append(comments, commenter.PRReviewComment{FileName: file, StartLine: 2, EndLine: 2, Body: "finding"})
+	}
+	drafts := c.CreateDraftPRReviewComments(comments)
+	require.Len(t, drafts, 1)
+	assert.Equal(t, "internal/app/app.go", drafts[0].GetPath())
+
+	results, err := c.WriteComments(comments)
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultCreated, results[0].Status)
+	for _, result := range results[1:] {
+		assert.Equal(t, commenter.ResultSuppressed, result.Status)
+	}
+}
+
+func Test_tool_paths_are_normalized_to_the_pr_files(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("pkg/a.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+
+	c, err := server.NewCommenter(
+		commenter.WithRepoRoot(`C:\work\repo\`),
+		commenter.WithStripPathPrefixes("/workspace/src/"),
+		commenter.WithConcurrency(1),
+	)
+	require.NoError(t, err)
+	results, err := c.WriteFindings([]commenter.Finding{
+		{Path: `.\pkg\a.go`, StartLine: 2, Message: "windows relative"},
+		{Path: `C:\work\repo\pkg\a.go`, StartLine: 2, Message: "windows absolute"},
+		{Path: "/workspace/src/pkg/a.go", StartLine: 2, Message: "container"},
+		{Path: "/elsewhere/pkg/a.go", StartLine: 2, Message: "unrelated"},
+	})
+	require.NoError(t, err)
+	for _, result := range results[:3] {
+		assert.Equal(t, commenter.ResultCreated, result.Status)
+		assert.Equal(t, "pkg/a.go", result.Comment.FileName)
+	}
+	assert.Equal(t, commenter.ResultSkipped, results[3].Status)
+	for _, comment := range server.Comments() {
+		assert.Equal(t, "pkg/a.go", comment.GetPath())
+	}
+}
+
+func Test_paths_match_case_insensitively_and_through_aliases(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	patch := "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d"
+	server.AddFile("README.md", patch)
+	server.AddFile("api/schema.proto", patch)
+	comments := []commenter.PRReviewComment{
+		{FileName: "README.MD", StartLine: 2, EndLine: 2, Body: "case"},
+		{FileName: `gen\schema.pb.go`, StartLine: 2, EndLine: 2, Body: "alias"},
+	}
+
+	c, err := server.NewCommenter(commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	results, err := c.WriteComments(comments)
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultSkipped, results[0].Status)
+	assert.Equal(t, commenter.ResultSkipped, results[1].Status)
+
+	c, err = server.NewCommenter(
+		commenter.WithCaseInsensitivePaths(),
+		commenter.WithPathAliases(map[string]string{"gen/schema.pb.go": "api/schema.proto"}),
+		commenter.WithConcurrency(1),
+	)
+	require.NoError(t, err)
+	results, err = c.WriteComments(comments)
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultCreated, results[0].Status)
+	assert.Equal(t, commenter.ResultCreated, results[1].Status)
+	written := server.Comments()
+	require.Len(t, written, 2)
+	assert.Equal(t, "README.md", written[0].GetPath())
+	assert.Equal(t, "api/schema.proto", written[1].GetPath())
+}
+
+func Test_path_prefix_maps_monorepo_paths_onto_the_repo(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	patch := "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d"
+	server.AddFile("services/api/main.tf", patch)
+	server.AddFile("services/shared/vars.tf", patch)
+
+	c, err := server.NewCommenter(commenter.WithPathPrefix("services/api/"), commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	results, err := c.WriteComments([]commenter.PRReviewComment{
+		{FileName: "main.tf", StartLine: 2, EndLine: 2, Body: "relative"},
+		{FileName: "./main.tf", StartLine: 3, EndLine: 3, Body: "dot relative"},
+		{FileName: "../shared/vars.tf", StartLine: 2, EndLine: 2, Body: "sibling module"},
+		{FileName: "services/api/main.tf", StartLine: 2, EndLine: 2, Body: "already rooted"},
+	})
+	require.NoError(t, err)
+	for _, result := range results {
+		assert.Equal(t, commenter.ResultCreated, result.Status, result.Comment.Body)
+	}
+	assert.Equal(t, "services/shared/vars.tf", results[2].Comment.FileName)
+}
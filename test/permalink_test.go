@@ -0,0 +1,21 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_permalink_points_at_the_head_commit(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("cmd/my tool/main.go", "@@ -1,1 +1,2 @@\n a\n+b")
+
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+	blob := server.URL + "/owner/repo/blob/" + commentertest.HeadSHA + "/cmd/my%20tool/main.go"
+	assert.Equal(t, blob+"#L2", c.Permalink("cmd/my tool/main.go", 2, 2))
+	assert.Equal(t, blob+"#L1-L2", c.Permalink("cmd/my tool/main.go", 1, 2))
+}
@@ -0,0 +1,119 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_policy_fails_on_thresholds(t *testing.T) {
+	findings := []commenter.Finding{
+		{Severity: commenter.SeverityCritical},
+		{Severity: commenter.SeverityError},
+		{Severity: commenter.SeverityWarning},
+		{Severity: commenter.SeverityInfo},
+	}
+	policy := commenter.Policy{FailOnErrors: 2, FailOnWarnings: 5}
+
+	result := policy.Evaluate(findings, nil)
+	assert.False(t, result.Passed)
+	assert.Equal(t, 1, result.ExitCode())
+	assert.Equal(t, 2, result.Errors)
+	assert.Equal(t, 1, result.Warnings)
+
+	// a suppressed error no longer counts
+	results := []commenter.Result{{Status: commenter.ResultSuppressed}, {Status: commenter.ResultCreated}}
+	result = policy.Evaluate(findings, results)
+	assert.True(t, result.Passed)
+	assert.Equal(t, 0, result.ExitCode())
+}
+
+func Test_policy_result_sets_a_commit_status(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+	result := commenter.Policy{FailOnErrors: 1}.Evaluate([]commenter.Finding{{Severity: commenter.SeverityError}}, nil)
+	require.NoError(t, c.SetCommitStatus("lint", result))
+
+	statuses := server.Statuses()
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "failure", statuses[0].GetState())
+	assert.Equal(t, "lint", statuses[0].GetContext())
+	assert.Equal(t, "1 errors found, the limit is 1", statuses[0].GetDescription())
+}
+
+func Test_check_run_carries_the_markdown_report(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+
+	var findings []commenter.Finding
+	for i := 0; i < 60; i++ {
+		findings = append(findings, commenter.Finding{RuleID: "R1", Severity: commenter.SeverityWarning, Path: "main.go", StartLine: 2, Message: "a | b"})
+	}
+	findings[0].Severity = commenter.SeverityError
+
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+	id, err := c.WriteCheckRun(commenter.CheckRunReport{
+		Name:     "lint",
+		Policy:   &commenter.Policy{FailOnErrors: 1},
+		Findings: findings,
+	})
+	require.NoError(t, err)
+	assert.NotZero(t, id)
+
+	runs := server.CheckRuns()
+	require.Len(t, runs, 1)
+	run := runs[0]
+	assert.Equal(t, commentertest.HeadSHA, run.GetHeadSHA())
+	assert.Equal(t, "failure", run.GetConclusion())
+	assert.Equal(t, "60 findings", run.GetOutput().GetSummary())
+	assert.Contains(t, run.GetOutput().GetText(), "| error | `main.go:2` | R1 | a \\| b |")
+	require.Len(t, run.GetOutput().Annotations, 60)
+	assert.Equal(t, "failure", run.GetOutput().Annotations[0].GetAnnotationLevel())
+	assert.Equal(t, "warning", run.GetOutput().Annotations[59].GetAnnotationLevel())
+}
+
+func Test_clean_runs_approve_the_pr(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+	finding := commenter.Finding{RuleID: "R1", Path: "main.go", StartLine: 2, Message: "bad"}
+
+	c, err := server.NewCommenter(commenter.WithAutoApprove(commenter.AutoApproveSubmit))
+	require.NoError(t, err)
+	_, err = c.WriteFindings([]commenter.Finding{finding})
+	require.NoError(t, err)
+	assert.Empty(t, server.Reviews())
+
+	_, err = c.WriteFindings(nil)
+	require.NoError(t, err)
+	reviews := server.Reviews()
+	require.Len(t, reviews, 1)
+	assert.Equal(t, commenter.Approve, reviews[0].GetEvent())
+}
+
+func Test_clean_runs_dismiss_earlier_change_requests(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+	require.NoError(t, c.WritePRReview(nil, commenter.RequestChanges))
+
+	baseline := commenter.NewBaseline([]commenter.Finding{{RuleID: "R1", Path: "main.go", Message: "bad"}})
+	c, err = server.NewCommenter(commenter.WithAutoApprove(commenter.AutoApproveDismiss), commenter.WithBaseline(baseline))
+	require.NoError(t, err)
+	_, err = c.WriteFindings([]commenter.Finding{{RuleID: "R1", Path: "main.go", StartLine: 2, Message: "bad"}})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1}, server.DismissedReviewIDs())
+	assert.Len(t, server.Reviews(), 1)
+}
@@ -0,0 +1,22 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter/prettier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_prettier_check_lists_unformatted_files(t *testing.T) {
+	output := "Checking formatting...\n[warn] src/app.ts\n[warn] src/my file.js\n[error] src/bad.js: SyntaxError: Unexpected token (3:1)\n" +
+		"[warn] Code style issues found in 2 files. Run Prettier with --write to fix.\n"
+	findings, err := prettier.Parse(strings.NewReader(output))
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	assert.Equal(t, "src/app.ts", findings[0].Path)
+	assert.Equal(t, 1, findings[0].StartLine)
+	assert.Equal(t, "This file isn't formatted with Prettier, run `prettier --write src/app.ts`", findings[0].Message)
+	assert.Equal(t, "src/my file.js", findings[1].Path)
+}
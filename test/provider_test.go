@@ -0,0 +1,89 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryProvider struct {
+	files    []*commenter.ChangedFile
+	comments []*commenter.Comment
+	inline   []commenter.InlineComment
+	summary  []string
+	deleted  []int64
+}
+
+func (p *memoryProvider) ListChangedFiles(context.Context) ([]*commenter.ChangedFile, error) {
+	return p.files, nil
+}
+
+func (p *memoryProvider) ListComments(context.Context) ([]*commenter.Comment, error) {
+	return p.comments, nil
+}
+
+func (p *memoryProvider) CreateInlineComment(_ context.Context, comment commenter.InlineComment) (*commenter.Comment, error) {
+	p.inline = append(p.inline, comment)
+	return &commenter.Comment{ID: int64(len(p.inline)), Path: comment.Path, Line: comment.EndLine, Body: comment.Body}, nil
+}
+
+func (p *memoryProvider) CreateSummaryComment(_ context.Context, body string) (*commenter.Comment, error) {
+	p.summary = append(p.summary, body)
+	return &commenter.Comment{Body: body}, nil
+}
+
+func (p *memoryProvider) UpdateComment(_ context.Context, comment *commenter.Comment, body string) error {
+	comment.Body = body
+	return nil
+}
+
+func (p *memoryProvider) DeleteComment(_ context.Context, comment *commenter.Comment) error {
+	p.deleted = append(p.deleted, comment.ID)
+	return nil
+}
+
+func Test_commenter_writes_through_a_custom_provider(t *testing.T) {
+	provider := &memoryProvider{
+		files: []*commenter.ChangedFile{{Filename: "main.go", Status: "modified", Patch: "@@ -1,3 +10,5 @@ func main()", CommitSHA: "abc123"}},
+		comments: []*commenter.Comment{
+			{ID: 7, Path: "main.go", Line: 11, Author: commenter.CommenterName},
+			{ID: 8, Path: "main.go", Line: 11, Author: "someone"},
+		},
+	}
+	c, err := commenter.NewCommenterWithProvider(context.Background(), provider)
+	require.NoError(t, err)
+	assert.Nil(t, c.GitHubClient())
+
+	drafts := c.CreateDraftPRReviewComments([]commenter.PRReviewComment{
+		{FileName: "main.go", StartLine: 11, EndLine: 12, Body: "in the diff"},
+		{FileName: "main.go", StartLine: 30, EndLine: 30, Body: "outside the diff"},
+	})
+	require.NoError(t, c.WritePRReview(drafts, commenter.RequestChanges))
+
+	assert.Equal(t, []int64{7}, provider.deleted)
+	require.Len(t, provider.inline, 1)
+	assert.Equal(t, commenter.InlineComment{Path: "main.go", StartLine: 11, EndLine: 12, Body: "in the diff", CommitSHA: "abc123"}, provider.inline[0])
+	assert.Equal(t, []string{commenter.RequestChangesBody}, provider.summary)
+}
+
+func Test_prune_minimize_is_not_supported_by_custom_providers(t *testing.T) {
+	provider := &memoryProvider{
+		files:    []*commenter.ChangedFile{{Filename: "main.go", Status: "modified", Patch: "@@ -1,3 +10,5 @@ func main()", CommitSHA: "abc123"}},
+		comments: []*commenter.Comment{{ID: 7, Path: "main.go", Line: 40, Author: commenter.CommenterName}},
+	}
+	c, err := commenter.NewCommenterWithProvider(context.Background(), provider)
+	require.NoError(t, err)
+
+	errs := c.PruneOutdatedComments(commenter.PruneMinimize)
+	require.Len(t, errs, 1)
+	assert.True(t, errors.Is(errs[0], commenter.ErrNotSupported))
+
+	assert.Empty(t, c.PruneOutdatedComments(commenter.PruneDelete))
+	assert.Equal(t, []int64{7}, provider.deleted)
+	assert.Equal(t, github.Rate{}, c.RateLimit())
+}
@@ -0,0 +1,26 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/pylint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_pylint_messages_become_findings(t *testing.T) {
+	output := `[{"type":"convention","module":"pkg.a","obj":"","line":1,"column":0,"endLine":null,"endColumn":null,"path":"pkg/a.py",
+"symbol":"missing-module-docstring","message":"Missing module docstring","message-id":"C0114"},
+{"type":"error","module":"pkg.a","obj":"f","line":4,"column":4,"endLine":6,"endColumn":8,"path":"pkg/a.py","symbol":"no-member","message":"Module 'os' has no 'nope' member","message-id":"E1101"}]`
+	findings, err := pylint.Parse(strings.NewReader(output))
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	assert.Equal(t, commenter.Finding{
+		Tool: pylint.Tool, RuleID: "missing-module-docstring", Severity: commenter.SeverityInfo, Path: "pkg/a.py", StartLine: 1, EndLine: 1,
+		Message: "Missing module docstring ([C0114](https://pylint.readthedocs.io/en/stable/user_guide/messages/convention/missing-module-docstring.html))",
+	}, findings[0])
+	assert.Equal(t, commenter.SeverityError, findings[1].Severity)
+	assert.Equal(t, 6, findings[1].EndLine)
+}
@@ -0,0 +1,101 @@
+package test
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+// rateLimitTransport reports a primary rate limit which goes down by one on every request
+type rateLimitTransport struct {
+	mu        sync.Mutex
+	remaining int
+	reset     time.Time
+	requests  int
+}
+
+func (r *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests++
+	r.remaining--
+	resp.Header.Set("X-RateLimit-Limit", "5000")
+	resp.Header.Set("X-RateLimit-Remaining", strconv.Itoa(r.remaining))
+	resp.Header.Set("X-RateLimit-Reset", strconv.FormatInt(r.reset.Unix(), 10))
+	return resp, nil
+}
+
+func (r *rateLimitTransport) calls() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.requests
+}
+
+func Test_rate_limit_budget_stops_before_the_limit_is_used_up(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,4 @@\n a\n-b\n+c\n+d\n+e")
+	transport := &rateLimitTransport{remaining: 5, reset: time.Now().Add(time.Hour)}
+	c, err := server.NewCommenter(
+		commenter.WithTransport(transport),
+		commenter.WithRateLimitBudget(3, commenter.RateLimitFail),
+		commenter.WithConcurrency(1),
+	)
+	require.NoError(t, err)
+
+	results, err := c.WriteComments([]commenter.PRReviewComment{
+		{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "first"},
+		{FileName: "main.go", StartLine: 3, EndLine: 3, Body: "second"},
+	})
+
+	require.Error(t, err)
+	for _, result := range results {
+		assert.Equal(t, commenter.ResultFailed, result.Status)
+		var budgetErr commenter.RateLimitBudgetError
+		require.True(t, errors.As(result.Err, &budgetErr))
+		assert.True(t, errors.Is(result.Err, commenter.ErrRateLimited))
+		assert.Equal(t, 3, budgetErr.Threshold)
+	}
+	// the request which left two requests spare is the last one made
+	assert.Equal(t, 2, c.RateLimit().Remaining)
+	assert.Equal(t, 3, transport.calls())
+	assert.Empty(t, server.Comments())
+}
+
+func Test_rate_limiter_paces_every_call(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,4 @@\n a\n-b\n+c\n+d\n+e")
+	transport := &rateLimitTransport{remaining: 5000, reset: time.Now().Add(time.Hour)}
+	interval := 20 * time.Millisecond
+
+	start := time.Now()
+	c, err := server.NewCommenter(
+		commenter.WithTransport(transport),
+		commenter.WithRateLimiter(rate.NewLimiter(rate.Every(interval), 1)),
+	)
+	require.NoError(t, err)
+	_, err = c.WriteComments([]commenter.PRReviewComment{
+		{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "first"},
+		{FileName: "main.go", StartLine: 3, EndLine: 3, Body: "second"},
+		{FileName: "main.go", StartLine: 4, EndLine: 4, Body: "third"},
+	})
+	require.NoError(t, err)
+
+	// the limiter's single token lets the first call through right away
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(time.Duration(transport.calls()-1)*interval))
+	assert.Len(t, server.Comments(), 3)
+}
@@ -0,0 +1,37 @@
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_recorded_interactions_can_be_replayed_without_the_server(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "cassette.json")
+	server := commentertest.NewServer("owner", "repo", 3)
+	server.AddFile("main.go", "@@ -1,2 +1,4 @@")
+	baseURL := server.URL
+
+	recorder, err := commentertest.NewRecorder(cassette, commentertest.ModeRecord)
+	require.NoError(t, err)
+	c, err := commenter.NewCommenter("token", "owner", "repo", 3, commenter.WithBaseURL(baseURL), commenter.WithTransport(recorder))
+	require.NoError(t, err)
+	_, err = c.WriteComments([]commenter.PRReviewComment{{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "recorded"}})
+	require.NoError(t, err)
+	require.NoError(t, recorder.Save())
+	server.Close()
+
+	replayer, err := commentertest.NewRecorder(cassette, commentertest.ModeReplay)
+	require.NoError(t, err)
+	c, err = commenter.NewCommenter("token", "owner", "repo", 3, commenter.WithBaseURL(baseURL), commenter.WithTransport(replayer))
+	require.NoError(t, err)
+	results, err := c.WriteComments([]commenter.PRReviewComment{{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "recorded"}})
+
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultCreated, results[0].Status)
+	assert.NotZero(t, results[0].CommentID)
+}
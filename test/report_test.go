@@ -0,0 +1,45 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_run_report_records_results_and_findings(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+
+	c, err := server.NewCommenter(commenter.WithMinSeverity(commenter.SeverityWarning), commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	findings := []commenter.Finding{
+		{Tool: "gosec", RuleID: "G104", Severity: commenter.SeverityError, Path: "main.go", StartLine: 2, Message: "errors unhandled"},
+		{RuleID: "S2", Severity: commenter.SeverityInfo, Path: "main.go", StartLine: 3, Message: "style"},
+	}
+	results, err := c.WriteFindings(findings)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, c.NewRunReport(results, findings).WriteJSON(&buf))
+	var report commenter.RunReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+
+	assert.Equal(t, "owner/repo#7", report.PullRequest)
+	assert.Equal(t, commentertest.HeadSHA, report.HeadSHA)
+	assert.Equal(t, map[commenter.ResultStatus]int{commenter.ResultCreated: 1, commenter.ResultSuppressed: 1}, report.Counts)
+	require.Len(t, report.Comments, 2)
+	created := report.Comments[0]
+	assert.Equal(t, commenter.ResultCreated, created.Status)
+	assert.NotZero(t, created.CommentID)
+	assert.Equal(t, server.Comments()[0].GetHTMLURL(), created.URL)
+	require.NotNil(t, created.Finding)
+	assert.Equal(t, commenter.Fingerprint(findings[0]), created.Finding.Fingerprint)
+	assert.Equal(t, "error", created.Finding.Severity)
+	assert.Contains(t, report.Comments[1].Error, "below the warning severity")
+}
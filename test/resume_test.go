@@ -0,0 +1,65 @@
+package test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_resume_file_skips_comments_written_before_the_interruption(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+	comments := []commenter.PRReviewComment{
+		{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "first"},
+		{FileName: "main.go", StartLine: 3, EndLine: 3, Body: "second"},
+	}
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	state, err := commenter.OpenResumeFile(path)
+	require.NoError(t, err)
+	c, err := server.NewCommenter(commenter.WithResumeState(state))
+	require.NoError(t, err)
+	_, err = c.WriteComments(comments[:1])
+	require.NoError(t, err)
+
+	state, err = commenter.OpenResumeFile(path)
+	require.NoError(t, err)
+	c, err = server.NewCommenter(commenter.WithResumeState(state))
+	require.NoError(t, err)
+	results, err := c.WriteComments(comments)
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultUnchanged, results[0].Status)
+	assert.Equal(t, server.Comments()[0].GetID(), results[0].CommentID)
+	assert.Equal(t, commenter.ResultCreated, results[1].Status)
+	assert.Len(t, server.Comments(), 2)
+}
+
+func Test_resume_state_round_trips(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+	comments := []commenter.PRReviewComment{{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "first"}}
+
+	state := commenter.NewResumeState()
+	c, err := server.NewCommenter(commenter.WithResumeState(state))
+	require.NoError(t, err)
+	_, err = c.WriteComments(comments)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, state.Write(&buf))
+	restored, err := commenter.ReadResumeState(&buf)
+	require.NoError(t, err)
+	c, err = server.NewCommenter(commenter.WithResumeState(restored))
+	require.NoError(t, err)
+	results, err := c.WriteComments(comments)
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultUnchanged, results[0].Status)
+	assert.Len(t, server.Comments(), 1)
+}
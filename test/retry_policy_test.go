@@ -0,0 +1,252 @@
+package test
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_backoff_policy_honours_retry_after(t *testing.T) {
+	policy := commenter.DefaultRetryPolicy()
+
+	delay, retry := policy.NextDelay(commenter.RetryAttempt{Attempt: 1, RetryAfter: 42 * time.Second})
+
+	assert.True(t, retry)
+	assert.Equal(t, 42*time.Second, delay)
+}
+
+func Test_backoff_policy_grows_exponentially_up_to_the_cap(t *testing.T) {
+	policy := &commenter.BackoffPolicy{
+		InitialInterval: time.Second,
+		MaxInterval:     5 * time.Second,
+		Multiplier:      2,
+	}
+
+	var delays []time.Duration
+	for attempt := 1; attempt <= 4; attempt++ {
+		delay, retry := policy.NextDelay(commenter.RetryAttempt{Attempt: attempt})
+		assert.True(t, retry)
+		delays = append(delays, delay)
+	}
+
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}, delays)
+}
+
+func Test_backoff_policy_jitter_stays_within_bounds(t *testing.T) {
+	policy := &commenter.BackoffPolicy{
+		InitialInterval: 10 * time.Second,
+		Multiplier:      1,
+		Jitter:          0.5,
+	}
+
+	for i := 0; i < 100; i++ {
+		delay, _ := policy.NextDelay(commenter.RetryAttempt{Attempt: 1})
+		assert.True(t, delay >= 5*time.Second && delay <= 15*time.Second)
+	}
+}
+
+func Test_backoff_policy_stops_after_max_attempts(t *testing.T) {
+	policy := &commenter.BackoffPolicy{MaxAttempts: 3, InitialInterval: time.Second}
+
+	_, retry := policy.NextDelay(commenter.RetryAttempt{Attempt: 3})
+
+	assert.False(t, retry)
+}
+
+func Test_backoff_policy_stops_after_max_elapsed(t *testing.T) {
+	policy := &commenter.BackoffPolicy{MaxElapsed: time.Minute, InitialInterval: time.Second}
+
+	_, retry := policy.NextDelay(commenter.RetryAttempt{Attempt: 2, Elapsed: 59 * time.Second, RetryAfter: 30 * time.Second})
+
+	assert.False(t, retry)
+}
+
+func Test_rate_limit_error_keeps_the_api_error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-GitHub-Request-Id", "ABCD:1234")
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"message":"slow down"}`))
+	}))
+	defer server.Close()
+
+	_, err := commenter.NewCommenter("fake-token", "owner", "repo", 8,
+		commenter.WithBaseURL(server.URL),
+		commenter.WithRetryPolicy(&commenter.BackoffPolicy{MaxAttempts: 1}))
+
+	assert.True(t, errors.Is(err, commenter.ErrRateLimited))
+	var limitErr commenter.AbuseRateLimitError
+	require.True(t, errors.As(err, &limitErr))
+	assert.False(t, errors.As(err, &commenter.PrimaryRateLimitError{}))
+	assert.Equal(t, 30, limitErr.BackoffInSeconds)
+	assert.Equal(t, "Secondary rate limit reached on PR [8] for owner/repo, retry after [30] seconds", limitErr.Error())
+	assert.Equal(t, "ABCD:1234", limitErr.Metadata.RequestID)
+	var apiErr commenter.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+	assert.Equal(t, "ABCD:1234", apiErr.Metadata.RequestID)
+}
+
+func Test_primary_rate_limit_is_told_apart_from_secondary(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-GitHub-Request-Id", "ABCD:5678")
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message":"API rate limit exceeded"}`))
+	}))
+	defer server.Close()
+
+	_, err := commenter.NewCommenter("fake-token", "owner", "repo", 8,
+		commenter.WithBaseURL(server.URL),
+		commenter.WithRetryPolicy(&commenter.BackoffPolicy{MaxAttempts: 1}))
+
+	assert.True(t, errors.Is(err, commenter.ErrRateLimited))
+	assert.False(t, errors.As(err, &commenter.AbuseRateLimitError{}))
+	var limitErr commenter.PrimaryRateLimitError
+	require.True(t, errors.As(err, &limitErr))
+	assert.True(t, reset.Equal(limitErr.Reset))
+	assert.Equal(t, "ABCD:5678", limitErr.Metadata.RequestID)
+	assert.Contains(t, limitErr.Error(), "Primary rate limit reached on PR [8] for owner/repo")
+}
+
+// faultTransport answers the requests matching method and path suffix with err or a response of
+// status and header, the first times ones only when times is set
+type faultTransport struct {
+	method, suffix string
+	status         int
+	header         http.Header
+	err            error
+	times          int
+
+	mu      sync.Mutex
+	matched int
+}
+
+func (f *faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == f.method && strings.HasSuffix(req.URL.Path, f.suffix) {
+		f.mu.Lock()
+		f.matched++
+		fail := f.times == 0 || f.matched <= f.times
+		f.mu.Unlock()
+		if fail && f.err != nil {
+			return nil, f.err
+		}
+		if fail {
+			header := http.Header{"Content-Type": {"application/json"}}
+			for key, values := range f.header {
+				header[key] = values
+			}
+			return &http.Response{
+				StatusCode: f.status,
+				Header:     header,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"message":"fault"}`)),
+				Request:    req,
+			}, nil
+		}
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (f *faultTransport) requests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.matched
+}
+
+// recordingPolicy retries right away up to max calls and records every attempt it was asked about
+type recordingPolicy struct {
+	max      int
+	attempts []commenter.RetryAttempt
+}
+
+func (p *recordingPolicy) NextDelay(attempt commenter.RetryAttempt) (time.Duration, bool) {
+	p.attempts = append(p.attempts, attempt)
+	return time.Millisecond, attempt.Attempt < p.max
+}
+
+func Test_retry_is_told_the_retry_after_github_sent(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+	fault := &faultTransport{method: http.MethodGet, suffix: "/pulls/7", status: http.StatusTooManyRequests, header: http.Header{"Retry-After": {"7"}}, times: 1}
+	policy := &recordingPolicy{max: 3}
+
+	_, err := server.NewCommenter(commenter.WithTransport(fault), commenter.WithRetryPolicy(policy))
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, fault.requests())
+	require.Len(t, policy.attempts, 1)
+	assert.Equal(t, 7*time.Second, policy.attempts[0].RetryAfter)
+}
+
+func Test_server_errors_are_retried_for_reads_but_not_for_new_comments(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+	read := &faultTransport{method: http.MethodGet, suffix: "/pulls/7", status: http.StatusBadGateway, times: 1}
+	c, err := server.NewCommenter(commenter.WithTransport(read), commenter.WithRetryPolicy(&recordingPolicy{max: 3}))
+	require.NoError(t, err)
+	assert.Equal(t, 2, read.requests())
+
+	write := &faultTransport{method: http.MethodPost, suffix: "/pulls/7/comments", status: http.StatusBadGateway, times: 1}
+	c, err = server.NewCommenter(commenter.WithTransport(write), commenter.WithRetryPolicy(&recordingPolicy{max: 3}))
+	require.NoError(t, err)
+	results, _ := c.WriteComments([]commenter.PRReviewComment{{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "finding"}})
+
+	assert.Equal(t, commenter.ResultFailed, results[0].Status)
+	assert.Equal(t, 1, write.requests())
+}
+
+func Test_network_errors_are_retried_for_reads_but_not_for_new_comments(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+	read := &faultTransport{method: http.MethodGet, suffix: "/pulls/7", err: io.ErrUnexpectedEOF, times: 1}
+	_, err := server.NewCommenter(commenter.WithTransport(read), commenter.WithRetryPolicy(&recordingPolicy{max: 3}))
+	require.NoError(t, err)
+	assert.Equal(t, 2, read.requests())
+
+	write := &faultTransport{method: http.MethodPost, suffix: "/pulls/7/comments", err: io.ErrUnexpectedEOF, times: 1}
+	c, err := server.NewCommenter(commenter.WithTransport(write), commenter.WithRetryPolicy(&recordingPolicy{max: 3}))
+	require.NoError(t, err)
+	results, _ := c.WriteComments([]commenter.PRReviewComment{{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "finding"}})
+
+	assert.Equal(t, commenter.ResultFailed, results[0].Status)
+	assert.Equal(t, 1, write.requests())
+	assert.Empty(t, server.Comments())
+}
+
+func Test_retry_gives_up_on_a_primary_rate_limit(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+	reset := time.Now().Add(time.Hour)
+	fault := &faultTransport{method: http.MethodGet, suffix: "/pulls/7", status: http.StatusForbidden, header: http.Header{
+		"X-Ratelimit-Limit":     {"5000"},
+		"X-Ratelimit-Remaining": {"0"},
+		"X-Ratelimit-Reset":     {strconv.FormatInt(reset.Unix(), 10)},
+	}}
+	policy := &recordingPolicy{max: 2}
+
+	_, err := server.NewCommenter(commenter.WithTransport(fault), commenter.WithRetryPolicy(policy))
+
+	var limitErr commenter.PrimaryRateLimitError
+	require.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, reset.Unix(), limitErr.Reset.Unix())
+	assert.Len(t, policy.attempts, 2)
+}
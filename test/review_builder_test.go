@@ -0,0 +1,79 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_review_builder_submits_the_added_comments(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,4 @@\n a\n-b\n+c\n+d\n+e")
+
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+	review, err := c.StartReview()
+	require.NoError(t, err)
+	require.NoError(t, review.AddLineComment("main.go", 2, 3, "unchecked error"))
+	require.NoError(t, review.AddSuggestion("main.go", 4, 4, "e := 1", "Use a constant"))
+	err = review.AddLineComment("main.go", 20, 20, "outside the diff")
+	assert.True(t, errors.Is(err, commenter.ErrCommentOutsideDiff))
+	assert.Equal(t, 2, review.Len())
+	assert.Empty(t, server.Reviews())
+
+	require.NoError(t, review.Submit(commenter.RequestChanges))
+	reviews := server.Reviews()
+	require.Len(t, reviews, 1)
+	assert.Equal(t, commenter.RequestChanges, reviews[0].GetEvent())
+	require.Len(t, reviews[0].Comments, 2)
+	assert.Equal(t, "Use a constant\n\n```suggestion\ne := 1\n```", reviews[0].Comments[1].GetBody())
+
+	assert.Error(t, review.Submit(commenter.Approve))
+	assert.Len(t, server.Reviews(), 1)
+}
+
+func Test_review_builder_discards_the_review(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,4 @@\n a\n-b\n+c\n+d\n+e")
+
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+	review, err := c.StartReview()
+	require.NoError(t, err)
+	require.NoError(t, review.AddLineComment("main.go", 2, 2, "unchecked error"))
+	review.Discard()
+
+	assert.Error(t, review.AddLineComment("main.go", 3, 3, "too late"))
+	assert.Error(t, review.Submit(commenter.Approve))
+	assert.Empty(t, server.Reviews())
+	assert.Empty(t, server.Comments())
+}
+
+func Test_pending_review_replaces_the_earlier_pending_review(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,4 @@\n a\n-b\n+c\n+d\n+e")
+
+	for _, body := range []string{"first draft", "second draft"} {
+		c, err := server.NewCommenter()
+		require.NoError(t, err)
+		review, err := c.StartReview()
+		require.NoError(t, err)
+		require.NoError(t, review.AddLineComment("main.go", 2, 2, body))
+		require.NoError(t, review.Submit(commenter.Pending))
+	}
+
+	reviews := server.Reviews()
+	require.Len(t, reviews, 1)
+	assert.Empty(t, reviews[0].GetEvent())
+	require.Len(t, reviews[0].Comments, 1)
+	assert.Equal(t, "second draft", reviews[0].Comments[0].GetBody())
+	// nothing is published until someone submits the review
+	assert.Empty(t, server.Comments())
+}
@@ -0,0 +1,30 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/rubocop"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_rubocop_offenses_become_findings(t *testing.T) {
+	output := `{"metadata":{"rubocop_version":"1.60.0"},"files":[{"path":"app/models/user.rb","offenses":[
+{"severity":"convention","message":"Style/StringLiterals: Prefer single-quoted strings when you don't need string interpolation or special symbols.","cop_name":"Style/StringLiterals","corrected":false,"correctable":true,
+ "location":{"start_line":3,"start_column":9,"last_line":3,"last_column":15,"length":7,"line":3,"column":9}},
+{"severity":"warning","message":"Rails/Output: Do not write to stdout.","cop_name":"Rails/Output","location":{"start_line":5,"last_line":6}}]},
+{"path":"app/b.rb","offenses":[]}],"summary":{"offense_count":2}}`
+	findings, err := rubocop.Parse(strings.NewReader(output))
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	assert.Equal(t, commenter.Finding{
+		Tool: rubocop.Tool, RuleID: "Style/StringLiterals", Severity: commenter.SeverityInfo, Path: "app/models/user.rb", StartLine: 3, EndLine: 3,
+		Message: "Prefer single-quoted strings when you don't need string interpolation or special symbols. " +
+			"([Style/StringLiterals](https://docs.rubocop.org/rubocop/cops_style.html#stylestringliterals))",
+	}, findings[0])
+	assert.Equal(t, "Do not write to stdout.", findings[1].Message)
+	assert.Equal(t, commenter.SeverityWarning, findings[1].Severity)
+	assert.Equal(t, 6, findings[1].EndLine)
+}
@@ -0,0 +1,125 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parse_severity(t *testing.T) {
+	for name, want := range map[string]commenter.Severity{
+		"note":     commenter.SeverityInfo,
+		"MEDIUM":   commenter.SeverityWarning,
+		"high":     commenter.SeverityError,
+		"critical": commenter.SeverityCritical,
+		"":         commenter.SeverityUnknown,
+	} {
+		got, err := commenter.ParseSeverity(name)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, name)
+	}
+	_, err := commenter.ParseSeverity("bogus")
+	assert.Error(t, err)
+}
+
+func Test_min_severity_rolls_low_findings_into_the_summary(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+
+	c, err := server.NewCommenter(commenter.WithMinSeverity(commenter.SeverityWarning), commenter.WithLowSeveritySummary())
+	require.NoError(t, err)
+	results, err := c.WriteFindings([]commenter.Finding{
+		{RuleID: "S1", Severity: commenter.SeverityError, Path: "main.go", StartLine: 2, Message: "bad"},
+		{RuleID: "S2", Severity: commenter.SeverityInfo, Path: "main.go", StartLine: 3, Message: "style"},
+		{RuleID: "S3", Path: "main.go", StartLine: 3, Message: "unranked"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultCreated, results[0].Status)
+	assert.Equal(t, commenter.ResultSuppressed, results[1].Status)
+	assert.Equal(t, commenter.ResultCreated, results[2].Status)
+
+	assert.Len(t, server.Comments(), 2)
+	summaries := server.IssueComments()
+	require.Len(t, summaries, 1)
+	assert.Contains(t, summaries[0].GetBody(), "- `main.go:3` (info) **S2**: style")
+}
+
+// rejectingTransport answers 400 to the review comments whose body contains reject
+type rejectingTransport struct {
+	reject string
+}
+
+func (r rejectingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/comments") && req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Contains(body, []byte(r.reject)) {
+			return &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body:       ioutil.NopCloser(strings.NewReader(`{"message":"rejected"}`)),
+				Request:    req,
+			}, nil
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func Test_low_severity_summary_is_written_when_an_inline_comment_fails(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+
+	c, err := server.NewCommenter(commenter.WithMinSeverity(commenter.SeverityWarning), commenter.WithLowSeveritySummary(),
+		commenter.WithTransport(rejectingTransport{reject: "doomed"}))
+	require.NoError(t, err)
+	results, err := c.WriteFindings([]commenter.Finding{
+		{RuleID: "S1", Severity: commenter.SeverityError, Path: "main.go", StartLine: 2, Message: "doomed"},
+		{RuleID: "S2", Severity: commenter.SeverityInfo, Path: "main.go", StartLine: 3, Message: "style"},
+		{RuleID: "S3", Severity: commenter.SeverityError, Path: "main.go", StartLine: 3, Message: "bad"},
+	})
+
+	var batchErr commenter.BatchError
+	require.True(t, errors.As(err, &batchErr))
+	assert.Len(t, batchErr.Failed, 1)
+	assert.Equal(t, commenter.ResultFailed, results[0].Status)
+	assert.Equal(t, commenter.ResultSuppressed, results[1].Status)
+	assert.Equal(t, commenter.ResultCreated, results[2].Status)
+
+	assert.Len(t, server.Comments(), 1)
+	summaries := server.IssueComments()
+	require.Len(t, summaries, 1)
+	assert.Contains(t, summaries[0].GetBody(), "**S2**: style")
+}
+
+func Test_mention_author_on_blocking_findings(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.Author = "alice"
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+
+	c, err := server.NewCommenter(commenter.WithMentionAuthor(commenter.SeverityError), commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	_, err = c.WriteFindings([]commenter.Finding{
+		{RuleID: "S1", Severity: commenter.SeverityCritical, Path: "main.go", StartLine: 2, Message: "injection"},
+		{RuleID: "S2", Severity: commenter.SeverityInfo, Path: "main.go", StartLine: 3, Message: "style"},
+	})
+	require.NoError(t, err)
+
+	comments := server.Comments()
+	require.Len(t, comments, 2)
+	assert.Equal(t, "**S1**: injection\n\ncc @alice", comments[0].GetBody())
+	assert.Equal(t, "**S2**: style", comments[1].GetBody())
+}
@@ -0,0 +1,60 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/shellcheck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const shellcheckOutput = `{"comments":[
+{"file":"deploy.sh","line":3,"endLine":3,"column":6,"endColumn":11,"level":"info","code":2086,"message":"Double quote to prevent globbing and word splitting.",
+ "fix":{"replacements":[
+  {"line":3,"endLine":3,"column":6,"endColumn":6,"insertionPoint":"afterEnd","precedence":7,"replacement":"\""},
+  {"line":3,"endLine":3,"column":11,"endColumn":11,"insertionPoint":"beforeStart","precedence":7,"replacement":"\""}]}},
+{"file":"deploy.sh","line":5,"endLine":5,"column":1,"endColumn":3,"level":"error","code":1009,"message":"The mentioned syntax error was in this if expression.","fix":null}
+]}`
+
+func Test_shellcheck_fixes_become_suggestions(t *testing.T) {
+	source := func(file string) ([]byte, error) {
+		if file != "deploy.sh" {
+			return nil, errors.New("no such file")
+		}
+		return []byte("#!/bin/sh\nset -e\necho $name done\n\nif [ x ]\n"), nil
+	}
+	findings, err := shellcheck.Parse(strings.NewReader(shellcheckOutput), source)
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	assert.Equal(t, "SC2086", findings[0].RuleID)
+	assert.Equal(t, commenter.SeverityInfo, findings[0].Severity)
+	require.NotNil(t, findings[0].Suggestion)
+	assert.Equal(t, `echo "$name" done`, *findings[0].Suggestion)
+	assert.Equal(t, "Double quote to prevent globbing and word splitting. ([SC2086](https://www.shellcheck.net/wiki/SC2086))\n\n"+
+		"```suggestion\necho \"$name\" done\n```", strings.TrimPrefix(findings[0].Comment().Body, "**SC2086**: "))
+	assert.Nil(t, findings[1].Suggestion)
+	assert.Equal(t, commenter.SeverityError, findings[1].Severity)
+
+	findings, err = shellcheck.Parse(strings.NewReader(shellcheckOutput), nil)
+	require.NoError(t, err)
+	assert.Nil(t, findings[0].Suggestion)
+}
+
+func Test_shellcheck_json_skips_suggestions_on_lines_with_tabs(t *testing.T) {
+	output := `[{"file":"a.sh","line":1,"endLine":1,"column":13,"endColumn":15,"level":"info","code":2086,"message":"Double quote",
+"fix":{"replacements":[{"line":1,"endLine":1,"column":13,"endColumn":13,"replacement":"\""},{"line":1,"endLine":1,"column":15,"endColumn":15,"replacement":"\""}]}}]`
+	source := func(string) ([]byte, error) { return []byte("\techo $a\n"), nil }
+	findings, err := shellcheck.Parse(strings.NewReader(output), source)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Nil(t, findings[0].Suggestion)
+}
+
+func Test_empty_finding_suggestions_delete_the_lines(t *testing.T) {
+	empty := ""
+	finding := commenter.Finding{Path: "a.sh", StartLine: 2, EndLine: 3, Message: "unused", Suggestion: &empty}
+	assert.Equal(t, "unused\n\n```suggestion\n```", finding.Comment().Body)
+}
@@ -0,0 +1,105 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_write_comments_stops_when_the_pr_changes(t *testing.T) {
+	comments := []commenter.PRReviewComment{
+		{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "first"},
+		{FileName: "main.go", StartLine: 3, EndLine: 3, Body: "second"},
+		{FileName: "main.go", StartLine: 4, EndLine: 4, Body: "third"},
+	}
+
+	t.Run("merged", func(t *testing.T) {
+		server := commentertest.NewServer("owner", "repo", 7)
+		defer server.Close()
+		server.AddFile("main.go", "@@ -1,2 +1,4 @@\n a\n-b\n+c\n+d\n+e")
+
+		c, err := server.NewCommenter(commenter.WithConcurrency(1), commenter.WithProgress(func(done, _ int, _ commenter.Result) {
+			if done == 1 {
+				server.Merge()
+			}
+		}))
+		require.NoError(t, err)
+		results, err := c.WriteComments(comments)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, commenter.ErrPRStateChanged))
+		var stateErr commenter.PRStateChangedError
+		require.True(t, errors.As(err, &stateErr))
+		assert.Equal(t, "merged", stateErr.State)
+		assert.Contains(t, err.Error(), "was merged")
+
+		assert.Equal(t, commenter.ResultCreated, results[0].Status)
+		assert.Equal(t, commenter.ResultFailed, results[1].Status)
+		assert.Equal(t, commenter.ResultSkipped, results[2].Status)
+		assert.True(t, errors.Is(results[2].Err, commenter.ErrPRStateChanged))
+		assert.Len(t, server.Comments(), 1)
+	})
+
+	t.Run("pushed", func(t *testing.T) {
+		server := commentertest.NewServer("owner", "repo", 7)
+		defer server.Close()
+		server.AddFile("main.go", "@@ -1,2 +1,4 @@\n a\n-b\n+c\n+d\n+e")
+
+		c, err := server.NewCommenter(commenter.WithConcurrency(1))
+		require.NoError(t, err)
+		server.Push("0000000000000000000000000000000000000000")
+		results, err := c.WriteComments(comments)
+		var stateErr commenter.PRStateChangedError
+		require.True(t, errors.As(err, &stateErr))
+		assert.Equal(t, "0000000000000000000000000000000000000000", stateErr.HeadSHA)
+		assert.Equal(t, commenter.ResultSkipped, results[2].Status)
+		assert.Empty(t, server.Comments())
+	})
+}
+
+func Test_write_pr_review_reports_a_merged_pr(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,4 @@\n a\n-b\n+c\n+d\n+e")
+
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+	drafts := c.CreateDraftPRReviewComments([]commenter.PRReviewComment{{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "issue"}})
+	server.Merge()
+	err = c.WritePRReview(drafts, commenter.RequestChanges)
+	assert.True(t, errors.Is(err, commenter.ErrPRStateChanged))
+}
+
+func Test_review_commit_comments_on_an_earlier_commit(t *testing.T) {
+	const earlier = "1111111111111111111111111111111111111111"
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,4 @@\n a\n-b\n+c\n+d\n+e")
+	server.AddCommit(earlier, "util.go", "@@ -1 +1,2 @@\n a\n+b")
+
+	c, err := server.NewCommenter(commenter.WithReviewCommit(earlier))
+	require.NoError(t, err)
+	results, err := c.WriteComments([]commenter.PRReviewComment{
+		{FileName: "util.go", StartLine: 2, EndLine: 2, Body: "in the commit"},
+		{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "only in the head"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultCreated, results[0].Status)
+	assert.Equal(t, commenter.ResultSkipped, results[1].Status)
+
+	comments := server.Comments()
+	require.Len(t, comments, 1)
+	assert.Equal(t, earlier, comments[0].GetCommitID())
+
+	drafts := c.CreateDraftPRReviewComments([]commenter.PRReviewComment{{FileName: "util.go", StartLine: 2, EndLine: 2, Body: "reviewed"}})
+	require.NoError(t, c.WritePRReview(drafts, commenter.RequestChanges))
+	reviews := server.Reviews()
+	require.Len(t, reviews, 1)
+	assert.Equal(t, earlier, reviews[0].GetCommitID())
+
+	_, err = server.NewCommenter(commenter.WithReviewCommit("2222222222222222222222222222222222222222"))
+	assert.True(t, errors.Is(err, commenter.ErrPRNotFound))
+}
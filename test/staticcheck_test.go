@@ -0,0 +1,41 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/mugioka/go-github-pr-commenter/commenter/staticcheck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const staticcheckOutput = `{"code":"SA4006","severity":"error","location":{"file":"/work/repo/pkg/a.go","line":3,"column":2},"end":{"file":"/work/repo/pkg/a.go","line":3,"column":5},"message":"this value of x is never used"}
+{"code":"ST1003","severity":"warning","location":{"file":"/work/repo/pkg/a.go","line":4,"column":6},"end":{"file":"/work/repo/pkg/a.go","line":5,"column":1},"message":"should not use underscores in Go names"}
+{"code":"SA1019","severity":"ignored","location":{"file":"/work/repo/pkg/a.go","line":2,"column":1},"end":{"file":"","line":0,"column":0},"message":"deprecated"}
+{"code":"compile","severity":"error","location":{"file":"/work/repo/pkg/b.go","line":1,"column":1},"end":{"file":"","line":0,"column":0},"message":"undefined: y"}
+`
+
+func Test_staticcheck_diagnostics_become_findings(t *testing.T) {
+	findings, err := staticcheck.Parse(strings.NewReader(staticcheckOutput))
+	require.NoError(t, err)
+	require.Len(t, findings, 3)
+	assert.Equal(t, commenter.Finding{
+		Tool: staticcheck.Tool, RuleID: "SA4006", Severity: commenter.SeverityError,
+		Path: "/work/repo/pkg/a.go", StartLine: 3, EndLine: 3,
+		Message: "this value of x is never used ([SA4006](https://staticcheck.dev/docs/checks/#SA4006))",
+	}, findings[0])
+	assert.Equal(t, 5, findings[1].EndLine)
+	assert.Equal(t, "undefined: y", findings[2].Message)
+
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("pkg/a.go", "@@ -1,2 +1,5 @@\n a\n b\n+c\n+d\n+e")
+	c, err := server.NewCommenter(commenter.WithRepoRoot("/work/repo"), commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	results, err := c.WriteFindings(findings[:2])
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultCreated, results[0].Status)
+	assert.Equal(t, commenter.ResultCreated, results[1].Status)
+}
@@ -0,0 +1,120 @@
+package test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// longReport has lines totalling several times GitHub's comment limit
+func longReport() string {
+	var b strings.Builder
+	for i := 0; b.Len() < 200000; i++ {
+		fmt.Fprintf(&b, "- finding %d: %s\n", i, strings.Repeat("x", 100))
+	}
+	return b.String()
+}
+
+func Test_long_summaries_are_split_into_several_comments(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+	require.NoError(t, c.WriteGeneralComment(longReport()))
+
+	comments := server.IssueComments()
+	require.Len(t, comments, 4)
+	for _, comment := range comments {
+		assert.True(t, len(comment.GetBody()) <= 65536)
+	}
+	assert.True(t, strings.HasPrefix(comments[1].GetBody(), "_(continued 2/4)_"))
+}
+
+func Test_summaries_over_the_chunk_limit_overflow_to_a_gist(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	report := longReport()
+
+	c, err := server.NewCommenter(commenter.WithMaxSummaryComments(2))
+	require.NoError(t, err)
+	require.NoError(t, c.WriteGeneralComment(report))
+	comments := server.IssueComments()
+	require.Len(t, comments, 2)
+	assert.Contains(t, comments[1].GetBody(), "The report was truncated.")
+
+	c, err = server.NewCommenter(commenter.WithMaxSummaryComments(2), commenter.WithGistOverflow(nil))
+	require.NoError(t, err)
+	require.NoError(t, c.WriteGeneralComment(report))
+	gists := server.Gists()
+	require.Len(t, gists, 1)
+	assert.False(t, gists[0].GetPublic())
+	file := gists[0].Files["report.md"]
+	assert.Equal(t, report, file.GetContent())
+	comments = server.IssueComments()
+	require.Len(t, comments, 3)
+	assert.Contains(t, comments[2].GetBody(), "[full report]("+gists[0].GetHTMLURL()+")")
+	assert.True(t, len(comments[2].GetBody()) < 3000)
+}
+
+func Test_general_comments_already_written_are_not_duplicated(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddIssueComment("alice", "nightly scan found 3 issues")
+
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+	require.NoError(t, c.WriteGeneralComment("nightly scan found 3 issues"))
+	require.NoError(t, c.WriteGeneralComment("nightly scan found 3 issues"))
+	require.NoError(t, c.WriteGeneralComment(longReport()))
+	require.NoError(t, c.WriteGeneralComment(longReport()))
+
+	comments := server.IssueComments()
+	require.Len(t, comments, 6)
+	assert.Equal(t, "alice", comments[0].GetUser().GetLogin())
+	assert.Equal(t, "nightly scan found 3 issues", comments[1].GetBody())
+}
+
+func Test_general_comments_written_once_per_key(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+	written, err := c.WriteGeneralCommentOnce("welcome", "Thanks for the PR!")
+	require.NoError(t, err)
+	assert.True(t, written)
+	written, err = c.WriteGeneralCommentOnce("welcome", "Thanks for the PR, again!")
+	require.NoError(t, err)
+	assert.False(t, written)
+	written, err = c.WriteGeneralCommentOnce("how-to-read", "Findings are grouped by severity.")
+	require.NoError(t, err)
+	assert.True(t, written)
+
+	comments := server.IssueComments()
+	require.Len(t, comments, 2)
+	assert.True(t, strings.HasPrefix(comments[0].GetBody(), "Thanks for the PR!\n\n<!-- pr-commenter:once "))
+}
+
+func Test_sticky_comments_are_edited_in_place(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddIssueComment("someone", "a review note")
+
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+	require.NoError(t, c.WriteStickyComment("bench", "3 regressions"))
+	require.NoError(t, c.WriteStickyComment("bench", "1 regression"))
+	require.NoError(t, c.WriteStickyComment("bench", "1 regression"))
+	require.NoError(t, c.WriteStickyComment("coverage", "80%"))
+
+	comments := server.IssueComments()
+	require.Len(t, comments, 3)
+	assert.True(t, strings.HasPrefix(comments[1].GetBody(), "1 regression\n\n<!-- pr-commenter:sticky "))
+	assert.True(t, strings.HasPrefix(comments[2].GetBody(), "80%"))
+}
@@ -0,0 +1,41 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_suppression_directives_skip_findings(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c := f() //nolint:errcheck\n+d")
+	server.AddFile("main.tf", "@@ -4,2 +4,3 @@\n resource \"a\" {\n-  x = 1\n+  x = 2\n+  y = 3")
+	server.AddContent("main.tf", "locals {}\n\n#tfsec:ignore:aws-001\nresource \"a\" {\n  x = 2\n  y = 3\n}\n")
+
+	findings := []commenter.Finding{
+		{RuleID: "errcheck", Path: "main.go", StartLine: 2, Message: "unchecked error"},
+		{RuleID: "gosec", Path: "main.go", StartLine: 2, Message: "insecure call"},
+		{RuleID: "aws-001", Path: "main.tf", StartLine: 4, Message: "open bucket"},
+	}
+
+	c, err := server.NewCommenter(commenter.WithSuppressionDirectives("//nolint", "#tfsec:ignore"))
+	require.NoError(t, err)
+	results, err := c.WriteFindings(findings)
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultSuppressed, results[0].Status)
+	assert.Equal(t, commenter.ResultCreated, results[1].Status)
+	// the directive above the hunk isn't in the patch
+	assert.Equal(t, commenter.ResultCreated, results[2].Status)
+
+	c, err = server.NewCommenter(commenter.WithSuppressionDirectives("//nolint", "#tfsec:ignore"), commenter.WithContentsFetch())
+	require.NoError(t, err)
+	results, err = c.WriteFindings(findings)
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultSuppressed, results[0].Status)
+	assert.Equal(t, commenter.ResultCreated, results[1].Status)
+	assert.Equal(t, commenter.ResultSuppressed, results[2].Status)
+}
@@ -0,0 +1,320 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_sync_leaves_reported_findings_alone(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,4 @@\n a\n-b\n+c\n+d\n+e")
+
+	findings := []commenter.Finding{
+		{RuleID: "G101", Path: "main.go", StartLine: 2, Message: "hardcoded credentials"},
+		{RuleID: "G104", Path: "main.go", StartLine: 3, Message: "errors unhandled"},
+	}
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+	first, err := c.Sync(findings)
+	require.NoError(t, err)
+	for _, result := range first.Results {
+		assert.Equal(t, commenter.ResultCreated, result.Status)
+	}
+	require.Len(t, server.Comments(), 2)
+	assert.True(t, strings.HasPrefix(server.Comments()[0].GetBody(), "**"))
+	assert.Contains(t, server.Comments()[0].GetBody(), "<!-- pr-commenter:finding ")
+
+	// the finding moved a line down but is still the same finding
+	findings[0].StartLine = 4
+	c, err = server.NewCommenter()
+	require.NoError(t, err)
+	second, err := c.Sync(findings)
+	require.NoError(t, err)
+	for _, result := range second.Results {
+		assert.Equal(t, commenter.ResultUnchanged, result.Status)
+	}
+	assert.Len(t, server.Comments(), 2)
+}
+
+func Test_sync_twice_on_one_commenter_leaves_its_comments_alone(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,4 @@\n a\n-b\n+c\n+d\n+e")
+
+	findings := []commenter.Finding{{RuleID: "G101", Path: "main.go", StartLine: 2, Message: "hardcoded credentials"}}
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+	first, err := c.Sync(findings)
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultCreated, first.Results[0].Status)
+
+	second, err := c.Sync(findings)
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultUnchanged, second.Results[0].Status)
+	assert.Equal(t, first.Results[0].CommentID, second.Results[0].CommentID)
+	assert.Len(t, server.Comments(), 1)
+}
+
+func Test_sync_of_a_cached_commenter_sees_the_comments_of_an_earlier_one(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,4 @@\n a\n-b\n+c\n+d\n+e")
+
+	findings := []commenter.Finding{{RuleID: "G101", Path: "main.go", StartLine: 2, Message: "hardcoded credentials"}}
+	cache := commenter.WithSnapshotCache(commenter.NewMemorySnapshotStore(), time.Minute)
+	first, err := server.NewCommenter(cache)
+	require.NoError(t, err)
+	_, err = first.Sync(findings)
+	require.NoError(t, err)
+
+	second, err := server.NewCommenter(cache)
+	require.NoError(t, err)
+	result, err := second.Sync(findings)
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultUnchanged, result.Results[0].Status)
+	assert.Len(t, server.Comments(), 1)
+}
+
+func Test_sync_suppresses_acknowledged_findings(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,4 @@\n a\n-b\n+c\n+d\n+e")
+
+	findings := []commenter.Finding{
+		{RuleID: "G101", Path: "main.go", StartLine: 2, Severity: commenter.SeverityError, Message: "hardcoded credentials"},
+		{RuleID: "G104", Path: "main.go", StartLine: 3, Severity: commenter.SeverityError, Message: "errors unhandled"},
+		{RuleID: "G304", Path: "main.go", StartLine: 4, Severity: commenter.SeverityError, Message: "file inclusion"},
+	}
+	c, err := server.NewCommenter(commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	_, err = c.Sync(findings)
+	require.NoError(t, err)
+	comments := server.Comments()
+	require.Len(t, comments, 3)
+
+	server.AddReaction(comments[0].GetID(), "maintainer", "+1")
+	server.AddReaction(comments[1].GetID(), commenter.CommenterName, "+1")
+	server.AddReply("maintainer", comments[2].GetID(), " ACK ")
+
+	c, err = server.NewCommenter(commenter.WithAcknowledgements())
+	require.NoError(t, err)
+	result, err := c.Sync(findings)
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultSuppressed, result.Results[0].Status)
+	assert.ErrorIs(t, result.Results[0].Err, commenter.ErrSuppressed)
+	// the commenter can't acknowledge its own findings
+	assert.Equal(t, commenter.ResultUnchanged, result.Results[1].Status)
+	assert.Equal(t, commenter.ResultSuppressed, result.Results[2].Status)
+
+	verdict := commenter.Policy{FailOnErrors: 2}.Evaluate(findings, result.Results)
+	assert.Equal(t, 1, verdict.Errors)
+	assert.True(t, verdict.Passed)
+	assert.Len(t, server.Comments(), 4)
+}
+
+func Test_sync_handles_comments_of_fixed_findings(t *testing.T) {
+	findings := []commenter.Finding{
+		{RuleID: "G101", Path: "main.go", StartLine: 2, Message: "hardcoded credentials"},
+		{RuleID: "G104", Path: "main.go", StartLine: 3, Message: "errors unhandled"},
+	}
+	setup := func(t *testing.T) *commentertest.Server {
+		server := commentertest.NewServer("owner", "repo", 7)
+		server.AddFile("main.go", "@@ -1,2 +1,4 @@\n a\n-b\n+c\n+d\n+e")
+		c, err := server.NewCommenter(commenter.WithConcurrency(1))
+		require.NoError(t, err)
+		_, err = c.Sync(findings)
+		require.NoError(t, err)
+		require.Len(t, server.Comments(), 2)
+		return server
+	}
+
+	t.Run("delete", func(t *testing.T) {
+		server := setup(t)
+		defer server.Close()
+		fixed := server.Comments()[1].GetID()
+		c, err := server.NewCommenter(commenter.WithResolvedFindings(commenter.ResolvedDelete))
+		require.NoError(t, err)
+		result, err := c.Sync(findings[:1])
+		require.NoError(t, err)
+		require.Len(t, result.Resolved, 1)
+		assert.Equal(t, commenter.ResultResolved, result.Resolved[0].Status)
+		assert.Equal(t, fixed, result.Resolved[0].CommentID)
+		assert.Equal(t, []int64{fixed}, server.DeletedCommentIDs())
+	})
+
+	t.Run("minimize", func(t *testing.T) {
+		server := setup(t)
+		defer server.Close()
+		c, err := server.NewCommenter(commenter.WithResolvedFindings(commenter.ResolvedMinimize))
+		require.NoError(t, err)
+		result, err := c.Sync(findings[:1])
+		require.NoError(t, err)
+		require.Len(t, result.Resolved, 1)
+		requests := server.GraphQLRequests()
+		require.Len(t, requests, 1)
+		assert.Contains(t, requests[0], "RESOLVED")
+		assert.Contains(t, requests[0], server.Comments()[1].GetNodeID())
+	})
+
+	t.Run("reply", func(t *testing.T) {
+		server := setup(t)
+		defer server.Close()
+		for i := 0; i < 2; i++ {
+			c, err := server.NewCommenter(commenter.WithResolvedFindings(commenter.ResolvedReply))
+			require.NoError(t, err)
+			result, err := c.Sync(findings[:1])
+			require.NoError(t, err)
+			require.Len(t, result.Resolved, 1)
+			assert.Equal(t, commenter.ResultResolved, result.Resolved[0].Status)
+		}
+		comments := server.Comments()
+		require.Len(t, comments, 3)
+		assert.Equal(t, comments[1].GetID(), comments[2].GetInReplyTo())
+		assert.Equal(t, "✅ fixed in "+commentertest.HeadSHA, comments[2].GetBody())
+	})
+}
+
+func Test_sync_reanchors_comments_after_a_force_push(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,4 @@\n a\n-b\n+c\n+d\n+e")
+
+	// the same finding on two lines, told apart by the content of their line
+	findings := []commenter.Finding{
+		{RuleID: "G104", Path: "main.go", StartLine: 2, Message: "errors unhandled"},
+		{RuleID: "G104", Path: "main.go", StartLine: 3, Message: "errors unhandled"},
+	}
+	c, err := server.NewCommenter(commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	_, err = c.Sync(findings)
+	require.NoError(t, err)
+	before := server.Comments()
+	require.Len(t, before, 2)
+
+	// a line was inserted above both findings and the branch force pushed
+	server.AddFile("main.go", "@@ -1,2 +1,5 @@\n a\n-b\n+x\n+c\n+d\n+e")
+	for _, comment := range before {
+		server.Outdate(comment.GetID(), "0000000000000000000000000000000000000000")
+	}
+	moved := []commenter.Finding{
+		{RuleID: "G104", Path: "main.go", StartLine: 4, Message: "errors unhandled"},
+		{RuleID: "G104", Path: "main.go", StartLine: 3, Message: "errors unhandled"},
+	}
+	c, err = server.NewCommenter(commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	result, err := c.Sync(moved)
+	require.NoError(t, err)
+	for _, res := range result.Results {
+		assert.Equal(t, commenter.ResultReanchored, res.Status)
+	}
+	assert.ElementsMatch(t, []int64{before[0].GetID(), before[1].GetID()}, server.DeletedCommentIDs())
+
+	after := server.Comments()
+	require.Len(t, after, 2)
+	var lines []int
+	for _, comment := range after {
+		lines = append(lines, comment.GetLine())
+		assert.Equal(t, before[0].GetBody(), comment.GetBody())
+		assert.Equal(t, commentertest.HeadSHA, comment.GetCommitID())
+	}
+	assert.ElementsMatch(t, []int{3, 4}, lines)
+}
+
+func Test_sync_prunes_comments_left_off_the_diff_by_a_force_push(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,4 @@\n a\n-b\n+c\n+d\n+e")
+
+	findings := []commenter.Finding{
+		{RuleID: "G101", Path: "main.go", StartLine: 2, Message: "hardcoded credentials"},
+		{RuleID: "G104", Path: "main.go", StartLine: 3, Message: "errors unhandled"},
+	}
+	c, err := server.NewCommenter(commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	_, err = c.Sync(findings)
+	require.NoError(t, err)
+	before := server.Comments()
+	require.Len(t, before, 2)
+	var fixedID int64
+	for _, comment := range before {
+		if strings.Contains(comment.GetBody(), "G104") {
+			fixedID = comment.GetID()
+		}
+		server.Outdate(comment.GetID(), "0000000000000000000000000000000000000000")
+	}
+
+	// the branch was force pushed with a line inserted above G101 and G104 fixed
+	server.AddFile("main.go", "@@ -1,2 +1,5 @@\n a\n-b\n+x\n+c\n+d\n+e")
+	c, err = server.NewCommenter(commenter.WithConcurrency(1), commenter.WithPruneOutdated(commenter.PruneDelete))
+	require.NoError(t, err)
+	result, err := c.Sync([]commenter.Finding{{RuleID: "G101", Path: "main.go", StartLine: 3, Message: "hardcoded credentials"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, commenter.ResultReanchored, result.Results[0].Status)
+	assert.Empty(t, result.Resolved)
+	require.Len(t, result.Pruned, 1)
+	assert.Equal(t, commenter.ResultPruned, result.Pruned[0].Status)
+	assert.Equal(t, fixedID, result.Pruned[0].CommentID)
+	assert.ElementsMatch(t, []int64{before[0].GetID(), before[1].GetID()}, server.DeletedCommentIDs())
+	after := server.Comments()
+	require.Len(t, after, 1)
+	assert.Equal(t, result.Results[0].CommentID, after[0].GetID())
+}
+func Test_thread_continuation_replies_to_the_rule_thread(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,4 @@\n a\n-b\n+c\n+d\n+e")
+	root := server.AddComment(commenter.CommenterName, "main.go", 2, "**G104**: errors unhandled")
+	server.AddComment("reviewer", "main.go", 3, "**G104**: not the commenter's")
+
+	c, err := server.NewCommenter(commenter.WithThreadContinuation(), commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	results, err := c.WriteFindings([]commenter.Finding{
+		{RuleID: "G104", Path: "main.go", StartLine: 4, Message: "errors unhandled again"},
+		{RuleID: "G101", Path: "main.go", StartLine: 3, Message: "hardcoded credentials"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultCreated, results[0].Status)
+	assert.Equal(t, commenter.ResultCreated, results[1].Status)
+
+	comments := server.Comments()
+	require.Len(t, comments, 4)
+	replies := map[string]int64{}
+	for _, comment := range comments[2:] {
+		replies[comment.GetBody()] = comment.GetInReplyTo()
+	}
+	assert.Equal(t, root, replies["**G104**: errors unhandled again"])
+	assert.Equal(t, int64(0), replies["**G101**: hardcoded credentials"])
+}
+
+func Test_sync_matches_identical_findings_by_their_line(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,1 +1,3 @@\n a\n+return err\n+return err")
+
+	finding := commenter.Finding{RuleID: "W1", Path: "main.go", StartLine: 2, Message: "wrap the error"}
+	c, err := server.NewCommenter(commenter.WithConcurrency(1))
+	require.NoError(t, err)
+	second := finding
+	second.StartLine = 3
+	_, err = c.Sync([]commenter.Finding{finding, second})
+	require.NoError(t, err)
+	comments := server.Comments()
+	require.Len(t, comments, 2)
+
+	// only the finding on the second of the two identical lines is still reported
+	c, err = server.NewCommenter(commenter.WithResolvedFindings(commenter.ResolvedDelete))
+	require.NoError(t, err)
+	result, err := c.Sync([]commenter.Finding{second})
+	require.NoError(t, err)
+	assert.Equal(t, commenter.ResultUnchanged, result.Results[0].Status)
+	assert.Equal(t, comments[1].GetID(), result.Results[0].CommentID)
+	assert.Equal(t, []int64{comments[0].GetID()}, server.DeletedCommentIDs())
+}
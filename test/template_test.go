@@ -0,0 +1,54 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_finding_template_uses_the_built_in_functions(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+
+	c, err := server.NewCommenter(commenter.WithFindingTemplate(`{{severityBadge .Severity}} {{truncate 8 .Message}} at {{fileLink .Path .StartLine .EndLine}}`))
+	require.NoError(t, err)
+	_, err = c.WriteFindings([]commenter.Finding{
+		{RuleID: "S1", Severity: commenter.SeverityCritical, Path: "main.go", StartLine: 2, Message: "sql injection"},
+	})
+	require.NoError(t, err)
+
+	comments := server.Comments()
+	require.Len(t, comments, 1)
+	link := server.URL + "/owner/repo/blob/" + commentertest.HeadSHA + "/main.go#L2"
+	assert.Equal(t, "🔴 critical sql inj… at [`main.go:2`]("+link+")", comments[0].GetBody())
+}
+
+func Test_template_pluralize_and_table(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+	tmpl, err := c.NewTemplate("summary", `{{pluralize (len .) "finding" "findings"}}`+"\n"+`{{table .}}`)
+	require.NoError(t, err)
+	findings := []commenter.Finding{{RuleID: "S1", Path: "main.go", StartLine: 2, Message: "bad"}}
+	var b strings.Builder
+	require.NoError(t, tmpl.Execute(&b, findings))
+	assert.Equal(t, "1 finding\n"+commenter.RenderReport(findings), b.String())
+}
+
+func Test_finding_template_which_does_not_parse(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+
+	c, err := server.NewCommenter(commenter.WithFindingTemplate(`{{.Message`))
+	require.NoError(t, err)
+	_, err = c.WriteFindings([]commenter.Finding{{Path: "main.go", StartLine: 2, Message: "bad"}})
+	assert.Error(t, err)
+	assert.Empty(t, server.Comments())
+}
@@ -0,0 +1,136 @@
+package test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// spanRecorder is a TracerProvider keeping every span it starts in memory
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+type recordedSpan struct {
+	trace.Span
+	recorder *spanRecorder
+	name     string
+	context  trace.SpanContext
+	parent   trace.SpanContext
+	status   codes.Code
+	ended    bool
+}
+
+func (r *spanRecorder) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return r
+}
+
+func (r *spanRecorder) Start(ctx context.Context, name string, _ ...trace.SpanStartOption) (context.Context, trace.Span) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	parent := trace.SpanContextFromContext(ctx)
+	traceID := parent.TraceID()
+	if !parent.IsValid() {
+		traceID = trace.TraceID{byte(len(r.spans) + 1)}
+	}
+	span := &recordedSpan{
+		Span:     trace.SpanFromContext(ctx),
+		recorder: r,
+		name:     name,
+		context:  trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: trace.SpanID{byte(len(r.spans) + 1)}}),
+		parent:   parent,
+	}
+	r.spans = append(r.spans, span)
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+func (s *recordedSpan) SpanContext() trace.SpanContext          { return s.context }
+func (s *recordedSpan) IsRecording() bool                       { return true }
+func (s *recordedSpan) SetAttributes(...attribute.KeyValue)     {}
+func (s *recordedSpan) AddEvent(string, ...trace.EventOption)   {}
+func (s *recordedSpan) RecordError(error, ...trace.EventOption) {}
+
+func (s *recordedSpan) SetStatus(code codes.Code, _ string) {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	s.status = code
+}
+
+func (s *recordedSpan) End(...trace.SpanEndOption) {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	s.ended = true
+}
+
+// named returns the recorded spans called name
+func (r *spanRecorder) named(name string) []*recordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var spans []*recordedSpan
+	for _, span := range r.spans {
+		if span.name == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func Test_written_comments_are_traced_as_children_of_the_callers_span(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,4 @@\n a\n-b\n+c\n+d\n+e")
+	recorder := &spanRecorder{}
+	c, err := server.NewCommenter(
+		commenter.WithTracerProvider(recorder),
+		commenter.WithTransport(rejectingTransport{reject: "rejected finding"}),
+		commenter.WithRetryPolicy(&commenter.BackoffPolicy{MaxAttempts: 1}),
+	)
+	require.NoError(t, err)
+
+	ctx, root := recorder.Start(context.Background(), "run")
+	_, err = c.WriteCommentsContext(ctx, []commenter.PRReviewComment{
+		{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "finding"},
+		{FileName: "main.go", StartLine: 3, EndLine: 3, Body: "rejected finding"},
+	})
+	root.End()
+	require.Error(t, err)
+
+	writes := recorder.named("commenter.WriteComments")
+	require.Len(t, writes, 1)
+	write := writes[0]
+	assert.Equal(t, root.SpanContext().SpanID(), write.parent.SpanID())
+	assert.Equal(t, codes.Error, write.status)
+	assert.True(t, write.ended)
+
+	loads := recorder.named("commenter.LoadPRInfo")
+	require.Len(t, loads, 1)
+	assert.Equal(t, write.context.SpanID(), loads[0].parent.SpanID())
+
+	comments := recorder.named("commenter.CreatePRReviewComment")
+	require.Len(t, comments, 2)
+	var failed int
+	for _, comment := range comments {
+		assert.Equal(t, write.context.SpanID(), comment.parent.SpanID())
+		assert.Equal(t, root.SpanContext().TraceID(), comment.context.TraceID())
+		if comment.status == codes.Error {
+			failed++
+		}
+	}
+	assert.Equal(t, 1, failed)
+
+	calls := recorder.named("github.PullRequests.CreateComment")
+	require.Len(t, calls, 2)
+	for _, call := range calls {
+		assert.Contains(t, []trace.SpanID{comments[0].context.SpanID(), comments[1].context.SpanID()}, call.parent.SpanID())
+		assert.True(t, call.ended)
+	}
+}
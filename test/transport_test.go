@@ -0,0 +1,130 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/commentertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_http_debug_dump_redacts_the_token(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+
+	var out bytes.Buffer
+	c, err := commenter.NewCommenter("ghp_secret-token", "owner", "repo", 7, append(server.Options(), commenter.WithHTTPDebug(&out))...)
+	require.NoError(t, err)
+	_, err = c.WriteComments([]commenter.PRReviewComment{{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "finding"}})
+	require.NoError(t, err)
+
+	dump := out.String()
+	assert.NotContains(t, dump, "ghp_secret-token")
+	assert.Contains(t, dump, "Authorization: [REDACTED]")
+	assert.Contains(t, dump, "GET /repos/owner/repo/pulls/7 HTTP/1.1")
+	assert.Contains(t, dump, `"body":"finding`)
+	assert.Contains(t, dump, "HTTP/1.1 201 Created")
+}
+
+// headerRecorder records the headers of every request it sends
+type headerRecorder struct {
+	mu      sync.Mutex
+	headers []http.Header
+}
+
+func (r *headerRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	r.headers = append(r.headers, req.Header.Clone())
+	r.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func Test_user_agent_and_api_version_are_sent_with_every_request(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+	recorder := &headerRecorder{}
+
+	c, err := server.NewCommenter(
+		commenter.WithTransport(recorder),
+		commenter.WithUserAgent("lint-bot/1.2"),
+		commenter.WithAPIVersion("2022-11-28"),
+	)
+	require.NoError(t, err)
+	_, err = c.WriteComments([]commenter.PRReviewComment{{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "finding"}})
+	require.NoError(t, err)
+
+	require.NotEmpty(t, recorder.headers)
+	for _, header := range recorder.headers {
+		assert.Equal(t, "lint-bot/1.2", header.Get("User-Agent"))
+		assert.Equal(t, "2022-11-28", header.Get("X-GitHub-Api-Version"))
+	}
+}
+
+func Test_timeout_bounds_a_hung_request(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	start := time.Now()
+	_, err := commenter.NewCommenter("fake-token", "owner", "repo", 8,
+		commenter.WithBaseURL(server.URL),
+		commenter.WithTimeout(50*time.Millisecond),
+		commenter.WithRetryPolicy(&commenter.BackoffPolicy{MaxAttempts: 1}))
+
+	require.Error(t, err)
+	var netErr net.Error
+	require.True(t, errors.As(err, &netErr))
+	assert.True(t, netErr.Timeout())
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+}
+
+func Test_commenter_with_a_client_needs_no_token_and_uses_it_as_is(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	server.AddFile("main.go", "@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d")
+	client := github.NewClient(nil)
+	baseURL, err := url.Parse(server.URL + "/")
+	require.NoError(t, err)
+	client.BaseURL = baseURL
+
+	// the client brings its own transport, so the one passed here is never used
+	c, err := commenter.NewCommenter("", "owner", "repo", 7, commenter.WithClient(client), commenter.WithTransport(failingTransport{t}))
+	require.NoError(t, err)
+	assert.Same(t, client, c.GitHubClient())
+	_, err = c.WriteComments([]commenter.PRReviewComment{{FileName: "main.go", StartLine: 2, EndLine: 2, Body: "finding"}})
+	require.NoError(t, err)
+	assert.Len(t, server.Comments(), 1)
+}
+
+func Test_github_client_reaches_endpoints_the_commenter_does_not_cover(t *testing.T) {
+	server := commentertest.NewServer("owner", "repo", 7)
+	defer server.Close()
+	c, err := server.NewCommenter()
+	require.NoError(t, err)
+
+	client := c.GitHubClient()
+	require.NotNil(t, client)
+	assert.Equal(t, server.URL+"/", client.BaseURL.String())
+	pr, _, err := client.PullRequests.Get(context.Background(), "owner", "repo", 7)
+	require.NoError(t, err)
+	assert.Equal(t, 7, pr.GetNumber())
+}
@@ -0,0 +1,26 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mugioka/go-github-pr-commenter/commenter"
+	"github.com/mugioka/go-github-pr-commenter/commenter/vale"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_vale_alerts_become_findings(t *testing.T) {
+	output := `{"docs/guide.md":[
+{"Action":{"Name":"","Params":null},"Span":[1,3],"Check":"Vale.Spelling","Description":"","Link":"","Message":"Did you really mean 'teh'?","Severity":"error","Match":"teh","Line":4},
+{"Action":{"Name":"replace","Params":["use"]},"Span":[5,11],"Check":"Microsoft.Wordiness","Description":"","Link":"https://docs.microsoft.com/en-us/style-guide/word-choice/","Message":"Consider using 'use' instead of 'utilize'.","Severity":"suggestion","Match":"utilize","Line":6}]}`
+	findings, err := vale.Parse(strings.NewReader(output))
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	assert.Equal(t, commenter.Finding{
+		Tool: vale.Tool, RuleID: "Vale.Spelling", Severity: commenter.SeverityError, Path: "docs/guide.md", StartLine: 4, EndLine: 4,
+		Message: "Did you really mean 'teh'?",
+	}, findings[0])
+	assert.Equal(t, commenter.SeverityInfo, findings[1].Severity)
+	assert.Equal(t, "Consider using 'use' instead of 'utilize'. ([Microsoft.Wordiness](https://docs.microsoft.com/en-us/style-guide/word-choice/))", findings[1].Message)
+}